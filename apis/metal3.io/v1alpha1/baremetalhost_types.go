@@ -17,6 +17,12 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -91,6 +97,42 @@ type RootDeviceHints struct {
 
 	// True if the device should use spinning media, false otherwise.
 	Rotational *bool `json:"rotational,omitempty"`
+
+	// How multiple hints are combined. With "all" (the default) a
+	// device must match every hint. With "any" the hints are tried
+	// one at a time, in the order the fields are listed here, and the
+	// first device matching one of them is used.
+	MatchMode RootDeviceHintsMatchMode `json:"matchMode,omitempty"`
+}
+
+// RootDeviceHintsMatchMode defines how multiple root device hints are
+// combined.
+// +kubebuilder:validation:Enum=all;any
+type RootDeviceHintsMatchMode string
+
+const (
+	// RootDeviceHintsMatchAll selects a device matching all hints
+	RootDeviceHintsMatchAll RootDeviceHintsMatchMode = "all"
+	// RootDeviceHintsMatchAny selects a device matching any hint,
+	// with the hints taking precedence in the order they are defined
+	RootDeviceHintsMatchAny RootDeviceHintsMatchMode = "any"
+)
+
+// ShutdownHook is a webhook that asks an agent running in the
+// operating system of the host to shut it down gracefully.
+type ShutdownHook struct {
+	// URL receives a POST request when the host needs to be powered
+	// off. The agent confirms the shutdown by adding the
+	// shutdownconfirmed.metal3.io annotation to the host. Only http and
+	// https URLs are allowed.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// TimeoutSeconds is how long to wait for the confirmation before
+	// powering off the host anyway. Defaults to 300.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
 }
 
 // BootMode is the boot mode of the system
@@ -105,6 +147,28 @@ const (
 	DefaultBootMode BootMode = UEFI
 )
 
+// IPVersion is the version of the IP protocol of a network.
+// +kubebuilder:validation:Enum=ipv4;ipv6
+type IPVersion string
+
+// Allowed IP versions
+const (
+	IPv4 IPVersion = "ipv4"
+	IPv6 IPVersion = "ipv6"
+)
+
+// RedfishAuthType is the authentication method used with a Redfish
+// BMC
+// +kubebuilder:validation:Enum=auto;basic;session
+type RedfishAuthType string
+
+// Allowed Redfish authentication methods
+const (
+	RedfishAuthAuto    RedfishAuthType = "auto"
+	RedfishAuthBasic   RedfishAuthType = "basic"
+	RedfishAuthSession RedfishAuthType = "session"
+)
+
 // OperationalStatus represents the state of the host
 type OperationalStatus string
 
@@ -155,6 +219,9 @@ const (
 	// PowerManagementError is an error condition occurring when the
 	// controller is unable to modify the power state of the Host.
 	PowerManagementError ErrorType = "power management error"
+	// ConductorError is an error condition occurring when the
+	// provisioning service that manages the Host is not running.
+	ConductorError ErrorType = "conductor error"
 	// DetachError is an error condition occurring when the
 	// controller is unable to detatch the host from the provisioner
 	DetachError ErrorType = "detach error"
@@ -231,6 +298,29 @@ type BMCDetails struct {
 	// insecure because it allows a man-in-the-middle to intercept the
 	// connection.
 	DisableCertificateVerification bool `json:"disableCertificateVerification,omitempty"`
+
+	// ManagementInterface overrides the management interface used
+	// for the host instead of the default one of the BMC driver, for
+	// example "redfish" or "ipmitool". It must be enabled in the
+	// provisioning backend.
+	// +optional
+	ManagementInterface string `json:"managementInterface,omitempty"`
+
+	// RedfishAuthType selects how the provisioning backend
+	// authenticates with a Redfish BMC, using HTTP basic
+	// authentication, a session, or "auto" to try a session first and
+	// fall back to basic authentication. Defaults to "auto". Only
+	// valid for Redfish BMCs.
+	// +optional
+	RedfishAuthType RedfishAuthType `json:"redfishAuthType,omitempty"`
+
+	// PowerOnDelaySeconds is how long to wait after the host is
+	// registered before its first power action, for PDUs and BMCs
+	// that need to settle before the power operations succeed.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=3600
+	// +optional
+	PowerOnDelaySeconds *int `json:"powerOnDelaySeconds,omitempty"`
 }
 
 // HardwareRAIDVolume defines the desired configuration of volume in hardware RAID
@@ -255,6 +345,24 @@ type HardwareRAIDVolume struct {
 	// for the particular RAID level.
 	// +kubebuilder:validation:Minimum=1
 	NumberOfPhysicalDisks *int `json:"numberOfPhysicalDisks,omitempty"`
+
+	// The name of the RAID controller to create the volume on, as read
+	// by the RAID interface. Required when PhysicalDisks is set.
+	Controller string `json:"controller,omitempty"`
+
+	// The physical disks of the controller to use for the volume, as
+	// read by the RAID interface.
+	PhysicalDisks []string `json:"physicalDisks,omitempty"`
+}
+
+// PassthroughDisk defines a physical disk of a RAID controller that is
+// exposed to the host as is (JBOD) instead of being part of a volume.
+type PassthroughDisk struct {
+	// The name of the RAID controller, as read by the RAID interface
+	Controller string `json:"controller"`
+
+	// The physical disk, as read by the RAID interface
+	PhysicalDisk string `json:"physicalDisk"`
 }
 
 // SoftwareRAIDVolume defines the desired configuration of volume in software RAID
@@ -287,6 +395,111 @@ type RAIDConfig struct {
 	// enforcing a RAID-1 reduces the risk of ending up with a non-booting node in case of a disk failure.
 	// +kubebuilder:validation:MaxItems=2
 	SoftwareRAIDVolumes []SoftwareRAIDVolume `json:"softwareRAIDVolumes,omitempty"`
+
+	// The physical disks to pass through as JBOD alongside the hardware
+	// RAID volumes. A disk cannot be both passed through and part of a
+	// volume.
+	PassthroughDisks []PassthroughDisk `json:"passthroughDisks,omitempty"`
+}
+
+// FirmwareConfig contains the BIOS settings to apply to the host
+type FirmwareConfig struct {
+	// Settings maps BIOS setting names, as reported by the BMC, to
+	// their desired values.
+	Settings map[string]string `json:"settings,omitempty"`
+
+	// BootOrder lists the UEFI boot entries in the order the host
+	// tries them, e.g. the disk before the network, as named by the
+	// BMC. It is applied as the BIOS setting named BootOrderSetting.
+	// +optional
+	BootOrder []string `json:"bootOrder,omitempty"`
+
+	// BootOrderSetting is the name of the BIOS setting holding the
+	// UEFI boot order as a comma separated list. Defaults to
+	// UefiBootSeq.
+	// +optional
+	BootOrderSetting string `json:"bootOrderSetting,omitempty"`
+}
+
+// SettingSchema describes the values a BIOS setting accepts, as
+// reported by the BIOS registry of the host.
+type SettingSchema struct {
+	// AttributeType is the type of the setting, one of Enumeration,
+	// String, Integer, Boolean or Password.
+	// +optional
+	AttributeType string `json:"attributeType,omitempty"`
+
+	// AllowableValues lists the values of an Enumeration setting.
+	// +optional
+	AllowableValues []string `json:"allowableValues,omitempty"`
+
+	// LowerBound is the lowest value of an Integer setting.
+	// +optional
+	LowerBound *int `json:"lowerBound,omitempty"`
+
+	// UpperBound is the highest value of an Integer setting.
+	// +optional
+	UpperBound *int `json:"upperBound,omitempty"`
+
+	// MinLength is the shortest value of a String setting.
+	// +optional
+	MinLength *int `json:"minLength,omitempty"`
+
+	// MaxLength is the longest value of a String setting.
+	// +optional
+	MaxLength *int `json:"maxLength,omitempty"`
+
+	// ReadOnly tells whether the setting cannot be changed.
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// FirmwareSchema maps the names of the BIOS settings of the host to
+// the values they accept.
+type FirmwareSchema map[string]SettingSchema
+
+// DefaultBootOrderSetting is the BIOS setting holding the UEFI boot
+// order when the firmware configuration does not name one
+const DefaultBootOrderSetting = "UefiBootSeq"
+
+// BIOSSettingChange describes how applying the requested firmware
+// configuration changes a BIOS setting.
+// +kubebuilder:validation:Enum=added;removed;changed
+type BIOSSettingChange string
+
+const (
+	// BIOSSettingAdded is a requested setting the host does not report
+	BIOSSettingAdded BIOSSettingChange = "added"
+
+	// BIOSSettingRemoved is a setting that was applied from the
+	// previous firmware configuration and is not requested anymore.
+	// Its current value stays on the host.
+	BIOSSettingRemoved BIOSSettingChange = "removed"
+
+	// BIOSSettingChanged is a requested setting the host reports with
+	// another value
+	BIOSSettingChanged BIOSSettingChange = "changed"
+)
+
+// BIOSSettingDiff is a difference between the requested and the
+// current BIOS settings of the host.
+type BIOSSettingDiff struct {
+	// Name is the name of the setting, as reported by the BMC when
+	// known.
+	Name string `json:"name"`
+
+	// Change tells how the setting differs.
+	Change BIOSSettingChange `json:"change"`
+
+	// Requested is the requested value of the setting, empty when it
+	// is removed.
+	// +optional
+	Requested string `json:"requested,omitempty"`
+
+	// Current is the value reported by the host, empty when it is
+	// added.
+	// +optional
+	Current string `json:"current,omitempty"`
 }
 
 // BareMetalHostSpec defines the desired state of BareMetalHost
@@ -306,11 +519,31 @@ type BareMetalHostSpec struct {
 	// RAID configuration for bare metal server
 	RAID *RAIDConfig `json:"raid,omitempty"`
 
+	// BIOS configuration for bare metal server
+	Firmware *FirmwareConfig `json:"firmware,omitempty"`
+
 	// What is the name of the hardware profile for this host? It
 	// should only be necessary to set this when inspection cannot
 	// automatically determine the profile.
 	HardwareProfile string `json:"hardwareProfile,omitempty"`
 
+	// MinimumNICs is the number of network interfaces the inspection
+	// must find. When it finds fewer, e.g. because of a missing
+	// driver, the host stays in the inspecting state with an
+	// inspection error instead of proceeding with an incomplete set
+	// of ports.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinimumNICs int `json:"minimumNICs,omitempty"`
+
+	// InspectionKernelParams are added to the kernel command line of
+	// the ramdisk booted for inspecting the host, e.g. to disable a
+	// driver that breaks the inspection. They are not used when
+	// cleaning or deploying the host.
+	// +kubebuilder:validation:MaxLength=1024
+	// +optional
+	InspectionKernelParams string `json:"inspectionKernelParams,omitempty"`
+
 	// Provide guidance about how to choose the device for the image
 	// being provisioned.
 	RootDeviceHints *RootDeviceHints `json:"rootDeviceHints,omitempty"`
@@ -325,9 +558,42 @@ type BareMetalHostSpec struct {
 	// +kubebuilder:validation:Pattern=`[0-9a-fA-F]{2}(:[0-9a-fA-F]{2}){5}`
 	BootMACAddress string `json:"bootMACAddress,omitempty"`
 
+	// BootNetworkIPVersion is the IP version of the network the host
+	// boots from. Hosts booting over IPv6 only have their boot MAC
+	// address PXE enabled, so that the DHCPv6 boot options are only
+	// served to the boot NIC.
+	// +optional
+	BootNetworkIPVersion IPVersion `json:"bootNetworkIPVersion,omitempty"`
+
 	// Should the server be online?
 	Online bool `json:"online"`
 
+	// PowerOnAfter is the name of another host in the same namespace
+	// that must be provisioned or ready before this host is powered
+	// on.
+	PowerOnAfter string `json:"powerOnAfter,omitempty"`
+
+	// PreprovisioningImageName is the name of the
+	// PreprovisioningImage in the same namespace providing the
+	// ramdisk used for inspection, cleaning and deployment. Without
+	// it, the image labelled with the hardware profile of the host is
+	// used, if any.
+	// +optional
+	PreprovisioningImageName string `json:"preprovisioningImageName,omitempty"`
+
+	// BootDevice is the device the host boots from persistently, for
+	// example "pxe" to always boot from the network or "disk" to
+	// always boot from the local storage. The device is set when the
+	// field changes. The host keeps the boot order set by its firmware
+	// when it is not set.
+	// +optional
+	BootDevice string `json:"bootDevice,omitempty"`
+
+	// ShutdownHook asks the operating system of a provisioned host
+	// to shut down gracefully before the host is powered off.
+	// +optional
+	ShutdownHook *ShutdownHook `json:"shutdownHook,omitempty"`
+
 	// ConsumerRef can be used to store information about something
 	// that is using a host. When it is not empty, the host is
 	// considered "in use".
@@ -349,9 +615,94 @@ type BareMetalHostSpec struct {
 	// (e.g. meta_data.json which is passed to Config Drive).
 	MetaData *corev1.SecretReference `json:"metaData,omitempty"`
 
+	// ConfigDriveFiles lists extra files written to the host by
+	// cloud-init from the Config Drive.
+	ConfigDriveFiles []ConfigDriveFile `json:"configDriveFiles,omitempty"`
+
+	// TimeSettings configures the time of the host on first boot. The
+	// settings are added to the user data of the Config Drive as
+	// cloud-config.
+	// +optional
+	TimeSettings *TimeSettings `json:"timeSettings,omitempty"`
+
 	// Description is a human-entered text used to help identify the host
 	Description string `json:"description,omitempty"`
 
+	// Tags is a set of arbitrary key/value pairs stored with the host
+	// in the provisioning backend for use by external tooling.
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// Traits is a list of scheduling traits set on the host in the
+	// provisioning backend, so that allocation requests can select
+	// hosts by trait. Custom traits must start with CUSTOM_.
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	Traits []string `json:"traits,omitempty"`
+
+	// Shard is the shard of the provisioning backend the host belongs
+	// to, so that the hosts can be balanced across the conductors
+	// serving distinct shards. Removing it takes the host out of its
+	// shard.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=255
+	// +optional
+	Shard string `json:"shard,omitempty"`
+
+	// NodeProperties sets the properties of the host in the
+	// provisioning backend that are used for scheduling, e.g. when
+	// inspection is disabled or reports wrong values.
+	// +optional
+	NodeProperties *NodeProperties `json:"nodeProperties,omitempty"`
+
+	// DeployNetworks selects the networks the host is attached to
+	// while it is provisioned or cleaned by the provisioning backend,
+	// when it uses the neutron network interface. The networks that
+	// are not set are taken from the configuration of the backend.
+	// +optional
+	DeployNetworks *DeployNetworks `json:"deployNetworks,omitempty"`
+
+	// Capabilities is a comma separated list of key:value capabilities
+	// merged into the capabilities of the host in the provisioning
+	// backend, e.g. "cpu_vt:true,hugepages_1G:true". The boot_mode
+	// and secure_boot capabilities are set from the boot mode and
+	// cannot be set here.
+	// +kubebuilder:validation:Pattern=`^[^:,]+:[^,]*(,[^:,]+:[^,]*)*$`
+	// +optional
+	Capabilities string `json:"capabilities,omitempty"`
+
+	// InstanceCapabilities are added to the capabilities of the
+	// instance when the host is provisioned, e.g. for huge pages or
+	// CPU pinning. The secure_boot and boot_mode capabilities are set
+	// from the boot mode and cannot be set here.
+	// +optional
+	InstanceCapabilities map[string]string `json:"instanceCapabilities,omitempty"`
+
+	// InstanceTraits are set on the instance when the host is
+	// provisioned, so that the deploy templates of the provisioning
+	// backend matching them run during the deploy. Each of them must
+	// also be one of the Traits of the host.
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	InstanceTraits []string `json:"instanceTraits,omitempty"`
+
+	// StepRetries limits how many times the deploy and the cleaning
+	// of the host are retried automatically after a step failed
+	// because of a transient problem.
+	// +optional
+	StepRetries *StepRetryLimits `json:"stepRetries,omitempty"`
+
+	// DeployTimeouts limits how long the deploy of the host may take,
+	// separately for the deploy ramdisk booting from the network and
+	// for the whole deploy.
+	// +optional
+	DeployTimeouts *DeployTimeouts `json:"deployTimeouts,omitempty"`
+
+	// PostDeployScript is run from within the deploy ramdisk once the
+	// image is written, e.g. to register the host with an inventory.
+	// +optional
+	PostDeployScript *PostDeployScript `json:"postDeployScript,omitempty"`
+
 	// ExternallyProvisioned means something else is managing the
 	// image running on the host and the operator should only manage
 	// the power status and hardware inventory inspection. If the
@@ -359,21 +710,48 @@ type BareMetalHostSpec struct {
 	ExternallyProvisioned bool `json:"externallyProvisioned,omitempty"`
 
 	// When set to disabled, automated cleaning will be avoided
-	// during provisioning and deprovisioning.
+	// during provisioning and deprovisioning. When set to metadata,
+	// only the disk metadata is erased. When set to full, the whole
+	// disks are also overwritten with a manual cleaning step after the
+	// host has been inspected and after every deprovisioning, before
+	// the host becomes ready again.
 	// +optional
 	// +kubebuilder:default:=metadata
 	// +kubebuilder:validation:Optional
 	AutomatedCleaningMode AutomatedCleaningMode `json:"automatedCleaningMode,omitempty"`
+
+	// SkipInitialCleaning disables cleaning until the host has been
+	// deployed for the first time, making its first deployment
+	// faster. The automated cleaning mode applies afterwards.
+	// +optional
+	SkipInitialCleaning bool `json:"skipInitialCleaning,omitempty"`
+
+	// FastTrack selects whether the host keeps running the agent
+	// between cleaning and deployment instead of rebooting into the
+	// ramdisk again, which shortens the deployment. Unset uses the
+	// setting of the provisioning backend.
+	// +optional
+	FastTrack *bool `json:"fastTrack,omitempty"`
+}
+
+// PostDeployScript describes a script run by a custom deploy step
+// after the image is written. The deploy ramdisk must provide the step,
+// e.g. with a custom hardware manager.
+type PostDeployScript struct {
+	// URL is the http or https location the deploy ramdisk downloads
+	// the script from.
+	URL string `json:"url"`
 }
 
 // AutomatedCleaningMode is the interface to enable/disable automated cleaning
-// +kubebuilder:validation:Enum:=metadata;disabled
+// +kubebuilder:validation:Enum:=metadata;full;disabled
 type AutomatedCleaningMode string
 
 // Allowed automated cleaning modes
 const (
 	CleaningModeDisabled AutomatedCleaningMode = "disabled"
 	CleaningModeMetadata AutomatedCleaningMode = "metadata"
+	CleaningModeFull     AutomatedCleaningMode = "full"
 )
 
 // ChecksumType holds the algorithm name for the checksum
@@ -394,7 +772,9 @@ const (
 // Image holds the details of an image either to provisioned or that
 // has been provisioned.
 type Image struct {
-	// URL is a location of an image to deploy.
+	// URL is a location of an image to deploy. Images stored as OCI
+	// artifacts in a container registry are referenced with the
+	// oci:// scheme, e.g. oci://quay.io/example/image:tag.
 	URL string `json:"url"`
 
 	// Checksum is the checksum for the image.
@@ -411,8 +791,95 @@ type Image struct {
 	// are not required and if specified will be ignored.
 	// +kubebuilder:validation:Enum=raw;qcow2;vdi;vmdk;live-iso
 	DiskFormat *string `json:"format,omitempty"`
+
+	// DownloadSource overrides how the image reaches the host: http
+	// lets the deploy agent download it from the URL, local has the
+	// conductor cache and serve it, and swift uses a temporary Swift
+	// URL. The Ironic configuration decides when it is not set.
+	// +optional
+	DownloadSource ImageDownloadSource `json:"downloadSource,omitempty"`
+
+	// Mirrors lists other locations of the image, tried in order when
+	// the deployment from the previous location fails.
+	// +optional
+	Mirrors []ImageMirror `json:"mirrors,omitempty"`
+
+	// RootPartitionSizeGibibytes makes Ironic deploy the image as a
+	// partition image, writing it to a root partition of this size and
+	// leaving the rest of the root device free. It cannot be set for
+	// live ISOs.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RootPartitionSizeGibibytes *int `json:"rootPartitionSizeGibibytes,omitempty"`
+
+	// Kind overrides the detection of whether the image is written to
+	// the whole disk or to a root partition, which otherwise treats the
+	// image as a partition image when a root partition size or a
+	// kernel and ramdisk are set. Images set as partition images need
+	// a kernel and a ramdisk, whole disk images must not have them.
+	// +optional
+	Kind ImageKind `json:"kind,omitempty"`
+
+	// KernelURL is the location of the kernel booting a partition
+	// image.
+	// +optional
+	KernelURL string `json:"kernelURL,omitempty"`
+
+	// RamdiskURL is the location of the initial ramdisk booting a
+	// partition image.
+	// +optional
+	RamdiskURL string `json:"ramdiskURL,omitempty"`
+
+	// HTTPHeadersSecret references the Secret holding the HTTP
+	// headers sent when downloading the image, e.g. to authenticate
+	// to a private image server. Each key of the Secret is the name
+	// of a header and its value the value of the header. The Secret
+	// must be in the namespace of the host.
+	// +optional
+	HTTPHeadersSecret *corev1.SecretReference `json:"httpHeadersSecret,omitempty"`
 }
 
+// ImageKind tells how an image is written to the root device.
+// +kubebuilder:validation:Enum=wholeDisk;partition
+type ImageKind string
+
+const (
+	// ImageKindWholeDisk is an image holding a partition table, written
+	// to the whole root device
+	ImageKindWholeDisk ImageKind = "wholeDisk"
+
+	// ImageKindPartition is an image holding a single file system,
+	// written to a root partition and booted with its own kernel and
+	// ramdisk
+	ImageKindPartition ImageKind = "partition"
+)
+
+// ImageMirror holds the details of another location of an image
+type ImageMirror struct {
+	// URL is the location of the copy of the image.
+	URL string `json:"url"`
+
+	// Checksum is the checksum for the copy of the image, needed when
+	// it is not an identical copy. The checksum of the image is used
+	// when it is not set.
+	Checksum string `json:"checksum,omitempty"`
+
+	// ChecksumType is the checksum algorithm for the copy of the
+	// image. e.g md5, sha256, sha512
+	ChecksumType ChecksumType `json:"checksumType,omitempty"`
+}
+
+// ImageDownloadSource is the way the deploy agent downloads the image
+// +kubebuilder:validation:Enum=swift;http;local
+type ImageDownloadSource string
+
+// Allowed image download sources
+const (
+	ImageDownloadSourceSwift ImageDownloadSource = "swift"
+	ImageDownloadSourceHTTP  ImageDownloadSource = "http"
+	ImageDownloadSourceLocal ImageDownloadSource = "local"
+)
+
 // FIXME(dhellmann): We probably want some other module to own these
 // data structures.
 
@@ -526,12 +993,18 @@ type NIC struct {
 
 	// Whether the NIC is PXE Bootable
 	PXE bool `json:"pxe,omitempty"`
+
+	// Whether inspection found no link on the NIC
+	LinkDown bool `json:"linkDown,omitempty"`
 }
 
 // Firmware describes the firmware on the host.
 type Firmware struct {
 	// The BIOS for this firmware
 	BIOS BIOS `json:"bios,omitempty"`
+
+	// The firmware version of the BMC
+	BMCVersion string `json:"bmcVersion,omitempty"`
 }
 
 // BIOS describes the BIOS version on the host.
@@ -556,6 +1029,250 @@ type HardwareDetails struct {
 	Storage      []Storage            `json:"storage,omitempty"`
 	CPU          CPU                  `json:"cpu,omitempty"`
 	Hostname     string               `json:"hostname,omitempty"`
+	// TPM is not set if the inspection did not report whether the
+	// host has a TPM.
+	TPM *TPM `json:"tpm,omitempty"`
+	// The hardware RAID controllers found by the inspection and the
+	// physical disks attached to them.
+	RAIDControllers []RAIDController `json:"raidControllers,omitempty"`
+	// The chassis holding the host, only known for BMCs using
+	// Redfish.
+	Chassis *Chassis `json:"chassis,omitempty"`
+	// The GPUs and other processing accelerators found among the PCI
+	// devices of the host.
+	Accelerators []Accelerator `json:"accelerators,omitempty"`
+	// The populated memory slots of the host.
+	MemoryModules []MemoryModule `json:"memoryModules,omitempty"`
+	// The URL of the full inspection data of the host, when the
+	// operator stores it outside of the host. The memory modules and
+	// accelerators are then left out of the hardware details.
+	InspectionDataURL string `json:"inspectionDataURL,omitempty"`
+}
+
+// MemoryModule describes a memory module (DIMM) of the host.
+type MemoryModule struct {
+	// The label of the slot holding the module, e.g. "DIMM_A1"
+	Slot string `json:"slot"`
+
+	// The size of the module
+	SizeMebibytes int `json:"sizeMebibytes,omitempty"`
+
+	// The clock speed of the module
+	SpeedMHz int `json:"speedMHz,omitempty"`
+
+	// The memory technology, e.g. "DDR4"
+	Type string `json:"type,omitempty"`
+
+	Manufacturer string `json:"manufacturer,omitempty"`
+	PartNumber   string `json:"partNumber,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+}
+
+// AcceleratorType tells GPUs from the other processing accelerators
+type AcceleratorType string
+
+const (
+	// AcceleratorTypeGPU is the type of the GPUs
+	AcceleratorTypeGPU AcceleratorType = "gpu"
+	// AcceleratorTypeOther is the type of the other processing
+	// accelerators
+	AcceleratorTypeOther AcceleratorType = "accelerator"
+)
+
+// Accelerator describes identical GPUs or other processing accelerators
+// of the host.
+type Accelerator struct {
+	// Either gpu or accelerator
+	Type AcceleratorType `json:"type"`
+
+	// The PCI vendor ID, e.g. "10de"
+	VendorID string `json:"vendorID"`
+
+	// The PCI device ID, e.g. "20b0"
+	DeviceID string `json:"deviceID"`
+
+	// The name of the vendor, if known
+	Vendor string `json:"vendor,omitempty"`
+
+	// The model, if known
+	Model string `json:"model,omitempty"`
+
+	// How many of these devices the host has
+	Count int `json:"count"`
+}
+
+// Chassis describes the physical enclosure of the host.
+type Chassis struct {
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"`
+	SerialNumber string `json:"serialNumber,omitempty"`
+
+	// The slot of the chassis in its enclosure, e.g. "Slot 3"
+	Slot string `json:"slot,omitempty"`
+
+	// The rack holding the chassis
+	Rack string `json:"rack,omitempty"`
+}
+
+// BlockingReasonType is the kind of condition keeping a host from
+// leaving its current provisioning state.
+// +kubebuilder:validation:Enum=WaitingForInspection;WaitingForCleaning;BMCUnreachable;CredentialsMissing
+type BlockingReasonType string
+
+const (
+	// BlockingReasonInspection is reported while the inspection of
+	// the host has not finished.
+	BlockingReasonInspection BlockingReasonType = "WaitingForInspection"
+
+	// BlockingReasonCleaning is reported while the host is being
+	// cleaned.
+	BlockingReasonCleaning BlockingReasonType = "WaitingForCleaning"
+
+	// BlockingReasonBMCUnreachable is reported when the provisioner
+	// cannot talk to the BMC of the host.
+	BlockingReasonBMCUnreachable BlockingReasonType = "BMCUnreachable"
+
+	// BlockingReasonCredentialsMissing is reported when the secret
+	// holding the BMC credentials does not exist.
+	BlockingReasonCredentialsMissing BlockingReasonType = "CredentialsMissing"
+)
+
+// BlockingReason describes a condition keeping a host from leaving
+// its current provisioning state.
+type BlockingReason struct {
+	Type BlockingReasonType `json:"type"`
+
+	// A human readable description of the condition
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// FanReading is the reading of a fan sensor of the host.
+type FanReading struct {
+	// The name of the fan, as reported by the BMC
+	Name string `json:"name"`
+
+	// The speed of the fan, in Units
+	// +optional
+	Reading *int `json:"reading,omitempty"`
+
+	// The units of the reading, e.g. "RPM" or "Percent"
+	// +optional
+	Units string `json:"units,omitempty"`
+
+	// The health of the fan reported by the BMC, e.g. "OK"
+	// +optional
+	Health string `json:"health,omitempty"`
+
+	// OutOfRange is set when the reading is outside the thresholds
+	// reported by the BMC.
+	// +optional
+	OutOfRange bool `json:"outOfRange,omitempty"`
+}
+
+// TemperatureReading is the reading of a temperature sensor of the
+// host.
+type TemperatureReading struct {
+	// The name of the sensor, as reported by the BMC
+	Name string `json:"name"`
+
+	// The temperature, in degrees Celsius
+	// +optional
+	ReadingCelsius *int `json:"readingCelsius,omitempty"`
+
+	// The temperature from which the BMC considers the reading
+	// critical, in degrees Celsius
+	// +optional
+	UpperThresholdCritical *int `json:"upperThresholdCritical,omitempty"`
+
+	// The health of the sensor reported by the BMC, e.g. "OK"
+	// +optional
+	Health string `json:"health,omitempty"`
+
+	// OutOfRange is set when the reading is outside the thresholds
+	// reported by the BMC.
+	// +optional
+	OutOfRange bool `json:"outOfRange,omitempty"`
+}
+
+// SensorStatus holds the fan and temperature readings of the host.
+type SensorStatus struct {
+	// +optional
+	Fans []FanReading `json:"fans,omitempty"`
+
+	// +optional
+	Temperatures []TemperatureReading `json:"temperatures,omitempty"`
+
+	// When the sensors were last read
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+
+	// When reading the sensors was last attempted, successfully or not
+	// +optional
+	LastAttempted *metav1.Time `json:"lastAttempted,omitempty"`
+}
+
+// OutOfRange returns the names of the sensors whose reading is out
+// of range.
+func (sensors *SensorStatus) OutOfRange() (names []string) {
+	if sensors == nil {
+		return nil
+	}
+	for _, fan := range sensors.Fans {
+		if fan.OutOfRange {
+			names = append(names, fan.Name)
+		}
+	}
+	for _, temperature := range sensors.Temperatures {
+		if temperature.OutOfRange {
+			names = append(names, temperature.Name)
+		}
+	}
+	return names
+}
+
+// RAIDController describes a hardware RAID controller of the host.
+type RAIDController struct {
+	// The name of the controller in the inspection data, e.g.
+	// "Controller_0" or "slot_0"
+	Name string `json:"name"`
+
+	// Hardware model
+	Model string `json:"model,omitempty"`
+
+	// The serial number of the controller
+	SerialNumber string `json:"serialNumber,omitempty"`
+
+	// The physical disks attached to the controller
+	PhysicalDisks []RAIDPhysicalDisk `json:"physicalDisks,omitempty"`
+}
+
+// RAIDPhysicalDisk describes a physical disk attached to a RAID
+// controller.
+type RAIDPhysicalDisk struct {
+	// The slot of the disk on the controller, e.g. "32:1" or "1I:1:1"
+	Slot string `json:"slot"`
+
+	// The interface type of the disk, e.g. "SAS" or "SATA"
+	Type string `json:"type,omitempty"`
+
+	// The size of the disk in Bytes
+	SizeBytes Capacity `json:"sizeBytes,omitempty"`
+
+	// Hardware model
+	Model string `json:"model,omitempty"`
+
+	// The serial number of the disk
+	SerialNumber string `json:"serialNumber,omitempty"`
+}
+
+// TPM describes the Trusted Platform Module of the host.
+type TPM struct {
+	// Whether the host has a TPM
+	Present bool `json:"present"`
+
+	// The version of the TPM specification it implements, e.g. 2.0
+	Version string `json:"version,omitempty"`
 }
 
 // HardwareSystemVendor stores details about the whole hardware system.
@@ -587,6 +1304,16 @@ type RebootAnnotationArguments struct {
 	Mode RebootMode `json:"mode"`
 }
 
+// BootDeviceAnnotationArguments defines the arguments of the boot device
+// annotation
+type BootDeviceAnnotationArguments struct {
+	// The device to boot from, e.g. "pxe" or "cdrom"
+	Device string `json:"device"`
+	// Whether the device is used for all the future boots instead of
+	// only the next one
+	Persistent bool `json:"persistent,omitempty"`
+}
+
 // Match compares the saved status information with the name and
 // content of a secret object.
 func (cs CredentialsStatus) Match(secret corev1.Secret) bool {
@@ -621,13 +1348,212 @@ func (om OperationMetric) Duration() time.Duration {
 	return om.End.Time.Sub(om.Start.Time)
 }
 
+// ConfigDriveFile is a file written to the host by cloud-init from the
+// Config Drive.
+type ConfigDriveFile struct {
+	// Path is the absolute path of the file on the host
+	Path string `json:"path"`
+
+	// SecretRef is the reference to the Secret holding the content of
+	// the file
+	SecretRef corev1.SecretReference `json:"secretRef"`
+
+	// Key is the key of the Secret holding the content of the file
+	Key string `json:"key"`
+}
+
+// TimeSettings holds the time configuration of a host.
+type TimeSettings struct {
+	// NTPServers are the IP addresses or host names of the NTP servers
+	// the host synchronizes its clock with.
+	// +optional
+	NTPServers []string `json:"ntpServers,omitempty"`
+
+	// Timezone is the name of the time zone of the host in the IANA
+	// time zone database, e.g. Europe/Berlin.
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// SerialConsole describes the TCP proxy exposing the serial-over-LAN
+// console of a host.
+type SerialConsole struct {
+	// Host is the address of the proxy
+	Host string `json:"host"`
+
+	// Port is the TCP port of the proxy
+	Port int `json:"port"`
+}
+
+// NodeProperties holds the scheduling properties of a host. The
+// properties that are not set are left to inspection.
+type NodeProperties struct {
+	// CPUArch is the architecture of the CPUs, e.g. x86_64.
+	// +optional
+	CPUArch string `json:"cpuArch,omitempty"`
+
+	// CPUs is the number of CPUs.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	CPUs *int `json:"cpus,omitempty"`
+
+	// MemoryMiB is the size of the memory in MiB.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MemoryMiB *int `json:"memoryMiB,omitempty"`
+
+	// LocalGiB is the size of the root device in GiB.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	LocalGiB *int `json:"localGiB,omitempty"`
+
+	// Force replaces the values reported by inspection, which are
+	// otherwise kept.
+	// +optional
+	Force bool `json:"force,omitempty"`
+}
+
+// StepRetryLimits holds the number of automatic retries of the steps
+// of a host that failed because of a transient problem.
+type StepRetryLimits struct {
+	// Deploy is how many times a failed deploy is retried, 3 if not
+	// set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	Deploy *int `json:"deploy,omitempty"`
+
+	// Clean is how many times a failed manual cleaning, applying the
+	// RAID and firmware settings, is retried, 0 if not set.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	Clean *int `json:"clean,omitempty"`
+}
+
+// DeployTimeouts holds the time limits of the deploy of a host. A limit
+// that is not set is left to the provisioning backend.
+type DeployTimeouts struct {
+	// NetworkBootSeconds is how long to wait for the deploy ramdisk
+	// to boot from the network and check in with the provisioning
+	// backend.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	NetworkBootSeconds int `json:"networkBootSeconds,omitempty"`
+
+	// DeploySeconds is how long the deploy may take since the
+	// provisioning of the host started, including the network boot.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	DeploySeconds int `json:"deploySeconds,omitempty"`
+}
+
+// DeployNetworks holds the UUIDs of the networks used to deploy a
+// host.
+type DeployNetworks struct {
+	// ProvisioningNetwork is the UUID of the network the host is
+	// attached to while its image is written.
+	// +kubebuilder:validation:Pattern=`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`
+	// +optional
+	ProvisioningNetwork string `json:"provisioningNetwork,omitempty"`
+
+	// CleaningNetwork is the UUID of the network the host is
+	// attached to while it is cleaned.
+	// +kubebuilder:validation:Pattern=`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`
+	// +optional
+	CleaningNetwork string `json:"cleaningNetwork,omitempty"`
+}
+
+// SchedulingStatus describes the scheduling properties of a host in
+// the provisioning backend.
+type SchedulingStatus struct {
+	// ResourceClass is the resource class of the host
+	ResourceClass string `json:"resourceClass,omitempty"`
+
+	// Traits are the traits of the host
+	Traits []string `json:"traits,omitempty"`
+}
+
+// ConductorStatus describes the conductor of the provisioning backend
+// serving a host.
+type ConductorStatus struct {
+	// Name is the hostname of the conductor managing the host, empty
+	// when no live conductor is available for it.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Group is the conductor group the host belongs to.
+	// +optional
+	Group string `json:"group,omitempty"`
+
+	// Reservation is the hostname of the conductor currently holding
+	// the lock of the host while it runs an operation on it.
+	// +optional
+	Reservation string `json:"reservation,omitempty"`
+}
+
+// BootDeviceStatus describes the device set for a host to boot from.
+type BootDeviceStatus struct {
+	// Device is the device the host boots from, e.g. "pxe" or "disk"
+	Device string `json:"device"`
+
+	// Persistent tells whether the device is used for all the future
+	// boots instead of only the next one
+	Persistent bool `json:"persistent"`
+}
+
+// AllocationStatus describes an allocation of the provisioning backend
+// that selected a host.
+type AllocationStatus struct {
+	// UUID is the identifier of the allocation in the provisioning
+	// backend, e.g. to correlate the host with the allocation
+	// requested by a higher level controller.
+	UUID string `json:"uuid,omitempty"`
+
+	// Name is the name of the allocation
+	Name string `json:"name,omitempty"`
+
+	// State is the state of the allocation
+	State string `json:"state"`
+
+	// Traits are the traits requested by the allocation
+	Traits []string `json:"traits,omitempty"`
+
+	// LastError is the reason the allocation failed, when it is in
+	// the error state.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
 // OperationHistory holds information about operations performed on a
 // host.
 type OperationHistory struct {
 	Register    OperationMetric `json:"register,omitempty"`
 	Inspect     OperationMetric `json:"inspect,omitempty"`
 	Provision   OperationMetric `json:"provision,omitempty"`
+	Provisioned OperationMetric `json:"provisioned,omitempty"`
 	Deprovision OperationMetric `json:"deprovision,omitempty"`
+
+	// Events holds the most recent events recorded by the
+	// provisioner for this host, oldest first.
+	// +optional
+	Events []HistoryEvent `json:"events,omitempty"`
+}
+
+// HistoryEvent is an event recorded by the provisioner for a host
+type HistoryEvent struct {
+	// Time is when the event happened
+	// +nullable
+	Time metav1.Time `json:"time,omitempty"`
+
+	// Severity of the event, e.g. "ERROR"
+	Severity string `json:"severity,omitempty"`
+
+	// EventType indicates the kind of action that caused the event
+	EventType string `json:"eventType,omitempty"`
+
+	// Event is the description of the event
+	Event string `json:"event"`
 }
 
 // BareMetalHostStatus defines the observed state of BareMetalHost
@@ -641,7 +1567,7 @@ type BareMetalHostStatus struct {
 
 	// ErrorType indicates the type of failure encountered when the
 	// OperationalStatus is OperationalStatusError
-	// +kubebuilder:validation:Enum=provisioned registration error;registration error;inspection error;preparation error;provisioning error;power management error
+	// +kubebuilder:validation:Enum=provisioned registration error;registration error;inspection error;preparation error;provisioning error;power management error;conductor error
 	ErrorType ErrorType `json:"errorType,omitempty"`
 
 	// LastUpdated identifies when this status was last observed.
@@ -669,6 +1595,91 @@ type BareMetalHostStatus struct {
 	// indicator for whether or not the host is powered on
 	PoweredOn bool `json:"poweredOn"`
 
+	// FirmwareConverged indicates whether the BIOS settings reported
+	// by the host match the requested firmware configuration. It is
+	// not set when no BIOS settings are requested.
+	// +optional
+	FirmwareConverged *bool `json:"firmwareConverged,omitempty"`
+
+	// FirmwareSettingsDiff lists the differences between the
+	// requested firmware configuration and the BIOS settings reported
+	// by the host, while a changed configuration waits to be applied.
+	// +optional
+	FirmwareSettingsDiff []BIOSSettingDiff `json:"firmwareSettingsDiff,omitempty"`
+
+	// FirmwareSettingsDiffGeneration is the generation of the host
+	// the firmware settings difference was computed for.
+	// +optional
+	FirmwareSettingsDiffGeneration int64 `json:"firmwareSettingsDiffGeneration,omitempty"`
+
+	// SerialConsole holds the details needed to connect to the
+	// serial-over-LAN console of the host when it is enabled.
+	// +optional
+	SerialConsole *SerialConsole `json:"serialConsole,omitempty"`
+
+	// Allocation describes the allocation the host belongs to in the
+	// provisioning backend, if any.
+	// +optional
+	Allocation *AllocationStatus `json:"allocation,omitempty"`
+
+	// Scheduling holds the resource class and traits of the host as
+	// observed in the provisioning backend, whether they are managed
+	// through the host or not.
+	// +optional
+	Scheduling *SchedulingStatus `json:"scheduling,omitempty"`
+
+	// Conductor describes the conductor serving the host in the
+	// provisioning backend, to help debugging stuck operations.
+	// +optional
+	Conductor *ConductorStatus `json:"conductor,omitempty"`
+
+	// LastInspected records when the last inspection of the host
+	// finished, as reported by the provisioning backend.
+	// +optional
+	LastInspected *metav1.Time `json:"lastInspected,omitempty"`
+
+	// NodeCreatedAt records when the host was created in the
+	// provisioning backend.
+	// +optional
+	NodeCreatedAt *metav1.Time `json:"nodeCreatedAt,omitempty"`
+
+	// BootInterface is the boot interface the provisioning backend
+	// uses for the host, e.g. ipxe or redfish-virtual-media.
+	// +optional
+	BootInterface string `json:"bootInterface,omitempty"`
+
+	// HardwareFault is the reason the host was put in maintenance
+	// mode in the provisioning backend because of a hardware fault,
+	// e.g. by the monitoring of the sensors of its BMC. The operator
+	// does not take the host out of such a maintenance mode.
+	// +optional
+	HardwareFault string `json:"hardwareFault,omitempty"`
+
+	// LastBMCReset records when the BMC was last reset on request.
+	// +optional
+	LastBMCReset *metav1.Time `json:"lastBMCReset,omitempty"`
+
+	// BlockingReasons lists what the host is waiting for while it is
+	// in a transitional state.
+	// +optional
+	BlockingReasons []BlockingReason `json:"blockingReasons,omitempty"`
+
+	// Sensors holds the fan and temperature readings of the host,
+	// refreshed periodically for BMCs reporting them.
+	// +optional
+	Sensors *SensorStatus `json:"sensors,omitempty"`
+
+	// BootDevice is the device last set from the bootDevice field of
+	// the spec.
+	// +optional
+	BootDevice *BootDeviceStatus `json:"bootDevice,omitempty"`
+
+	// ShutdownDeadline is set once the shutdown hook has been called
+	// and records until when the pending power off waits for the
+	// shutdown to be confirmed.
+	// +optional
+	ShutdownDeadline *metav1.Time `json:"shutdownDeadline,omitempty"`
+
 	// OperationHistory holds information about operations performed
 	// on this host.
 	OperationHistory OperationHistory `json:"operationHistory,omitempty"`
@@ -698,6 +1709,47 @@ type ProvisionStatus struct {
 
 	// The Raid set by the user
 	RAID *RAIDConfig `json:"raid,omitempty"`
+
+	// The Firmware set by the user
+	Firmware *FirmwareConfig `json:"firmware,omitempty"`
+
+	// DeployRetries records how many times the deploy has been retried
+	// automatically after a recoverable failure
+	DeployRetries int `json:"deployRetries,omitempty"`
+
+	// CleanRetries records how many times the manual cleaning has
+	// been retried automatically after a recoverable failure
+	CleanRetries int `json:"cleanRetries,omitempty"`
+
+	// InitialDeployComplete records that the host has been
+	// provisioned at least once
+	InitialDeployComplete bool `json:"initialDeployComplete,omitempty"`
+
+	// ErasePending records that the whole disks of the host are
+	// overwritten the next time it is prepared, as requested by the
+	// full automated cleaning mode
+	ErasePending bool `json:"erasePending,omitempty"`
+
+	// DeploymentID identifies the consumer the image was provisioned
+	// for. The provisioned instance is tagged with it.
+	DeploymentID string `json:"deploymentID,omitempty"`
+
+	// ImageCache tells whether the image was already cached by the
+	// provisioner when its deploy started.
+	ImageCache *ImageCacheStatus `json:"imageCache,omitempty"`
+}
+
+// ImageCacheStatus describes whether an image is cached by the
+// provisioner
+type ImageCacheStatus struct {
+	// URL is the location of the image.
+	URL string `json:"url"`
+
+	// Cached is true when the image does not need to be downloaded
+	// again. It is not set if the provisioner does not report its
+	// cache.
+	// +optional
+	Cached *bool `json:"cached,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -803,6 +1855,15 @@ func (host *BareMetalHost) CredentialsKey() types.NamespacedName {
 	}
 }
 
+// PowerOnDependencyKey returns a NamespacedName suitable for loading
+// the host that must be ready before this host is powered on.
+func (host *BareMetalHost) PowerOnDependencyKey() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      host.Spec.PowerOnAfter,
+		Namespace: host.ObjectMeta.Namespace,
+	}
+}
+
 // NeedsHardwareInspection looks at the state of the host to determine
 // if hardware inspection should be run.
 func (host *BareMetalHost) NeedsHardwareInspection() bool {
@@ -918,6 +1979,8 @@ func (host *BareMetalHost) OperationMetricForState(operation ProvisioningState)
 		metric = &history.Inspect
 	case StateProvisioning:
 		metric = &history.Provision
+	case StateProvisioned:
+		metric = &history.Provisioned
 	case StateDeprovisioning:
 		metric = &history.Deprovision
 	}
@@ -936,6 +1999,12 @@ func (image *Image) GetChecksum() (checksum, checksumType string, ok bool) {
 		return
 	}
 
+	if image.IsOCI() && image.Checksum == "" {
+		// The registry provides the digest of OCI artifacts
+		ok = true
+		return
+	}
+
 	if image.Checksum == "" {
 		// Return empty if checksum is not provided
 		return
@@ -955,6 +2024,322 @@ func (image *Image) GetChecksum() (checksum, checksumType string, ok bool) {
 	return
 }
 
+// OCIImagePrefix is the URL scheme of images stored in a container
+// registry as OCI artifacts
+const OCIImagePrefix = "oci://"
+
+// ociReferenceRegexp matches a reference to an OCI artifact, i.e. a
+// registry with an optional port, a repository path and a tag and/or
+// a digest.
+var ociReferenceRegexp = regexp.MustCompile(
+	`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]+)?` +
+		`(/[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*)+` +
+		`(:[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127})?` +
+		`(@[a-zA-Z][a-zA-Z0-9]*([-_+.][a-zA-Z][a-zA-Z0-9]*)*:[0-9a-fA-F]{32,})?$`)
+
+// IsOCI returns whether the image is an OCI artifact in a container
+// registry.
+func (image *Image) IsOCI() bool {
+	return image != nil && strings.HasPrefix(image.URL, OCIImagePrefix)
+}
+
+// ValidateOCIReference checks the format of the reference of an OCI
+// image. Images using other schemes are not checked.
+func (image *Image) ValidateOCIReference() error {
+	if !image.IsOCI() {
+		return nil
+	}
+	if !ociReferenceRegexp.MatchString(strings.TrimPrefix(image.URL, OCIImagePrefix)) {
+		return fmt.Errorf("invalid OCI image reference %q", image.URL)
+	}
+	if image.DiskFormat != nil && *image.DiskFormat == "live-iso" {
+		return fmt.Errorf("OCI image %q cannot be used as a live ISO", image.URL)
+	}
+	return nil
+}
+
+// ValidateLiveISO checks that the URL of an image booted as a live ISO
+// can be passed to Ironic as the boot ISO of a ramdisk deployment.
+// Images using other formats are not checked.
+func (image *Image) ValidateLiveISO() error {
+	if image == nil || image.DiskFormat == nil || *image.DiskFormat != "live-iso" {
+		return nil
+	}
+	isoURL, err := url.Parse(image.URL)
+	if err != nil || (isoURL.Host == "" && isoURL.Scheme != "file") {
+		return fmt.Errorf("invalid live ISO URL %q", image.URL)
+	}
+	switch isoURL.Scheme {
+	case "http", "https", "file":
+		return nil
+	default:
+		return fmt.Errorf("unsupported scheme %q for live ISO %q, expected http, https or file",
+			isoURL.Scheme, image.URL)
+	}
+}
+
+// ValidateRootPartitionSize checks that the root partition size of the
+// image is positive and only set for images written to disk.
+func (image *Image) ValidateRootPartitionSize() error {
+	if image == nil || image.RootPartitionSizeGibibytes == nil {
+		return nil
+	}
+	if *image.RootPartitionSizeGibibytes <= 0 {
+		return fmt.Errorf("the root partition size of image %q must be positive, got %d",
+			image.URL, *image.RootPartitionSizeGibibytes)
+	}
+	if image.DiskFormat != nil && *image.DiskFormat == "live-iso" {
+		return fmt.Errorf("live ISO %q cannot have a root partition size", image.URL)
+	}
+	return nil
+}
+
+// GetKind returns whether the image is written to the whole disk or to
+// a root partition. Unless the kind is set, images with a root
+// partition size or a kernel and ramdisk are partition images.
+func (image *Image) GetKind() ImageKind {
+	switch {
+	case image.Kind != "":
+		return image.Kind
+	case image.RootPartitionSizeGibibytes != nil, image.KernelURL != "", image.RamdiskURL != "":
+		return ImageKindPartition
+	default:
+		return ImageKindWholeDisk
+	}
+}
+
+// ValidateKind checks that the kernel and ramdisk of the image match
+// its kind.
+func (image *Image) ValidateKind() error {
+	if image == nil {
+		return nil
+	}
+	if image.DiskFormat != nil && *image.DiskFormat == "live-iso" {
+		if image.Kind != "" || image.KernelURL != "" || image.RamdiskURL != "" {
+			return fmt.Errorf("live ISO %q cannot have an image kind, a kernel or a ramdisk", image.URL)
+		}
+		return nil
+	}
+	if (image.KernelURL == "") != (image.RamdiskURL == "") {
+		return fmt.Errorf("image %q must have both a kernel and a ramdisk or neither", image.URL)
+	}
+	switch image.GetKind() {
+	case ImageKindWholeDisk:
+		if image.KernelURL != "" {
+			return fmt.Errorf("whole disk image %q cannot have a kernel and a ramdisk", image.URL)
+		}
+		if image.RootPartitionSizeGibibytes != nil {
+			return fmt.Errorf("whole disk image %q cannot have a root partition size", image.URL)
+		}
+	case ImageKindPartition:
+		if image.Kind == ImageKindPartition && image.KernelURL == "" {
+			return fmt.Errorf("partition image %q must have a kernel and a ramdisk", image.URL)
+		}
+		if image.RootPartitionSizeGibibytes == nil {
+			return fmt.Errorf("partition image %q must have a root partition size", image.URL)
+		}
+	default:
+		return fmt.Errorf("unknown kind %q of image %q", image.Kind, image.URL)
+	}
+	return nil
+}
+
+// Sources returns the locations the image can be deployed from: the
+// image itself, then each of its mirrors. The mirrors without a
+// checksum use the checksum of the image.
+func (image *Image) Sources() (sources []Image) {
+	if image == nil {
+		return nil
+	}
+	primary := *image
+	primary.Mirrors = nil
+	sources = append(sources, primary)
+
+	for _, mirror := range image.Mirrors {
+		source := primary
+		source.URL = mirror.URL
+		if mirror.Checksum != "" {
+			source.Checksum = mirror.Checksum
+			source.ChecksumType = mirror.ChecksumType
+		}
+		sources = append(sources, source)
+	}
+	return
+}
+
+// ValidateMirrors checks that each mirror of the image can be deployed
+// the same way as the image.
+func (image *Image) ValidateMirrors() error {
+	if image == nil {
+		return nil
+	}
+	urls := map[string]bool{image.URL: true}
+	for _, source := range image.Sources()[1:] {
+		if source.URL == "" {
+			return fmt.Errorf("mirror of image %q has no URL", image.URL)
+		}
+		if urls[source.URL] {
+			return fmt.Errorf("image location %q is listed more than once", source.URL)
+		}
+		urls[source.URL] = true
+
+		if err := source.ValidateOCIReference(); err != nil {
+			return err
+		}
+		if err := source.ValidateLiveISO(); err != nil {
+			return err
+		}
+		if _, _, ok := source.GetChecksum(); !ok {
+			return fmt.Errorf("mirror %q of image %q has no valid checksum", source.URL, image.URL)
+		}
+	}
+	return nil
+}
+
+// ValidateDownloadSource checks that the download source requested
+// for the image is supported.
+func (image *Image) ValidateDownloadSource() error {
+	if image == nil {
+		return nil
+	}
+	switch image.DownloadSource {
+	case "", ImageDownloadSourceSwift, ImageDownloadSourceHTTP, ImageDownloadSourceLocal:
+		return nil
+	default:
+		return fmt.Errorf("unsupported download source %q for image %q, expected swift, http or local",
+			image.DownloadSource, image.URL)
+	}
+}
+
+// BIOSSettings returns all the BIOS settings requested, including the
+// one holding the boot order.
+func (config *FirmwareConfig) BIOSSettings() map[string]string {
+	if config == nil {
+		return nil
+	}
+	if len(config.BootOrder) == 0 {
+		return config.Settings
+	}
+
+	settings := make(map[string]string, len(config.Settings)+1)
+	for name, value := range config.Settings {
+		settings[name] = value
+	}
+	name := config.BootOrderSetting
+	if name == "" {
+		name = DefaultBootOrderSetting
+	}
+	settings[name] = strings.Join(config.BootOrder, ",")
+	return settings
+}
+
+// ValidateSettings checks the settings against the schema. Setting
+// names are matched case-insensitively and the settings the schema does
+// not describe are accepted, since not every BMC reports a registry.
+func (schema FirmwareSchema) ValidateSettings(settings map[string]string) error {
+	if len(schema) == 0 {
+		return nil
+	}
+
+	schemaNames := make(map[string]string, len(schema))
+	for name := range schema {
+		schemaNames[strings.ToLower(name)] = name
+	}
+
+	names := make([]string, 0, len(settings))
+	for name := range settings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		schemaName, found := schemaNames[strings.ToLower(name)]
+		if !found {
+			continue
+		}
+		if err := schema[schemaName].validate(settings[name]); err != nil {
+			return fmt.Errorf("invalid value %q for BIOS setting %s, %s", settings[name], schemaName, err)
+		}
+	}
+	return nil
+}
+
+func (setting SettingSchema) validate(value string) error {
+	if setting.ReadOnly {
+		return fmt.Errorf("the setting is read-only")
+	}
+
+	switch setting.AttributeType {
+	case "Enumeration":
+		for _, allowed := range setting.AllowableValues {
+			if strings.EqualFold(allowed, value) {
+				return nil
+			}
+		}
+		if len(setting.AllowableValues) != 0 {
+			return fmt.Errorf("it must be one of %s", strings.Join(setting.AllowableValues, ", "))
+		}
+	case "Integer":
+		number, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("it must be an integer")
+		}
+		if setting.LowerBound != nil && number < *setting.LowerBound {
+			return fmt.Errorf("it must not be lower than %d", *setting.LowerBound)
+		}
+		if setting.UpperBound != nil && number > *setting.UpperBound {
+			return fmt.Errorf("it must not be higher than %d", *setting.UpperBound)
+		}
+	case "String", "Password":
+		if setting.MinLength != nil && len(value) < *setting.MinLength {
+			return fmt.Errorf("it must not be shorter than %d characters", *setting.MinLength)
+		}
+		if setting.MaxLength != nil && len(value) > *setting.MaxLength {
+			return fmt.Errorf("it must not be longer than %d characters", *setting.MaxLength)
+		}
+	case "Boolean":
+		if _, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("it must be true or false")
+		}
+	}
+	return nil
+}
+
+// ValidateBootOrder checks that the boot entries can be written to a
+// single BIOS setting.
+func (config *FirmwareConfig) ValidateBootOrder() error {
+	if config == nil || len(config.BootOrder) == 0 {
+		if config != nil && config.BootOrderSetting != "" {
+			return fmt.Errorf("bootOrderSetting is set without a bootOrder")
+		}
+		return nil
+	}
+
+	seen := make(map[string]bool, len(config.BootOrder))
+	for _, entry := range config.BootOrder {
+		switch {
+		case strings.TrimSpace(entry) == "":
+			return fmt.Errorf("the boot order has an empty entry")
+		case strings.Contains(entry, ","):
+			return fmt.Errorf("invalid boot entry %q, it must not contain a comma", entry)
+		case seen[entry]:
+			return fmt.Errorf("boot entry %q is listed more than once", entry)
+		}
+		seen[entry] = true
+	}
+
+	name := config.BootOrderSetting
+	if name == "" {
+		name = DefaultBootOrderSetting
+	}
+	for setting := range config.Settings {
+		if strings.EqualFold(setting, name) {
+			return fmt.Errorf("the boot order setting %s is also set in the settings", setting)
+		}
+	}
+	return nil
+}
+
 // +kubebuilder:object:root=true
 
 // BareMetalHostList contains a list of BareMetalHost
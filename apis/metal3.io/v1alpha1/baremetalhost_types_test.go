@@ -430,6 +430,21 @@ func TestGetImageChecksum(t *testing.T) {
 			},
 			Expected: false,
 		},
+		{
+			Scenario: "oci image without checksum",
+			Host: BareMetalHost{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "myhost",
+					Namespace: "myns",
+				},
+				Spec: BareMetalHostSpec{
+					Image: &Image{
+						URL: "oci://quay.io/example/image:v1",
+					},
+				},
+			},
+			Expected: true,
+		},
 		{
 			Scenario: "no image",
 			Host: BareMetalHost{
@@ -451,6 +466,552 @@ func TestGetImageChecksum(t *testing.T) {
 	}
 }
 
+func TestValidateOCIReference(t *testing.T) {
+	liveISO := "live-iso"
+	for _, tc := range []struct {
+		Scenario string
+		Image    *Image
+		Error    string
+	}{
+		{
+			Scenario: "not an oci image",
+			Image:    &Image{URL: "http://example.com/image.qcow2"},
+		},
+		{
+			Scenario: "tag",
+			Image:    &Image{URL: "oci://quay.io/example/image:v1.0"},
+		},
+		{
+			Scenario: "registry port and nested repository",
+			Image:    &Image{URL: "oci://registry.example.com:5000/team/images/centos-stream_9"},
+		},
+		{
+			Scenario: "digest",
+			Image:    &Image{URL: "oci://quay.io/example/image@sha256:8f3e1cd3e5a6e4d6c0dfd6a4b2f1c1a0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4"},
+		},
+		{
+			Scenario: "tag and digest",
+			Image:    &Image{URL: "oci://quay.io/example/image:v1@sha256:8f3e1cd3e5a6e4d6c0dfd6a4b2f1c1a0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4"},
+		},
+		{
+			Scenario: "no repository",
+			Image:    &Image{URL: "oci://quay.io"},
+			Error:    "invalid OCI image reference \"oci://quay.io\"",
+		},
+		{
+			Scenario: "uppercase repository",
+			Image:    &Image{URL: "oci://quay.io/Example/image"},
+			Error:    "invalid OCI image reference \"oci://quay.io/Example/image\"",
+		},
+		{
+			Scenario: "invalid digest",
+			Image:    &Image{URL: "oci://quay.io/example/image@sha256:abc"},
+			Error:    "invalid OCI image reference \"oci://quay.io/example/image@sha256:abc\"",
+		},
+		{
+			Scenario: "live iso",
+			Image:    &Image{URL: "oci://quay.io/example/image:v1", DiskFormat: &liveISO},
+			Error:    "OCI image \"oci://quay.io/example/image:v1\" cannot be used as a live ISO",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			err := tc.Image.ValidateOCIReference()
+			if tc.Error == "" {
+				if err != nil {
+					t.Errorf("unexpected error %s", err)
+				}
+			} else if err == nil || err.Error() != tc.Error {
+				t.Errorf("expected error %q but got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
+func TestValidateLiveISO(t *testing.T) {
+	liveISO := "live-iso"
+	qcow2 := "qcow2"
+	for _, tc := range []struct {
+		Scenario string
+		Image    *Image
+		Error    string
+	}{
+		{
+			Scenario: "no image",
+		},
+		{
+			Scenario: "not a live iso",
+			Image:    &Image{URL: "image.qcow2", DiskFormat: &qcow2},
+		},
+		{
+			Scenario: "http",
+			Image:    &Image{URL: "http://example.com/boot.iso", DiskFormat: &liveISO},
+		},
+		{
+			Scenario: "https",
+			Image:    &Image{URL: "https://example.com:8443/images/boot.iso", DiskFormat: &liveISO},
+		},
+		{
+			Scenario: "file",
+			Image:    &Image{URL: "file:///images/boot.iso", DiskFormat: &liveISO},
+		},
+		{
+			Scenario: "relative path",
+			Image:    &Image{URL: "images/boot.iso", DiskFormat: &liveISO},
+			Error:    "invalid live ISO URL \"images/boot.iso\"",
+		},
+		{
+			Scenario: "unsupported scheme",
+			Image:    &Image{URL: "nfs://example.com/boot.iso", DiskFormat: &liveISO},
+			Error:    "unsupported scheme \"nfs\" for live ISO \"nfs://example.com/boot.iso\", expected http, https or file",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			err := tc.Image.ValidateLiveISO()
+			if tc.Error == "" {
+				if err != nil {
+					t.Errorf("unexpected error %s", err)
+				}
+			} else if err == nil || err.Error() != tc.Error {
+				t.Errorf("expected error %q but got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
+func TestValidateRootPartitionSize(t *testing.T) {
+	liveISO := "live-iso"
+	size := func(value int) *int { return &value }
+	for _, tc := range []struct {
+		Scenario string
+		Image    *Image
+		Error    string
+	}{
+		{
+			Scenario: "no image",
+		},
+		{
+			Scenario: "not set",
+			Image:    &Image{URL: "image.qcow2"},
+		},
+		{
+			Scenario: "positive",
+			Image:    &Image{URL: "image.qcow2", RootPartitionSizeGibibytes: size(40)},
+		},
+		{
+			Scenario: "zero",
+			Image:    &Image{URL: "image.qcow2", RootPartitionSizeGibibytes: size(0)},
+			Error:    "the root partition size of image \"image.qcow2\" must be positive, got 0",
+		},
+		{
+			Scenario: "negative",
+			Image:    &Image{URL: "image.qcow2", RootPartitionSizeGibibytes: size(-10)},
+			Error:    "the root partition size of image \"image.qcow2\" must be positive, got -10",
+		},
+		{
+			Scenario: "live iso",
+			Image:    &Image{URL: "http://example.com/boot.iso", DiskFormat: &liveISO, RootPartitionSizeGibibytes: size(40)},
+			Error:    "live ISO \"http://example.com/boot.iso\" cannot have a root partition size",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			err := tc.Image.ValidateRootPartitionSize()
+			if tc.Error == "" {
+				if err != nil {
+					t.Errorf("unexpected error %s", err)
+				}
+			} else if err == nil || err.Error() != tc.Error {
+				t.Errorf("expected error %q but got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
+func TestValidateKind(t *testing.T) {
+	liveISO := "live-iso"
+	size := 40
+	for _, tc := range []struct {
+		Scenario string
+		Image    *Image
+		Kind     ImageKind
+		Error    string
+	}{
+		{
+			Scenario: "no image",
+		},
+		{
+			Scenario: "detected whole disk",
+			Image:    &Image{URL: "image.qcow2"},
+			Kind:     ImageKindWholeDisk,
+		},
+		{
+			Scenario: "detected partition",
+			Image:    &Image{URL: "image.qcow2", RootPartitionSizeGibibytes: &size},
+			Kind:     ImageKindPartition,
+		},
+		{
+			Scenario: "whole disk",
+			Image:    &Image{URL: "image.qcow2", Kind: ImageKindWholeDisk},
+			Kind:     ImageKindWholeDisk,
+		},
+		{
+			Scenario: "whole disk with kernel",
+			Image:    &Image{URL: "image.qcow2", Kind: ImageKindWholeDisk, KernelURL: "vmlinuz", RamdiskURL: "initrd"},
+			Kind:     ImageKindWholeDisk,
+			Error:    "whole disk image \"image.qcow2\" cannot have a kernel and a ramdisk",
+		},
+		{
+			Scenario: "whole disk with root partition",
+			Image:    &Image{URL: "image.qcow2", Kind: ImageKindWholeDisk, RootPartitionSizeGibibytes: &size},
+			Kind:     ImageKindWholeDisk,
+			Error:    "whole disk image \"image.qcow2\" cannot have a root partition size",
+		},
+		{
+			Scenario: "partition",
+			Image:    &Image{URL: "image.qcow2", Kind: ImageKindPartition, RootPartitionSizeGibibytes: &size, KernelURL: "vmlinuz", RamdiskURL: "initrd"},
+			Kind:     ImageKindPartition,
+		},
+		{
+			Scenario: "partition without kernel",
+			Image:    &Image{URL: "image.qcow2", Kind: ImageKindPartition, RootPartitionSizeGibibytes: &size},
+			Kind:     ImageKindPartition,
+			Error:    "partition image \"image.qcow2\" must have a kernel and a ramdisk",
+		},
+		{
+			Scenario: "partition without root partition",
+			Image:    &Image{URL: "image.qcow2", KernelURL: "vmlinuz", RamdiskURL: "initrd"},
+			Kind:     ImageKindPartition,
+			Error:    "partition image \"image.qcow2\" must have a root partition size",
+		},
+		{
+			Scenario: "kernel without ramdisk",
+			Image:    &Image{URL: "image.qcow2", RootPartitionSizeGibibytes: &size, KernelURL: "vmlinuz"},
+			Kind:     ImageKindPartition,
+			Error:    "image \"image.qcow2\" must have both a kernel and a ramdisk or neither",
+		},
+		{
+			Scenario: "live iso",
+			Image:    &Image{URL: "http://example.com/boot.iso", DiskFormat: &liveISO, Kind: ImageKindWholeDisk},
+			Kind:     ImageKindWholeDisk,
+			Error:    "live ISO \"http://example.com/boot.iso\" cannot have an image kind, a kernel or a ramdisk",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			if tc.Image != nil && tc.Image.GetKind() != tc.Kind {
+				t.Errorf("expected kind %q but got %q", tc.Kind, tc.Image.GetKind())
+			}
+			err := tc.Image.ValidateKind()
+			if tc.Error == "" {
+				if err != nil {
+					t.Errorf("unexpected error %s", err)
+				}
+			} else if err == nil || err.Error() != tc.Error {
+				t.Errorf("expected error %q but got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
+func TestValidateBootOrder(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		Config   *FirmwareConfig
+		Error    string
+	}{
+		{
+			Scenario: "no config",
+		},
+		{
+			Scenario: "no boot order",
+			Config:   &FirmwareConfig{Settings: map[string]string{"UefiBootSeq": "Disk"}},
+		},
+		{
+			Scenario: "valid",
+			Config:   &FirmwareConfig{BootOrder: []string{"Disk.SATAEmbedded.A-1", "NIC.PxeDevice.1-1"}},
+		},
+		{
+			Scenario: "setting without boot order",
+			Config:   &FirmwareConfig{BootOrderSetting: "BootSeq"},
+			Error:    "bootOrderSetting is set without a bootOrder",
+		},
+		{
+			Scenario: "empty entry",
+			Config:   &FirmwareConfig{BootOrder: []string{"Disk", " "}},
+			Error:    "the boot order has an empty entry",
+		},
+		{
+			Scenario: "comma",
+			Config:   &FirmwareConfig{BootOrder: []string{"Disk,NIC"}},
+			Error:    "invalid boot entry \"Disk,NIC\", it must not contain a comma",
+		},
+		{
+			Scenario: "duplicate",
+			Config:   &FirmwareConfig{BootOrder: []string{"Disk", "NIC", "Disk"}},
+			Error:    "boot entry \"Disk\" is listed more than once",
+		},
+		{
+			Scenario: "conflicting setting",
+			Config: &FirmwareConfig{
+				Settings:  map[string]string{"uefibootseq": "NIC"},
+				BootOrder: []string{"Disk"},
+			},
+			Error: "the boot order setting uefibootseq is also set in the settings",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			err := tc.Config.ValidateBootOrder()
+			if tc.Error == "" {
+				if err != nil {
+					t.Errorf("unexpected error %s", err)
+				}
+			} else if err == nil || err.Error() != tc.Error {
+				t.Errorf("expected error %q but got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
+func TestBIOSSettings(t *testing.T) {
+	config := &FirmwareConfig{
+		Settings:  map[string]string{"ProcVirtualization": "Enabled"},
+		BootOrder: []string{"Disk", "NIC"},
+	}
+	assert.Equal(t, map[string]string{
+		"ProcVirtualization": "Enabled",
+		"UefiBootSeq":        "Disk,NIC",
+	}, config.BIOSSettings())
+	assert.Equal(t, map[string]string{"ProcVirtualization": "Enabled"}, config.Settings)
+
+	config.BootOrderSetting = "BootSeq"
+	assert.Equal(t, "Disk,NIC", config.BIOSSettings()["BootSeq"])
+
+	var noConfig *FirmwareConfig
+	assert.Empty(t, noConfig.BIOSSettings())
+}
+
+func TestFirmwareSchemaValidateSettings(t *testing.T) {
+	one, four, sixteen := 1, 4, 16
+	schema := FirmwareSchema{
+		"ProcVirtualization": {AttributeType: "Enumeration", AllowableValues: []string{"Enabled", "Disabled"}},
+		"NumCores":           {AttributeType: "Integer", LowerBound: &one, UpperBound: &sixteen},
+		"AssetTag":           {AttributeType: "String", MinLength: &four, MaxLength: &sixteen},
+		"SecureBoot":         {AttributeType: "Boolean"},
+		"SerialNumber":       {AttributeType: "String", ReadOnly: true},
+	}
+
+	for _, tc := range []struct {
+		Scenario string
+		Settings map[string]string
+		Error    string
+	}{
+		{
+			Scenario: "allowable values",
+			Settings: map[string]string{
+				"procvirtualization": "enabled",
+				"NumCores":           "16",
+				"AssetTag":           "rack-1",
+				"SecureBoot":         "true",
+				"UnknownSetting":     "anything",
+			},
+		},
+		{
+			Scenario: "not an allowable value",
+			Settings: map[string]string{"ProcVirtualization": "Maybe"},
+			Error:    "invalid value \"Maybe\" for BIOS setting ProcVirtualization, it must be one of Enabled, Disabled",
+		},
+		{
+			Scenario: "not an integer",
+			Settings: map[string]string{"NumCores": "many"},
+			Error:    "invalid value \"many\" for BIOS setting NumCores, it must be an integer",
+		},
+		{
+			Scenario: "below the lower bound",
+			Settings: map[string]string{"NumCores": "0"},
+			Error:    "invalid value \"0\" for BIOS setting NumCores, it must not be lower than 1",
+		},
+		{
+			Scenario: "above the upper bound",
+			Settings: map[string]string{"NumCores": "17"},
+			Error:    "invalid value \"17\" for BIOS setting NumCores, it must not be higher than 16",
+		},
+		{
+			Scenario: "too short",
+			Settings: map[string]string{"AssetTag": "r1"},
+			Error:    "invalid value \"r1\" for BIOS setting AssetTag, it must not be shorter than 4 characters",
+		},
+		{
+			Scenario: "not a boolean",
+			Settings: map[string]string{"SecureBoot": "sometimes"},
+			Error:    "invalid value \"sometimes\" for BIOS setting SecureBoot, it must be true or false",
+		},
+		{
+			Scenario: "read-only",
+			Settings: map[string]string{"SerialNumber": "1234"},
+			Error:    "invalid value \"1234\" for BIOS setting SerialNumber, the setting is read-only",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			err := schema.ValidateSettings(tc.Settings)
+			if tc.Error == "" {
+				if err != nil {
+					t.Errorf("unexpected error %s", err)
+				}
+			} else if err == nil || err.Error() != tc.Error {
+				t.Errorf("expected error %q but got %v", tc.Error, err)
+			}
+		})
+	}
+
+	var noSchema FirmwareSchema
+	assert.NoError(t, noSchema.ValidateSettings(map[string]string{"NumCores": "many"}))
+}
+
+func TestImageSources(t *testing.T) {
+	image := &Image{
+		URL:          "http://primary.test/image.qcow2",
+		Checksum:     "aaaa",
+		ChecksumType: SHA256,
+		Mirrors: []ImageMirror{
+			{URL: "http://mirror1.test/image.qcow2.gz", Checksum: "bbbb", ChecksumType: SHA512},
+			{URL: "http://mirror2.test/image.qcow2"},
+		},
+	}
+
+	expected := []Image{
+		{URL: "http://primary.test/image.qcow2", Checksum: "aaaa", ChecksumType: SHA256},
+		{URL: "http://mirror1.test/image.qcow2.gz", Checksum: "bbbb", ChecksumType: SHA512},
+		{URL: "http://mirror2.test/image.qcow2", Checksum: "aaaa", ChecksumType: SHA256},
+	}
+	assert.Equal(t, expected, image.Sources())
+
+	var noImage *Image
+	assert.Nil(t, noImage.Sources())
+}
+
+func TestValidateMirrors(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		Image    *Image
+		Error    string
+	}{
+		{
+			Scenario: "no image",
+		},
+		{
+			Scenario: "no mirrors",
+			Image:    &Image{URL: "http://primary.test/image.qcow2", Checksum: "aaaa"},
+		},
+		{
+			Scenario: "mirrors",
+			Image: &Image{
+				URL:      "http://primary.test/image.qcow2",
+				Checksum: "aaaa",
+				Mirrors: []ImageMirror{
+					{URL: "http://mirror1.test/image.qcow2.gz", Checksum: "bbbb", ChecksumType: SHA512},
+					{URL: "http://mirror2.test/image.qcow2"},
+				},
+			},
+		},
+		{
+			Scenario: "no url",
+			Image: &Image{
+				URL:      "http://primary.test/image.qcow2",
+				Checksum: "aaaa",
+				Mirrors:  []ImageMirror{{Checksum: "bbbb"}},
+			},
+			Error: "mirror of image \"http://primary.test/image.qcow2\" has no URL",
+		},
+		{
+			Scenario: "duplicate",
+			Image: &Image{
+				URL:      "http://primary.test/image.qcow2",
+				Checksum: "aaaa",
+				Mirrors:  []ImageMirror{{URL: "http://primary.test/image.qcow2"}},
+			},
+			Error: "image location \"http://primary.test/image.qcow2\" is listed more than once",
+		},
+		{
+			Scenario: "no checksum",
+			Image: &Image{
+				URL:     "http://primary.test/image.qcow2",
+				Mirrors: []ImageMirror{{URL: "http://mirror1.test/image.qcow2"}},
+			},
+			Error: "mirror \"http://mirror1.test/image.qcow2\" of image \"http://primary.test/image.qcow2\" has no valid checksum",
+		},
+		{
+			Scenario: "invalid checksum type",
+			Image: &Image{
+				URL:      "http://primary.test/image.qcow2",
+				Checksum: "aaaa",
+				Mirrors:  []ImageMirror{{URL: "http://mirror1.test/image.qcow2", Checksum: "bbbb", ChecksumType: "crc32"}},
+			},
+			Error: "mirror \"http://mirror1.test/image.qcow2\" of image \"http://primary.test/image.qcow2\" has no valid checksum",
+		},
+		{
+			Scenario: "invalid oci reference",
+			Image: &Image{
+				URL:     "oci://quay.io/example/image:v1",
+				Mirrors: []ImageMirror{{URL: "oci://quay.io/Example/image:v1"}},
+			},
+			Error: "invalid OCI image reference \"oci://quay.io/Example/image:v1\"",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			err := tc.Image.ValidateMirrors()
+			if tc.Error == "" {
+				if err != nil {
+					t.Errorf("unexpected error %s", err)
+				}
+			} else if err == nil || err.Error() != tc.Error {
+				t.Errorf("expected error %q but got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
+func TestValidateDownloadSource(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		Image    *Image
+		Error    string
+	}{
+		{
+			Scenario: "no image",
+		},
+		{
+			Scenario: "default",
+			Image:    &Image{URL: "http://example.com/image.qcow2"},
+		},
+		{
+			Scenario: "http",
+			Image:    &Image{URL: "http://example.com/image.qcow2", DownloadSource: ImageDownloadSourceHTTP},
+		},
+		{
+			Scenario: "local",
+			Image:    &Image{URL: "http://example.com/image.qcow2", DownloadSource: ImageDownloadSourceLocal},
+		},
+		{
+			Scenario: "swift",
+			Image:    &Image{URL: "http://example.com/image.qcow2", DownloadSource: ImageDownloadSourceSwift},
+		},
+		{
+			Scenario: "unsupported",
+			Image:    &Image{URL: "http://example.com/image.qcow2", DownloadSource: "ftp"},
+			Error:    "unsupported download source \"ftp\" for image \"http://example.com/image.qcow2\", expected swift, http or local",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			err := tc.Image.ValidateDownloadSource()
+			if tc.Error == "" {
+				if err != nil {
+					t.Errorf("unexpected error %s", err)
+				}
+			} else if err == nil || err.Error() != tc.Error {
+				t.Errorf("expected error %q but got %v", tc.Error, err)
+			}
+		})
+	}
+}
+
 func TestBootMode(t *testing.T) {
 	for _, tc := range []struct {
 		Scenario  string
@@ -0,0 +1,94 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// PreprovisioningImageHardwareProfileLabel is the label selecting
+	// the hosts of a hardware profile that use a preprovisioning
+	// image when they do not name one explicitly.
+	PreprovisioningImageHardwareProfileLabel = "preprovisioningimage.metal3.io/hardware-profile"
+)
+
+// PreprovisioningImageSpec defines the desired state of
+// PreprovisioningImage
+type PreprovisioningImageSpec struct {
+	// Architecture is the processor architecture the image is built
+	// for.
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+}
+
+// PreprovisioningImageStatus defines the observed state of
+// PreprovisioningImage
+type PreprovisioningImageStatus struct {
+	// KernelURL is the location of the kernel to boot the ramdisk
+	// with.
+	// +optional
+	KernelURL string `json:"kernelUrl,omitempty"`
+
+	// ImageURL is the location of the ramdisk, once it has been
+	// built.
+	// +optional
+	ImageURL string `json:"imageUrl,omitempty"`
+
+	// ErrorMessage describes why the image could not be built.
+	// +optional
+	ErrorMessage string `json:"errorMessage,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PreprovisioningImage is the Schema for the preprovisioningimages
+// API. It describes the ramdisk of the agent hosts boot to be
+// inspected, cleaned and provisioned. The image is built by another
+// controller, which reports its location in the status. The operator
+// only reads the images and never writes their status.
+// +k8s:openapi-gen=true
+// +kubebuilder:resource:shortName=ppimg
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Image",type="string",JSONPath=".status.imageUrl",description="Location of the ramdisk"
+// +kubebuilder:printcolumn:name="Error",type="string",JSONPath=".status.errorMessage",description="Why the image could not be built",priority=1
+// +kubebuilder:object:root=true
+type PreprovisioningImage struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PreprovisioningImageSpec   `json:"spec,omitempty"`
+	Status PreprovisioningImageStatus `json:"status,omitempty"`
+}
+
+// Ready returns whether the image has been built and can be booted.
+func (image *PreprovisioningImage) Ready() bool {
+	return image.Status.KernelURL != "" && image.Status.ImageURL != ""
+}
+
+// +kubebuilder:object:root=true
+
+// PreprovisioningImageList contains a list of PreprovisioningImage
+type PreprovisioningImageList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PreprovisioningImage `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PreprovisioningImage{}, &PreprovisioningImageList{})
+}
@@ -25,6 +25,41 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Accelerator) DeepCopyInto(out *Accelerator) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Accelerator.
+func (in *Accelerator) DeepCopy() *Accelerator {
+	if in == nil {
+		return nil
+	}
+	out := new(Accelerator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllocationStatus) DeepCopyInto(out *AllocationStatus) {
+	*out = *in
+	if in.Traits != nil {
+		in, out := &in.Traits, &out.Traits
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationStatus.
+func (in *AllocationStatus) DeepCopy() *AllocationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AllocationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BIOS) DeepCopyInto(out *BIOS) {
 	*out = *in
@@ -40,9 +75,29 @@ func (in *BIOS) DeepCopy() *BIOS {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BIOSSettingDiff) DeepCopyInto(out *BIOSSettingDiff) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BIOSSettingDiff.
+func (in *BIOSSettingDiff) DeepCopy() *BIOSSettingDiff {
+	if in == nil {
+		return nil
+	}
+	out := new(BIOSSettingDiff)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BMCDetails) DeepCopyInto(out *BMCDetails) {
 	*out = *in
+	if in.PowerOnDelaySeconds != nil {
+		in, out := &in.PowerOnDelaySeconds, &out.PowerOnDelaySeconds
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BMCDetails.
@@ -124,17 +179,27 @@ func (in *BareMetalHostSpec) DeepCopyInto(out *BareMetalHostSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	out.BMC = in.BMC
+	in.BMC.DeepCopyInto(&out.BMC)
 	if in.RAID != nil {
 		in, out := &in.RAID, &out.RAID
 		*out = new(RAIDConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Firmware != nil {
+		in, out := &in.Firmware, &out.Firmware
+		*out = new(FirmwareConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.RootDeviceHints != nil {
 		in, out := &in.RootDeviceHints, &out.RootDeviceHints
 		*out = new(RootDeviceHints)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ShutdownHook != nil {
+		in, out := &in.ShutdownHook, &out.ShutdownHook
+		*out = new(ShutdownHook)
+		**out = **in
+	}
 	if in.ConsumerRef != nil {
 		in, out := &in.ConsumerRef, &out.ConsumerRef
 		*out = new(v1.ObjectReference)
@@ -160,6 +225,70 @@ func (in *BareMetalHostSpec) DeepCopyInto(out *BareMetalHostSpec) {
 		*out = new(v1.SecretReference)
 		**out = **in
 	}
+	if in.ConfigDriveFiles != nil {
+		in, out := &in.ConfigDriveFiles, &out.ConfigDriveFiles
+		*out = make([]ConfigDriveFile, len(*in))
+		copy(*out, *in)
+	}
+	if in.TimeSettings != nil {
+		in, out := &in.TimeSettings, &out.TimeSettings
+		*out = new(TimeSettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Traits != nil {
+		in, out := &in.Traits, &out.Traits
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeProperties != nil {
+		in, out := &in.NodeProperties, &out.NodeProperties
+		*out = new(NodeProperties)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeployNetworks != nil {
+		in, out := &in.DeployNetworks, &out.DeployNetworks
+		*out = new(DeployNetworks)
+		**out = **in
+	}
+	if in.InstanceCapabilities != nil {
+		in, out := &in.InstanceCapabilities, &out.InstanceCapabilities
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.InstanceTraits != nil {
+		in, out := &in.InstanceTraits, &out.InstanceTraits
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StepRetries != nil {
+		in, out := &in.StepRetries, &out.StepRetries
+		*out = new(StepRetryLimits)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeployTimeouts != nil {
+		in, out := &in.DeployTimeouts, &out.DeployTimeouts
+		*out = new(DeployTimeouts)
+		**out = **in
+	}
+	if in.PostDeployScript != nil {
+		in, out := &in.PostDeployScript, &out.PostDeployScript
+		*out = new(PostDeployScript)
+		**out = **in
+	}
+	if in.FastTrack != nil {
+		in, out := &in.FastTrack, &out.FastTrack
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BareMetalHostSpec.
@@ -187,6 +316,67 @@ func (in *BareMetalHostStatus) DeepCopyInto(out *BareMetalHostStatus) {
 	in.Provisioning.DeepCopyInto(&out.Provisioning)
 	in.GoodCredentials.DeepCopyInto(&out.GoodCredentials)
 	in.TriedCredentials.DeepCopyInto(&out.TriedCredentials)
+	if in.FirmwareConverged != nil {
+		in, out := &in.FirmwareConverged, &out.FirmwareConverged
+		*out = new(bool)
+		**out = **in
+	}
+	if in.FirmwareSettingsDiff != nil {
+		in, out := &in.FirmwareSettingsDiff, &out.FirmwareSettingsDiff
+		*out = make([]BIOSSettingDiff, len(*in))
+		copy(*out, *in)
+	}
+	if in.SerialConsole != nil {
+		in, out := &in.SerialConsole, &out.SerialConsole
+		*out = new(SerialConsole)
+		**out = **in
+	}
+	if in.Allocation != nil {
+		in, out := &in.Allocation, &out.Allocation
+		*out = new(AllocationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Scheduling != nil {
+		in, out := &in.Scheduling, &out.Scheduling
+		*out = new(SchedulingStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conductor != nil {
+		in, out := &in.Conductor, &out.Conductor
+		*out = new(ConductorStatus)
+		**out = **in
+	}
+	if in.LastInspected != nil {
+		in, out := &in.LastInspected, &out.LastInspected
+		*out = (*in).DeepCopy()
+	}
+	if in.NodeCreatedAt != nil {
+		in, out := &in.NodeCreatedAt, &out.NodeCreatedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.LastBMCReset != nil {
+		in, out := &in.LastBMCReset, &out.LastBMCReset
+		*out = (*in).DeepCopy()
+	}
+	if in.BlockingReasons != nil {
+		in, out := &in.BlockingReasons, &out.BlockingReasons
+		*out = make([]BlockingReason, len(*in))
+		copy(*out, *in)
+	}
+	if in.Sensors != nil {
+		in, out := &in.Sensors, &out.Sensors
+		*out = new(SensorStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.BootDevice != nil {
+		in, out := &in.BootDevice, &out.BootDevice
+		*out = new(BootDeviceStatus)
+		**out = **in
+	}
+	if in.ShutdownDeadline != nil {
+		in, out := &in.ShutdownDeadline, &out.ShutdownDeadline
+		*out = (*in).DeepCopy()
+	}
 	in.OperationHistory.DeepCopyInto(&out.OperationHistory)
 }
 
@@ -201,285 +391,794 @@ func (in *BareMetalHostStatus) DeepCopy() *BareMetalHostStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CPU) DeepCopyInto(out *CPU) {
+func (in *BlockingReason) DeepCopyInto(out *BlockingReason) {
 	*out = *in
-	if in.Flags != nil {
-		in, out := &in.Flags, &out.Flags
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPU.
-func (in *CPU) DeepCopy() *CPU {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlockingReason.
+func (in *BlockingReason) DeepCopy() *BlockingReason {
 	if in == nil {
 		return nil
 	}
-	out := new(CPU)
+	out := new(BlockingReason)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CredentialsStatus) DeepCopyInto(out *CredentialsStatus) {
+func (in *BootDeviceAnnotationArguments) DeepCopyInto(out *BootDeviceAnnotationArguments) {
 	*out = *in
-	if in.Reference != nil {
-		in, out := &in.Reference, &out.Reference
-		*out = new(v1.SecretReference)
-		**out = **in
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsStatus.
-func (in *CredentialsStatus) DeepCopy() *CredentialsStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootDeviceAnnotationArguments.
+func (in *BootDeviceAnnotationArguments) DeepCopy() *BootDeviceAnnotationArguments {
 	if in == nil {
 		return nil
 	}
-	out := new(CredentialsStatus)
+	out := new(BootDeviceAnnotationArguments)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Firmware) DeepCopyInto(out *Firmware) {
+func (in *BootDeviceStatus) DeepCopyInto(out *BootDeviceStatus) {
 	*out = *in
-	out.BIOS = in.BIOS
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Firmware.
-func (in *Firmware) DeepCopy() *Firmware {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BootDeviceStatus.
+func (in *BootDeviceStatus) DeepCopy() *BootDeviceStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(Firmware)
+	out := new(BootDeviceStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HardwareDetails) DeepCopyInto(out *HardwareDetails) {
+func (in *CPU) DeepCopyInto(out *CPU) {
 	*out = *in
-	out.SystemVendor = in.SystemVendor
-	out.Firmware = in.Firmware
-	if in.NIC != nil {
-		in, out := &in.NIC, &out.NIC
-		*out = make([]NIC, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.Storage != nil {
-		in, out := &in.Storage, &out.Storage
-		*out = make([]Storage, len(*in))
+	if in.Flags != nil {
+		in, out := &in.Flags, &out.Flags
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	in.CPU.DeepCopyInto(&out.CPU)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDetails.
-func (in *HardwareDetails) DeepCopy() *HardwareDetails {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CPU.
+func (in *CPU) DeepCopy() *CPU {
 	if in == nil {
 		return nil
 	}
-	out := new(HardwareDetails)
+	out := new(CPU)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HardwareRAIDVolume) DeepCopyInto(out *HardwareRAIDVolume) {
+func (in *Chassis) DeepCopyInto(out *Chassis) {
 	*out = *in
-	if in.SizeGibibytes != nil {
-		in, out := &in.SizeGibibytes, &out.SizeGibibytes
-		*out = new(int)
-		**out = **in
-	}
-	if in.Rotational != nil {
-		in, out := &in.Rotational, &out.Rotational
-		*out = new(bool)
-		**out = **in
-	}
-	if in.NumberOfPhysicalDisks != nil {
-		in, out := &in.NumberOfPhysicalDisks, &out.NumberOfPhysicalDisks
-		*out = new(int)
-		**out = **in
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareRAIDVolume.
-func (in *HardwareRAIDVolume) DeepCopy() *HardwareRAIDVolume {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Chassis.
+func (in *Chassis) DeepCopy() *Chassis {
 	if in == nil {
 		return nil
 	}
-	out := new(HardwareRAIDVolume)
+	out := new(Chassis)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HardwareSystemVendor) DeepCopyInto(out *HardwareSystemVendor) {
+func (in *ConductorStatus) DeepCopyInto(out *ConductorStatus) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSystemVendor.
-func (in *HardwareSystemVendor) DeepCopy() *HardwareSystemVendor {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConductorStatus.
+func (in *ConductorStatus) DeepCopy() *ConductorStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(HardwareSystemVendor)
+	out := new(ConductorStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Image) DeepCopyInto(out *Image) {
+func (in *ConfigDriveFile) DeepCopyInto(out *ConfigDriveFile) {
 	*out = *in
-	if in.DiskFormat != nil {
-		in, out := &in.DiskFormat, &out.DiskFormat
-		*out = new(string)
-		**out = **in
-	}
+	out.SecretRef = in.SecretRef
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Image.
-func (in *Image) DeepCopy() *Image {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigDriveFile.
+func (in *ConfigDriveFile) DeepCopy() *ConfigDriveFile {
 	if in == nil {
 		return nil
 	}
-	out := new(Image)
+	out := new(ConfigDriveFile)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NIC) DeepCopyInto(out *NIC) {
+func (in *CredentialsStatus) DeepCopyInto(out *CredentialsStatus) {
 	*out = *in
-	if in.VLANs != nil {
-		in, out := &in.VLANs, &out.VLANs
-		*out = make([]VLAN, len(*in))
-		copy(*out, *in)
+	if in.Reference != nil {
+		in, out := &in.Reference, &out.Reference
+		*out = new(v1.SecretReference)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NIC.
-func (in *NIC) DeepCopy() *NIC {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsStatus.
+func (in *CredentialsStatus) DeepCopy() *CredentialsStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(NIC)
+	out := new(CredentialsStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperationHistory) DeepCopyInto(out *OperationHistory) {
+func (in *DeployNetworks) DeepCopyInto(out *DeployNetworks) {
 	*out = *in
-	in.Register.DeepCopyInto(&out.Register)
-	in.Inspect.DeepCopyInto(&out.Inspect)
-	in.Provision.DeepCopyInto(&out.Provision)
-	in.Deprovision.DeepCopyInto(&out.Deprovision)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationHistory.
-func (in *OperationHistory) DeepCopy() *OperationHistory {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeployNetworks.
+func (in *DeployNetworks) DeepCopy() *DeployNetworks {
 	if in == nil {
 		return nil
 	}
-	out := new(OperationHistory)
+	out := new(DeployNetworks)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperationMetric) DeepCopyInto(out *OperationMetric) {
+func (in *DeployTimeouts) DeepCopyInto(out *DeployTimeouts) {
 	*out = *in
-	in.Start.DeepCopyInto(&out.Start)
-	in.End.DeepCopyInto(&out.End)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationMetric.
-func (in *OperationMetric) DeepCopy() *OperationMetric {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeployTimeouts.
+func (in *DeployTimeouts) DeepCopy() *DeployTimeouts {
 	if in == nil {
 		return nil
 	}
-	out := new(OperationMetric)
+	out := new(DeployTimeouts)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ProvisionStatus) DeepCopyInto(out *ProvisionStatus) {
+func (in *FanReading) DeepCopyInto(out *FanReading) {
 	*out = *in
-	in.Image.DeepCopyInto(&out.Image)
-	if in.RootDeviceHints != nil {
-		in, out := &in.RootDeviceHints, &out.RootDeviceHints
-		*out = new(RootDeviceHints)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.RAID != nil {
-		in, out := &in.RAID, &out.RAID
-		*out = new(RAIDConfig)
-		(*in).DeepCopyInto(*out)
+	if in.Reading != nil {
+		in, out := &in.Reading, &out.Reading
+		*out = new(int)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisionStatus.
-func (in *ProvisionStatus) DeepCopy() *ProvisionStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FanReading.
+func (in *FanReading) DeepCopy() *FanReading {
 	if in == nil {
 		return nil
 	}
-	out := new(ProvisionStatus)
+	out := new(FanReading)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RAIDConfig) DeepCopyInto(out *RAIDConfig) {
+func (in *Firmware) DeepCopyInto(out *Firmware) {
 	*out = *in
-	if in.HardwareRAIDVolumes != nil {
-		in, out := &in.HardwareRAIDVolumes, &out.HardwareRAIDVolumes
-		*out = make([]HardwareRAIDVolume, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.SoftwareRAIDVolumes != nil {
-		in, out := &in.SoftwareRAIDVolumes, &out.SoftwareRAIDVolumes
-		*out = make([]SoftwareRAIDVolume, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
+	out.BIOS = in.BIOS
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RAIDConfig.
-func (in *RAIDConfig) DeepCopy() *RAIDConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Firmware.
+func (in *Firmware) DeepCopy() *Firmware {
 	if in == nil {
 		return nil
 	}
-	out := new(RAIDConfig)
+	out := new(Firmware)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RebootAnnotationArguments) DeepCopyInto(out *RebootAnnotationArguments) {
+func (in *FirmwareConfig) DeepCopyInto(out *FirmwareConfig) {
 	*out = *in
+	if in.Settings != nil {
+		in, out := &in.Settings, &out.Settings
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.BootOrder != nil {
+		in, out := &in.BootOrder, &out.BootOrder
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RebootAnnotationArguments.
-func (in *RebootAnnotationArguments) DeepCopy() *RebootAnnotationArguments {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareConfig.
+func (in *FirmwareConfig) DeepCopy() *FirmwareConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(RebootAnnotationArguments)
+	out := new(FirmwareConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RootDeviceHints) DeepCopyInto(out *RootDeviceHints) {
-	*out = *in
+func (in FirmwareSchema) DeepCopyInto(out *FirmwareSchema) {
+	{
+		in := &in
+		*out = make(FirmwareSchema, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FirmwareSchema.
+func (in FirmwareSchema) DeepCopy() FirmwareSchema {
+	if in == nil {
+		return nil
+	}
+	out := new(FirmwareSchema)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareDetails) DeepCopyInto(out *HardwareDetails) {
+	*out = *in
+	out.SystemVendor = in.SystemVendor
+	out.Firmware = in.Firmware
+	if in.NIC != nil {
+		in, out := &in.NIC, &out.NIC
+		*out = make([]NIC, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = make([]Storage, len(*in))
+		copy(*out, *in)
+	}
+	in.CPU.DeepCopyInto(&out.CPU)
+	if in.TPM != nil {
+		in, out := &in.TPM, &out.TPM
+		*out = new(TPM)
+		**out = **in
+	}
+	if in.RAIDControllers != nil {
+		in, out := &in.RAIDControllers, &out.RAIDControllers
+		*out = make([]RAIDController, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Chassis != nil {
+		in, out := &in.Chassis, &out.Chassis
+		*out = new(Chassis)
+		**out = **in
+	}
+	if in.Accelerators != nil {
+		in, out := &in.Accelerators, &out.Accelerators
+		*out = make([]Accelerator, len(*in))
+		copy(*out, *in)
+	}
+	if in.MemoryModules != nil {
+		in, out := &in.MemoryModules, &out.MemoryModules
+		*out = make([]MemoryModule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareDetails.
+func (in *HardwareDetails) DeepCopy() *HardwareDetails {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareDetails)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareRAIDVolume) DeepCopyInto(out *HardwareRAIDVolume) {
+	*out = *in
+	if in.SizeGibibytes != nil {
+		in, out := &in.SizeGibibytes, &out.SizeGibibytes
+		*out = new(int)
+		**out = **in
+	}
+	if in.Rotational != nil {
+		in, out := &in.Rotational, &out.Rotational
+		*out = new(bool)
+		**out = **in
+	}
+	if in.NumberOfPhysicalDisks != nil {
+		in, out := &in.NumberOfPhysicalDisks, &out.NumberOfPhysicalDisks
+		*out = new(int)
+		**out = **in
+	}
+	if in.PhysicalDisks != nil {
+		in, out := &in.PhysicalDisks, &out.PhysicalDisks
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareRAIDVolume.
+func (in *HardwareRAIDVolume) DeepCopy() *HardwareRAIDVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareRAIDVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HardwareSystemVendor) DeepCopyInto(out *HardwareSystemVendor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HardwareSystemVendor.
+func (in *HardwareSystemVendor) DeepCopy() *HardwareSystemVendor {
+	if in == nil {
+		return nil
+	}
+	out := new(HardwareSystemVendor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HistoryEvent) DeepCopyInto(out *HistoryEvent) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HistoryEvent.
+func (in *HistoryEvent) DeepCopy() *HistoryEvent {
+	if in == nil {
+		return nil
+	}
+	out := new(HistoryEvent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Image) DeepCopyInto(out *Image) {
+	*out = *in
+	if in.DiskFormat != nil {
+		in, out := &in.DiskFormat, &out.DiskFormat
+		*out = new(string)
+		**out = **in
+	}
+	if in.Mirrors != nil {
+		in, out := &in.Mirrors, &out.Mirrors
+		*out = make([]ImageMirror, len(*in))
+		copy(*out, *in)
+	}
+	if in.RootPartitionSizeGibibytes != nil {
+		in, out := &in.RootPartitionSizeGibibytes, &out.RootPartitionSizeGibibytes
+		*out = new(int)
+		**out = **in
+	}
+	if in.HTTPHeadersSecret != nil {
+		in, out := &in.HTTPHeadersSecret, &out.HTTPHeadersSecret
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Image.
+func (in *Image) DeepCopy() *Image {
+	if in == nil {
+		return nil
+	}
+	out := new(Image)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageCacheStatus) DeepCopyInto(out *ImageCacheStatus) {
+	*out = *in
+	if in.Cached != nil {
+		in, out := &in.Cached, &out.Cached
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageCacheStatus.
+func (in *ImageCacheStatus) DeepCopy() *ImageCacheStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageCacheStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageMirror) DeepCopyInto(out *ImageMirror) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageMirror.
+func (in *ImageMirror) DeepCopy() *ImageMirror {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageMirror)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemoryModule) DeepCopyInto(out *MemoryModule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemoryModule.
+func (in *MemoryModule) DeepCopy() *MemoryModule {
+	if in == nil {
+		return nil
+	}
+	out := new(MemoryModule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NIC) DeepCopyInto(out *NIC) {
+	*out = *in
+	if in.VLANs != nil {
+		in, out := &in.VLANs, &out.VLANs
+		*out = make([]VLAN, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NIC.
+func (in *NIC) DeepCopy() *NIC {
+	if in == nil {
+		return nil
+	}
+	out := new(NIC)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeProperties) DeepCopyInto(out *NodeProperties) {
+	*out = *in
+	if in.CPUs != nil {
+		in, out := &in.CPUs, &out.CPUs
+		*out = new(int)
+		**out = **in
+	}
+	if in.MemoryMiB != nil {
+		in, out := &in.MemoryMiB, &out.MemoryMiB
+		*out = new(int)
+		**out = **in
+	}
+	if in.LocalGiB != nil {
+		in, out := &in.LocalGiB, &out.LocalGiB
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeProperties.
+func (in *NodeProperties) DeepCopy() *NodeProperties {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeProperties)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationHistory) DeepCopyInto(out *OperationHistory) {
+	*out = *in
+	in.Register.DeepCopyInto(&out.Register)
+	in.Inspect.DeepCopyInto(&out.Inspect)
+	in.Provision.DeepCopyInto(&out.Provision)
+	in.Provisioned.DeepCopyInto(&out.Provisioned)
+	in.Deprovision.DeepCopyInto(&out.Deprovision)
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]HistoryEvent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationHistory.
+func (in *OperationHistory) DeepCopy() *OperationHistory {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationHistory)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperationMetric) DeepCopyInto(out *OperationMetric) {
+	*out = *in
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperationMetric.
+func (in *OperationMetric) DeepCopy() *OperationMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(OperationMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PassthroughDisk) DeepCopyInto(out *PassthroughDisk) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PassthroughDisk.
+func (in *PassthroughDisk) DeepCopy() *PassthroughDisk {
+	if in == nil {
+		return nil
+	}
+	out := new(PassthroughDisk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostDeployScript) DeepCopyInto(out *PostDeployScript) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostDeployScript.
+func (in *PostDeployScript) DeepCopy() *PostDeployScript {
+	if in == nil {
+		return nil
+	}
+	out := new(PostDeployScript)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreprovisioningImage) DeepCopyInto(out *PreprovisioningImage) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreprovisioningImage.
+func (in *PreprovisioningImage) DeepCopy() *PreprovisioningImage {
+	if in == nil {
+		return nil
+	}
+	out := new(PreprovisioningImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PreprovisioningImage) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreprovisioningImageList) DeepCopyInto(out *PreprovisioningImageList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PreprovisioningImage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreprovisioningImageList.
+func (in *PreprovisioningImageList) DeepCopy() *PreprovisioningImageList {
+	if in == nil {
+		return nil
+	}
+	out := new(PreprovisioningImageList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PreprovisioningImageList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreprovisioningImageSpec) DeepCopyInto(out *PreprovisioningImageSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreprovisioningImageSpec.
+func (in *PreprovisioningImageSpec) DeepCopy() *PreprovisioningImageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PreprovisioningImageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreprovisioningImageStatus) DeepCopyInto(out *PreprovisioningImageStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreprovisioningImageStatus.
+func (in *PreprovisioningImageStatus) DeepCopy() *PreprovisioningImageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreprovisioningImageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProvisionStatus) DeepCopyInto(out *ProvisionStatus) {
+	*out = *in
+	in.Image.DeepCopyInto(&out.Image)
+	if in.RootDeviceHints != nil {
+		in, out := &in.RootDeviceHints, &out.RootDeviceHints
+		*out = new(RootDeviceHints)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RAID != nil {
+		in, out := &in.RAID, &out.RAID
+		*out = new(RAIDConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Firmware != nil {
+		in, out := &in.Firmware, &out.Firmware
+		*out = new(FirmwareConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageCache != nil {
+		in, out := &in.ImageCache, &out.ImageCache
+		*out = new(ImageCacheStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProvisionStatus.
+func (in *ProvisionStatus) DeepCopy() *ProvisionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ProvisionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RAIDConfig) DeepCopyInto(out *RAIDConfig) {
+	*out = *in
+	if in.HardwareRAIDVolumes != nil {
+		in, out := &in.HardwareRAIDVolumes, &out.HardwareRAIDVolumes
+		*out = make([]HardwareRAIDVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SoftwareRAIDVolumes != nil {
+		in, out := &in.SoftwareRAIDVolumes, &out.SoftwareRAIDVolumes
+		*out = make([]SoftwareRAIDVolume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PassthroughDisks != nil {
+		in, out := &in.PassthroughDisks, &out.PassthroughDisks
+		*out = make([]PassthroughDisk, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RAIDConfig.
+func (in *RAIDConfig) DeepCopy() *RAIDConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RAIDConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RAIDController) DeepCopyInto(out *RAIDController) {
+	*out = *in
+	if in.PhysicalDisks != nil {
+		in, out := &in.PhysicalDisks, &out.PhysicalDisks
+		*out = make([]RAIDPhysicalDisk, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RAIDController.
+func (in *RAIDController) DeepCopy() *RAIDController {
+	if in == nil {
+		return nil
+	}
+	out := new(RAIDController)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RAIDPhysicalDisk) DeepCopyInto(out *RAIDPhysicalDisk) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RAIDPhysicalDisk.
+func (in *RAIDPhysicalDisk) DeepCopy() *RAIDPhysicalDisk {
+	if in == nil {
+		return nil
+	}
+	out := new(RAIDPhysicalDisk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RebootAnnotationArguments) DeepCopyInto(out *RebootAnnotationArguments) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RebootAnnotationArguments.
+func (in *RebootAnnotationArguments) DeepCopy() *RebootAnnotationArguments {
+	if in == nil {
+		return nil
+	}
+	out := new(RebootAnnotationArguments)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RootDeviceHints) DeepCopyInto(out *RootDeviceHints) {
+	*out = *in
 	if in.Rotational != nil {
 		in, out := &in.Rotational, &out.Rotational
 		*out = new(bool)
@@ -497,6 +1196,133 @@ func (in *RootDeviceHints) DeepCopy() *RootDeviceHints {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SchedulingStatus) DeepCopyInto(out *SchedulingStatus) {
+	*out = *in
+	if in.Traits != nil {
+		in, out := &in.Traits, &out.Traits
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SchedulingStatus.
+func (in *SchedulingStatus) DeepCopy() *SchedulingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SchedulingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SensorStatus) DeepCopyInto(out *SensorStatus) {
+	*out = *in
+	if in.Fans != nil {
+		in, out := &in.Fans, &out.Fans
+		*out = make([]FanReading, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Temperatures != nil {
+		in, out := &in.Temperatures, &out.Temperatures
+		*out = make([]TemperatureReading, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastUpdated != nil {
+		in, out := &in.LastUpdated, &out.LastUpdated
+		*out = (*in).DeepCopy()
+	}
+	if in.LastAttempted != nil {
+		in, out := &in.LastAttempted, &out.LastAttempted
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SensorStatus.
+func (in *SensorStatus) DeepCopy() *SensorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SensorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SerialConsole) DeepCopyInto(out *SerialConsole) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SerialConsole.
+func (in *SerialConsole) DeepCopy() *SerialConsole {
+	if in == nil {
+		return nil
+	}
+	out := new(SerialConsole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SettingSchema) DeepCopyInto(out *SettingSchema) {
+	*out = *in
+	if in.AllowableValues != nil {
+		in, out := &in.AllowableValues, &out.AllowableValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LowerBound != nil {
+		in, out := &in.LowerBound, &out.LowerBound
+		*out = new(int)
+		**out = **in
+	}
+	if in.UpperBound != nil {
+		in, out := &in.UpperBound, &out.UpperBound
+		*out = new(int)
+		**out = **in
+	}
+	if in.MinLength != nil {
+		in, out := &in.MinLength, &out.MinLength
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxLength != nil {
+		in, out := &in.MaxLength, &out.MaxLength
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SettingSchema.
+func (in *SettingSchema) DeepCopy() *SettingSchema {
+	if in == nil {
+		return nil
+	}
+	out := new(SettingSchema)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutdownHook) DeepCopyInto(out *ShutdownHook) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutdownHook.
+func (in *ShutdownHook) DeepCopy() *ShutdownHook {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutdownHook)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SoftwareRAIDVolume) DeepCopyInto(out *SoftwareRAIDVolume) {
 	*out = *in
@@ -524,6 +1350,31 @@ func (in *SoftwareRAIDVolume) DeepCopy() *SoftwareRAIDVolume {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StepRetryLimits) DeepCopyInto(out *StepRetryLimits) {
+	*out = *in
+	if in.Deploy != nil {
+		in, out := &in.Deploy, &out.Deploy
+		*out = new(int)
+		**out = **in
+	}
+	if in.Clean != nil {
+		in, out := &in.Clean, &out.Clean
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StepRetryLimits.
+func (in *StepRetryLimits) DeepCopy() *StepRetryLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(StepRetryLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Storage) DeepCopyInto(out *Storage) {
 	*out = *in
@@ -539,6 +1390,66 @@ func (in *Storage) DeepCopy() *Storage {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TPM) DeepCopyInto(out *TPM) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TPM.
+func (in *TPM) DeepCopy() *TPM {
+	if in == nil {
+		return nil
+	}
+	out := new(TPM)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TemperatureReading) DeepCopyInto(out *TemperatureReading) {
+	*out = *in
+	if in.ReadingCelsius != nil {
+		in, out := &in.ReadingCelsius, &out.ReadingCelsius
+		*out = new(int)
+		**out = **in
+	}
+	if in.UpperThresholdCritical != nil {
+		in, out := &in.UpperThresholdCritical, &out.UpperThresholdCritical
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TemperatureReading.
+func (in *TemperatureReading) DeepCopy() *TemperatureReading {
+	if in == nil {
+		return nil
+	}
+	out := new(TemperatureReading)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSettings) DeepCopyInto(out *TimeSettings) {
+	*out = *in
+	if in.NTPServers != nil {
+		in, out := &in.NTPServers, &out.NTPServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSettings.
+func (in *TimeSettings) DeepCopy() *TimeSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VLAN) DeepCopyInto(out *VLAN) {
 	*out = *in
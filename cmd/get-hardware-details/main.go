@@ -1,15 +1,17 @@
 // get-hardware-details is a tool that can be used to convert raw Ironic introspection data into the HardwareDetails
-// type used by Metal3.
+// type used by Metal3, or into a Redfish ComputerSystem inventory.
 package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
 
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/hardwaredetails"
 )
@@ -18,6 +20,7 @@ type options struct {
 	Endpoint   string
 	AuthConfig clients.AuthConfig
 	NodeID     string
+	Redfish    bool
 }
 
 func main() {
@@ -34,7 +37,7 @@ func main() {
 		InsecureSkipVerify: ironicInsecure,
 	}
 
-	inspector, err := clients.InspectorClient(opts.Endpoint, opts.AuthConfig, tlsConf)
+	inspector, err := clients.InspectorClient(opts.Endpoint, opts.AuthConfig, tlsConf, clients.ConnectionConfig{})
 	if err != nil {
 		fmt.Printf("could not get inspector client: %s", err)
 		os.Exit(1)
@@ -47,7 +50,13 @@ func main() {
 		os.Exit(1)
 	}
 
-	json, err := json.MarshalIndent(hardwaredetails.GetHardwareDetails(data), "", "\t")
+	details := hardwaredetails.GetHardwareDetails(data)
+	var output interface{} = details
+	if opts.Redfish {
+		output = hardware.NewRedfishSystem(opts.NodeID, details)
+	}
+
+	json, err := json.MarshalIndent(output, "", "\t")
 	if err != nil {
 		fmt.Printf("could not convert introspection data: %s", err)
 		os.Exit(1)
@@ -57,17 +66,19 @@ func main() {
 }
 
 func getOptions() (o options) {
-	if len(os.Args) != 3 {
-		fmt.Println("Usage: get-hardware-details <inspector URI> <node UUID>")
+	flag.BoolVar(&o.Redfish, "redfish", false, "print the inventory as a Redfish ComputerSystem")
+	flag.Parse()
+	if flag.NArg() != 2 {
+		fmt.Println("Usage: get-hardware-details [-redfish] <inspector URI> <node UUID>")
 		os.Exit(1)
 	}
 
 	var err error
-	o.Endpoint, o.AuthConfig, err = clients.ConfigFromEndpointURL(os.Args[1])
+	o.Endpoint, o.AuthConfig, err = clients.ConfigFromEndpointURL(flag.Arg(0))
 	if err != nil {
 		fmt.Printf("Error: %s\n", err)
 		os.Exit(1)
 	}
-	o.NodeID = os.Args[2]
+	o.NodeID = flag.Arg(1)
 	return
 }
@@ -0,0 +1,215 @@
+// Package importer converts existing Ironic nodes into BareMetalHost
+// resources adopting them.
+package importer
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// nameSeparator separates the namespace from the name of the host in
+// the names given to the nodes registered by the operator
+const nameSeparator = "~"
+
+// HostName returns the namespace and name of the host for a node. The
+// nodes registered by the operator are named after the namespace and
+// name of their host, the others after their own name or UUID.
+func HostName(node nodes.Node, defaultNamespace string) (namespace, name string) {
+	if parts := strings.SplitN(node.Name, nameSeparator, 2); len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	name = node.Name
+	if name == "" {
+		name = node.UUID
+	}
+	return defaultNamespace, strings.ToLower(strings.Replace(name, "_", "-", -1))
+}
+
+// bmcAddress rebuilds the BMC address of the host from the driver_info
+// of the node
+func bmcAddress(node nodes.Node) (address string, disableCertificateVerification bool, err error) {
+	switch node.Driver {
+	case "ipmi":
+		host, _ := node.DriverInfo["ipmi_address"].(string)
+		if host == "" {
+			return "", false, fmt.Errorf("node %s has no ipmi_address", node.UUID)
+		}
+		if port, ok := node.DriverInfo["ipmi_port"]; ok && port != nil {
+			host = net.JoinHostPort(host, fmt.Sprint(port))
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		return "ipmi://" + host, false, nil
+
+	case "redfish":
+		redfishAddress, _ := node.DriverInfo["redfish_address"].(string)
+		systemID, _ := node.DriverInfo["redfish_system_id"].(string)
+		parsed, err := url.Parse(redfishAddress)
+		if redfishAddress == "" || err != nil || parsed.Host == "" {
+			return "", false, fmt.Errorf("node %s has an invalid redfish_address %q", node.UUID, redfishAddress)
+		}
+		bmcType := "redfish"
+		if node.BootInterface == "redfish-virtual-media" {
+			bmcType = "redfish-virtualmedia"
+		}
+		if parsed.Scheme == "http" {
+			bmcType += "+http"
+		}
+		if verifyCA, ok := node.DriverInfo["redfish_verify_ca"].(bool); ok && !verifyCA {
+			disableCertificateVerification = true
+		}
+		return bmcType + "://" + parsed.Host + systemID, disableCertificateVerification, nil
+
+	default:
+		return "", false, fmt.Errorf("node %s uses the unsupported driver %q", node.UUID, node.Driver)
+	}
+}
+
+// bootMode returns the boot mode of the host from the capabilities of
+// the node, or an empty string to use the default one
+func bootMode(node nodes.Node) metal3v1alpha1.BootMode {
+	capabilities, _ := node.Properties["capabilities"].(string)
+	for _, capability := range strings.Split(capabilities, ",") {
+		switch capability {
+		case "boot_mode:uefi":
+			return metal3v1alpha1.UEFI
+		case "boot_mode:bios":
+			return metal3v1alpha1.Legacy
+		}
+	}
+	return ""
+}
+
+// HostFromNode returns the host adopting a node. Active nodes are
+// marked as externally provisioned, so that their instance is kept
+// instead of being deployed again. Ironic does not return the BMC
+// credentials, so the host refers to a secret named after it that has
+// to be created separately.
+func HostFromNode(node nodes.Node, bootMACAddress, defaultNamespace string) (*metal3v1alpha1.BareMetalHost, error) {
+	address, disableCertificateVerification, err := bmcAddress(node)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, name := HostName(node, defaultNamespace)
+	return &metal3v1alpha1.BareMetalHost{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: metal3v1alpha1.GroupVersion.String(),
+			Kind:       "BareMetalHost",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: metal3v1alpha1.BareMetalHostSpec{
+			BMC: metal3v1alpha1.BMCDetails{
+				Address:                        address,
+				CredentialsName:                name + "-bmc-secret",
+				DisableCertificateVerification: disableCertificateVerification,
+			},
+			BootMACAddress:        bootMACAddress,
+			BootMode:              bootMode(node),
+			Online:                node.PowerState == "power on",
+			ExternallyProvisioned: nodes.ProvisionState(node.ProvisionState) == nodes.Active,
+		},
+	}, nil
+}
+
+// bootMACAddress returns the address of the PXE enabled port of the
+// node, which the operator uses to find the node again
+func bootMACAddress(client *gophercloud.ServiceClient, node nodes.Node) (string, error) {
+	pages, err := ports.ListDetail(client, ports.ListOpts{NodeUUID: node.UUID}).AllPages()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the ports of node %s", node.UUID)
+	}
+	nodePorts, err := ports.ExtractPorts(pages)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the ports of node %s", node.UUID)
+	}
+	for _, port := range nodePorts {
+		if port.PXEEnabled {
+			return port.Address, nil
+		}
+	}
+	return "", nil
+}
+
+// Import returns the hosts adopting all the nodes of Ironic, sorted by
+// namespace and name so that the same inventory is always imported the
+// same way. The nodes that cannot be adopted are returned as errors.
+func Import(client *gophercloud.ServiceClient, defaultNamespace string) (hosts []metal3v1alpha1.BareMetalHost, skipped []error, err error) {
+	pages, err := nodes.ListDetail(client, nodes.ListOpts{}).AllPages()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list the nodes")
+	}
+	allNodes, err := nodes.ExtractNodes(pages)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to list the nodes")
+	}
+
+	for _, node := range allNodes {
+		mac, err := bootMACAddress(client, node)
+		if err != nil {
+			return nil, nil, err
+		}
+		host, err := HostFromNode(node, mac, defaultNamespace)
+		if err != nil {
+			skipped = append(skipped, err)
+			continue
+		}
+		hosts = append(hosts, *host)
+	}
+
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].Namespace != hosts[j].Namespace {
+			return hosts[i].Namespace < hosts[j].Namespace
+		}
+		return hosts[i].Name < hosts[j].Name
+	})
+	return hosts, skipped, nil
+}
+
+// manifest is the part of a host that is written out, leaving out the
+// status and the metadata set by the API server
+type manifest struct {
+	APIVersion string                           `json:"apiVersion"`
+	Kind       string                           `json:"kind"`
+	Metadata   manifestMetadata                 `json:"metadata"`
+	Spec       metal3v1alpha1.BareMetalHostSpec `json:"spec"`
+}
+
+type manifestMetadata struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// Render returns the hosts as a multi-document YAML manifest
+func Render(hosts []metal3v1alpha1.BareMetalHost) (string, error) {
+	var result strings.Builder
+	for _, host := range hosts {
+		content, err := yaml.Marshal(manifest{
+			APIVersion: host.APIVersion,
+			Kind:       host.Kind,
+			Metadata:   manifestMetadata{Name: host.Name, Namespace: host.Namespace},
+			Spec:       host.Spec,
+		})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to render host %s", host.Name)
+		}
+		result.WriteString("---\n")
+		result.Write(content)
+	}
+	return result.String(), nil
+}
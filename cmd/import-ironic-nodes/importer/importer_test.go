@@ -0,0 +1,202 @@
+package importer
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestHostFromNode(t *testing.T) {
+	cases := []struct {
+		name string
+		node nodes.Node
+
+		expectedNamespace string
+		expectedName      string
+		expectedSpec      metal3v1alpha1.BareMetalHostSpec
+		expectedError     string
+	}{
+		{
+			name: "active ipmi node",
+			node: nodes.Node{
+				UUID:           "uuid-1",
+				Name:           "worker_0",
+				Driver:         "ipmi",
+				DriverInfo:     map[string]interface{}{"ipmi_address": "192.168.1.10", "ipmi_port": "6230", "ipmi_password": "******"},
+				ProvisionState: string(nodes.Active),
+				PowerState:     "power on",
+				Properties:     map[string]interface{}{"capabilities": "cpu_vt:true,boot_mode:uefi"},
+			},
+			expectedNamespace: "imported",
+			expectedName:      "worker-0",
+			expectedSpec: metal3v1alpha1.BareMetalHostSpec{
+				BMC: metal3v1alpha1.BMCDetails{
+					Address:         "ipmi://192.168.1.10:6230",
+					CredentialsName: "worker-0-bmc-secret",
+				},
+				BootMACAddress:        "00:11:22:33:44:55",
+				BootMode:              metal3v1alpha1.UEFI,
+				Online:                true,
+				ExternallyProvisioned: true,
+			},
+		},
+		{
+			name: "available redfish node",
+			node: nodes.Node{
+				UUID:   "uuid-2",
+				Name:   "storage~storage-0",
+				Driver: "redfish",
+				DriverInfo: map[string]interface{}{
+					"redfish_address":   "https://10.0.0.2:8000",
+					"redfish_system_id": "/redfish/v1/Systems/1",
+					"redfish_verify_ca": false,
+				},
+				ProvisionState: string(nodes.Available),
+				PowerState:     "power off",
+			},
+			expectedNamespace: "storage",
+			expectedName:      "storage-0",
+			expectedSpec: metal3v1alpha1.BareMetalHostSpec{
+				BMC: metal3v1alpha1.BMCDetails{
+					Address:                        "redfish://10.0.0.2:8000/redfish/v1/Systems/1",
+					CredentialsName:                "storage-0-bmc-secret",
+					DisableCertificateVerification: true,
+				},
+				BootMACAddress: "00:11:22:33:44:55",
+			},
+		},
+		{
+			name: "redfish virtual media over http",
+			node: nodes.Node{
+				UUID:          "uuid-3",
+				Driver:        "redfish",
+				BootInterface: "redfish-virtual-media",
+				DriverInfo: map[string]interface{}{
+					"redfish_address":   "http://10.0.0.3",
+					"redfish_system_id": "/redfish/v1/Systems/3",
+				},
+				ProvisionState: string(nodes.Manageable),
+			},
+			expectedNamespace: "imported",
+			expectedName:      "uuid-3",
+			expectedSpec: metal3v1alpha1.BareMetalHostSpec{
+				BMC: metal3v1alpha1.BMCDetails{
+					Address:         "redfish-virtualmedia+http://10.0.0.3/redfish/v1/Systems/3",
+					CredentialsName: "uuid-3-bmc-secret",
+				},
+				BootMACAddress: "00:11:22:33:44:55",
+			},
+		},
+		{
+			name:          "unsupported driver",
+			node:          nodes.Node{UUID: "uuid-4", Driver: "fake-hardware"},
+			expectedError: "node uuid-4 uses the unsupported driver \"fake-hardware\"",
+		},
+		{
+			name:          "missing address",
+			node:          nodes.Node{UUID: "uuid-5", Driver: "ipmi"},
+			expectedError: "node uuid-5 has no ipmi_address",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host, err := HostFromNode(tc.node, "00:11:22:33:44:55", "imported")
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedNamespace, host.Namespace)
+			assert.Equal(t, tc.expectedName, host.Name)
+			assert.Equal(t, tc.expectedSpec, host.Spec)
+		})
+	}
+}
+
+func TestImport(t *testing.T) {
+	allNodes := []nodes.Node{
+		{
+			UUID:           "uuid-b",
+			Name:           "worker-b",
+			Driver:         "ipmi",
+			DriverInfo:     map[string]interface{}{"ipmi_address": "192.168.1.11"},
+			ProvisionState: string(nodes.Active),
+			PowerState:     "power on",
+		},
+		{
+			UUID:           "uuid-a",
+			Name:           "worker-a",
+			Driver:         "ipmi",
+			DriverInfo:     map[string]interface{}{"ipmi_address": "192.168.1.10"},
+			ProvisionState: string(nodes.Available),
+			PowerState:     "power off",
+		},
+		{
+			UUID:   "uuid-c",
+			Name:   "fake",
+			Driver: "fake-hardware",
+		},
+	}
+
+	ironic := testserver.NewIronic(t).Ready()
+	ironic.ResponseJSON("/v1/nodes/detail", map[string][]nodes.Node{"nodes": allNodes})
+	ironic.Handler("/v1/ports/detail", func(w http.ResponseWriter, r *http.Request) {
+		nodePorts := []ports.Port{}
+		if nodeUUID := r.URL.Query().Get("node_uuid"); nodeUUID != "uuid-c" {
+			nodePorts = append(nodePorts, ports.Port{NodeUUID: nodeUUID, Address: "00:00:00:00:00:0" + nodeUUID[5:], PXEEnabled: true})
+		}
+		ironic.SendJSONResponse(map[string][]ports.Port{"ports": nodePorts}, http.StatusOK, w, r)
+	})
+	ironic.Start()
+	defer ironic.Stop()
+
+	client, err := clients.IronicClient(ironic.Endpoint(), clients.AuthConfig{Type: clients.NoAuth}, clients.TLSConfig{}, clients.ConnectionConfig{})
+	if err != nil {
+		t.Fatalf("could not create ironic client: %s", err)
+	}
+
+	hosts, skipped, err := Import(client, "")
+	assert.NoError(t, err)
+	assert.Len(t, skipped, 1)
+
+	rendered, err := Render(hosts)
+	assert.NoError(t, err)
+	assert.Equal(t, `---
+apiVersion: metal3.io/v1alpha1
+kind: BareMetalHost
+metadata:
+  name: worker-a
+spec:
+  bmc:
+    address: ipmi://192.168.1.10
+    credentialsName: worker-a-bmc-secret
+  bootMACAddress: 00:00:00:00:00:0a
+  online: false
+---
+apiVersion: metal3.io/v1alpha1
+kind: BareMetalHost
+metadata:
+  name: worker-b
+spec:
+  bmc:
+    address: ipmi://192.168.1.11
+    credentialsName: worker-b-bmc-secret
+  bootMACAddress: 00:00:00:00:00:0b
+  externallyProvisioned: true
+  online: true
+`, rendered)
+
+	// Importing the same inventory again gives the same hosts
+	again, _, err := Import(client, "")
+	assert.NoError(t, err)
+	assert.Equal(t, hosts, again)
+}
@@ -0,0 +1,57 @@
+// import-ironic-nodes is a tool that generates the BareMetalHost
+// resources adopting the nodes of an existing Ironic, so that they can
+// be managed by the operator without being provisioned again.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/metal3-io/baremetal-operator/cmd/import-ironic-nodes/importer"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+func main() {
+	var namespace = flag.String("namespace", "", "namespace of the hosts for nodes not registered by the operator")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: import-ironic-nodes [-namespace <namespace>] <ironic URI>")
+		os.Exit(1)
+	}
+
+	endpoint, auth, err := clients.ConfigFromEndpointURL(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
+	tlsConf := clients.TLSConfig{
+		TrustedCAFile:      os.Getenv("IRONIC_CACERT_FILE"),
+		InsecureSkipVerify: strings.ToLower(os.Getenv("IRONIC_INSECURE")) == "true",
+	}
+	client, err := clients.IronicClient(endpoint, auth, tlsConf, clients.ConnectionConfig{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not get ironic client: %s\n", err)
+		os.Exit(1)
+	}
+
+	hosts, skipped, err := importer.Import(client, *namespace)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	for _, reason := range skipped {
+		fmt.Fprintf(os.Stderr, "skipping node: %s\n", reason)
+	}
+
+	result, err := importer.Render(hosts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprint(os.Stdout, result)
+	if len(hosts) != 0 {
+		fmt.Fprintln(os.Stderr, "the BMC credentials are not returned by Ironic, create the secret named in credentialsName for each host")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -30,14 +31,18 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
@@ -47,12 +52,22 @@ import (
 )
 
 const (
-	hostErrorRetryDelay           = time.Second * 10
-	unmanagedRetryDelay           = time.Minute * 10
-	provisionerNotReadyRetryDelay = time.Second * 30
-	rebootAnnotationPrefix        = "reboot.metal3.io"
-	inspectAnnotationPrefix       = "inspect.metal3.io"
-	hardwareDetailsAnnotation     = inspectAnnotationPrefix + "/hardwaredetails"
+	hostErrorRetryDelay            = time.Second * 10
+	unmanagedRetryDelay            = time.Minute * 10
+	provisionerNotReadyRetryDelay  = time.Second * 30
+	powerOnDependencyRetryDelay    = time.Second * 30
+	preprovisioningImageRetryDelay = time.Second * 30
+	rebootAnnotationPrefix         = "reboot.metal3.io"
+	inspectAnnotationPrefix        = "inspect.metal3.io"
+	bootDeviceAnnotation           = "bootdevice.metal3.io"
+	resetBMCAnnotation             = "resetbmc.metal3.io"
+	syncPowerAnnotation            = "syncpower.metal3.io"
+	retryAnnotation                = "retry.metal3.io"
+	hardwareDetailsAnnotation      = inspectAnnotationPrefix + "/hardwaredetails"
+	maxDeployRetries               = 3
+	bmcResetCooldown               = time.Minute * 10
+	bmcResetRequeueDelay           = time.Minute
+	sensorRefreshInterval          = time.Minute * 5
 )
 
 // BareMetalHostReconciler reconciles a BareMetalHost object
@@ -81,6 +96,7 @@ func (info *reconcileInfo) publishEvent(reason, message string) {
 
 // +kubebuilder:rbac:groups=metal3.io,resources=baremetalhosts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=metal3.io,resources=baremetalhosts/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=metal3.io,resources=preprovisioningimages,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;update
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;patch
 
@@ -329,6 +345,7 @@ func recordActionFailure(info *reconcileInfo, errorType metal3v1alpha1.ErrorType
 		metal3v1alpha1.InspectionError:              "InspectionError",
 		metal3v1alpha1.ProvisioningError:            "ProvisioningError",
 		metal3v1alpha1.PowerManagementError:         "PowerManagementError",
+		metal3v1alpha1.ConductorError:               "ConductorError",
 	}[errorType]
 
 	counter := actionFailureCounters.WithLabelValues(eventType)
@@ -362,6 +379,9 @@ func (r *BareMetalHostReconciler) credentialsErrorResult(err error, request ctrl
 	// at some point in the future.
 	case *ResolveBMCSecretRefError:
 		credentialsMissing.Inc()
+		host.Status.BlockingReasons = []metal3v1alpha1.BlockingReason{
+			{Type: metal3v1alpha1.BlockingReasonCredentialsMissing, Message: err.Error()},
+		}
 		saveErr := r.setErrorCondition(request, host, metal3v1alpha1.RegistrationError, err.Error())
 		if saveErr != nil {
 			return ctrl.Result{Requeue: true}, saveErr
@@ -521,6 +541,8 @@ func (r *BareMetalHostReconciler) actionDeleting(prov provisioner.Provisioner, i
 	if err := r.Update(context.Background(), info.host); err != nil {
 		return actionError{errors.Wrap(err, "failed to remove finalizer")}
 	}
+	deleteHostStateTimes(info.request)
+	forgetShutdownHook(info.request.NamespacedName)
 
 	return deleteComplete{}
 }
@@ -550,6 +572,36 @@ func getCurrentImage(host *metal3v1alpha1.BareMetalHost) *metal3v1alpha1.Image {
 	return nil
 }
 
+// getAutomatedCleaningMode returns the cleaning mode to configure in
+// the provisioner. Cleaning is disabled until the host has been
+// deployed for the first time when the initial cleaning is skipped.
+func getAutomatedCleaningMode(host *metal3v1alpha1.BareMetalHost) metal3v1alpha1.AutomatedCleaningMode {
+	if !host.Spec.SkipInitialCleaning {
+		return host.Spec.AutomatedCleaningMode
+	}
+	if host.Status.Provisioning.InitialDeployComplete || host.Status.Provisioning.Image.URL != "" {
+		return host.Spec.AutomatedCleaningMode
+	}
+	switch host.Status.Provisioning.State {
+	case metal3v1alpha1.StateProvisioned, metal3v1alpha1.StateExternallyProvisioned,
+		metal3v1alpha1.StateDeprovisioning:
+		// Never skip cleaning a host deployed before the status
+		// was recorded
+		return host.Spec.AutomatedCleaningMode
+	}
+	return metal3v1alpha1.CleaningModeDisabled
+}
+
+// requestFullClean records that the whole disks of the host are to be
+// overwritten when it is prepared next, if its cleaning mode asks for
+// it. Ironic only erases the disk metadata in its automated cleaning,
+// so the erase is run as a manual cleaning step instead.
+func requestFullClean(host *metal3v1alpha1.BareMetalHost) {
+	if getAutomatedCleaningMode(host) == metal3v1alpha1.CleaningModeFull {
+		host.Status.Provisioning.ErasePending = true
+	}
+}
+
 // detachHost() detaches the host from the Provisioner
 func (r *BareMetalHostReconciler) detachHost(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
 	provResult, err := prov.Detach()
@@ -591,12 +643,50 @@ func (r *BareMetalHostReconciler) registerHost(prov provisioner.Provisioner, inf
 		dirty = true
 	}
 
+	// Fail early with a precise error until the credentials are known
+	// to work, rather than after the node has been created.
+	if !info.host.Status.GoodCredentials.Match(*info.bmcCredsSecret) {
+		provResult, err := prov.CheckBMCAccess()
+		if err != nil {
+			noManagementAccess.Inc()
+			return actionError{errors.Wrap(err, "failed to check BMC access")}
+		}
+		if provResult.ErrorMessage != "" {
+			return recordActionFailure(info, metal3v1alpha1.RegistrationError, provResult.ErrorMessage)
+		}
+		if provResult.Dirty {
+			info.log.Info("waiting for the BMC access check")
+			return actionContinue{provResult.RequeueAfter}
+		}
+	}
+
+	ppImage, ppImageReady, err := r.getPreprovisioningImage(info)
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to get the preprovisioning image")}
+	}
+	if !ppImageReady {
+		info.log.Info("waiting for the preprovisioning image", "image", info.host.Spec.PreprovisioningImageName)
+		return actionContinue{preprovisioningImageRetryDelay}
+	}
+
 	provResult, provID, err := prov.ValidateManagementAccess(
 		provisioner.ManagementAccessData{
 			BootMode:              info.host.Status.Provisioning.BootMode,
-			AutomatedCleaningMode: info.host.Spec.AutomatedCleaningMode,
+			AutomatedCleaningMode: getAutomatedCleaningMode(info.host),
 			State:                 info.host.Status.Provisioning.State,
 			CurrentImage:          getCurrentImage(info.host),
+			Tags:                  info.host.Spec.Tags,
+			ManagementInterface:   info.host.Spec.BMC.ManagementInterface,
+			RedfishAuthType:       info.host.Spec.BMC.RedfishAuthType,
+			Description:           info.host.Spec.Description,
+			Traits:                info.host.Spec.Traits,
+			Shard:                 info.host.Spec.Shard,
+			NodeProperties:        info.host.Spec.NodeProperties,
+			DeployNetworks:        info.host.Spec.DeployNetworks,
+			FastTrack:             info.host.Spec.FastTrack,
+			Capabilities:          info.host.Spec.Capabilities,
+			InstanceCapabilities:  info.host.Spec.InstanceCapabilities,
+			PreprovisioningImage:  ppImage,
 		},
 		credsChanged,
 		info.host.Status.ErrorType == metal3v1alpha1.RegistrationError)
@@ -610,7 +700,7 @@ func (r *BareMetalHostReconciler) registerHost(prov provisioner.Provisioner, inf
 	}
 
 	if provID != "" && info.host.Status.Provisioning.ID != provID {
-		info.log.Info("setting provisioning id", "ID", provID)
+		info.log.Info("setting provisioning id", "ID", provID, "previousID", info.host.Status.Provisioning.ID)
 		info.host.Status.Provisioning.ID = provID
 		if info.host.Status.Provisioning.State == metal3v1alpha1.StatePreparing {
 			clearHostProvisioningSettings(info.host)
@@ -669,7 +759,8 @@ func (r *BareMetalHostReconciler) actionInspecting(prov provisioner.Provisioner,
 	refresh := hasInspectAnnotation(info.host)
 	provResult, details, err := prov.InspectHardware(
 		provisioner.InspectData{
-			BootMode: info.host.Status.Provisioning.BootMode,
+			BootMode:     info.host.Status.Provisioning.BootMode,
+			KernelParams: info.host.Spec.InspectionKernelParams,
 		},
 		info.host.Status.ErrorType == metal3v1alpha1.InspectionError,
 		refresh)
@@ -698,11 +789,62 @@ func (r *BareMetalHostReconciler) actionInspecting(prov provisioner.Provisioner,
 		return result
 	}
 
-	clearError(info.host)
+	// Keep the details of an incomplete inspection for troubleshooting
 	info.host.Status.HardwareDetails = details
+	if found := countNICs(details); found < info.host.Spec.MinimumNICs {
+		return recordActionFailure(info, metal3v1alpha1.InspectionError,
+			fmt.Sprintf("incomplete inspection: found %d NICs, expected at least %d (minimumNICs)",
+				found, info.host.Spec.MinimumNICs))
+	}
+	// The host would never boot from the network with a MAC address
+	// that none of its NICs has
+	if mac := info.host.Spec.BootMACAddress; mac != "" && len(details.NIC) != 0 && !hasNICWithMAC(details, mac) {
+		return recordActionFailure(info, metal3v1alpha1.InspectionError,
+			fmt.Sprintf("the boot MAC address %s does not belong to any of the inspected NICs (%s)",
+				mac, strings.Join(nicMACs(details), ", ")))
+	}
+
+	clearError(info.host)
 	return actionComplete{}
 }
 
+// hasNICWithMAC returns whether one of the network interfaces in the
+// hardware details has the MAC address
+func hasNICWithMAC(details *metal3v1alpha1.HardwareDetails, mac string) bool {
+	for _, nic := range details.NIC {
+		if strings.EqualFold(nic.MAC, mac) {
+			return true
+		}
+	}
+	return false
+}
+
+// nicMACs returns the sorted MAC addresses of the network interfaces
+// in the hardware details
+func nicMACs(details *metal3v1alpha1.HardwareDetails) []string {
+	seen := map[string]bool{}
+	macs := []string{}
+	for _, nic := range details.NIC {
+		mac := strings.ToLower(nic.MAC)
+		if mac != "" && !seen[mac] {
+			seen[mac] = true
+			macs = append(macs, mac)
+		}
+	}
+	sort.Strings(macs)
+	return macs
+}
+
+// countNICs returns the number of network interfaces in the hardware
+// details, which list an interface once per IP address family.
+func countNICs(details *metal3v1alpha1.HardwareDetails) int {
+	names := map[string]bool{}
+	for _, nic := range details.NIC {
+		names[nic.Name] = true
+	}
+	return len(names)
+}
+
 func (r *BareMetalHostReconciler) actionMatchProfile(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
 
 	var hardwareProfile string
@@ -760,6 +902,11 @@ func (r *BareMetalHostReconciler) actionPreparing(prov provisioner.Provisioner,
 	prepareData := provisioner.PrepareData{
 		RAIDConfig:      info.host.Status.Provisioning.RAID.DeepCopy(),
 		RootDeviceHints: info.host.Status.Provisioning.RootDeviceHints.DeepCopy(),
+		FirmwareConfig:  info.host.Status.Provisioning.Firmware.DeepCopy(),
+		HardwareDetails: info.host.Status.HardwareDetails.DeepCopy(),
+
+		EraseDevices:            info.host.Status.Provisioning.ErasePending,
+		RetryRecoverableFailure: info.host.Status.Provisioning.CleanRetries < cleanRetryLimit(info.host),
 	}
 	// Do prepare(manual clean).
 	provResult, started, err := prov.Prepare(prepareData, dirty)
@@ -767,6 +914,17 @@ func (r *BareMetalHostReconciler) actionPreparing(prov provisioner.Provisioner,
 		return actionError{errors.Wrap(err, "error preparing host")}
 	}
 
+	if provResult.Retried {
+		// Forget the saved settings, so that they are applied again
+		info.host.Status.Provisioning.RAID = nil
+		info.host.Status.Provisioning.Firmware = nil
+		info.host.Status.Provisioning.CleanRetries++
+		info.publishEvent("PreparationRetried",
+			fmt.Sprintf("Retrying cleaning after a recoverable failure (attempt %d of %d)",
+				info.host.Status.Provisioning.CleanRetries, cleanRetryLimit(info.host)))
+		return actionUpdate{actionContinue{provResult.RequeueAfter}}
+	}
+
 	if provResult.ErrorMessage != "" {
 		info.log.Info("handling cleaning error in controller")
 		clearHostProvisioningSettings(info.host)
@@ -785,10 +943,85 @@ func (r *BareMetalHostReconciler) actionPreparing(prov provisioner.Provisioner,
 		return result
 	}
 
+	// Check whether the host now reports the requested BIOS settings.
+	var converged *bool
+	if len(info.host.Status.Provisioning.Firmware.BIOSSettings()) != 0 {
+		pending, err := prov.CheckFirmwareSettings(info.host.Status.Provisioning.Firmware)
+		if err != nil {
+			return actionError{errors.Wrap(err, "failed to check the firmware settings")}
+		}
+		allApplied := len(pending) == 0
+		converged = &allApplied
+		if !allApplied {
+			names := make([]string, 0, len(pending))
+			for name := range pending {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			info.publishEvent("FirmwareSettingsNotConverged",
+				fmt.Sprintf("BIOS settings not applied: %s", strings.Join(names, ", ")))
+		}
+	}
+	info.host.Status.FirmwareConverged = converged
+	info.host.Status.FirmwareSettingsDiff = nil
+	info.host.Status.Provisioning.CleanRetries = 0
+	info.host.Status.Provisioning.ErasePending = false
+
 	clearError(info.host)
 	return actionComplete{}
 }
 
+// powerOnDelayRemaining returns how long the power actions of the host
+// still wait after its registration
+func powerOnDelayRemaining(host *metal3v1alpha1.BareMetalHost, now time.Time) time.Duration {
+	registered := host.Status.OperationHistory.Register.End
+	if host.Spec.BMC.PowerOnDelaySeconds == nil || registered.IsZero() {
+		return 0
+	}
+	delay := time.Duration(*host.Spec.BMC.PowerOnDelaySeconds) * time.Second
+	if remaining := registered.Add(delay).Sub(now); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// deployRetryLimit returns how many times a deploy that failed because
+// of a transient problem is retried automatically
+func deployRetryLimit(host *metal3v1alpha1.BareMetalHost) int {
+	if host.Spec.StepRetries != nil && host.Spec.StepRetries.Deploy != nil {
+		return *host.Spec.StepRetries.Deploy
+	}
+	return maxDeployRetries
+}
+
+// postDeployScriptURL returns the location of the script to run after
+// the image is written, if any
+func postDeployScriptURL(host *metal3v1alpha1.BareMetalHost) string {
+	if host.Spec.PostDeployScript == nil {
+		return ""
+	}
+	return host.Spec.PostDeployScript.URL
+}
+
+// deployTimeouts returns the time limits of the network boot and of
+// the whole deploy of the host, zero when not limited
+func deployTimeouts(host *metal3v1alpha1.BareMetalHost) (networkBoot, deploy time.Duration) {
+	if host.Spec.DeployTimeouts == nil {
+		return 0, 0
+	}
+	return time.Second * time.Duration(host.Spec.DeployTimeouts.NetworkBootSeconds),
+		time.Second * time.Duration(host.Spec.DeployTimeouts.DeploySeconds)
+}
+
+// cleanRetryLimit returns how many times a manual cleaning that failed
+// because of a transient problem is retried automatically
+func cleanRetryLimit(host *metal3v1alpha1.BareMetalHost) int {
+	if host.Spec.StepRetries != nil && host.Spec.StepRetries.Clean != nil {
+		return *host.Spec.StepRetries.Clean
+	}
+	return 0
+}
+
 // Start/continue provisioning if we need to.
 func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
 	hostConf := &hostConfigData{
@@ -812,17 +1045,47 @@ func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisione
 		return actionContinue{}
 	}
 
+	imageCacheChecked := checkImageCache(prov, info)
+
+	imageHeaders, err := r.getImageHeaders(info.host)
+	if err != nil {
+		if _, invalid := err.(InvalidImageHeadersError); invalid {
+			return recordActionFailure(info, metal3v1alpha1.ProvisioningError, err.Error())
+		}
+		return actionError{errors.Wrap(err, "could not read the image HTTP headers")}
+	}
+
+	networkBootTimeout, deployTimeout := deployTimeouts(info.host)
 	provResult, err := prov.Provision(provisioner.ProvisionData{
-		Image:           *info.host.Spec.Image.DeepCopy(),
-		HostConfig:      hostConf,
-		BootMode:        info.host.Status.Provisioning.BootMode,
-		HardwareProfile: hwProf,
-		RootDeviceHints: info.host.Status.Provisioning.RootDeviceHints.DeepCopy(),
+		Image:                   *info.host.Spec.Image.DeepCopy(),
+		HostConfig:              hostConf,
+		BootMode:                info.host.Status.Provisioning.BootMode,
+		HardwareProfile:         hwProf,
+		RootDeviceHints:         info.host.Status.Provisioning.RootDeviceHints.DeepCopy(),
+		HardwareDetails:         info.host.Status.HardwareDetails.DeepCopy(),
+		DeploymentID:            deploymentID(info.host),
+		RetryRecoverableFailure: info.host.Status.Provisioning.DeployRetries < deployRetryLimit(info.host),
+		TimeSettings:            info.host.Spec.TimeSettings.DeepCopy(),
+		InstanceCapabilities:    info.host.Spec.InstanceCapabilities,
+		InstanceTraits:          info.host.Spec.InstanceTraits,
+		ImageHeaders:            imageHeaders,
+		NetworkBootTimeout:      networkBootTimeout,
+		DeployTimeout:           deployTimeout,
+		ProvisionStarted:        info.host.Status.OperationHistory.Provision.Start.Time,
+		PostDeployScriptURL:     postDeployScriptURL(info.host),
 	})
 	if err != nil {
 		return actionError{errors.Wrap(err, "failed to provision")}
 	}
 
+	if provResult.Retried {
+		info.host.Status.Provisioning.DeployRetries++
+		info.publishEvent("ProvisioningRetried",
+			fmt.Sprintf("Retrying deploy after a recoverable failure (attempt %d of %d)",
+				info.host.Status.Provisioning.DeployRetries, deployRetryLimit(info.host)))
+		return actionUpdate{actionContinue{provResult.RequeueAfter}}
+	}
+
 	if provResult.ErrorMessage != "" {
 		info.log.Info("handling provisioning error in controller")
 		return recordActionFailure(info, metal3v1alpha1.ProvisioningError, provResult.ErrorMessage)
@@ -833,29 +1096,91 @@ func (r *BareMetalHostReconciler) actionProvisioning(prov provisioner.Provisione
 		// to return false, indicating that it has no more work to
 		// do.
 		result := actionContinue{provResult.RequeueAfter}
-		if clearError(info.host) {
+		if clearError(info.host) || imageCacheChecked {
 			return actionUpdate{result}
 		}
 		return result
 	}
 
 	// If the provisioner had no work, ensure the image settings match.
-	if info.host.Status.Provisioning.Image != *(info.host.Spec.Image) {
+	if !equality.Semantic.DeepEqual(info.host.Status.Provisioning.Image, *info.host.Spec.Image) {
 		info.log.Info("updating deployed image in status")
 		info.host.Status.Provisioning.Image = *(info.host.Spec.Image)
 	}
+	info.host.Status.Provisioning.InitialDeployComplete = true
+	info.host.Status.Provisioning.DeploymentID = deploymentID(info.host)
 
 	// After provisioning we always requeue to ensure we enter the
 	// "provisioned" state and start monitoring power status.
 	return actionComplete{}
 }
 
+// checkImageCache records whether the provisioner already cached the
+// image before its deploy starts, so that a deploy stalled by the
+// download of the image can be told apart. The cache is only checked
+// once for each image, and a failure to check it does not hold the
+// deploy back. It reports whether the status changed.
+func checkImageCache(prov provisioner.Provisioner, info *reconcileInfo) (checked bool) {
+	image := info.host.Spec.Image
+	if cache := info.host.Status.Provisioning.ImageCache; cache != nil && cache.URL == image.URL {
+		return false
+	}
+
+	cached, err := prov.IsImageCached(*image)
+	if err != nil {
+		info.log.Info("could not check the image cache", "image", image.URL, "error", err)
+		return false
+	}
+	if cached != nil && !*cached {
+		info.log.Info("image not cached, the deploy waits for its download", "image", image.URL)
+	}
+	info.host.Status.Provisioning.ImageCache = &metal3v1alpha1.ImageCacheStatus{
+		URL:    image.URL,
+		Cached: cached,
+	}
+	return true
+}
+
+// actionAbortDeploy stops the deploy in progress when provisioning is
+// cancelled, so that the host can be deprovisioned right away.
+func (r *BareMetalHostReconciler) actionAbortDeploy(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
+	provResult, err := prov.AbortDeploy()
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to abort deploy")}
+	}
+	if provResult.Dirty {
+		return actionContinue{provResult.RequeueAfter}
+	}
+	return actionComplete{}
+}
+
+// deploymentID returns the identifier of the consumer of the host, its
+// UID if known and its namespaced name otherwise.
+func deploymentID(host *metal3v1alpha1.BareMetalHost) string {
+	consumer := host.Spec.ConsumerRef
+	switch {
+	case consumer == nil:
+		return ""
+	case consumer.UID != "":
+		return string(consumer.UID)
+	case consumer.Name == "":
+		return ""
+	case consumer.Namespace == "":
+		return fmt.Sprintf("%s/%s", host.Namespace, consumer.Name)
+	}
+	return fmt.Sprintf("%s/%s", consumer.Namespace, consumer.Name)
+}
+
 // clearHostProvisioningSettings removes the values related to
 // provisioning that do not trigger re-provisioning from the status
 // fields of a host.
 func clearHostProvisioningSettings(host *metal3v1alpha1.BareMetalHost) {
 	host.Status.Provisioning.RootDeviceHints = nil
 	host.Status.Provisioning.RAID = nil
+	host.Status.Provisioning.Firmware = nil
+	host.Status.Provisioning.DeployRetries = 0
+	host.Status.Provisioning.CleanRetries = 0
+	host.Status.Provisioning.ImageCache = nil
 }
 
 func (r *BareMetalHostReconciler) actionDeprovisioning(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
@@ -909,6 +1234,7 @@ func (r *BareMetalHostReconciler) actionDeprovisioning(prov provisioner.Provisio
 	// After the provisioner is done, clear the provisioning settings
 	// so we transition to the next state.
 	info.host.Status.Provisioning.Image = metal3v1alpha1.Image{}
+	info.host.Status.Provisioning.DeploymentID = ""
 	clearHostProvisioningSettings(info.host)
 
 	return actionComplete{}
@@ -921,9 +1247,76 @@ func (r *BareMetalHostReconciler) manageHostPower(prov provisioner.Provisioner,
 	// Check the current status and save it before trying to update it.
 	hwState, err := prov.UpdateHardwareState()
 	if err != nil {
+		if errors.Is(err, provisioner.ErrConductorDown) {
+			return recordActionFailure(info, metal3v1alpha1.ConductorError, err.Error())
+		}
 		return actionError{errors.Wrap(err, "failed to update the host power status")}
 	}
 
+	if info.host.Status.ErrorType == metal3v1alpha1.ConductorError {
+		info.log.Info("conductor is back")
+		clearError(info.host)
+		return actionUpdate{}
+	}
+
+	if hwState.History != nil && !equality.Semantic.DeepEqual(hwState.History, info.host.Status.OperationHistory.Events) {
+		info.host.Status.OperationHistory.Events = hwState.History
+		return actionUpdate{}
+	}
+
+	if !hwState.SerialConsoleUnknown && !equality.Semantic.DeepEqual(hwState.SerialConsole, info.host.Status.SerialConsole) {
+		info.log.Info("updating serial console details", "console", hwState.SerialConsole)
+		info.host.Status.SerialConsole = hwState.SerialConsole
+		return actionUpdate{}
+	}
+
+	if !hwState.AllocationUnknown && !equality.Semantic.DeepEqual(hwState.Allocation, info.host.Status.Allocation) {
+		info.log.Info("updating allocation details", "allocation", hwState.Allocation)
+		info.host.Status.Allocation = hwState.Allocation
+		return actionUpdate{}
+	}
+
+	if !equality.Semantic.DeepEqual(hwState.Scheduling, info.host.Status.Scheduling) {
+		info.log.Info("updating scheduling details", "scheduling", hwState.Scheduling)
+		info.host.Status.Scheduling = hwState.Scheduling
+		return actionUpdate{}
+	}
+
+	if !hwState.ConductorUnknown && !equality.Semantic.DeepEqual(hwState.Conductor, info.host.Status.Conductor) {
+		info.log.Info("updating conductor details", "conductor", hwState.Conductor)
+		info.host.Status.Conductor = hwState.Conductor
+		return actionUpdate{}
+	}
+
+	if hwState.HardwareFault != info.host.Status.HardwareFault {
+		if hwState.HardwareFault != "" {
+			info.log.Info("host in maintenance because of a hardware fault", "reason", hwState.HardwareFault)
+			info.publishEvent("HardwareFault", fmt.Sprintf("Hardware fault reported: %s", hwState.HardwareFault))
+		} else {
+			info.log.Info("hardware fault cleared", "reason", info.host.Status.HardwareFault)
+		}
+		info.host.Status.HardwareFault = hwState.HardwareFault
+		return actionUpdate{}
+	}
+
+	if !hwState.TimestampsUnknown && !equality.Semantic.DeepEqual(hwState.LastInspected, info.host.Status.LastInspected) {
+		info.log.Info("updating the inspection time", "lastInspected", hwState.LastInspected)
+		info.host.Status.LastInspected = hwState.LastInspected
+		return actionUpdate{}
+	}
+
+	if !hwState.TimestampsUnknown && !equality.Semantic.DeepEqual(hwState.NodeCreatedAt, info.host.Status.NodeCreatedAt) {
+		info.log.Info("updating the node creation time", "createdAt", hwState.NodeCreatedAt)
+		info.host.Status.NodeCreatedAt = hwState.NodeCreatedAt
+		return actionUpdate{}
+	}
+
+	if hwState.BootInterface != info.host.Status.BootInterface {
+		info.log.Info("updating the boot interface", "bootInterface", hwState.BootInterface)
+		info.host.Status.BootInterface = hwState.BootInterface
+		return actionUpdate{}
+	}
+
 	if hwState.PoweredOn != nil && *hwState.PoweredOn != info.host.Status.PoweredOn {
 		info.log.Info("updating power status", "discovered", *hwState.PoweredOn)
 		info.host.Status.PoweredOn = *hwState.PoweredOn
@@ -931,6 +1324,18 @@ func (r *BareMetalHostReconciler) manageHostPower(prov provisioner.Provisioner,
 		return actionUpdate{}
 	}
 
+	if value, present := info.host.Annotations[bootDeviceAnnotation]; present {
+		return r.setBootDevice(prov, info, value)
+	}
+
+	if _, present := info.host.Annotations[resetBMCAnnotation]; present {
+		return r.resetBMC(prov, info)
+	}
+
+	if result := manageBootDevice(prov, info); result != nil {
+		return result
+	}
+
 	desiredPowerOnState := info.host.Spec.Online
 
 	if !info.host.Status.PoweredOn {
@@ -953,11 +1358,18 @@ func (r *BareMetalHostReconciler) manageHostPower(prov provisioner.Provisioner,
 		desiredPowerOnState = false
 	}
 
+	if _, present := info.host.Annotations[syncPowerAnnotation]; present {
+		return r.syncPowerState(prov, info, desiredPowerOnState)
+	}
+
 	// Power state needs to be monitored regularly, so if we leave
 	// this function without an error we always want to requeue after
 	// a delay.
 	steadyStateResult := actionContinue{time.Second * 60}
 	if info.host.Status.PoweredOn == desiredPowerOnState {
+		if result := r.clearShutdownHook(info); result != nil {
+			return result
+		}
 		return steadyStateResult
 	}
 
@@ -967,12 +1379,41 @@ func (r *BareMetalHostReconciler) manageHostPower(prov provisioner.Provisioner,
 		"reboot mode", desiredRebootMode,
 		"reboot process", desiredPowerOnState != info.host.Spec.Online)
 
+	if desiredPowerOnState && info.host.Spec.PowerOnAfter != "" {
+		ready, err := r.powerOnDependencyReady(info)
+		if err != nil {
+			if errors.Is(err, errPowerOnDependencyCycle) {
+				return recordActionFailure(info, metal3v1alpha1.PowerManagementError, err.Error())
+			}
+			return actionError{errors.Wrap(err, "failed to check the power on dependency")}
+		}
+		if !ready {
+			info.log.Info("waiting for dependency before powering on",
+				"dependency", info.host.Spec.PowerOnAfter)
+			return actionContinue{powerOnDependencyRetryDelay}
+		}
+	}
+
+	if remaining := powerOnDelayRemaining(info.host, time.Now()); remaining > 0 {
+		info.log.Info("waiting after registration before changing the power state", "remaining", remaining)
+		return actionContinue{remaining}
+	}
+
+	if !desiredPowerOnState && needsShutdownHook(info.host, desiredRebootMode) {
+		if result := r.waitForShutdownHook(info); result != nil {
+			return result
+		}
+	}
+
 	if desiredPowerOnState {
 		provResult, err = prov.PowerOn()
 	} else {
 		provResult, err = prov.PowerOff(desiredRebootMode)
 	}
 	if err != nil {
+		if errors.Is(err, provisioner.ErrConductorDown) {
+			return recordActionFailure(info, metal3v1alpha1.ConductorError, err.Error())
+		}
 		return actionError{errors.Wrap(err, "failed to manage power state of host")}
 	}
 
@@ -1005,6 +1446,285 @@ func (r *BareMetalHostReconciler) manageHostPower(prov provisioner.Provisioner,
 	return actionUpdate{steadyStateResult}
 }
 
+// getPreprovisioningImage returns the ramdisk of the preprovisioning
+// image named by the host or labelled with its hardware profile, then
+// the deploy ramdisk configured for the hardware profile, or nil if the
+// default ramdisk is used. The image named by the host may not be
+// available yet, in which case ready is false.
+func (r *BareMetalHostReconciler) getPreprovisioningImage(info *reconcileInfo) (image *provisioner.PreprovisioningImage, ready bool, err error) {
+	if name := info.host.Spec.PreprovisioningImageName; name != "" {
+		ppImage := &metal3v1alpha1.PreprovisioningImage{}
+		key := types.NamespacedName{Name: name, Namespace: info.host.Namespace}
+		if err = r.Get(context.TODO(), key, ppImage); err != nil {
+			if k8serrors.IsNotFound(err) {
+				info.log.Info("preprovisioning image not found", "image", name)
+				err = nil
+			}
+			return
+		}
+		if !ppImage.Ready() {
+			if ppImage.Status.ErrorMessage != "" {
+				info.log.Info("preprovisioning image failed to build", "image", name, "error", ppImage.Status.ErrorMessage)
+			}
+			return
+		}
+		return &provisioner.PreprovisioningImage{
+			KernelURL: ppImage.Status.KernelURL,
+			ImageURL:  ppImage.Status.ImageURL,
+		}, true, nil
+	}
+
+	profile := info.host.HardwareProfile()
+	if profile == "" {
+		return nil, true, nil
+	}
+	ppImages := &metal3v1alpha1.PreprovisioningImageList{}
+	err = r.List(context.TODO(), ppImages, client.InNamespace(info.host.Namespace),
+		client.MatchingLabels{metal3v1alpha1.PreprovisioningImageHardwareProfileLabel: profile})
+	if err != nil {
+		return
+	}
+	// Use the first ready image in a stable order
+	sort.Slice(ppImages.Items, func(i, j int) bool {
+		return ppImages.Items[i].Name < ppImages.Items[j].Name
+	})
+	for _, ppImage := range ppImages.Items {
+		if ppImage.Ready() {
+			image = &provisioner.PreprovisioningImage{
+				KernelURL: ppImage.Status.KernelURL,
+				ImageURL:  ppImage.Status.ImageURL,
+			}
+			return image, true, nil
+		}
+	}
+
+	if hwProf, profErr := hardware.GetProfile(profile); profErr == nil && hwProf.DeployKernelURL != "" {
+		image = &provisioner.PreprovisioningImage{
+			KernelURL: hwProf.DeployKernelURL,
+			ImageURL:  hwProf.DeployRamdiskURL,
+		}
+	}
+	return image, true, nil
+}
+
+// preprovisioningImageToHosts returns the requests reconciling the
+// hosts that name the preprovisioning image or may select it by their
+// hardware profile, so that they pick up the image once it is built.
+func (r *BareMetalHostReconciler) preprovisioningImageToHosts(obj client.Object) (requests []ctrl.Request) {
+	hosts := &metal3v1alpha1.BareMetalHostList{}
+	if err := r.List(context.TODO(), hosts, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "failed to list the hosts of a preprovisioning image",
+			"preprovisioningimage", types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()})
+		return
+	}
+
+	profile := obj.GetLabels()[metal3v1alpha1.PreprovisioningImageHardwareProfileLabel]
+	for _, host := range hosts.Items {
+		if host.Spec.PreprovisioningImageName == obj.GetName() ||
+			(host.Spec.PreprovisioningImageName == "" && profile != "" && host.HardwareProfile() == profile) {
+			requests = append(requests, ctrl.Request{
+				NamespacedName: types.NamespacedName{Name: host.Name, Namespace: host.Namespace},
+			})
+		}
+	}
+	return
+}
+
+var errPowerOnDependencyCycle = errors.New("power on dependencies form a cycle")
+
+// powerOnDependencyReady returns whether the host the current host
+// depends on for powering on is provisioned or ready. The whole chain
+// of dependencies is followed to detect cycles.
+func (r *BareMetalHostReconciler) powerOnDependencyReady(info *reconcileInfo) (ready bool, err error) {
+	var dependency *metal3v1alpha1.BareMetalHost
+	visited := map[string]bool{info.host.Name: true}
+	for current := info.host; current.Spec.PowerOnAfter != ""; {
+		if visited[current.Spec.PowerOnAfter] {
+			return false, fmt.Errorf("%w: host %s depends on %s",
+				errPowerOnDependencyCycle, current.Name, current.Spec.PowerOnAfter)
+		}
+		visited[current.Spec.PowerOnAfter] = true
+
+		next := &metal3v1alpha1.BareMetalHost{}
+		err = r.Get(context.TODO(), current.PowerOnDependencyKey(), next)
+		if err != nil {
+			if k8serrors.IsNotFound(err) {
+				info.log.Info("power on dependency not found", "dependency", current.Spec.PowerOnAfter)
+				err = nil
+				break
+			}
+			return
+		}
+		if dependency == nil {
+			dependency = next
+		}
+		current = next
+	}
+
+	if dependency == nil {
+		return false, err
+	}
+	switch dependency.Status.Provisioning.State {
+	case metal3v1alpha1.StateProvisioned, metal3v1alpha1.StateExternallyProvisioned, metal3v1alpha1.StateReady:
+		ready = true
+	}
+	return
+}
+
+// setBootDevice applies the boot device requested through the boot
+// device annotation, then removes the annotation.
+func (r *BareMetalHostReconciler) setBootDevice(prov provisioner.Provisioner, info *reconcileInfo, value string) actionResult {
+	args := metal3v1alpha1.BootDeviceAnnotationArguments{}
+	if err := json.Unmarshal([]byte(value), &args); err != nil || args.Device == "" {
+		info.publishEvent("InvalidAnnotationValue", fmt.Sprintf("could not parse boot device annotation (%s), ignoring it", value))
+	} else {
+		provResult, err := prov.SetBootDevice(args.Device, args.Persistent)
+		if err != nil {
+			return actionError{errors.Wrap(err, "failed to set boot device")}
+		}
+		if provResult.Dirty {
+			return actionContinue{provResult.RequeueAfter}
+		}
+		if provResult.ErrorMessage != "" {
+			info.publishEvent("BootDeviceRejected", provResult.ErrorMessage)
+		}
+	}
+
+	delete(info.host.Annotations, bootDeviceAnnotation)
+	if err := r.Update(context.TODO(), info.host); err != nil {
+		return actionError{errors.Wrap(err, "failed to remove boot device annotation from host")}
+	}
+	return actionContinue{}
+}
+
+// manageBootDevice sets the boot device requested in the spec
+// persistently and records it in the status. The device is only set
+// when the field changes, so the boot devices the provisioner sets
+// while cleaning or provisioning the host are not undone.
+func manageBootDevice(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
+	desired := info.host.Spec.BootDevice
+	if desired == "" {
+		if info.host.Status.BootDevice != nil {
+			info.host.Status.BootDevice = nil
+			return actionUpdate{}
+		}
+		return nil
+	}
+	if info.host.Status.BootDevice != nil && info.host.Status.BootDevice.Device == desired {
+		return nil
+	}
+
+	provResult, err := prov.SetBootDevice(desired, true)
+	if err != nil {
+		return actionError{errors.Wrap(err, "failed to set boot device")}
+	}
+	if provResult.Dirty {
+		return actionContinue{provResult.RequeueAfter}
+	}
+	if provResult.ErrorMessage != "" {
+		info.publishEvent("BootDeviceRejected", provResult.ErrorMessage)
+		return nil
+	}
+	info.log.Info("updating boot device", "device", desired)
+	info.host.Status.BootDevice = &metal3v1alpha1.BootDeviceStatus{
+		Device:     desired,
+		Persistent: true,
+	}
+	return actionUpdate{}
+}
+
+// resetBMC restarts the BMC as requested through the reset BMC
+// annotation, then removes the annotation. Requests made less than
+// bmcResetCooldown after the previous reset are ignored so that a
+// struggling BMC is not reset over and over.
+func (r *BareMetalHostReconciler) resetBMC(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
+	resetDone := false
+	if lastReset := info.host.Status.LastBMCReset; lastReset != nil && time.Since(lastReset.Time) < bmcResetCooldown {
+		info.publishEvent("BMCResetRejected",
+			fmt.Sprintf("the BMC was reset less than %s ago, ignoring the request", bmcResetCooldown))
+	} else {
+		provResult, err := prov.ResetBMC()
+		if err != nil {
+			return actionError{errors.Wrap(err, "failed to reset the BMC")}
+		}
+		if provResult.Dirty {
+			return actionContinue{provResult.RequeueAfter}
+		}
+		if provResult.ErrorMessage != "" {
+			info.publishEvent("BMCResetRejected", provResult.ErrorMessage)
+		} else {
+			resetDone = true
+		}
+	}
+
+	delete(info.host.Annotations, resetBMCAnnotation)
+	if err := r.Update(context.TODO(), info.host); err != nil {
+		return actionError{errors.Wrap(err, "failed to remove reset BMC annotation from host")}
+	}
+	if !resetDone {
+		return actionContinue{}
+	}
+
+	// Give the BMC time to come back before talking to it again
+	now := metav1.Now()
+	info.host.Status.LastBMCReset = &now
+	return actionUpdate{actionContinue{bmcResetRequeueDelay}}
+}
+
+// syncPowerState makes the provisioner read the power state from the
+// BMC as requested through the sync power annotation, then removes the
+// annotation even if the sync failed, recording an event instead. The
+// recorded power state is then picked up like after a periodic sync.
+func (r *BareMetalHostReconciler) syncPowerState(prov provisioner.Provisioner, info *reconcileInfo, online bool) actionResult {
+	provResult, err := prov.SyncPowerState(online)
+	switch {
+	case err != nil:
+		info.log.Info("failed to sync the power state", "error", err)
+		info.publishEvent("PowerSyncFailed", fmt.Sprintf("Failed to sync the power state: %s", err))
+	case provResult.Dirty:
+		return actionContinue{provResult.RequeueAfter}
+	case provResult.ErrorMessage != "":
+		info.publishEvent("PowerSyncRejected", provResult.ErrorMessage)
+	}
+
+	delete(info.host.Annotations, syncPowerAnnotation)
+	if err := r.Update(context.TODO(), info.host); err != nil {
+		return actionError{errors.Wrap(err, "failed to remove sync power annotation from host")}
+	}
+	return actionContinue{}
+}
+
+// clearFault clears the fault of a failed host as requested through the
+// retry annotation, so that the failed operation is retried right away
+// instead of after the backoff, then removes the annotation.
+func (r *BareMetalHostReconciler) clearFault(prov provisioner.Provisioner, info *reconcileInfo) actionResult {
+	if info.host.Status.ErrorType != "" {
+		provResult, err := prov.ClearFault()
+		if err != nil && !errors.Is(err, provisioner.ErrNeedsRegistration) {
+			return actionError{errors.Wrap(err, "failed to clear the fault")}
+		}
+		if provResult.Dirty {
+			return actionContinue{provResult.RequeueAfter}
+		}
+		if provResult.ErrorMessage != "" {
+			info.publishEvent("FaultNotCleared", provResult.ErrorMessage)
+		} else {
+			info.log.Info("retrying after the fault was cleared",
+				"errorType", info.host.Status.ErrorType)
+			info.publishEvent("RetryRequested",
+				fmt.Sprintf("Retrying after %s: %s", info.host.Status.ErrorType, info.host.Status.ErrorMessage))
+			clearError(info.host)
+			info.host.Status.ErrorCount = 0
+		}
+	}
+
+	delete(info.host.Annotations, retryAnnotation)
+	if err := r.Update(context.TODO(), info.host); err != nil {
+		return actionError{errors.Wrap(err, "failed to remove retry annotation from host")}
+	}
+	return actionUpdate{}
+}
+
 // A host reaching this action handler should be provisioned or externally
 // provisioned -- a state that it will stay in until the user takes further
 // action. We use the Adopt() API to make sure that the provisioner is aware of
@@ -1027,6 +1747,10 @@ func (r *BareMetalHostReconciler) actionManageSteadyState(prov provisioner.Provi
 		return result
 	}
 
+	if r.updateSensors(prov, info) {
+		return actionUpdate{}
+	}
+
 	return r.manageHostPower(prov, info)
 }
 
@@ -1052,6 +1776,84 @@ func (r *BareMetalHostReconciler) actionManageReady(prov provisioner.Provisioner
 	return r.manageHostPower(prov, info)
 }
 
+// updateFirmwareSettingsDiff reports in the status how a changed
+// firmware configuration would change the BIOS settings of the host
+// once it is applied, returning whether the status changed. Reading
+// the settings is expensive, so the difference is only computed again
+// when the spec of the host changes.
+func (r *BareMetalHostReconciler) updateFirmwareSettingsDiff(prov provisioner.Provisioner, info *reconcileInfo) (dirty bool, err error) {
+	if reflect.DeepEqual(info.host.Spec.Firmware, info.host.Status.Provisioning.Firmware) {
+		if info.host.Status.FirmwareSettingsDiff == nil {
+			return false, nil
+		}
+		info.log.Info("clearing the firmware settings difference")
+		info.host.Status.FirmwareSettingsDiff = nil
+		return true, nil
+	}
+	if info.host.Status.FirmwareSettingsDiffGeneration == info.host.Generation {
+		return false, nil
+	}
+
+	diff, err := prov.DiffFirmwareSettings(info.host.Spec.Firmware, info.host.Status.Provisioning.Firmware)
+	if err != nil {
+		return false, errors.Wrap(err, "failed to compare the firmware settings")
+	}
+	info.log.Info("updating the firmware settings difference", "diff", diff)
+	info.host.Status.FirmwareSettingsDiff = diff
+	info.host.Status.FirmwareSettingsDiffGeneration = info.host.Generation
+	return true, nil
+}
+
+// updateSensors refreshes the fan and temperature readings in the
+// status every sensorRefreshInterval, returning whether the status
+// changed. Failing to read the sensors does not block the host, the
+// attempt is recorded so that the readings are only retried after the
+// interval.
+func (r *BareMetalHostReconciler) updateSensors(prov provisioner.Provisioner, info *reconcileInfo) (dirty bool) {
+	previous := info.host.Status.Sensors
+	if previous != nil {
+		last := previous.LastAttempted
+		if last == nil {
+			last = previous.LastUpdated
+		}
+		if last != nil && time.Since(last.Time) < sensorRefreshInterval {
+			return false
+		}
+	}
+
+	now := metav1.Now()
+	sensors, err := prov.GetSensors()
+	if err != nil {
+		info.log.Info("failed to read the sensors", "error", err)
+		attempted := previous.DeepCopy()
+		if attempted == nil {
+			attempted = &metal3v1alpha1.SensorStatus{}
+		}
+		attempted.LastAttempted = &now
+		info.host.Status.Sensors = attempted
+		return true
+	}
+	if sensors == nil {
+		info.host.Status.Sensors = nil
+		return previous != nil
+	}
+
+	wasOutOfRange := map[string]bool{}
+	for _, name := range previous.OutOfRange() {
+		wasOutOfRange[name] = true
+	}
+	for _, name := range sensors.OutOfRange() {
+		if !wasOutOfRange[name] {
+			info.publishEvent("SensorOutOfRange", fmt.Sprintf("Sensor %s reports a reading out of range", name))
+		}
+	}
+
+	sensors.LastUpdated = &now
+	sensors.LastAttempted = &now
+	info.host.Status.Sensors = sensors
+	return true
+}
+
 // saveHostProvisioningSettings copies the values related to
 // provisioning that do not trigger re-provisioning into the status
 // fields of the host.
@@ -1086,8 +1888,8 @@ func saveHostProvisioningSettings(host *metal3v1alpha1.BareMetalHost) (dirty boo
 				host.Status.Provisioning.RAID = &metal3v1alpha1.RAIDConfig{}
 				dirty = true
 			}
-			// If HardwareRAIDVolumes isn't nil, we will ignore SoftwareRAIDVolumes.
-			if len(host.Spec.RAID.HardwareRAIDVolumes) != 0 {
+			// If HardwareRAIDVolumes or PassthroughDisks aren't nil, we will ignore SoftwareRAIDVolumes.
+			if len(host.Spec.RAID.HardwareRAIDVolumes) != 0 || len(host.Spec.RAID.PassthroughDisks) != 0 {
 				// If software RAID has been saved, remove it.
 				if len(host.Status.Provisioning.RAID.SoftwareRAIDVolumes) != 0 {
 					host.Status.Provisioning.RAID.SoftwareRAIDVolumes = nil
@@ -1097,10 +1899,15 @@ func saveHostProvisioningSettings(host *metal3v1alpha1.BareMetalHost) (dirty boo
 					host.Status.Provisioning.RAID.HardwareRAIDVolumes = host.Spec.RAID.HardwareRAIDVolumes
 					dirty = true
 				}
+				if !reflect.DeepEqual(host.Spec.RAID.PassthroughDisks, host.Status.Provisioning.RAID.PassthroughDisks) {
+					host.Status.Provisioning.RAID.PassthroughDisks = host.Spec.RAID.PassthroughDisks
+					dirty = true
+				}
 			} else {
 				// If hardware RAID has been saved, remove it.
-				if len(host.Status.Provisioning.RAID.HardwareRAIDVolumes) != 0 {
+				if len(host.Status.Provisioning.RAID.HardwareRAIDVolumes) != 0 || len(host.Status.Provisioning.RAID.PassthroughDisks) != 0 {
 					host.Status.Provisioning.RAID.HardwareRAIDVolumes = nil
+					host.Status.Provisioning.RAID.PassthroughDisks = nil
 					dirty = true
 				}
 				// Compare software RAID settings
@@ -1112,6 +1919,12 @@ func saveHostProvisioningSettings(host *metal3v1alpha1.BareMetalHost) (dirty boo
 		}
 	}
 
+	// Copy BIOS settings
+	if !reflect.DeepEqual(host.Spec.Firmware, host.Status.Provisioning.Firmware) {
+		host.Status.Provisioning.Firmware = host.Spec.Firmware.DeepCopy()
+		dirty = true
+	}
+
 	return
 }
 
@@ -1218,6 +2031,82 @@ func credentialsFromSecret(bmcCredsSecret *corev1.Secret) *bmc.Credentials {
 	}
 }
 
+// getImageHeaders reads the HTTP headers sent when downloading the
+// image of the host, if any. The secret must be in the namespace of the
+// host, so that a host cannot send the credentials of another namespace.
+func (r *BareMetalHostReconciler) getImageHeaders(host *metal3v1alpha1.BareMetalHost) (map[string]string, error) {
+	if host.Spec.Image == nil || host.Spec.Image.HTTPHeadersSecret == nil {
+		return nil, nil
+	}
+	ref := host.Spec.Image.HTTPHeadersSecret
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = host.Namespace
+	}
+	key := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+	if namespace != host.Namespace {
+		return nil, InvalidImageHeadersError{secret: key.String(), message: "the secret is not in the namespace of the host"}
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(context.TODO(), key, secret); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, InvalidImageHeadersError{secret: key.String(), message: "the secret does not exist"}
+		}
+		return nil, err
+	}
+	return imageHeadersFromSecret(secret)
+}
+
+// imageHeadersFromSecret checks that each key of the secret is a valid
+// HTTP header name and each value a valid header value, which must not
+// span several lines. Surrounding whitespace of the values is trimmed.
+func imageHeadersFromSecret(secret *corev1.Secret) (map[string]string, error) {
+	name := types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}.String()
+	if len(secret.Data) == 0 {
+		return nil, InvalidImageHeadersError{secret: name, message: "the secret holds no headers"}
+	}
+	headers := make(map[string]string, len(secret.Data))
+	for header, value := range secret.Data {
+		if !isHTTPHeaderName(header) {
+			return nil, InvalidImageHeadersError{secret: name, message: fmt.Sprintf("%q is not a valid header name", header)}
+		}
+		trimmed := strings.TrimSpace(string(value))
+		if !isHTTPHeaderValue(trimmed) {
+			return nil, InvalidImageHeadersError{secret: name, message: fmt.Sprintf("the value of header %s is not valid", header)}
+		}
+		headers[header] = trimmed
+	}
+	return headers, nil
+}
+
+// isHTTPHeaderName returns whether the name is a token as defined by
+// RFC 7230.
+func isHTTPHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// isHTTPHeaderValue returns whether the value holds no control
+// characters other than tabs.
+func isHTTPHeaderValue(value string) bool {
+	for _, c := range value {
+		if (c < ' ' && c != '\t') || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
 // Make sure the credentials for the management controller look
 // right and manufacture bmc.Credentials.  This does not actually try
 // to use the credentials.
@@ -1325,6 +2214,10 @@ func (r *BareMetalHostReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		ctrl.Log.Info(fmt.Sprintf("Operator Concurrency will be set to a default value of %d", maxConcurrentReconciles))
 	}
 
+	if err := loadShutdownHookClient(); err != nil {
+		return err
+	}
+
 	opts := controller.Options{
 		MaxConcurrentReconciles: maxConcurrentReconciles,
 	}
@@ -1337,5 +2230,7 @@ func (r *BareMetalHostReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			}).
 		WithOptions(opts).
 		Owns(&corev1.Secret{}).
+		Watches(&source.Kind{Type: &metal3v1alpha1.PreprovisioningImage{}},
+			handler.EnqueueRequestsFromMapFunc(r.preprovisioningImageToHosts)).
 		Complete(r)
 }
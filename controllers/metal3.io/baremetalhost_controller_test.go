@@ -6,7 +6,12 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -24,6 +29,8 @@ import (
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
 	"github.com/metal3-io/baremetal-operator/pkg/utils"
 )
@@ -608,6 +615,597 @@ func TestRebootWithSuffixlessAnnotation(t *testing.T) {
 	)
 }
 
+// TestBootDeviceAnnotation tests that the boot device annotation is
+// consumed without changing the power state
+func TestBootDeviceAnnotation(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = make(map[string]string)
+	host.Annotations[bootDeviceAnnotation] = `{"device": "pxe"}`
+	host.Status.PoweredOn = true
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Spec.Online = true
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "foo", Checksum: "123"}
+	host.Status.Provisioning.Image.URL = "foo"
+
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			if _, exists := host.Annotations[bootDeviceAnnotation]; exists {
+				return false
+			}
+
+			return host.Status.PoweredOn
+		},
+	)
+}
+
+// TestPersistentBootDevice tests that the boot device requested in the
+// spec is set persistently and reported in the status
+func TestPersistentBootDevice(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Status.PoweredOn = true
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Spec.Online = true
+	host.Spec.BootDevice = "pxe"
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "foo", Checksum: "123"}
+	host.Status.Provisioning.Image.URL = "foo"
+
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.BootDevice != nil &&
+				host.Status.BootDevice.Device == "pxe" &&
+				host.Status.BootDevice.Persistent
+		},
+	)
+}
+
+// TestPersistentBootDeviceCleared tests that the reported boot device
+// is removed from the status when none is requested anymore
+func TestPersistentBootDeviceCleared(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Status.PoweredOn = true
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Status.BootDevice = &metal3v1alpha1.BootDeviceStatus{Device: "pxe", Persistent: true}
+	host.Spec.Online = true
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "foo", Checksum: "123"}
+	host.Status.Provisioning.Image.URL = "foo"
+
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.BootDevice == nil
+		},
+	)
+}
+
+// TestResetBMCAnnotation tests that the reset BMC annotation is
+// consumed and the reset recorded
+func TestResetBMCAnnotation(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{resetBMCAnnotation: ""}
+	host.Status.PoweredOn = true
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Spec.Online = true
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "foo", Checksum: "123"}
+	host.Status.Provisioning.Image.URL = "foo"
+
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			if _, exists := host.Annotations[resetBMCAnnotation]; exists {
+				return false
+			}
+
+			return host.Status.LastBMCReset != nil
+		},
+	)
+}
+
+// TestResetBMCCooldown tests that the BMC is not reset again shortly
+// after the previous reset
+func TestResetBMCCooldown(t *testing.T) {
+	lastReset := metav1.NewTime(time.Now().Add(-time.Minute).Truncate(time.Second))
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{resetBMCAnnotation: ""}
+	host.Status.PoweredOn = true
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Status.LastBMCReset = &lastReset
+	host.Spec.Online = true
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "foo", Checksum: "123"}
+	host.Status.Provisioning.Image.URL = "foo"
+
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			if _, exists := host.Annotations[resetBMCAnnotation]; exists {
+				return false
+			}
+
+			return host.Status.LastBMCReset != nil && host.Status.LastBMCReset.Equal(&lastReset)
+		},
+	)
+}
+
+// TestSyncPowerAnnotation tests that the sync power annotation is
+// consumed
+func TestSyncPowerAnnotation(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{syncPowerAnnotation: ""}
+	host.Status.PoweredOn = true
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Spec.Online = true
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "foo", Checksum: "123"}
+	host.Status.Provisioning.Image.URL = "foo"
+
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			_, exists := host.Annotations[syncPowerAnnotation]
+			return !exists && host.Status.PoweredOn
+		},
+	)
+}
+
+// TestRetryAnnotation tests that the retry annotation is consumed and
+// the error of the host cleared
+func TestRetryAnnotation(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Annotations = map[string]string{retryAnnotation: ""}
+	host.Status.PoweredOn = true
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Status.ErrorType = metal3v1alpha1.PowerManagementError
+	host.Status.ErrorMessage = "BMC not responding"
+	host.Status.ErrorCount = 5
+	host.Status.OperationalStatus = metal3v1alpha1.OperationalStatusError
+	host.Spec.Online = true
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "foo", Checksum: "123"}
+	host.Status.Provisioning.Image.URL = "foo"
+
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			if _, exists := host.Annotations[retryAnnotation]; exists {
+				return false
+			}
+
+			return host.Status.ErrorType == "" && host.Status.ErrorCount == 0 &&
+				host.Status.OperationalStatus == metal3v1alpha1.OperationalStatusOK
+		},
+	)
+}
+
+// newShutdownHookServer returns a shutdown hook answering with the
+// given status code and counting the requests it receives
+func newShutdownHookServer(t *testing.T, code int, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request shutdownHookRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("invalid shutdown hook request: %s", err)
+		}
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, namespace, request.Namespace)
+		atomic.AddInt32(calls, 1)
+		w.WriteHeader(code)
+	}))
+}
+
+// createPoweredOnHost creates a provisioned host and powers it on
+func createPoweredOnHost(t *testing.T, r *BareMetalHostReconciler, host *metal3v1alpha1.BareMetalHost) {
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Spec.Online = true
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "foo", Checksum: "123"}
+	host.Status.Provisioning.Image.URL = "foo"
+	if err := r.Create(goctx.TODO(), host); err != nil {
+		t.Fatal(err)
+	}
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.PoweredOn
+		},
+	)
+}
+
+// TestShutdownHookConfirmed tests that the host is only powered off
+// once the shutdown hook confirms the shutdown
+func TestShutdownHookConfirmed(t *testing.T) {
+	var calls int32
+	server := newShutdownHookServer(t, http.StatusAccepted, &calls)
+	defer server.Close()
+
+	host := newDefaultHost(t)
+	r := newTestReconciler()
+	createPoweredOnHost(t, r, host)
+
+	host.Spec.Online = false
+	host.Spec.ShutdownHook = &metal3v1alpha1.ShutdownHook{URL: server.URL}
+	if err := r.Update(goctx.TODO(), host); err != nil {
+		t.Fatal(err)
+	}
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return result.RequeueAfter == shutdownHookPollDelay
+		},
+	)
+	assert.True(t, host.Status.PoweredOn)
+	assert.NotNil(t, host.Status.ShutdownDeadline)
+	// The hook is called in the background
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&calls) == 1 }, time.Second*5, time.Millisecond*10)
+
+	host.Annotations = map[string]string{shutdownConfirmedAnnotation: ""}
+	if err := r.Update(goctx.TODO(), host); err != nil {
+		t.Fatal(err)
+	}
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			_, confirmed := host.Annotations[shutdownConfirmedAnnotation]
+			return !host.Status.PoweredOn && host.Status.ShutdownDeadline == nil && !confirmed
+		},
+	)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// TestShutdownHookTimeout tests that the host is powered off when the
+// shutdown is not confirmed in time
+func TestShutdownHookTimeout(t *testing.T) {
+	var calls int32
+	server := newShutdownHookServer(t, http.StatusAccepted, &calls)
+	defer server.Close()
+
+	host := newDefaultHost(t)
+	r := newTestReconciler()
+	createPoweredOnHost(t, r, host)
+
+	deadline := metav1.NewTime(time.Now().Add(-time.Second))
+	host.Spec.Online = false
+	host.Spec.ShutdownHook = &metal3v1alpha1.ShutdownHook{URL: server.URL, TimeoutSeconds: 60}
+	host.Status.ShutdownDeadline = &deadline
+	if err := r.Update(goctx.TODO(), host); err != nil {
+		t.Fatal(err)
+	}
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return !host.Status.PoweredOn && host.Status.ShutdownDeadline == nil
+		},
+	)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&calls))
+}
+
+// TestShutdownHookFailure tests that the host is powered off without
+// waiting when the shutdown hook cannot be called
+func TestShutdownHookFailure(t *testing.T) {
+	var calls int32
+	server := newShutdownHookServer(t, http.StatusInternalServerError, &calls)
+	defer server.Close()
+
+	host := newDefaultHost(t)
+	r := newTestReconciler()
+	createPoweredOnHost(t, r, host)
+
+	host.Spec.Online = false
+	host.Spec.ShutdownHook = &metal3v1alpha1.ShutdownHook{URL: server.URL}
+	if err := r.Update(goctx.TODO(), host); err != nil {
+		t.Fatal(err)
+	}
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.ShutdownDeadline != nil
+		},
+	)
+	assert.True(t, host.Status.PoweredOn)
+	// The power off waits for the result of the hook called in the background
+	assert.Eventually(t, func() bool { return shutdownHookFailed(newRequest(host).NamespacedName) != nil },
+		time.Second*5, time.Millisecond*10)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return !host.Status.PoweredOn && host.Status.ShutdownDeadline == nil
+		},
+	)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestCallShutdownHookScheme(t *testing.T) {
+	name := types.NamespacedName{Namespace: namespace, Name: "myhost"}
+	for _, hookURL := range []string{"file:///etc/passwd", "gopher://example.com", "example.com/hook"} {
+		t.Run(hookURL, func(t *testing.T) {
+			err := callShutdownHook(hookURL, name)
+			assert.EqualError(t, err, fmt.Sprintf("shutdown hook URL %q must be an http or https URL", hookURL))
+		})
+	}
+}
+
+func TestGetPreprovisioningImage(t *testing.T) {
+	newImage := func(name, profile, kernelURL, imageURL string) *metal3v1alpha1.PreprovisioningImage {
+		image := &metal3v1alpha1.PreprovisioningImage{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Status: metal3v1alpha1.PreprovisioningImageStatus{
+				KernelURL: kernelURL,
+				ImageURL:  imageURL,
+			},
+		}
+		if profile != "" {
+			image.Labels = map[string]string{metal3v1alpha1.PreprovisioningImageHardwareProfileLabel: profile}
+		}
+		return image
+	}
+	images := []runtime.Object{
+		newImage("arm64", "", "http://images.test/arm64.kernel", "http://images.test/arm64.initramfs"),
+		newImage("building", "", "http://images.test/building.kernel", ""),
+		newImage("dell-b", "dell", "http://images.test/dell-b.kernel", "http://images.test/dell-b.initramfs"),
+		newImage("dell-a", "dell", "http://images.test/dell-a.kernel", "http://images.test/dell-a.initramfs"),
+		newImage("dell-0", "dell", "http://images.test/dell-0.kernel", ""),
+	}
+
+	cases := []struct {
+		name          string
+		imageName     string
+		profile       string
+		expected      *provisioner.PreprovisioningImage
+		expectedReady bool
+	}{
+		{
+			name:          "default",
+			expectedReady: true,
+		},
+		{
+			name:      "named",
+			imageName: "arm64",
+			profile:   "dell",
+			expected: &provisioner.PreprovisioningImage{
+				KernelURL: "http://images.test/arm64.kernel",
+				ImageURL:  "http://images.test/arm64.initramfs",
+			},
+			expectedReady: true,
+		},
+		{
+			name:      "named not built",
+			imageName: "building",
+		},
+		{
+			name:      "named missing",
+			imageName: "missing",
+		},
+		{
+			name:    "hardware profile",
+			profile: "dell",
+			expected: &provisioner.PreprovisioningImage{
+				KernelURL: "http://images.test/dell-a.kernel",
+				ImageURL:  "http://images.test/dell-a.initramfs",
+			},
+			expectedReady: true,
+		},
+		{
+			name:          "other hardware profile",
+			profile:       "libvirt",
+			expectedReady: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := newDefaultHost(t)
+			host.Spec.PreprovisioningImageName = tc.imageName
+			host.Status.HardwareProfile = tc.profile
+			r := newTestReconciler(images...)
+
+			image, ready, err := r.getPreprovisioningImage(makeReconcileInfo(host))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedReady, ready)
+			assert.Equal(t, tc.expected, image)
+		})
+	}
+}
+
+// TestGetPreprovisioningImageProfileDeployImages tests that the deploy
+// ramdisk of the hardware profile is used when no preprovisioning
+// image is selected
+func TestGetPreprovisioningImageProfileDeployImages(t *testing.T) {
+	err := hardware.SetDeployImages([]byte(`
+dell:
+  kernel: http://images.test/dell-profile.kernel
+  ramdisk: http://images.test/dell-profile.initramfs
+libvirt:
+  kernel: http://images.test/libvirt-profile.kernel
+  ramdisk: http://images.test/libvirt-profile.initramfs
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hardware.SetDeployImages([]byte("{}"))
+
+	labelled := &metal3v1alpha1.PreprovisioningImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dell",
+			Namespace: namespace,
+			Labels:    map[string]string{metal3v1alpha1.PreprovisioningImageHardwareProfileLabel: "dell"},
+		},
+		Status: metal3v1alpha1.PreprovisioningImageStatus{
+			KernelURL: "http://images.test/dell.kernel",
+			ImageURL:  "http://images.test/dell.initramfs",
+		},
+	}
+
+	cases := []struct {
+		name     string
+		profile  string
+		expected *provisioner.PreprovisioningImage
+	}{
+		{
+			name:    "profile deploy images",
+			profile: "libvirt",
+			expected: &provisioner.PreprovisioningImage{
+				KernelURL: "http://images.test/libvirt-profile.kernel",
+				ImageURL:  "http://images.test/libvirt-profile.initramfs",
+			},
+		},
+		{
+			name:    "labelled image first",
+			profile: "dell",
+			expected: &provisioner.PreprovisioningImage{
+				KernelURL: "http://images.test/dell.kernel",
+				ImageURL:  "http://images.test/dell.initramfs",
+			},
+		},
+		{
+			name:    "profile without deploy images",
+			profile: "openstack",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := newDefaultHost(t)
+			host.Status.HardwareProfile = tc.profile
+			r := newTestReconciler(labelled)
+
+			image, ready, err := r.getPreprovisioningImage(makeReconcileInfo(host))
+
+			assert.NoError(t, err)
+			assert.True(t, ready)
+			assert.Equal(t, tc.expected, image)
+		})
+	}
+}
+
+// TestPreprovisioningImageWait tests that a host is not registered
+// before the preprovisioning image it names is built
+func TestPreprovisioningImageWait(t *testing.T) {
+	image := &metal3v1alpha1.PreprovisioningImage{
+		ObjectMeta: metav1.ObjectMeta{Name: "arm64", Namespace: namespace},
+	}
+	host := newDefaultHost(t)
+	host.Spec.PreprovisioningImageName = "arm64"
+	r := newTestReconciler(host, image)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return result.RequeueAfter == preprovisioningImageRetryDelay
+		},
+	)
+	assert.Equal(t, "", host.Status.Provisioning.ID)
+
+	image.Status.KernelURL = "http://images.test/arm64.kernel"
+	image.Status.ImageURL = "http://images.test/arm64.initramfs"
+	if err := r.Update(goctx.TODO(), image); err != nil {
+		t.Fatal(err)
+	}
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.Provisioning.ID != ""
+		},
+	)
+}
+
+// TestPreprovisioningImageToHosts tests that a change of a
+// preprovisioning image reconciles the hosts that may use it
+func TestPreprovisioningImageToHosts(t *testing.T) {
+	newImageHost := func(name, imageName, profile string) *metal3v1alpha1.BareMetalHost {
+		host := newDefaultNamedHost(name, t)
+		host.Spec.PreprovisioningImageName = imageName
+		host.Status.HardwareProfile = profile
+		return host
+	}
+	otherNamespace := newImageHost("other-namespace", "dell-a", "")
+	otherNamespace.Namespace = "other"
+	r := newTestReconciler(
+		newImageHost("named", "dell-a", ""),
+		newImageHost("profile", "", "dell"),
+		newImageHost("named-other", "arm64", "dell"),
+		newImageHost("other-profile", "", "libvirt"),
+		otherNamespace,
+	)
+
+	image := &metal3v1alpha1.PreprovisioningImage{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dell-a",
+			Namespace: namespace,
+			Labels:    map[string]string{metal3v1alpha1.PreprovisioningImageHardwareProfileLabel: "dell"},
+		},
+	}
+	requests := r.preprovisioningImageToHosts(image)
+
+	names := []string{}
+	for _, request := range requests {
+		assert.Equal(t, namespace, request.Namespace)
+		names = append(names, request.Name)
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"named", "profile"}, names)
+}
+
+// TestPowerOnDependency tests that a host is only powered on once the
+// host it depends on is provisioned
+func TestPowerOnDependency(t *testing.T) {
+	dependency := newDefaultNamedHost("storage", t)
+	dependency.Status.Provisioning.State = metal3v1alpha1.StateProvisioning
+
+	host := newDefaultHost(t)
+	host.Status.PoweredOn = false
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Spec.Online = true
+	host.Spec.PowerOnAfter = "storage"
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "foo", Checksum: "123"}
+	host.Status.Provisioning.Image.URL = "foo"
+
+	r := newTestReconciler(host, dependency)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return result.RequeueAfter == powerOnDependencyRetryDelay
+		},
+	)
+	assert.False(t, host.Status.PoweredOn)
+
+	dependency.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	if err := r.Update(goctx.TODO(), dependency); err != nil {
+		t.Fatal(err)
+	}
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.PoweredOn
+		},
+	)
+}
+
+// TestPowerOnDependencyCycle tests that a cycle in the power on
+// dependencies is reported as an error
+func TestPowerOnDependencyCycle(t *testing.T) {
+	dependency := newDefaultNamedHost("storage", t)
+	dependency.Spec.PowerOnAfter = t.Name()
+	dependency.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+
+	host := newDefaultHost(t)
+	host.Status.PoweredOn = false
+	host.Status.Provisioning.State = metal3v1alpha1.StateProvisioned
+	host.Spec.Online = true
+	host.Spec.PowerOnAfter = "storage"
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "foo", Checksum: "123"}
+	host.Status.Provisioning.Image.URL = "foo"
+
+	r := newTestReconciler(host, dependency)
+
+	waitForError(t, r, host)
+	assert.Equal(t, metal3v1alpha1.PowerManagementError, host.Status.ErrorType)
+	assert.Contains(t, host.Status.ErrorMessage, "power on dependencies form a cycle")
+	assert.False(t, host.Status.PoweredOn)
+}
+
 // TestRebootWithSuffixedAnnotation tests a full reboot cycle, with suffixed annotation
 // to verify that controller holds power off until annotation removal
 func TestRebootWithSuffixedAnnotation(t *testing.T) {
@@ -879,6 +1477,25 @@ func TestMissingBMCParameters(t *testing.T) {
 	}
 }
 
+// TestMissingSecretBlockingReason ensures that a host whose secret
+// does not exist reports it as a blocking reason.
+func TestMissingSecretBlockingReason(t *testing.T) {
+	host := newHost("missing-secret-blocking-reason",
+		&metal3v1alpha1.BareMetalHostSpec{
+			BMC: metal3v1alpha1.BMCDetails{
+				Address:         "ipmi://192.168.122.1:6233",
+				CredentialsName: "this-secret-does-not-exist",
+			},
+		})
+	r := newTestReconciler(host)
+	waitForError(t, r, host)
+
+	if assert.Len(t, host.Status.BlockingReasons, 1) {
+		assert.Equal(t, metal3v1alpha1.BlockingReasonCredentialsMissing, host.Status.BlockingReasons[0].Type)
+		assert.Contains(t, host.Status.BlockingReasons[0].Message, "this-secret-does-not-exist does not exist")
+	}
+}
+
 // TestFixSecret ensures that when the secret for a host is updated to
 // be correct the status of the host moves out of the error state.
 func TestFixSecret(t *testing.T) {
@@ -1059,6 +1676,77 @@ func TestProvision(t *testing.T) {
 	)
 }
 
+// TestProvisionDeploymentID ensures that the consumer of the host is
+// recorded as the deployment ID while the host is provisioned.
+func TestProvisionDeploymentID(t *testing.T) {
+	host := newDefaultHost(t)
+	host.Spec.Image = &metal3v1alpha1.Image{
+		URL:      "https://example.com/image-name",
+		Checksum: "12345",
+	}
+	host.Spec.Online = true
+	host.Spec.ConsumerRef = &corev1.ObjectReference{Name: "worker-0"}
+	r := newTestReconciler(host)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.Provisioning.State == metal3v1alpha1.StateProvisioned
+		},
+	)
+	assert.Equal(t, host.Namespace+"/worker-0", host.Status.Provisioning.DeploymentID)
+
+	host.Spec.Image = nil
+	host.Spec.ConsumerRef = nil
+	err := r.Update(goctx.TODO(), host)
+	assert.NoError(t, err)
+
+	tryReconcile(t, r, host,
+		func(host *metal3v1alpha1.BareMetalHost, result reconcile.Result) bool {
+			return host.Status.Provisioning.State == metal3v1alpha1.StateReady
+		},
+	)
+	assert.Equal(t, "", host.Status.Provisioning.DeploymentID)
+}
+
+func TestDeploymentID(t *testing.T) {
+	cases := []struct {
+		name     string
+		consumer *corev1.ObjectReference
+		expected string
+	}{
+		{
+			name: "no consumer",
+		},
+		{
+			name:     "consumer UID",
+			consumer: &corev1.ObjectReference{Name: "worker-0", Namespace: "machines", UID: "1234"},
+			expected: "1234",
+		},
+		{
+			name:     "consumer namespace",
+			consumer: &corev1.ObjectReference{Name: "worker-0", Namespace: "machines"},
+			expected: "machines/worker-0",
+		},
+		{
+			name:     "host namespace",
+			consumer: &corev1.ObjectReference{Name: "worker-0"},
+			expected: namespace + "/worker-0",
+		},
+		{
+			name:     "empty consumer",
+			consumer: &corev1.ObjectReference{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := newDefaultHost(t)
+			host.Spec.ConsumerRef = tc.consumer
+			assert.Equal(t, tc.expected, deploymentID(host))
+		})
+	}
+}
+
 // TestExternallyProvisionedTransitions ensures that host enters the
 // expected states when it looks like it has been provisioned by
 // another tool.
@@ -1665,6 +2353,32 @@ func TestUpdateRAID(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "PassthroughDisks configured, software RAID saved",
+			specRAID: &metal3v1alpha1.RAIDConfig{
+				PassthroughDisks: []metal3v1alpha1.PassthroughDisk{
+					{Controller: "RAID.Integrated.1-1", PhysicalDisk: "Disk.Bay.0"},
+				},
+				SoftwareRAIDVolumes: []metal3v1alpha1.SoftwareRAIDVolume{
+					{
+						Level: "1",
+					},
+				},
+			},
+			statusRAID: &metal3v1alpha1.RAIDConfig{
+				SoftwareRAIDVolumes: []metal3v1alpha1.SoftwareRAIDVolume{
+					{
+						Level: "1",
+					},
+				},
+			},
+			dirty: true,
+			expected: &metal3v1alpha1.RAIDConfig{
+				PassthroughDisks: []metal3v1alpha1.PassthroughDisk{
+					{Controller: "RAID.Integrated.1-1", PhysicalDisk: "Disk.Bay.0"},
+				},
+			},
+		},
 		{
 			name: "SoftwareRAIDVolumes configured, not saved",
 			specRAID: &metal3v1alpha1.RAIDConfig{
@@ -2061,3 +2775,86 @@ func TestCredentialsFromSecret(t *testing.T) {
 		})
 	}
 }
+
+func TestImageHeadersFromSecret(t *testing.T) {
+	cases := []struct {
+		name          string
+		data          map[string][]byte
+		expected      map[string]string
+		expectedError string
+	}{
+		{
+			name: "headers",
+			data: map[string][]byte{
+				"Authorization": []byte("Bearer abc\n"),
+				"X-Auth-Token":  []byte("xyz"),
+			},
+			expected: map[string]string{
+				"Authorization": "Bearer abc",
+				"X-Auth-Token":  "xyz",
+			},
+		},
+		{
+			name:          "no headers",
+			expectedError: "Invalid image HTTP headers secret myns/headers: the secret holds no headers",
+		},
+		{
+			name:          "invalid name",
+			data:          map[string][]byte{"X-Auth/Token": []byte("xyz")},
+			expectedError: "Invalid image HTTP headers secret myns/headers: \"X-Auth/Token\" is not a valid header name",
+		},
+		{
+			name:          "multiple lines",
+			data:          map[string][]byte{"Authorization": []byte("Bearer abc\r\nX-Other: 1")},
+			expectedError: "Invalid image HTTP headers secret myns/headers: the value of header Authorization is not valid",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "headers", Namespace: "myns"},
+				Data:       tc.data,
+			}
+			actual, err := imageHeadersFromSecret(secret)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestGetImageHeaders(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "headers", Namespace: namespace},
+		Data:       map[string][]byte{"Authorization": []byte("Bearer abc")},
+	}
+	r := newTestReconciler(secret)
+
+	host := newDefaultHost(t)
+	host.Spec.Image = &metal3v1alpha1.Image{URL: "https://images.test/image.qcow2"}
+	headers, err := r.getImageHeaders(host)
+	assert.NoError(t, err)
+	assert.Nil(t, headers)
+
+	host.Spec.Image.HTTPHeadersSecret = &corev1.SecretReference{Name: "headers"}
+	headers, err = r.getImageHeaders(host)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer abc"}, headers)
+
+	host.Spec.Image.HTTPHeadersSecret = &corev1.SecretReference{Name: "headers", Namespace: namespace}
+	headers, err = r.getImageHeaders(host)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Authorization": "Bearer abc"}, headers)
+
+	host.Spec.Image.HTTPHeadersSecret = &corev1.SecretReference{Name: "missing"}
+	_, err = r.getImageHeaders(host)
+	assert.EqualError(t, err, "Invalid image HTTP headers secret "+namespace+"/missing: the secret does not exist")
+
+	host.Spec.Image.HTTPHeadersSecret = &corev1.SecretReference{Name: "headers", Namespace: "other"}
+	_, err = r.getImageHeaders(host)
+	assert.EqualError(t, err, "Invalid image HTTP headers secret other/headers: the secret is not in the namespace of the host")
+}
@@ -58,3 +58,14 @@ type NoDataInSecretError struct {
 func (e NoDataInSecretError) Error() string {
 	return fmt.Sprintf("Secret %s does not contain key %s", e.secret, e.key)
 }
+
+// InvalidImageHeadersError is returned when the secret referenced for
+// the HTTP headers of the image is missing or malformed
+type InvalidImageHeadersError struct {
+	secret  string
+	message string
+}
+
+func (e InvalidImageHeadersError) Error() string {
+	return fmt.Sprintf("Invalid image HTTP headers secret %s: %s", e.secret, e.message)
+}
@@ -100,3 +100,35 @@ func (hcd *hostConfigData) MetaData() (string, error) {
 		"metaData",
 	)
 }
+
+// ExtraFiles get the content of the extra files of the config drive
+func (hcd *hostConfigData) ExtraFiles() (map[string]string, error) {
+	if len(hcd.host.Spec.ConfigDriveFiles) == 0 {
+		return nil, nil
+	}
+	files := make(map[string]string, len(hcd.host.Spec.ConfigDriveFiles))
+	for _, file := range hcd.host.Spec.ConfigDriveFiles {
+		if _, exists := files[file.Path]; exists {
+			return nil, fmt.Errorf("config drive file %s is listed more than once", file.Path)
+		}
+		namespace := file.SecretRef.Namespace
+		if namespace == "" {
+			namespace = hcd.host.Namespace
+		}
+		secret := &corev1.Secret{}
+		key := types.NamespacedName{
+			Name:      file.SecretRef.Name,
+			Namespace: namespace,
+		}
+		if err := hcd.client.Get(context.TODO(), key, secret); err != nil {
+			errMsg := fmt.Sprintf("failed to fetch config drive file %s from secret %s defined in namespace %s", file.Path, file.SecretRef.Name, namespace)
+			return nil, errors.Wrap(err, errMsg)
+		}
+		data, ok := secret.Data[file.Key]
+		if !ok {
+			return nil, NoDataInSecretError{secret: file.SecretRef.Name, key: file.Key}
+		}
+		files[file.Path] = string(data)
+	}
+	return files, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -260,3 +261,85 @@ func TestProvisionWithHostConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestExtraFiles(t *testing.T) {
+	testCases := []struct {
+		Scenario      string
+		Files         []metal3v1alpha1.ConfigDriveFile
+		ExpectedFiles map[string]string
+		ExpectedError string
+	}{
+		{
+			Scenario: "no files",
+		},
+		{
+			Scenario: "files from one secret",
+			Files: []metal3v1alpha1.ConfigDriveFile{
+				{
+					Path:      "/etc/motd",
+					SecretRef: corev1.SecretReference{Name: "files"},
+					Key:       "motd",
+				},
+				{
+					Path:      "/etc/pki/ca-trust/source/anchors/ca.pem",
+					SecretRef: corev1.SecretReference{Name: "files", Namespace: namespace},
+					Key:       "ca",
+				},
+			},
+			ExpectedFiles: map[string]string{
+				"/etc/motd": base64.StdEncoding.EncodeToString([]byte("Welcome")),
+				"/etc/pki/ca-trust/source/anchors/ca.pem": base64.StdEncoding.EncodeToString([]byte("certificate")),
+			},
+		},
+		{
+			Scenario: "missing key",
+			Files: []metal3v1alpha1.ConfigDriveFile{
+				{
+					Path:      "/etc/motd",
+					SecretRef: corev1.SecretReference{Name: "files"},
+					Key:       "banner",
+				},
+			},
+			ExpectedError: "Secret files does not contain key banner",
+		},
+		{
+			Scenario: "duplicate path",
+			Files: []metal3v1alpha1.ConfigDriveFile{
+				{
+					Path:      "/etc/motd",
+					SecretRef: corev1.SecretReference{Name: "files"},
+					Key:       "motd",
+				},
+				{
+					Path:      "/etc/motd",
+					SecretRef: corev1.SecretReference{Name: "files"},
+					Key:       "ca",
+				},
+			},
+			ExpectedError: "config drive file /etc/motd is listed more than once",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := newHost("host-extra-files", &metal3v1alpha1.BareMetalHostSpec{
+				ConfigDriveFiles: tc.Files,
+			})
+			c := fakeclient.NewFakeClient(host)
+			c.Create(goctx.TODO(), newSecret("files", map[string]string{"motd": "Welcome", "ca": "certificate"}))
+			hcd := &hostConfigData{
+				host:   host,
+				log:    ctrl.Log.WithName("controllers").WithName("BareMetalHost").WithName("host_config_data"),
+				client: c,
+			}
+
+			files, err := hcd.ExtraFiles()
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ExpectedFiles, files)
+		})
+	}
+}
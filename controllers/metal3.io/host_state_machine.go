@@ -2,11 +2,13 @@ package controllers
 
 import (
 	"fmt"
+	"time"
 
 	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -190,11 +192,19 @@ func (hsm *hostStateMachine) ReconcileState(info *reconcileInfo) (actionRes acti
 		return detachedResult
 	}
 
+	if retryResult := hsm.checkRetryRequested(info); retryResult != nil {
+		return retryResult
+	}
+
 	if registerResult := hsm.ensureRegistered(info); registerResult != nil {
 		hostRegistrationRequired.Inc()
 		return registerResult
 	}
 
+	if blockedResult := hsm.updateBlockingReasons(info); blockedResult != nil {
+		return blockedResult
+	}
+
 	if stateHandler, found := hsm.handlers()[initialState]; found {
 		return stateHandler(info)
 	}
@@ -278,6 +288,45 @@ func (hsm *hostStateMachine) checkDetachedHost(info *reconcileInfo) (result acti
 	return nil
 }
 
+// checkRetryRequested clears the fault of the host when the retry
+// annotation is set
+func (hsm *hostStateMachine) checkRetryRequested(info *reconcileInfo) actionResult {
+	if _, present := hsm.Host.Annotations[retryAnnotation]; !present {
+		return nil
+	}
+	return hsm.Reconciler.clearFault(hsm.Provisioner, info)
+}
+
+// transitionalStates are the states in which the host waits for the
+// provisioner, and the ones for which blocking reasons are reported
+var transitionalStates = map[metal3v1alpha1.ProvisioningState]bool{
+	metal3v1alpha1.StateInspecting:     true,
+	metal3v1alpha1.StatePreparing:      true,
+	metal3v1alpha1.StateProvisioning:   true,
+	metal3v1alpha1.StateDeprovisioning: true,
+}
+
+// updateBlockingReasons reports in the status what a host in a
+// transitional state is waiting for, and clears the list in the other
+// states. Failing to read the reasons does not block the host.
+func (hsm *hostStateMachine) updateBlockingReasons(info *reconcileInfo) actionResult {
+	var reasons []metal3v1alpha1.BlockingReason
+	if transitionalStates[hsm.Host.Status.Provisioning.State] {
+		var err error
+		reasons, err = hsm.Provisioner.GetBlockingReasons()
+		if err != nil {
+			info.log.Info("failed to read the blocking reasons", "error", err)
+			return nil
+		}
+	}
+	if equality.Semantic.DeepEqual(reasons, hsm.Host.Status.BlockingReasons) {
+		return nil
+	}
+	info.log.Info("updating the blocking reasons", "reasons", reasons)
+	hsm.Host.Status.BlockingReasons = reasons
+	return actionUpdate{}
+}
+
 func (hsm *hostStateMachine) ensureRegistered(info *reconcileInfo) (result actionResult) {
 	if !hsm.haveCreds {
 		// If we are in the process of deletion (which may start with
@@ -343,6 +392,17 @@ func (hsm *hostStateMachine) handleRegistering(info *reconcileInfo) actionResult
 	// registered using the current BMC credentials, so we can move to the
 	// next state. We will not return to the Registering state, even
 	// if the credentials change and the Host must be re-registered.
+	if hsm.Host.Spec.BMC.PowerOnDelaySeconds != nil {
+		// The registration is over, the next states power the host
+		changed := recordStateEnd(info, hsm.Host, metal3v1alpha1.StateRegistering, metav1.Now())
+		if remaining := powerOnDelayRemaining(hsm.Host, time.Now()); remaining > 0 {
+			info.log.Info("waiting after registration before powering the host", "remaining", remaining)
+			if changed {
+				return actionUpdate{actionContinue{remaining}}
+			}
+			return actionContinue{remaining}
+		}
+	}
 	if hsm.Host.Spec.ExternallyProvisioned {
 		hsm.NextState = metal3v1alpha1.StateExternallyProvisioned
 	} else {
@@ -366,6 +426,7 @@ func (hsm *hostStateMachine) handleMatchProfile(info *reconcileInfo) actionResul
 	if _, complete := actResult.(actionComplete); complete {
 		hsm.NextState = metal3v1alpha1.StatePreparing
 		hsm.Host.Status.ErrorCount = 0
+		requestFullClean(hsm.Host)
 	}
 	return actResult
 }
@@ -408,6 +469,16 @@ func (hsm *hostStateMachine) handleReady(info *reconcileInfo) actionResult {
 		return actionComplete{}
 	}
 
+	if !hsm.Host.NeedsProvisioning() {
+		dirty, err := hsm.Reconciler.updateFirmwareSettingsDiff(hsm.Provisioner, info)
+		if err != nil {
+			return actionError{err}
+		}
+		if dirty || hsm.Reconciler.updateSensors(hsm.Provisioner, info) {
+			return actionUpdate{}
+		}
+	}
+
 	// ErrorCount is cleared when appropriate inside actionManageReady
 	actResult := hsm.Reconciler.actionManageReady(hsm.Provisioner, info)
 	if _, update := actResult.(actionUpdate); update {
@@ -419,7 +490,8 @@ func (hsm *hostStateMachine) handleReady(info *reconcileInfo) actionResult {
 }
 
 func (hsm *hostStateMachine) provisioningCancelled() bool {
-	if hsm.Host.Status.ErrorMessage != "" {
+	// An unavailable conductor says nothing about the host itself
+	if hsm.Host.Status.ErrorMessage != "" && hsm.Host.Status.ErrorType != metal3v1alpha1.ConductorError {
 		return true
 	}
 	if hsm.Host.Spec.Image == nil {
@@ -439,8 +511,11 @@ func (hsm *hostStateMachine) provisioningCancelled() bool {
 
 func (hsm *hostStateMachine) handleProvisioning(info *reconcileInfo) actionResult {
 	if hsm.provisioningCancelled() {
-		hsm.NextState = metal3v1alpha1.StateDeprovisioning
-		return actionComplete{}
+		actResult := hsm.Reconciler.actionAbortDeploy(hsm.Provisioner, info)
+		if _, complete := actResult.(actionComplete); complete {
+			hsm.NextState = metal3v1alpha1.StateDeprovisioning
+		}
+		return actResult
 	}
 
 	actResult := hsm.Reconciler.actionProvisioning(hsm.Provisioner, info)
@@ -468,6 +543,12 @@ func (hsm *hostStateMachine) handleDeprovisioning(info *reconcileInfo) actionRes
 		if _, complete := actResult.(actionComplete); complete {
 			hsm.NextState = metal3v1alpha1.StateReady
 			hsm.Host.Status.ErrorCount = 0
+			// The disks are erased before the host is handed to
+			// another consumer
+			requestFullClean(hsm.Host)
+			if hsm.Host.Status.Provisioning.ErasePending {
+				hsm.NextState = metal3v1alpha1.StatePreparing
+			}
 		}
 	} else {
 		skipToDelete := func() actionResult {
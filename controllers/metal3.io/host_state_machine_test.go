@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -15,7 +16,9 @@ import (
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	promutil "github.com/prometheus/client_golang/prometheus/testutil"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -124,7 +127,7 @@ func TestProvisioningCapacity(t *testing.T) {
 		t.Run(tc.Scenario, func(t *testing.T) {
 			prov := newMockProvisioner()
 			prov.setHasCapacity(tc.HasProvisioningCapacity)
-			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 			info := makeDefaultReconcileInfo(tc.Host)
 			delayedProvisioningHostCounters.Reset()
 
@@ -178,7 +181,7 @@ func TestDeprovisioningCapacity(t *testing.T) {
 		t.Run(tc.Scenario, func(t *testing.T) {
 			prov := newMockProvisioner()
 			prov.setHasCapacity(tc.HasDeprovisioningCapacity)
-			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 			info := makeDefaultReconcileInfo(tc.Host)
 			delayedDeprovisioningHostCounters.Reset()
 
@@ -363,7 +366,7 @@ func TestDetach(t *testing.T) {
 				}
 			}
 			prov := newMockProvisioner()
-			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 			info := makeDefaultReconcileInfo(tc.Host)
 			result := hsm.ReconcileState(info)
 
@@ -405,7 +408,7 @@ func TestDetachError(t *testing.T) {
 				metal3v1alpha1.DetachedAnnotation: "true",
 			}
 			prov := newMockProvisioner()
-			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 			info := makeDefaultReconcileInfo(tc.Host)
 
 			prov.setNextError("Detach", "some error")
@@ -635,7 +638,7 @@ func TestErrorCountIncreasedOnActionFailure(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.Scenario, func(t *testing.T) {
 			prov := newMockProvisioner()
-			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 			info := makeDefaultReconcileInfo(tt.Host)
 
 			prov.setNextError(tt.ProvisionerErrorOn, "some error")
@@ -694,7 +697,7 @@ func TestErrorCountClearedOnStateTransition(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.Scenario, func(t *testing.T) {
 			prov := newMockProvisioner()
-			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 			info := makeDefaultReconcileInfo(tt.Host)
 
 			info.host.Status.ErrorCount = 1
@@ -731,7 +734,7 @@ func TestErrorClean(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.Scenario, func(t *testing.T) {
 			prov := newMockProvisioner()
-			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{}, prov, true)
+			hsm := newHostStateMachine(tt.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
 
 			info := makeDefaultReconcileInfo(tt.Host)
 			if tt.SecretName != "" {
@@ -878,9 +881,26 @@ func newMockProvisioner() *mockProvisioner {
 }
 
 type mockProvisioner struct {
-	hasCapacity  bool
-	nextResults  map[string]provisioner.Result
-	callsNoError map[string]bool
+	hasCapacity          bool
+	nextResults          map[string]provisioner.Result
+	callsNoError         map[string]bool
+	provID               string
+	hardwareState        provisioner.HardwareState
+	hardwareStateError   error
+	prepareData          provisioner.PrepareData
+	provisionData        provisioner.ProvisionData
+	managementAccessData provisioner.ManagementAccessData
+	imageCached          *bool
+	imageCacheQueries    int
+	imageCacheError      error
+	firmwareDiff         []metal3v1alpha1.BIOSSettingDiff
+	firmwarePending      map[string]string
+	hardwareDetails      *metal3v1alpha1.HardwareDetails
+	syncPowerOnline      *bool
+	syncPowerError       error
+	sensors              *metal3v1alpha1.SensorStatus
+	sensorsError         error
+	blockingReasons      []metal3v1alpha1.BlockingReason
 }
 
 func (m *mockProvisioner) getNextResultByMethod(name string) (result provisioner.Result) {
@@ -914,31 +934,57 @@ func (m *mockProvisioner) calledNoError(methodName string) bool {
 	return m.callsNoError[methodName]
 }
 
+func (m *mockProvisioner) CheckBMCAccess() (result provisioner.Result, err error) {
+	return m.getNextResultByMethod("CheckBMCAccess"), err
+}
+
 func (m *mockProvisioner) ValidateManagementAccess(data provisioner.ManagementAccessData, credentialsChanged, force bool) (result provisioner.Result, provID string, err error) {
-	return m.getNextResultByMethod("ValidateManagementAccess"), "", err
+	m.managementAccessData = data
+	return m.getNextResultByMethod("ValidateManagementAccess"), m.provID, err
 }
 
 func (m *mockProvisioner) InspectHardware(data provisioner.InspectData, force, refresh bool) (result provisioner.Result, details *metal3v1alpha1.HardwareDetails, err error) {
 	details = &metal3v1alpha1.HardwareDetails{}
+	if m.hardwareDetails != nil {
+		details = m.hardwareDetails
+	}
 	return m.getNextResultByMethod("InspectHardware"), details, err
 }
 
+func (m *mockProvisioner) setHardwareStateError(err error) {
+	m.hardwareStateError = err
+}
+
 func (m *mockProvisioner) UpdateHardwareState() (hwState provisioner.HardwareState, err error) {
-	return
+	return m.hardwareState, m.hardwareStateError
 }
 
 func (m *mockProvisioner) Prepare(data provisioner.PrepareData, unprepared bool) (result provisioner.Result, started bool, err error) {
+	m.prepareData = data
 	return m.getNextResultByMethod("Prepare"), m.nextResults["Prepare"].Dirty, err
 }
 
+func (m *mockProvisioner) CheckFirmwareSettings(config *metal3v1alpha1.FirmwareConfig) (pending map[string]string, err error) {
+	return m.firmwarePending, nil
+}
+
+func (m *mockProvisioner) DiffFirmwareSettings(config, previous *metal3v1alpha1.FirmwareConfig) (diff []metal3v1alpha1.BIOSSettingDiff, err error) {
+	return m.firmwareDiff, nil
+}
+
 func (m *mockProvisioner) Adopt(data provisioner.AdoptData, force bool) (result provisioner.Result, err error) {
 	return m.getNextResultByMethod("Adopt"), err
 }
 
 func (m *mockProvisioner) Provision(data provisioner.ProvisionData) (result provisioner.Result, err error) {
+	m.provisionData = data
 	return m.getNextResultByMethod("Provision"), err
 }
 
+func (m *mockProvisioner) AbortDeploy() (result provisioner.Result, err error) {
+	return m.getNextResultByMethod("AbortDeploy"), err
+}
+
 func (m *mockProvisioner) Deprovision(force bool) (result provisioner.Result, err error) {
 	return m.getNextResultByMethod("Deprovision"), err
 }
@@ -960,10 +1006,1154 @@ func (m *mockProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode) (result
 	return m.getNextResultByMethod("PowerOff"), err
 }
 
+func (m *mockProvisioner) SetBootDevice(device string, persistent bool) (result provisioner.Result, err error) {
+	return m.getNextResultByMethod("SetBootDevice"), err
+}
+
+func (m *mockProvisioner) ClearFault() (result provisioner.Result, err error) {
+	return m.getNextResultByMethod("ClearFault"), err
+}
+
+func (m *mockProvisioner) IsImageCached(image metal3v1alpha1.Image) (cached *bool, err error) {
+	m.imageCacheQueries++
+	return m.imageCached, m.imageCacheError
+}
+
+func (m *mockProvisioner) GetAvailableSteps() (steps provisioner.AvailableSteps, err error) {
+	return
+}
+
+func (m *mockProvisioner) ResetBMC() (result provisioner.Result, err error) {
+	return m.getNextResultByMethod("ResetBMC"), err
+}
+
+func (m *mockProvisioner) SyncPowerState(online bool) (result provisioner.Result, err error) {
+	m.syncPowerOnline = &online
+	return m.getNextResultByMethod("SyncPowerState"), m.syncPowerError
+}
+
+func (m *mockProvisioner) GetBlockingReasons() (reasons []metal3v1alpha1.BlockingReason, err error) {
+	return m.blockingReasons, nil
+}
+
+func (m *mockProvisioner) GetSensors() (sensors *metal3v1alpha1.SensorStatus, err error) {
+	m.callsNoError["GetSensors"] = true
+	return m.sensors, m.sensorsError
+}
+
 func (m *mockProvisioner) IsReady() (result bool, err error) {
 	return
 }
 
+func TestConductorDown(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.setHardwareStateError(fmt.Errorf("%w: conductor-0", provisioner.ErrConductorDown))
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, metal3v1alpha1.OperationalStatusError, host.Status.OperationalStatus)
+	assert.Equal(t, metal3v1alpha1.ConductorError, host.Status.ErrorType)
+	assert.Equal(t, "Conductor managing the host is down: conductor-0", host.Status.ErrorMessage)
+
+	prov.setHardwareStateError(nil)
+	result = hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, metal3v1alpha1.OperationalStatusOK, host.Status.OperationalStatus)
+	assert.Empty(t, host.Status.ErrorType)
+}
+
+func TestNodeReregistered(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	host.Status.Provisioning.ID = "deleted-uuid"
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	// The provisioner registered the host again under a new ID
+	prov.provID = "new-uuid"
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, "new-uuid", host.Status.Provisioning.ID)
+	assert.Equal(t, metal3v1alpha1.StateProvisioned, host.Status.Provisioning.State)
+	assert.Equal(t, metal3v1alpha1.OperationalStatusOK, host.Status.OperationalStatus)
+}
+
+func TestNodeHistory(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	events := []metal3v1alpha1.HistoryEvent{
+		{
+			Time:      metav1.Now(),
+			Severity:  "ERROR",
+			EventType: "power",
+			Event:     "power failure",
+		},
+	}
+	prov.hardwareState.History = events
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, events, host.Status.OperationHistory.Events)
+
+	// Without any change the status is not updated again
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+
+	// A nil history means that it could not be read
+	prov.hardwareState.History = nil
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+	assert.Equal(t, events, host.Status.OperationHistory.Events)
+}
+
+func TestSerialConsole(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	serialConsole := &metal3v1alpha1.SerialConsole{Host: "192.0.2.10", Port: 8023}
+	prov.hardwareState.SerialConsole = serialConsole
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, serialConsole, host.Status.SerialConsole)
+
+	// Without any change the status is not updated again
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+
+	// The details are kept when they cannot be read
+	prov.hardwareState.SerialConsole = nil
+	prov.hardwareState.SerialConsoleUnknown = true
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+	assert.Equal(t, serialConsole, host.Status.SerialConsole)
+
+	// The details are removed when the console is disabled
+	prov.hardwareState.SerialConsoleUnknown = false
+	result = hsm.ReconcileState(info)
+	assert.True(t, result.Dirty())
+	assert.Nil(t, host.Status.SerialConsole)
+}
+
+func TestAllocationStatus(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	allocation := &metal3v1alpha1.AllocationStatus{UUID: "0b1f5a3c-9d6e-4c7a-8f2b-3e4d5c6b7a89", Name: "worker-0", State: "active", Traits: []string{"CUSTOM_GPU"}}
+	prov.hardwareState.Allocation = allocation
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, allocation, host.Status.Allocation)
+
+	// The details are kept when they cannot be read
+	prov.hardwareState.Allocation = nil
+	prov.hardwareState.AllocationUnknown = true
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+	assert.Equal(t, allocation, host.Status.Allocation)
+
+	prov.hardwareState.AllocationUnknown = false
+	result = hsm.ReconcileState(info)
+	assert.True(t, result.Dirty())
+	assert.Nil(t, host.Status.Allocation)
+}
+
+func TestProvisioningCancelledAbortsDeploy(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).SetImageURL("").build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.nextResults["AbortDeploy"] = provisioner.Result{Dirty: true}
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateProvisioning, host.Status.Provisioning.State)
+
+	delete(prov.nextResults, "AbortDeploy")
+	hsm.ReconcileState(info)
+	assert.True(t, prov.callsNoError["AbortDeploy"])
+	assert.Equal(t, metal3v1alpha1.StateDeprovisioning, host.Status.Provisioning.State)
+}
+
+func TestSchedulingStatus(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	scheduling := &metal3v1alpha1.SchedulingStatus{ResourceClass: "baremetal", Traits: []string{"CUSTOM_GPU"}}
+	prov.hardwareState.Scheduling = scheduling
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, scheduling, host.Status.Scheduling)
+
+	prov.hardwareState.Scheduling = nil
+	result = hsm.ReconcileState(info)
+	assert.True(t, result.Dirty())
+	assert.Nil(t, host.Status.Scheduling)
+}
+
+func TestPersistentBootDeviceOnlyOnChange(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	host.Spec.BootDevice = "pxe"
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	result := hsm.ReconcileState(info)
+	assert.True(t, result.Dirty())
+	assert.True(t, prov.calledNoError("SetBootDevice"))
+	assert.Equal(t, &metal3v1alpha1.BootDeviceStatus{Device: "pxe", Persistent: true}, host.Status.BootDevice)
+
+	// The device is not set again until the field changes
+	prov = newMockProvisioner()
+	hsm = newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	hsm.ReconcileState(info)
+	assert.False(t, prov.calledNoError("SetBootDevice"))
+
+	host.Spec.BootDevice = "disk"
+	result = hsm.ReconcileState(info)
+	assert.True(t, result.Dirty())
+	assert.True(t, prov.calledNoError("SetBootDevice"))
+	assert.Equal(t, "disk", host.Status.BootDevice.Device)
+}
+
+func TestConductorStatus(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	conductor := &metal3v1alpha1.ConductorStatus{Name: "conductor-0", Group: "rack-1", Reservation: "conductor-0"}
+	prov.hardwareState.Conductor = conductor
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, conductor, host.Status.Conductor)
+
+	// The details are kept when they cannot be read
+	prov.hardwareState.Conductor = nil
+	prov.hardwareState.ConductorUnknown = true
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+	assert.Equal(t, conductor, host.Status.Conductor)
+
+	prov.hardwareState.ConductorUnknown = false
+	result = hsm.ReconcileState(info)
+	assert.True(t, result.Dirty())
+	assert.Nil(t, host.Status.Conductor)
+}
+
+func TestHardwareFaultStatus(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.hardwareState.HardwareFault = "PSU 1 failed"
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, "PSU 1 failed", host.Status.HardwareFault)
+	assert.Len(t, info.events, 1)
+	assert.Equal(t, "HardwareFault", info.events[0].Reason)
+
+	prov.hardwareState.HardwareFault = ""
+	result = hsm.ReconcileState(info)
+	assert.True(t, result.Dirty())
+	assert.Equal(t, "", host.Status.HardwareFault)
+	assert.Len(t, info.events, 1)
+}
+
+func TestLastInspectedStatus(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	lastInspected := metav1.NewTime(time.Date(2021, 5, 12, 10, 23, 45, 0, time.UTC))
+	prov.hardwareState.LastInspected = &lastInspected
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, &lastInspected, host.Status.LastInspected)
+
+	// The same time read back from the status is not an update
+	host.Status.LastInspected = &metav1.Time{Time: lastInspected.Local()}
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+
+	// The time is kept when it cannot be read
+	prov.hardwareState.LastInspected = nil
+	prov.hardwareState.TimestampsUnknown = true
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+	assert.NotNil(t, host.Status.LastInspected)
+}
+
+func TestNodeTimestampsStatus(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	created := metav1.NewTime(time.Date(2021, 5, 12, 10, 23, 45, 0, time.UTC))
+	prov.hardwareState.NodeCreatedAt = &created
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, &created, host.Status.NodeCreatedAt)
+
+	// The same time read back from the status is not an update
+	host.Status.NodeCreatedAt = &metav1.Time{Time: created.Local()}
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+
+	// The time is kept when it cannot be read
+	prov.hardwareState.NodeCreatedAt = nil
+	prov.hardwareState.TimestampsUnknown = true
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+	assert.NotNil(t, host.Status.NodeCreatedAt)
+}
+
+func TestBootInterfaceStatus(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioned).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.hardwareState.BootInterface = "redfish-virtual-media"
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, "redfish-virtual-media", host.Status.BootInterface)
+
+	result = hsm.ReconcileState(info)
+	assert.False(t, result.Dirty())
+
+	// A change of the boot interface of the node is reported
+	prov.hardwareState.BootInterface = "ipxe"
+	result = hsm.ReconcileState(info)
+	assert.True(t, result.Dirty())
+	assert.Equal(t, "ipxe", host.Status.BootInterface)
+}
+
+func TestCheckBMCAccess(t *testing.T) {
+	host := host(metal3v1alpha1.StateRegistering).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+	host.Status.GoodCredentials = metal3v1alpha1.CredentialsStatus{}
+
+	prov.setNextError("CheckBMCAccess", "BMC https://192.0.2.1 rejected the credentials")
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, metal3v1alpha1.RegistrationError, host.Status.ErrorType)
+	assert.Equal(t, "BMC https://192.0.2.1 rejected the credentials", host.Status.ErrorMessage)
+	assert.False(t, prov.calledNoError("ValidateManagementAccess"))
+
+	prov.clearNextError("CheckBMCAccess")
+	hsm.ReconcileState(info)
+
+	assert.True(t, prov.calledNoError("ValidateManagementAccess"))
+	assert.Equal(t, metal3v1alpha1.ErrorType(""), host.Status.ErrorType)
+	assert.True(t, host.Status.GoodCredentials.Match(*info.bmcCredsSecret))
+}
+
+func TestCheckBMCAccessSkippedWithGoodCredentials(t *testing.T) {
+	host := host(metal3v1alpha1.StateRegistering).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	hsm.ReconcileState(info)
+
+	assert.False(t, prov.calledNoError("CheckBMCAccess"))
+	assert.True(t, prov.calledNoError("ValidateManagementAccess"))
+}
+
+func TestDeployRetry(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).SetImageURL("imageSpecUrl").build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.nextResults["Provision"] = provisioner.Result{Dirty: true, Retried: true}
+	for i := 1; i <= maxDeployRetries; i++ {
+		result := hsm.ReconcileState(info)
+
+		assert.True(t, prov.provisionData.RetryRecoverableFailure)
+		assert.True(t, result.Dirty())
+		assert.Equal(t, i, host.Status.Provisioning.DeployRetries)
+		assert.Equal(t, metal3v1alpha1.StateProvisioning, host.Status.Provisioning.State)
+		assert.Equal(t, metal3v1alpha1.ErrorType(""), host.Status.ErrorType)
+	}
+
+	// Once no retries are left the failure is reported
+	prov.nextResults["Provision"] = provisioner.Result{ErrorMessage: "Image provisioning failed: timeout"}
+	hsm.ReconcileState(info)
+
+	assert.False(t, prov.provisionData.RetryRecoverableFailure)
+	assert.Equal(t, maxDeployRetries, host.Status.Provisioning.DeployRetries)
+	assert.Equal(t, metal3v1alpha1.ProvisioningError, host.Status.ErrorType)
+}
+
+func TestDeployNotRetried(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).SetImageURL("imageSpecUrl").build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.nextResults["Provision"] = provisioner.Result{ErrorMessage: "Image provisioning failed: no disk"}
+	hsm.ReconcileState(info)
+
+	assert.True(t, prov.provisionData.RetryRecoverableFailure)
+	assert.Equal(t, 0, host.Status.Provisioning.DeployRetries)
+	assert.Equal(t, metal3v1alpha1.ProvisioningError, host.Status.ErrorType)
+}
+
+func TestImageCacheRecorded(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).SetImageURL("imageSpecUrl").build()
+	prov := newMockProvisioner()
+	cached := true
+	prov.imageCached = &cached
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.nextResults["Provision"] = provisioner.Result{Dirty: true}
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, &metal3v1alpha1.ImageCacheStatus{URL: "imageSpecUrl", Cached: &cached},
+		host.Status.Provisioning.ImageCache)
+
+	// The cache is only queried once for each image
+	hsm.ReconcileState(info)
+	assert.Equal(t, 1, prov.imageCacheQueries)
+
+	host.Spec.Image.URL = "otherImageUrl"
+	hsm.ReconcileState(info)
+	assert.Equal(t, 2, prov.imageCacheQueries)
+	assert.Equal(t, "otherImageUrl", host.Status.Provisioning.ImageCache.URL)
+}
+
+func TestImageCacheErrorIgnored(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).SetImageURL("imageSpecUrl").build()
+	prov := newMockProvisioner()
+	prov.imageCacheError = fmt.Errorf("cache unavailable")
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	hsm.ReconcileState(info)
+
+	assert.True(t, prov.calledNoError("Provision"))
+	assert.Nil(t, host.Status.Provisioning.ImageCache)
+}
+
+func TestDeployRetryLimit(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).SetImageURL("imageSpecUrl").build()
+	retries := 1
+	host.Spec.StepRetries = &metal3v1alpha1.StepRetryLimits{Deploy: &retries}
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.nextResults["Provision"] = provisioner.Result{Dirty: true, Retried: true}
+	hsm.ReconcileState(info)
+	assert.True(t, prov.provisionData.RetryRecoverableFailure)
+	assert.Equal(t, 1, host.Status.Provisioning.DeployRetries)
+
+	prov.nextResults["Provision"] = provisioner.Result{Dirty: true}
+	hsm.ReconcileState(info)
+	assert.False(t, prov.provisionData.RetryRecoverableFailure)
+}
+
+func TestDeployTimeouts(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).SetImageURL("imageSpecUrl").build()
+	host.Spec.DeployTimeouts = &metal3v1alpha1.DeployTimeouts{NetworkBootSeconds: 900}
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	hsm.ReconcileState(info)
+	assert.Equal(t, 15*time.Minute, prov.provisionData.NetworkBootTimeout)
+	assert.Zero(t, prov.provisionData.DeployTimeout)
+	assert.Equal(t, host.Status.OperationHistory.Provision.Start.Time, prov.provisionData.ProvisionStarted)
+}
+
+func TestFirmwareSettingsDiff(t *testing.T) {
+	host := host(metal3v1alpha1.StateReady).SaveHostProvisioningSettings().build()
+	host.Spec.Image = nil
+	host.Spec.Firmware = &metal3v1alpha1.FirmwareConfig{
+		Settings: map[string]string{"ProcVirtualization": "Disabled"},
+	}
+	host.Generation = 1
+	prov := newMockProvisioner()
+	changed := []metal3v1alpha1.BIOSSettingDiff{
+		{Name: "ProcVirtualization", Change: metal3v1alpha1.BIOSSettingChanged, Requested: "Disabled", Current: "Enabled"},
+	}
+	prov.firmwareDiff = changed
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	result := hsm.ReconcileState(info)
+	assert.True(t, result.Dirty())
+	assert.Equal(t, metal3v1alpha1.StateReady, host.Status.Provisioning.State)
+	assert.Equal(t, changed, host.Status.FirmwareSettingsDiff)
+
+	// The settings are not compared again until the spec changes
+	prov.firmwareDiff = nil
+	hsm.ReconcileState(info)
+	assert.Equal(t, changed, host.Status.FirmwareSettingsDiff)
+
+	host.Generation = 2
+	hsm.ReconcileState(info)
+	assert.Empty(t, host.Status.FirmwareSettingsDiff)
+	prov.firmwareDiff = changed
+	hsm.ReconcileState(info)
+	assert.Empty(t, host.Status.FirmwareSettingsDiff)
+
+	// The difference is gone once the configuration is saved
+	host.Status.Provisioning.Firmware = host.Spec.Firmware.DeepCopy()
+	hsm.ReconcileState(info)
+	assert.Empty(t, host.Status.FirmwareSettingsDiff)
+}
+
+func TestBlockingReasons(t *testing.T) {
+	inspecting := []metal3v1alpha1.BlockingReason{
+		{Type: metal3v1alpha1.BlockingReasonInspection, Message: "the node is in the inspect wait state"},
+	}
+	unreachable := []metal3v1alpha1.BlockingReason{
+		{Type: metal3v1alpha1.BlockingReasonCleaning, Message: "the node is in the clean wait state"},
+		{Type: metal3v1alpha1.BlockingReasonBMCUnreachable, Message: "power state unknown"},
+	}
+
+	testCases := []struct {
+		Scenario        string
+		State           metal3v1alpha1.ProvisioningState
+		Current         []metal3v1alpha1.BlockingReason
+		Reported        []metal3v1alpha1.BlockingReason
+		ExpectedReasons []metal3v1alpha1.BlockingReason
+		ExpectedUpdate  bool
+	}{
+		{
+			Scenario:        "waiting for inspection",
+			State:           metal3v1alpha1.StateInspecting,
+			Reported:        inspecting,
+			ExpectedReasons: inspecting,
+			ExpectedUpdate:  true,
+		},
+		{
+			Scenario:        "cleaning with unreachable BMC",
+			State:           metal3v1alpha1.StatePreparing,
+			Reported:        unreachable,
+			ExpectedReasons: unreachable,
+			ExpectedUpdate:  true,
+		},
+		{
+			Scenario:        "unchanged",
+			State:           metal3v1alpha1.StateInspecting,
+			Current:         inspecting,
+			Reported:        inspecting,
+			ExpectedReasons: inspecting,
+		},
+		{
+			Scenario:       "no longer blocked",
+			State:          metal3v1alpha1.StateProvisioning,
+			Current:        inspecting,
+			ExpectedUpdate: true,
+		},
+		{
+			Scenario:       "steady state",
+			State:          metal3v1alpha1.StateReady,
+			Current:        inspecting,
+			Reported:       inspecting,
+			ExpectedUpdate: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(tc.State).build()
+			host.Status.BlockingReasons = tc.Current
+			prov := newMockProvisioner()
+			prov.blockingReasons = tc.Reported
+			hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+			info := makeDefaultReconcileInfo(host)
+
+			result := hsm.updateBlockingReasons(info)
+
+			if tc.ExpectedUpdate {
+				assert.Equal(t, actionUpdate{}, result)
+			} else {
+				assert.Nil(t, result)
+			}
+			assert.Equal(t, tc.ExpectedReasons, host.Status.BlockingReasons)
+		})
+	}
+}
+
+func TestUpdateSensors(t *testing.T) {
+	reading := 98
+	hot := &metal3v1alpha1.SensorStatus{
+		Temperatures: []metal3v1alpha1.TemperatureReading{
+			{Name: "CPU1 Temp", ReadingCelsius: &reading, OutOfRange: true},
+		},
+	}
+	recently := metav1.NewTime(time.Now().Add(-time.Minute))
+	longAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	testCases := []struct {
+		Scenario       string
+		Current        *metal3v1alpha1.SensorStatus
+		Sensors        *metal3v1alpha1.SensorStatus
+		Error          error
+		ExpectedDirty  bool
+		ExpectedCalled bool
+		ExpectedEvent  bool
+	}{
+		{
+			Scenario:       "first reading",
+			Sensors:        hot,
+			ExpectedDirty:  true,
+			ExpectedCalled: true,
+			ExpectedEvent:  true,
+		},
+		{
+			Scenario: "read recently",
+			Current:  &metal3v1alpha1.SensorStatus{LastUpdated: &recently},
+			Sensors:  hot,
+		},
+		{
+			Scenario:       "still out of range",
+			Current:        &metal3v1alpha1.SensorStatus{Temperatures: hot.Temperatures, LastUpdated: &longAgo},
+			Sensors:        hot,
+			ExpectedDirty:  true,
+			ExpectedCalled: true,
+		},
+		{
+			Scenario:       "not reported",
+			ExpectedCalled: true,
+		},
+		{
+			Scenario:       "no longer reported",
+			Current:        &metal3v1alpha1.SensorStatus{LastUpdated: &longAgo},
+			ExpectedDirty:  true,
+			ExpectedCalled: true,
+		},
+		{
+			Scenario:       "read failed",
+			Current:        &metal3v1alpha1.SensorStatus{Temperatures: hot.Temperatures, LastUpdated: &longAgo},
+			Error:          fmt.Errorf("BMC unreachable"),
+			ExpectedDirty:  true,
+			ExpectedCalled: true,
+		},
+		{
+			Scenario: "read failed recently",
+			Current:  &metal3v1alpha1.SensorStatus{LastUpdated: &longAgo, LastAttempted: &recently},
+			Error:    fmt.Errorf("BMC unreachable"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateProvisioned).build()
+			host.Status.Sensors = tc.Current.DeepCopy()
+			prov := newMockProvisioner()
+			prov.sensors = tc.Sensors.DeepCopy()
+			prov.sensorsError = tc.Error
+			r := &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}
+			info := makeDefaultReconcileInfo(host)
+
+			dirty := r.updateSensors(prov, info)
+
+			assert.Equal(t, tc.ExpectedDirty, dirty)
+			assert.Equal(t, tc.ExpectedCalled, prov.calledNoError("GetSensors"))
+			if tc.ExpectedCalled {
+				switch {
+				case tc.Error != nil:
+					// The previous readings are kept until the next attempt
+					assert.Equal(t, tc.Current.Temperatures, host.Status.Sensors.Temperatures)
+					assert.Equal(t, tc.Current.LastUpdated, host.Status.Sensors.LastUpdated)
+					assert.NotNil(t, host.Status.Sensors.LastAttempted)
+				case tc.Sensors == nil:
+					assert.Nil(t, host.Status.Sensors)
+				default:
+					assert.Equal(t, tc.Sensors.Temperatures, host.Status.Sensors.Temperatures)
+					assert.NotNil(t, host.Status.Sensors.LastUpdated)
+				}
+			}
+			if tc.ExpectedEvent {
+				assert.Len(t, info.events, 1)
+				assert.Equal(t, "SensorOutOfRange", info.events[0].Reason)
+			} else {
+				assert.Empty(t, info.events)
+			}
+		})
+	}
+}
+
+func TestInspectionMinimumNICs(t *testing.T) {
+	details := &metal3v1alpha1.HardwareDetails{
+		NIC: []metal3v1alpha1.NIC{
+			{Name: "eth0", IP: "192.0.2.10"},
+			{Name: "eth0", IP: "2001:db8::10"},
+			{Name: "eth1"},
+		},
+	}
+	cases := []struct {
+		name          string
+		minimumNICs   int
+		expectedState metal3v1alpha1.ProvisioningState
+		expectedError string
+	}{
+		{
+			name:          "below threshold",
+			minimumNICs:   3,
+			expectedState: metal3v1alpha1.StateInspecting,
+			expectedError: "incomplete inspection: found 2 NICs, expected at least 3 (minimumNICs)",
+		},
+		{
+			name:          "threshold met",
+			minimumNICs:   2,
+			expectedState: metal3v1alpha1.StateMatchProfile,
+		},
+		{
+			name:          "not set",
+			expectedState: metal3v1alpha1.StateMatchProfile,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateInspecting).build()
+			host.Spec.MinimumNICs = tc.minimumNICs
+			prov := newMockProvisioner()
+			prov.hardwareDetails = details
+			hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+			info := makeDefaultReconcileInfo(host)
+
+			result := hsm.ReconcileState(info)
+			assert.True(t, result.Dirty())
+			assert.Equal(t, tc.expectedState, host.Status.Provisioning.State)
+			assert.Equal(t, tc.expectedError, host.Status.ErrorMessage)
+			assert.Equal(t, details, host.Status.HardwareDetails)
+			if tc.expectedError != "" {
+				assert.Equal(t, metal3v1alpha1.InspectionError, host.Status.ErrorType)
+			}
+		})
+	}
+}
+
+func TestInspectionBootMACAddress(t *testing.T) {
+	details := &metal3v1alpha1.HardwareDetails{
+		NIC: []metal3v1alpha1.NIC{
+			{Name: "eth0", MAC: "52:54:00:aa:bb:01", IP: "192.0.2.10"},
+			{Name: "eth0", MAC: "52:54:00:aa:bb:01", IP: "2001:db8::10"},
+			{Name: "eth1", MAC: "52:54:00:aa:bb:02"},
+		},
+	}
+	cases := []struct {
+		name           string
+		bootMACAddress string
+		details        *metal3v1alpha1.HardwareDetails
+		expectedState  metal3v1alpha1.ProvisioningState
+		expectedError  string
+	}{
+		{
+			name:           "inspected",
+			bootMACAddress: "52:54:00:AA:BB:02",
+			details:        details,
+			expectedState:  metal3v1alpha1.StateMatchProfile,
+		},
+		{
+			name:           "not inspected",
+			bootMACAddress: "52:54:00:aa:bb:03",
+			details:        details,
+			expectedState:  metal3v1alpha1.StateInspecting,
+			expectedError:  "the boot MAC address 52:54:00:aa:bb:03 does not belong to any of the inspected NICs (52:54:00:aa:bb:01, 52:54:00:aa:bb:02)",
+		},
+		{
+			name:          "no boot MAC address",
+			details:       details,
+			expectedState: metal3v1alpha1.StateMatchProfile,
+		},
+		{
+			name:           "no NICs reported",
+			bootMACAddress: "52:54:00:aa:bb:03",
+			details:        &metal3v1alpha1.HardwareDetails{},
+			expectedState:  metal3v1alpha1.StateMatchProfile,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateInspecting).build()
+			host.Spec.BootMACAddress = tc.bootMACAddress
+			prov := newMockProvisioner()
+			prov.hardwareDetails = tc.details
+			hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+			info := makeDefaultReconcileInfo(host)
+
+			result := hsm.ReconcileState(info)
+			assert.True(t, result.Dirty())
+			assert.Equal(t, tc.expectedState, host.Status.Provisioning.State)
+			assert.Equal(t, tc.expectedError, host.Status.ErrorMessage)
+			if tc.expectedError != "" {
+				assert.Equal(t, metal3v1alpha1.InspectionError, host.Status.ErrorType)
+			}
+		})
+	}
+}
+
+func TestCleanRetry(t *testing.T) {
+	host := host(metal3v1alpha1.StatePreparing).build()
+	retries := 1
+	host.Spec.StepRetries = &metal3v1alpha1.StepRetryLimits{Clean: &retries}
+	host.Spec.RAID = &metal3v1alpha1.RAIDConfig{
+		SoftwareRAIDVolumes: []metal3v1alpha1.SoftwareRAIDVolume{{Level: "1"}},
+	}
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	// The cleaning fails once because of a transient problem
+	prov.nextResults["Prepare"] = provisioner.Result{Dirty: true, Retried: true}
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, prov.prepareData.RetryRecoverableFailure)
+	assert.True(t, result.Dirty())
+	assert.Equal(t, 1, host.Status.Provisioning.CleanRetries)
+	assert.Nil(t, host.Status.Provisioning.RAID)
+	assert.Equal(t, metal3v1alpha1.StatePreparing, host.Status.Provisioning.State)
+	assert.Equal(t, metal3v1alpha1.ErrorType(""), host.Status.ErrorType)
+	assert.Len(t, info.events, 1)
+	assert.Equal(t, "PreparationRetried", info.events[0].Reason)
+
+	// The settings are applied again and the cleaning succeeds
+	prov.nextResults["Prepare"] = provisioner.Result{}
+	hsm.ReconcileState(info)
+
+	assert.False(t, prov.prepareData.RetryRecoverableFailure)
+	assert.NotNil(t, host.Status.Provisioning.RAID)
+	assert.Equal(t, 0, host.Status.Provisioning.CleanRetries)
+	assert.NotEqual(t, metal3v1alpha1.StatePreparing, host.Status.Provisioning.State)
+	assert.Equal(t, metal3v1alpha1.ErrorType(""), host.Status.ErrorType)
+}
+
+func TestCleanNotRetried(t *testing.T) {
+	host := host(metal3v1alpha1.StatePreparing).build()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.nextResults["Prepare"] = provisioner.Result{ErrorMessage: "Clean step failed: timeout"}
+	hsm.ReconcileState(info)
+
+	assert.False(t, prov.prepareData.RetryRecoverableFailure)
+	assert.Equal(t, metal3v1alpha1.PreparationError, host.Status.ErrorType)
+}
+
+func TestCleaningSkippedBeforeFirstDeploy(t *testing.T) {
+	host := host(metal3v1alpha1.StateProvisioning).SetImageURL("imageSpecUrl").build()
+	host.Spec.AutomatedCleaningMode = metal3v1alpha1.CleaningModeFull
+	host.Spec.SkipInitialCleaning = true
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	prov.nextResults["Provision"] = provisioner.Result{Dirty: true}
+	hsm.ReconcileState(info)
+
+	assert.Equal(t, metal3v1alpha1.CleaningModeDisabled, prov.managementAccessData.AutomatedCleaningMode)
+	assert.False(t, host.Status.Provisioning.InitialDeployComplete)
+
+	prov.nextResults["Provision"] = provisioner.Result{}
+	hsm.ReconcileState(info)
+
+	assert.Equal(t, metal3v1alpha1.StateProvisioned, host.Status.Provisioning.State)
+	assert.True(t, host.Status.Provisioning.InitialDeployComplete)
+
+	hsm.ReconcileState(info)
+
+	assert.Equal(t, metal3v1alpha1.CleaningModeFull, prov.managementAccessData.AutomatedCleaningMode)
+}
+
+func TestFullCleaningAfterDeprovisioning(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Mode     metal3v1alpha1.AutomatedCleaningMode
+
+		ExpectedState metal3v1alpha1.ProvisioningState
+	}{
+		{
+			Scenario:      "full",
+			Mode:          metal3v1alpha1.CleaningModeFull,
+			ExpectedState: metal3v1alpha1.StatePreparing,
+		},
+		{
+			Scenario:      "metadata",
+			Mode:          metal3v1alpha1.CleaningModeMetadata,
+			ExpectedState: metal3v1alpha1.StateReady,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateDeprovisioning).build()
+			host.Spec.AutomatedCleaningMode = tc.Mode
+			host.Status.Provisioning.InitialDeployComplete = true
+			prov := newMockProvisioner()
+			hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+			info := makeDefaultReconcileInfo(host)
+
+			hsm.ReconcileState(info)
+
+			assert.Equal(t, tc.ExpectedState, host.Status.Provisioning.State)
+			assert.Equal(t, tc.Mode == metal3v1alpha1.CleaningModeFull, host.Status.Provisioning.ErasePending)
+			if tc.ExpectedState != metal3v1alpha1.StatePreparing {
+				return
+			}
+
+			prov.nextResults["Prepare"] = provisioner.Result{Dirty: true}
+			hsm.ReconcileState(info)
+
+			assert.True(t, prov.prepareData.EraseDevices)
+			assert.Equal(t, metal3v1alpha1.StatePreparing, host.Status.Provisioning.State)
+
+			prov.nextResults["Prepare"] = provisioner.Result{}
+			hsm.ReconcileState(info)
+
+			assert.Equal(t, metal3v1alpha1.StateReady, host.Status.Provisioning.State)
+			assert.False(t, host.Status.Provisioning.ErasePending)
+		})
+	}
+}
+
+// TestRAIDOnlyChangeDoesNotErase tests that a host prepared again for
+// a change of its RAID settings only has them applied, without erasing
+// its disks, even with the full cleaning mode
+func TestRAIDOnlyChangeDoesNotErase(t *testing.T) {
+	host := host(metal3v1alpha1.StateReady).SetImageURL("imageSpecUrl").build()
+	host.Spec.AutomatedCleaningMode = metal3v1alpha1.CleaningModeFull
+	host.Status.Provisioning.InitialDeployComplete = true
+	if _, err := saveHostProvisioningSettings(host); err != nil {
+		t.Fatal(err)
+	}
+	host.Spec.RAID = &metal3v1alpha1.RAIDConfig{
+		HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{{Name: "root", Level: "1"}},
+	}
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StatePreparing, host.Status.Provisioning.State)
+
+	prov.nextResults["Prepare"] = provisioner.Result{Dirty: true}
+	hsm.ReconcileState(info)
+	assert.False(t, prov.prepareData.EraseDevices)
+	assert.Equal(t, host.Spec.RAID, prov.prepareData.RAIDConfig)
+
+	prov.nextResults["Prepare"] = provisioner.Result{}
+	hsm.ReconcileState(info)
+	assert.Equal(t, metal3v1alpha1.StateReady, host.Status.Provisioning.State)
+	assert.False(t, host.Status.Provisioning.ErasePending)
+}
+
+func TestFirmwareConverged(t *testing.T) {
+	converged, notConverged := true, false
+	testCases := []struct {
+		Scenario string
+		Firmware *metal3v1alpha1.FirmwareConfig
+		Pending  map[string]string
+
+		Expected *bool
+	}{
+		{
+			Scenario: "no firmware settings",
+		},
+		{
+			Scenario: "no BIOS settings",
+			Firmware: &metal3v1alpha1.FirmwareConfig{},
+		},
+		{
+			Scenario: "applied",
+			Firmware: &metal3v1alpha1.FirmwareConfig{Settings: map[string]string{"ProcVirtualization": "Enabled"}},
+			Expected: &converged,
+		},
+		{
+			Scenario: "not applied",
+			Firmware: &metal3v1alpha1.FirmwareConfig{Settings: map[string]string{"ProcVirtualization": "Enabled"}},
+			Pending:  map[string]string{"ProcVirtualization": "Enabled"},
+			Expected: &notConverged,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StatePreparing).build()
+			host.Spec.Firmware = tc.Firmware
+			prov := newMockProvisioner()
+			prov.firmwarePending = tc.Pending
+			hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+			info := makeDefaultReconcileInfo(host)
+
+			hsm.ReconcileState(info)
+			assert.Equal(t, metal3v1alpha1.StateReady, host.Status.Provisioning.State)
+			assert.Equal(t, tc.Expected, host.Status.FirmwareConverged)
+		})
+	}
+}
+
+func TestFullCleaningAfterInspection(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Mode     metal3v1alpha1.AutomatedCleaningMode
+		Skip     bool
+
+		ExpectedErase bool
+	}{
+		{
+			Scenario:      "full",
+			Mode:          metal3v1alpha1.CleaningModeFull,
+			ExpectedErase: true,
+		},
+		{
+			Scenario: "full skipping initial cleaning",
+			Mode:     metal3v1alpha1.CleaningModeFull,
+			Skip:     true,
+		},
+		{
+			Scenario: "metadata",
+			Mode:     metal3v1alpha1.CleaningModeMetadata,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateMatchProfile).build()
+			host.Spec.AutomatedCleaningMode = tc.Mode
+			host.Spec.SkipInitialCleaning = tc.Skip
+			prov := newMockProvisioner()
+			hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+			info := makeDefaultReconcileInfo(host)
+
+			hsm.ReconcileState(info)
+			assert.Equal(t, metal3v1alpha1.StatePreparing, host.Status.Provisioning.State)
+
+			hsm.ReconcileState(info)
+			assert.Equal(t, tc.ExpectedErase, prov.prepareData.EraseDevices)
+			assert.Equal(t, metal3v1alpha1.StateReady, host.Status.Provisioning.State)
+			assert.False(t, host.Status.Provisioning.ErasePending)
+		})
+	}
+}
+
+func TestGetAutomatedCleaningMode(t *testing.T) {
+	testCases := []struct {
+		Scenario string
+		Mode     metal3v1alpha1.AutomatedCleaningMode
+		Skip     bool
+		State    metal3v1alpha1.ProvisioningState
+		Deployed bool
+		Image    string
+		Expected metal3v1alpha1.AutomatedCleaningMode
+	}{
+		{
+			Scenario: "metadata",
+			Mode:     metal3v1alpha1.CleaningModeMetadata,
+			State:    metal3v1alpha1.StateProvisioning,
+			Expected: metal3v1alpha1.CleaningModeMetadata,
+		},
+		{
+			Scenario: "first deploy without skipping",
+			Mode:     metal3v1alpha1.CleaningModeFull,
+			State:    metal3v1alpha1.StateProvisioning,
+			Expected: metal3v1alpha1.CleaningModeFull,
+		},
+		{
+			Scenario: "metadata after first deploy",
+			Mode:     metal3v1alpha1.CleaningModeMetadata,
+			Skip:     true,
+			State:    metal3v1alpha1.StateDeprovisioning,
+			Deployed: true,
+			Expected: metal3v1alpha1.CleaningModeMetadata,
+		},
+		{
+			Scenario: "first deploy",
+			Mode:     metal3v1alpha1.CleaningModeFull,
+			Skip:     true,
+			State:    metal3v1alpha1.StateProvisioning,
+			Expected: metal3v1alpha1.CleaningModeDisabled,
+		},
+		{
+			Scenario: "ready",
+			Mode:     metal3v1alpha1.CleaningModeFull,
+			Skip:     true,
+			State:    metal3v1alpha1.StateReady,
+			Expected: metal3v1alpha1.CleaningModeDisabled,
+		},
+		{
+			Scenario: "deprovisioning after first deploy",
+			Mode:     metal3v1alpha1.CleaningModeFull,
+			Skip:     true,
+			State:    metal3v1alpha1.StateDeprovisioning,
+			Deployed: true,
+			Expected: metal3v1alpha1.CleaningModeFull,
+		},
+		{
+			Scenario: "second deploy",
+			Mode:     metal3v1alpha1.CleaningModeFull,
+			Skip:     true,
+			State:    metal3v1alpha1.StateProvisioning,
+			Deployed: true,
+			Expected: metal3v1alpha1.CleaningModeFull,
+		},
+		{
+			Scenario: "deployed without status",
+			Mode:     metal3v1alpha1.CleaningModeFull,
+			Skip:     true,
+			State:    metal3v1alpha1.StateProvisioned,
+			Image:    "imageUrl",
+			Expected: metal3v1alpha1.CleaningModeFull,
+		},
+		{
+			Scenario: "externally provisioned",
+			Mode:     metal3v1alpha1.CleaningModeFull,
+			Skip:     true,
+			State:    metal3v1alpha1.StateExternallyProvisioned,
+			Expected: metal3v1alpha1.CleaningModeFull,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(tc.State).build()
+			host.Spec.AutomatedCleaningMode = tc.Mode
+			host.Spec.SkipInitialCleaning = tc.Skip
+			host.Status.Provisioning.InitialDeployComplete = tc.Deployed
+			host.Status.Provisioning.Image.URL = tc.Image
+
+			assert.Equal(t, tc.Expected, getAutomatedCleaningMode(host))
+		})
+	}
+}
+
 func TestUpdateBootModeStatus(t *testing.T) {
 	testCases := []struct {
 		Scenario       string
@@ -1041,3 +2231,231 @@ func TestUpdateBootModeStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestSyncPowerState(t *testing.T) {
+	testCases := []struct {
+		Scenario           string
+		Result             *provisioner.Result
+		Error              error
+		ExpectedAnnotation bool
+		ExpectedEvent      string
+		ExpectedAction     actionResult
+	}{
+		{
+			Scenario:       "requested",
+			ExpectedAction: actionContinue{},
+		},
+		{
+			Scenario:           "in progress",
+			Result:             &provisioner.Result{Dirty: true, RequeueAfter: time.Second},
+			ExpectedAnnotation: true,
+			ExpectedAction:     actionContinue{time.Second},
+		},
+		{
+			Scenario:       "rejected",
+			Result:         &provisioner.Result{ErrorMessage: "not supported"},
+			ExpectedEvent:  "PowerSyncRejected",
+			ExpectedAction: actionContinue{},
+		},
+		{
+			Scenario:       "failed",
+			Error:          fmt.Errorf("ironic is down"),
+			ExpectedEvent:  "PowerSyncFailed",
+			ExpectedAction: actionContinue{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateProvisioned).build()
+			host.Name = "myhost"
+			host.Namespace = "myns"
+			host.Annotations = map[string]string{syncPowerAnnotation: ""}
+			host.Status.PoweredOn = true
+			r := &BareMetalHostReconciler{Client: fakeclient.NewFakeClient(host)}
+			prov := newMockProvisioner()
+			if tc.Result != nil {
+				prov.nextResults["SyncPowerState"] = *tc.Result
+			}
+			prov.syncPowerError = tc.Error
+			info := makeDefaultReconcileInfo(host)
+
+			result := r.syncPowerState(prov, info, true)
+
+			assert.Equal(t, tc.ExpectedAction, result)
+			assert.True(t, host.Status.PoweredOn)
+			if assert.NotNil(t, prov.syncPowerOnline) {
+				assert.True(t, *prov.syncPowerOnline)
+			}
+			_, present := host.Annotations[syncPowerAnnotation]
+			assert.Equal(t, tc.ExpectedAnnotation, present)
+			if tc.ExpectedEvent == "" {
+				assert.Empty(t, info.events)
+			} else {
+				assert.Len(t, info.events, 1)
+				assert.Equal(t, tc.ExpectedEvent, info.events[0].Reason)
+			}
+		})
+	}
+}
+
+func TestPowerOnDelayAfterRegistration(t *testing.T) {
+	delay := 60
+	host := host(metal3v1alpha1.StateRegistering).build()
+	host.Spec.BMC.PowerOnDelaySeconds = &delay
+	host.Status.OperationHistory.Register.Start = metav1.Now()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	// The end of the registration is recorded and the host waits
+	result := hsm.ReconcileState(info)
+
+	assert.True(t, result.Dirty())
+	assert.Equal(t, metal3v1alpha1.StateRegistering, host.Status.Provisioning.State)
+	assert.False(t, host.Status.OperationHistory.Register.End.IsZero())
+	res, _ := result.Result()
+	assert.True(t, res.RequeueAfter > 0 && res.RequeueAfter <= time.Minute, res.RequeueAfter)
+
+	// The host moves on once the delay is over
+	host.Status.OperationHistory.Register.End = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	hsm.ReconcileState(info)
+
+	assert.Equal(t, metal3v1alpha1.StateInspecting, host.Status.Provisioning.State)
+}
+
+func TestPowerOnDelayPowerAction(t *testing.T) {
+	delay := 60
+	host := host(metal3v1alpha1.StateReady).SaveHostProvisioningSettings().build()
+	host.Spec.Image = nil
+	host.Spec.BMC.PowerOnDelaySeconds = &delay
+	host.Spec.Online = true
+	host.Status.PoweredOn = false
+	host.Status.OperationHistory.Register.Start = metav1.NewTime(time.Now().Add(-time.Minute))
+	host.Status.OperationHistory.Register.End = metav1.Now()
+	prov := newMockProvisioner()
+	hsm := newHostStateMachine(host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+	info := makeDefaultReconcileInfo(host)
+
+	result := hsm.ReconcileState(info)
+
+	assert.False(t, prov.calledNoError("PowerOn"))
+	res, _ := result.Result()
+	assert.True(t, res.RequeueAfter > 0 && res.RequeueAfter <= time.Minute, res.RequeueAfter)
+
+	// The host is powered on once the delay is over
+	host.Status.OperationHistory.Register.End = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	hsm.ReconcileState(info)
+
+	assert.True(t, prov.calledNoError("PowerOn"))
+}
+
+func TestPowerOnDelayRemaining(t *testing.T) {
+	now := time.Date(2021, 5, 12, 10, 0, 0, 0, time.UTC)
+	delay := 60
+
+	testCases := []struct {
+		Scenario   string
+		Delay      *int
+		Registered time.Time
+		Expected   time.Duration
+	}{
+		{
+			Scenario:   "no delay",
+			Registered: now,
+		},
+		{
+			Scenario: "not registered yet",
+			Delay:    &delay,
+		},
+		{
+			Scenario:   "just registered",
+			Delay:      &delay,
+			Registered: now.Add(-10 * time.Second),
+			Expected:   50 * time.Second,
+		},
+		{
+			Scenario:   "delay over",
+			Delay:      &delay,
+			Registered: now.Add(-2 * time.Minute),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			host := host(metal3v1alpha1.StateReady).build()
+			host.Spec.BMC.PowerOnDelaySeconds = tc.Delay
+			if !tc.Registered.IsZero() {
+				host.Status.OperationHistory.Register.End = metav1.NewTime(tc.Registered)
+			}
+			assert.Equal(t, tc.Expected, powerOnDelayRemaining(host, now))
+		})
+	}
+}
+
+func TestOperationDurationRecorded(t *testing.T) {
+	testCases := []struct {
+		Scenario      string
+		Host          *metal3v1alpha1.BareMetalHost
+		ExpectedState metal3v1alpha1.ProvisioningState
+	}{
+		{
+			Scenario:      "registering",
+			Host:          host(metal3v1alpha1.StateRegistering).build(),
+			ExpectedState: metal3v1alpha1.StateInspecting,
+		},
+		{
+			Scenario:      "inspecting",
+			Host:          host(metal3v1alpha1.StateInspecting).build(),
+			ExpectedState: metal3v1alpha1.StateMatchProfile,
+		},
+		{
+			Scenario: "provisioned",
+			Host: func() *metal3v1alpha1.BareMetalHost {
+				host := host(metal3v1alpha1.StateProvisioned).build()
+				host.Spec.Image = nil
+				return host
+			}(),
+			ExpectedState: metal3v1alpha1.StateDeprovisioning,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			state := tc.Host.Status.Provisioning.State
+			metric := tc.Host.OperationMetricForState(state)
+			metric.Start = metav1.NewTime(time.Now().Add(-time.Hour))
+			metric.End = metav1.Time{}
+			prov := newMockProvisioner()
+			prov.setHasCapacity(true)
+			hsm := newHostStateMachine(tc.Host, &BareMetalHostReconciler{Client: fakeclient.NewFakeClient()}, prov, true)
+			info := makeDefaultReconcileInfo(tc.Host)
+			stateTime[state].Reset()
+
+			hsm.ReconcileState(info)
+			assert.Equal(t, tc.ExpectedState, tc.Host.Status.Provisioning.State)
+			assert.False(t, tc.Host.OperationMetricForState(state).End.IsZero())
+
+			// The duration is only observed once the host is saved
+			assert.Equal(t, 0, promutil.CollectAndCount(stateTime[state]))
+			for _, cb := range info.postSaveCallbacks {
+				cb()
+			}
+			assert.Equal(t, 1, promutil.CollectAndCount(stateTime[state]))
+		})
+	}
+}
+
+func TestDeleteHostStateTimes(t *testing.T) {
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "myns", Name: "deleted"}}
+	other := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "myns", Name: "other"}}
+	metric := stateTime[metal3v1alpha1.StateInspecting]
+	metric.Reset()
+	metric.With(hostMetricLabels(request)).Observe(60)
+	metric.With(hostMetricLabels(other)).Observe(60)
+	stateTime[metal3v1alpha1.StateProvisioning].Reset()
+	stateTime[metal3v1alpha1.StateProvisioning].With(hostMetricLabels(request)).Observe(600)
+
+	deleteHostStateTimes(request)
+
+	assert.Equal(t, 1, promutil.CollectAndCount(metric))
+	assert.Equal(t, 0, promutil.CollectAndCount(stateTime[metal3v1alpha1.StateProvisioning]))
+}
@@ -72,6 +72,10 @@ var delayedDeprovisioningHostCounters = prometheus.NewCounterVec(prometheus.Coun
 
 var slowOperationBuckets = []float64{30, 90, 180, 360, 720, 1440}
 
+// provisionedBuckets range from an hour to several months, since hosts
+// may stay provisioned for a long time.
+var provisionedBuckets = prometheus.ExponentialBuckets(3600, 4, 8)
+
 var stateTime = map[metal3v1alpha1.ProvisioningState]*prometheus.HistogramVec{
 	metal3v1alpha1.StateRegistering: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name: "metal3_operation_register_duration_seconds",
@@ -87,6 +91,11 @@ var stateTime = map[metal3v1alpha1.ProvisioningState]*prometheus.HistogramVec{
 		Help:    "Length of time per hardware provision operation per host",
 		Buckets: slowOperationBuckets,
 	}, []string{labelHostNamespace, labelHostName}),
+	metal3v1alpha1.StateProvisioned: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "metal3_operation_provisioned_duration_seconds",
+		Help:    "Length of time per host staying provisioned",
+		Buckets: provisionedBuckets,
+	}, []string{labelHostNamespace, labelHostName}),
 	metal3v1alpha1.StateDeprovisioning: prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    "metal3_operation_deprovision_duration_seconds",
 		Help:    "Length of time per hardware deprovision operation per host",
@@ -149,6 +158,15 @@ func hostMetricLabels(request ctrl.Request) prometheus.Labels {
 	}
 }
 
+// deleteHostStateTimes drops the operation duration series of a deleted
+// host, so that the number of series stays bounded by the number of
+// hosts rather than growing with every host ever managed.
+func deleteHostStateTimes(request ctrl.Request) {
+	for _, collector := range stateTime {
+		collector.Delete(hostMetricLabels(request))
+	}
+}
+
 func stateChangeMetricLabels(prevState, newState metal3v1alpha1.ProvisioningState) prometheus.Labels {
 	return prometheus.Labels{
 		labelPrevState: string(prevState),
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+const (
+	shutdownConfirmedAnnotation = "shutdownconfirmed.metal3.io"
+	defaultShutdownHookTimeout  = time.Minute * 5
+	shutdownHookPollDelay       = time.Second * 10
+	shutdownHookRequestTimeout  = time.Second * 10
+)
+
+// shutdownHookClient sends the requests to the shutdown hooks, its TLS
+// settings are loaded by loadShutdownHookClient.
+var shutdownHookClient = &http.Client{Timeout: shutdownHookRequestTimeout}
+
+// shutdownHookCall is the outcome of a request to a shutdown hook
+type shutdownHookCall struct {
+	done bool
+	err  error
+}
+
+// shutdownHookCalls holds the requests to the shutdown hooks, which are
+// sent in the background so that a slow hook does not block the
+// reconcile. Like the other in-process state it is lost on restart,
+// the power off then only waits for the confirmation or the deadline.
+var shutdownHookCalls = struct {
+	sync.Mutex
+	calls map[types.NamespacedName]*shutdownHookCall
+}{calls: map[types.NamespacedName]*shutdownHookCall{}}
+
+// loadShutdownHookClient builds the client of the shutdown hooks with
+// the CA certificate and certificate validation settings from the
+// environment.
+func loadShutdownHookClient() error {
+	client, err := clients.HTTPClient(
+		clients.TLSConfig{
+			TrustedCAFile:      os.Getenv("SHUTDOWN_HOOK_CACERT_FILE"),
+			InsecureSkipVerify: strings.ToLower(os.Getenv("SHUTDOWN_HOOK_INSECURE")) == "true",
+		},
+		clients.ConnectionConfig{RequestTimeout: shutdownHookRequestTimeout})
+	if err != nil {
+		return errors.Wrap(err, "failed to configure the shutdown hook client")
+	}
+	shutdownHookClient = client
+	return nil
+}
+
+// startShutdownHook calls the shutdown hook of the host in the
+// background.
+func startShutdownHook(name types.NamespacedName, hookURL string) {
+	call := &shutdownHookCall{}
+	shutdownHookCalls.Lock()
+	shutdownHookCalls.calls[name] = call
+	shutdownHookCalls.Unlock()
+
+	go func() {
+		err := callShutdownHook(hookURL, name)
+		shutdownHookCalls.Lock()
+		defer shutdownHookCalls.Unlock()
+		call.done, call.err = true, err
+	}()
+}
+
+// shutdownHookFailed returns the error of the call to the shutdown hook
+// of the host, nil while it is running, when it succeeded or when there
+// is none.
+func shutdownHookFailed(name types.NamespacedName) error {
+	shutdownHookCalls.Lock()
+	defer shutdownHookCalls.Unlock()
+	if call, ok := shutdownHookCalls.calls[name]; ok && call.done {
+		return call.err
+	}
+	return nil
+}
+
+// forgetShutdownHook stops tracking the call to the shutdown hook of
+// the host.
+func forgetShutdownHook(name types.NamespacedName) {
+	shutdownHookCalls.Lock()
+	defer shutdownHookCalls.Unlock()
+	delete(shutdownHookCalls.calls, name)
+}
+
+// shutdownHookRequest is the body of the request sent to the shutdown
+// hook of a host
+type shutdownHookRequest struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// needsShutdownHook returns whether the operating system of the host
+// must be asked to shut down before powering it off.
+func needsShutdownHook(host *metal3v1alpha1.BareMetalHost, rebootMode metal3v1alpha1.RebootMode) bool {
+	provState := host.Status.Provisioning.State
+	isProvisioned := provState == metal3v1alpha1.StateProvisioned || provState == metal3v1alpha1.StateExternallyProvisioned
+	return host.Spec.ShutdownHook != nil && isProvisioned && rebootMode != metal3v1alpha1.RebootModeHard
+}
+
+// waitForShutdownHook calls the shutdown hook of the host and waits for
+// the shutdown to be confirmed or to time out. It returns nil once the
+// host can be powered off.
+func (r *BareMetalHostReconciler) waitForShutdownHook(info *reconcileInfo) actionResult {
+	hook := info.host.Spec.ShutdownHook
+	name := info.request.NamespacedName
+
+	deadline := info.host.Status.ShutdownDeadline
+	if deadline == nil {
+		timeout := defaultShutdownHookTimeout
+		if hook.TimeoutSeconds > 0 {
+			timeout = time.Second * time.Duration(hook.TimeoutSeconds)
+		}
+		startShutdownHook(name, hook.URL)
+		info.publishEvent("ShutdownRequested", "Graceful shutdown requested through the shutdown hook")
+		value := metav1.NewTime(time.Now().Add(timeout))
+		info.host.Status.ShutdownDeadline = &value
+		return actionUpdate{actionContinue{}}
+	}
+
+	if _, confirmed := info.host.Annotations[shutdownConfirmedAnnotation]; confirmed {
+		forgetShutdownHook(name)
+		return nil
+	}
+	if err := shutdownHookFailed(name); err != nil {
+		info.publishEvent("ShutdownHookFailed",
+			fmt.Sprintf("Could not call the shutdown hook, powering off: %s", err))
+		forgetShutdownHook(name)
+		return nil
+	}
+	if remaining := time.Until(deadline.Time); remaining > 0 {
+		info.log.Info("waiting for the shutdown to be confirmed", "remaining", remaining)
+		if remaining > shutdownHookPollDelay {
+			remaining = shutdownHookPollDelay
+		}
+		return actionContinue{remaining}
+	}
+	info.log.Info("shutdown was not confirmed in time, powering off")
+	forgetShutdownHook(name)
+	return nil
+}
+
+// clearShutdownHook forgets about a completed shutdown request, it
+// returns nil if there was none.
+func (r *BareMetalHostReconciler) clearShutdownHook(info *reconcileInfo) actionResult {
+	_, confirmed := info.host.Annotations[shutdownConfirmedAnnotation]
+	if info.host.Status.ShutdownDeadline == nil && !confirmed {
+		return nil
+	}
+
+	if confirmed {
+		delete(info.host.Annotations, shutdownConfirmedAnnotation)
+		if err := r.Update(context.TODO(), info.host); err != nil {
+			return actionError{errors.Wrap(err, "failed to remove shutdown confirmed annotation from host")}
+		}
+	}
+	forgetShutdownHook(info.request.NamespacedName)
+	info.host.Status.ShutdownDeadline = nil
+	return actionUpdate{}
+}
+
+// callShutdownHook asks the agent behind the hook to shut down the
+// operating system of the host.
+func callShutdownHook(hookURL string, name types.NamespacedName) error {
+	if parsed, err := url.Parse(hookURL); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("shutdown hook URL %q must be an http or https URL", hookURL)
+	}
+	body, err := json.Marshal(shutdownHookRequest{Name: name.Name, Namespace: name.Namespace})
+	if err != nil {
+		return err
+	}
+
+	response, err := shutdownHookClient.Post(hookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s returned %s", hookURL, response.Status)
+	}
+	return nil
+}
@@ -31,6 +31,7 @@ import (
 
 	metal3iov1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	metal3iocontroller "github.com/metal3-io/baremetal-operator/controllers/metal3.io"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/demo"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
@@ -123,6 +124,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	if deployImages := os.Getenv("HARDWARE_PROFILE_DEPLOY_IMAGES"); deployImages != "" {
+		if err = hardware.LoadDeployImages(deployImages); err != nil {
+			setupLog.Error(err, "unable to load the deploy images of the hardware profiles")
+			os.Exit(1)
+		}
+	}
+
 	var provisionerFactory provisioner.Factory
 	if runInTestMode {
 		ctrl.Log.Info("using test provisioner")
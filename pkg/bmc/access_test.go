@@ -683,6 +683,18 @@ func TestDriverInfo(t *testing.T) {
 			},
 		},
 
+		{
+			Scenario: "ipmi custom port",
+			input:    "ipmi://192.168.122.1:6234",
+			expects: map[string]interface{}{
+				"ipmi_port":      "6234",
+				"ipmi_password":  "",
+				"ipmi_username":  "",
+				"ipmi_address":   "192.168.122.1",
+				"ipmi_verify_ca": false,
+			},
+		},
+
 		{
 			Scenario: "idrac",
 			input:    "idrac://192.168.122.1",
@@ -1121,3 +1133,91 @@ func TestUnknownType(t *testing.T) {
 		t.Fatalf("unexpected parse success")
 	}
 }
+
+func TestInvalidIPMIPort(t *testing.T) {
+	for _, address := range []string{
+		"ipmi://192.168.122.1:0",
+		"ipmi://192.168.122.1:65536",
+		"libvirt://192.168.122.1:70000/",
+		"192.168.122.1:99999",
+	} {
+		t.Run(address, func(t *testing.T) {
+			acc, err := NewAccessDetails(address, false)
+			if err == nil || acc != nil {
+				t.Fatalf("unexpected parse success")
+			}
+		})
+	}
+}
+
+func TestRedfishSystemID(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario    string
+		input       string
+		expects     string
+		ExpectError bool
+	}{
+		{
+			Scenario: "blade system",
+			input:    "redfish://192.168.122.1/redfish/v1/Systems/Blade3",
+			expects:  "/redfish/v1/Systems/Blade3",
+		},
+		{
+			Scenario: "blade system trailing slash",
+			input:    "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/Blade3/",
+			expects:  "/redfish/v1/Systems/Blade3",
+		},
+		{
+			Scenario: "escaped system id",
+			input:    "redfish://192.168.122.1/redfish/v1/Systems/Chassis1%2FBlade2",
+			expects:  "/redfish/v1/Systems/Chassis1%2FBlade2",
+		},
+		{
+			Scenario: "idrac system",
+			input:    "idrac-virtualmedia://192.168.122.1/redfish/v1/Systems/System.Embedded.2",
+			expects:  "/redfish/v1/Systems/System.Embedded.2",
+		},
+		{
+			Scenario: "no path",
+			input:    "redfish://192.168.122.1",
+			expects:  "",
+		},
+		{
+			Scenario:    "systems collection",
+			input:       "redfish://192.168.122.1/redfish/v1/Systems",
+			ExpectError: true,
+		},
+		{
+			Scenario:    "systems collection trailing slash",
+			input:       "idrac-redfish://192.168.122.1/redfish/v1/Systems/",
+			ExpectError: true,
+		},
+		{
+			Scenario:    "system subresource",
+			input:       "redfish://192.168.122.1/redfish/v1/Systems/Blade3/Bios",
+			ExpectError: true,
+		},
+		{
+			Scenario:    "empty system id",
+			input:       "ilo5-virtualmedia://192.168.122.1/redfish/v1/Systems//Blade3",
+			ExpectError: true,
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			acc, err := NewAccessDetails(tc.input, false)
+			if tc.ExpectError {
+				if err == nil {
+					t.Fatal("Expected error, did not get one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected parse error: %v", err)
+			}
+			systemID := acc.DriverInfo(Credentials{})["redfish_system_id"]
+			if systemID != tc.expects {
+				t.Fatalf("expected system ID %q but got %q", tc.expects, systemID)
+			}
+		})
+	}
+}
@@ -10,10 +10,14 @@ func init() {
 }
 
 func newRedfishiDracVirtualMediaAccessDetails(parsedURL *url.URL, disableCertificateVerification bool) (AccessDetails, error) {
+	path, err := redfishSystemID(parsedURL)
+	if err != nil {
+		return nil, err
+	}
 	return &redfishiDracVirtualMediaAccessDetails{
 		bmcType:                        parsedURL.Scheme,
 		host:                           parsedURL.Host,
-		path:                           parsedURL.Path,
+		path:                           path,
 		disableCertificateVerification: disableCertificateVerification,
 	}, nil
 }
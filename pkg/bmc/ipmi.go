@@ -1,7 +1,9 @@
 package bmc
 
 import (
+	"fmt"
 	"net/url"
+	"strconv"
 )
 
 func init() {
@@ -10,6 +12,12 @@ func init() {
 }
 
 func newIPMIAccessDetails(parsedURL *url.URL, disableCertificateVerification bool) (AccessDetails, error) {
+	if port := parsedURL.Port(); port != "" {
+		if portNum, err := strconv.Atoi(port); err != nil || portNum < 1 || portNum > 65535 {
+			return nil, fmt.Errorf("invalid IPMI port %q in BMC address %s, expected a number between 1 and 65535",
+				port, parsedURL.Host)
+		}
+	}
 	return &ipmiAccessDetails{
 		bmcType:                        parsedURL.Scheme,
 		portNum:                        parsedURL.Port(),
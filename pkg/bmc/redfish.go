@@ -1,6 +1,7 @@
 package bmc
 
 import (
+	"fmt"
 	"net/url"
 	"strings"
 )
@@ -12,23 +13,57 @@ func init() {
 	RegisterFactory("idrac-redfish", newRedfishiDracAccessDetails, schemes)
 }
 
-func redfishDetails(parsedURL *url.URL, disableCertificateVerification bool) *redfishAccessDetails {
+// redfishSystemsCollection is the name of the path segment of the
+// collection of the systems of a Redfish service
+const redfishSystemsCollection = "Systems"
+
+// redfishSystemID returns the path of the system in the BMC address.
+// The path is kept escaped, so that system IDs with reserved
+// characters reach the BMC unchanged. Services managing several
+// systems, like chassis with multiple blades, need the path to target
+// a single member of the collection of systems.
+func redfishSystemID(parsedURL *url.URL) (string, error) {
+	path := parsedURL.EscapedPath()
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment != redfishSystemsCollection {
+			continue
+		}
+		if len(segments) != i+2 || segments[i+1] == "" {
+			return "", fmt.Errorf("the Redfish system path %s must point to a single system, for example /redfish/v1/%s/<id>",
+				path, redfishSystemsCollection)
+		}
+	}
+	return path, nil
+}
+
+func redfishDetails(parsedURL *url.URL, disableCertificateVerification bool) (*redfishAccessDetails, error) {
+	path, err := redfishSystemID(parsedURL)
+	if err != nil {
+		return nil, err
+	}
 	return &redfishAccessDetails{
 		bmcType:                        parsedURL.Scheme,
 		host:                           parsedURL.Host,
-		path:                           parsedURL.Path,
+		path:                           path,
 		disableCertificateVerification: disableCertificateVerification,
-	}
+	}, nil
 }
 
 func newRedfishAccessDetails(parsedURL *url.URL, disableCertificateVerification bool) (AccessDetails, error) {
-	return redfishDetails(parsedURL, disableCertificateVerification), nil
+	return redfishDetails(parsedURL, disableCertificateVerification)
 }
 
 func newRedfishiDracAccessDetails(parsedURL *url.URL, disableCertificateVerification bool) (AccessDetails, error) {
-	return &redfishiDracAccessDetails{
-		*redfishDetails(parsedURL, disableCertificateVerification),
-	}, nil
+	details, err := redfishDetails(parsedURL, disableCertificateVerification)
+	if err != nil {
+		return nil, err
+	}
+	return &redfishiDracAccessDetails{*details}, nil
 }
 
 type redfishAccessDetails struct {
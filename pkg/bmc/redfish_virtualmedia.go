@@ -11,10 +11,14 @@ func init() {
 }
 
 func newRedfishVirtualMediaAccessDetails(parsedURL *url.URL, disableCertificateVerification bool) (AccessDetails, error) {
+	path, err := redfishSystemID(parsedURL)
+	if err != nil {
+		return nil, err
+	}
 	return &redfishVirtualMediaAccessDetails{
 		bmcType:                        parsedURL.Scheme,
 		host:                           parsedURL.Host,
-		path:                           parsedURL.Path,
+		path:                           path,
 		disableCertificateVerification: disableCertificateVerification,
 	}, nil
 }
@@ -0,0 +1,59 @@
+package hardware
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DeployImages is the location of the deploy kernel and ramdisk of a
+// hardware profile
+type DeployImages struct {
+	KernelURL  string `json:"kernel"`
+	RamdiskURL string `json:"ramdisk"`
+}
+
+// SetDeployImages configures the deploy ramdisk of hardware profiles
+// from a YAML mapping of profile names to deploy images. Profiles not
+// listed use the default ramdisk. Nothing is changed if any of the
+// entries is invalid.
+func SetDeployImages(data []byte) error {
+	images := map[string]DeployImages{}
+	if err := yaml.UnmarshalStrict(data, &images); err != nil {
+		return fmt.Errorf("invalid deploy images: %w", err)
+	}
+
+	names := make([]string, 0, len(images))
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := profiles[name]; !ok {
+			return fmt.Errorf("no hardware profile named %q", name)
+		}
+		if images[name].KernelURL == "" || images[name].RamdiskURL == "" {
+			return fmt.Errorf("both the deploy kernel and ramdisk must be set for hardware profile %q", name)
+		}
+	}
+
+	for name, profile := range profiles {
+		profile.DeployKernelURL = images[name].KernelURL
+		profile.DeployRamdiskURL = images[name].RamdiskURL
+		profiles[name] = profile
+	}
+	return nil
+}
+
+// LoadDeployImages configures the deploy ramdisk of hardware profiles
+// from a file in the format accepted by SetDeployImages.
+func LoadDeployImages(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read deploy images: %w", err)
+	}
+	return SetDeployImages(data)
+}
@@ -0,0 +1,68 @@
+package hardware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDeployImages(t *testing.T) {
+	defer SetDeployImages([]byte("{}"))
+
+	err := SetDeployImages([]byte(`
+dell:
+  kernel: http://images.test/dell.kernel
+  ramdisk: http://images.test/dell.initramfs
+`))
+	assert.NoError(t, err)
+
+	profile, _ := GetProfile("dell")
+	assert.Equal(t, "http://images.test/dell.kernel", profile.DeployKernelURL)
+	assert.Equal(t, "http://images.test/dell.initramfs", profile.DeployRamdiskURL)
+	profile, _ = GetProfile("libvirt")
+	assert.Equal(t, "", profile.DeployKernelURL)
+	assert.Equal(t, "", profile.DeployRamdiskURL)
+
+	for _, tc := range []struct {
+		name          string
+		data          string
+		expectedError string
+	}{
+		{
+			name:          "missing ramdisk",
+			data:          "libvirt: {kernel: http://images.test/libvirt.kernel}",
+			expectedError: `both the deploy kernel and ramdisk must be set for hardware profile "libvirt"`,
+		},
+		{
+			name:          "missing kernel",
+			data:          "libvirt: {ramdisk: http://images.test/libvirt.initramfs}",
+			expectedError: `both the deploy kernel and ramdisk must be set for hardware profile "libvirt"`,
+		},
+		{
+			name:          "unknown profile",
+			data:          "hpe: {kernel: http://images.test/hpe.kernel, ramdisk: http://images.test/hpe.initramfs}",
+			expectedError: `no hardware profile named "hpe"`,
+		},
+		{
+			name:          "unknown field",
+			data:          "libvirt: {kernel: http://images.test/libvirt.kernel, initrd: http://images.test/libvirt.initramfs}",
+			expectedError: "invalid deploy images",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := SetDeployImages([]byte(tc.data))
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.expectedError)
+			}
+
+			// An invalid configuration changes nothing
+			profile, _ := GetProfile("dell")
+			assert.Equal(t, "http://images.test/dell.kernel", profile.DeployKernelURL)
+		})
+	}
+
+	assert.NoError(t, SetDeployImages([]byte("{}")))
+	profile, _ = GetProfile("dell")
+	assert.Equal(t, "", profile.DeployKernelURL)
+	assert.Equal(t, "", profile.DeployRamdiskURL)
+}
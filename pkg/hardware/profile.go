@@ -29,6 +29,12 @@ type Profile struct {
 
 	// CPUArch is the architecture of the CPU.
 	CPUArch string
+
+	// DeployKernelURL and DeployRamdiskURL hold the location of the
+	// deploy ramdisk booted by the hosts of the profile instead of the
+	// default one, if set.
+	DeployKernelURL  string
+	DeployRamdiskURL string
 }
 
 var profiles = make(map[string]Profile)
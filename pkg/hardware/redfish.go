@@ -0,0 +1,201 @@
+package hardware
+
+import (
+	"fmt"
+	"net"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// RedfishSystemType is the Redfish schema version the inventory
+// follows.
+const RedfishSystemType = "#ComputerSystem.v1_13_0.ComputerSystem"
+
+// RedfishSystem is the inventory of a host in the format of a Redfish
+// ComputerSystem resource. Unlike a resource returned by a BMC, the
+// collections are embedded instead of being linked to.
+type RedfishSystem struct {
+	ODataType          string                     `json:"@odata.type"`
+	ID                 string                     `json:"Id"`
+	Name               string                     `json:"Name"`
+	HostName           string                     `json:"HostName,omitempty"`
+	Manufacturer       string                     `json:"Manufacturer,omitempty"`
+	Model              string                     `json:"Model,omitempty"`
+	SerialNumber       string                     `json:"SerialNumber,omitempty"`
+	BiosVersion        string                     `json:"BiosVersion,omitempty"`
+	ProcessorSummary   RedfishProcessorSummary    `json:"ProcessorSummary"`
+	MemorySummary      RedfishMemorySummary       `json:"MemorySummary"`
+	Processors         []RedfishProcessor         `json:"Processors"`
+	Drives             []RedfishDrive             `json:"Drives"`
+	EthernetInterfaces []RedfishEthernetInterface `json:"EthernetInterfaces"`
+}
+
+// RedfishProcessorSummary summarizes the processors of the host
+type RedfishProcessorSummary struct {
+	LogicalProcessorCount int    `json:"LogicalProcessorCount"`
+	Model                 string `json:"Model,omitempty"`
+}
+
+// RedfishMemorySummary summarizes the memory of the host
+type RedfishMemorySummary struct {
+	TotalSystemMemoryGiB float64 `json:"TotalSystemMemoryGiB"`
+}
+
+// RedfishProcessor describes the processors of the host. The
+// inspection data does not tell processors apart, so there is a
+// single entry for all of them.
+type RedfishProcessor struct {
+	ID                    string   `json:"Id"`
+	ProcessorArchitecture string   `json:"ProcessorArchitecture,omitempty"`
+	Model                 string   `json:"Model,omitempty"`
+	MaxSpeedMHz           int      `json:"MaxSpeedMHz,omitempty"`
+	TotalThreads          int      `json:"TotalThreads"`
+	Flags                 []string `json:"Flags,omitempty"`
+}
+
+// RedfishDrive describes a storage device of the host
+type RedfishDrive struct {
+	ID            string              `json:"Id"`
+	Name          string              `json:"Name"`
+	Manufacturer  string              `json:"Manufacturer,omitempty"`
+	Model         string              `json:"Model,omitempty"`
+	SerialNumber  string              `json:"SerialNumber,omitempty"`
+	CapacityBytes int64               `json:"CapacityBytes"`
+	MediaType     string              `json:"MediaType"`
+	Identifiers   []RedfishIdentifier `json:"Identifiers,omitempty"`
+}
+
+// RedfishIdentifier is a durable name of a device
+type RedfishIdentifier struct {
+	DurableName       string `json:"DurableName"`
+	DurableNameFormat string `json:"DurableNameFormat"`
+}
+
+// RedfishEthernetInterface describes a network interface of the host
+type RedfishEthernetInterface struct {
+	ID            string             `json:"Id"`
+	Name          string             `json:"Name"`
+	Description   string             `json:"Description,omitempty"`
+	MACAddress    string             `json:"MACAddress"`
+	SpeedMbps     int                `json:"SpeedMbps,omitempty"`
+	LinkStatus    string             `json:"LinkStatus"`
+	IPv4Addresses []RedfishIPAddress `json:"IPv4Addresses,omitempty"`
+	IPv6Addresses []RedfishIPAddress `json:"IPv6Addresses,omitempty"`
+	VLAN          *RedfishVLAN       `json:"VLAN,omitempty"`
+	VLANs         []RedfishVLAN      `json:"VLANs,omitempty"`
+}
+
+// RedfishIPAddress is an address assigned to a network interface
+type RedfishIPAddress struct {
+	Address string `json:"Address"`
+}
+
+// RedfishVLAN is a VLAN a network interface is connected to
+type RedfishVLAN struct {
+	VLANEnable bool                  `json:"VLANEnable"`
+	VLANID     metal3v1alpha1.VLANID `json:"VLANId"`
+	Name       string                `json:"Name,omitempty"`
+}
+
+// NewRedfishSystem converts the hardware details of a host into its
+// Redfish inventory.
+func NewRedfishSystem(name string, details *metal3v1alpha1.HardwareDetails) RedfishSystem {
+	system := RedfishSystem{
+		ODataType:    RedfishSystemType,
+		ID:           name,
+		Name:         name,
+		HostName:     details.Hostname,
+		Manufacturer: details.SystemVendor.Manufacturer,
+		Model:        details.SystemVendor.ProductName,
+		SerialNumber: details.SystemVendor.SerialNumber,
+		BiosVersion:  details.Firmware.BIOS.Version,
+		ProcessorSummary: RedfishProcessorSummary{
+			LogicalProcessorCount: details.CPU.Count,
+			Model:                 details.CPU.Model,
+		},
+		MemorySummary: RedfishMemorySummary{
+			TotalSystemMemoryGiB: float64(details.RAMMebibytes) / 1024,
+		},
+		Processors: []RedfishProcessor{
+			{
+				ID:                    "CPU",
+				ProcessorArchitecture: redfishArchitecture(details.CPU.Arch),
+				Model:                 details.CPU.Model,
+				MaxSpeedMHz:           int(details.CPU.ClockMegahertz / metal3v1alpha1.MegaHertz),
+				TotalThreads:          details.CPU.Count,
+				Flags:                 details.CPU.Flags,
+			},
+		},
+		Drives:             []RedfishDrive{},
+		EthernetInterfaces: []RedfishEthernetInterface{},
+	}
+
+	for i, storage := range details.Storage {
+		drive := RedfishDrive{
+			ID:            fmt.Sprintf("Drive%d", i),
+			Name:          storage.Name,
+			Manufacturer:  storage.Vendor,
+			Model:         storage.Model,
+			SerialNumber:  storage.SerialNumber,
+			CapacityBytes: int64(storage.SizeBytes),
+			MediaType:     "SSD",
+		}
+		if storage.Rotational {
+			drive.MediaType = "HDD"
+		}
+		if storage.WWN != "" {
+			drive.Identifiers = []RedfishIdentifier{
+				{DurableName: storage.WWN, DurableNameFormat: "NAA"},
+			}
+		}
+		system.Drives = append(system.Drives, drive)
+	}
+
+	for i, nic := range details.NIC {
+		iface := RedfishEthernetInterface{
+			ID:          fmt.Sprintf("NIC%d", i),
+			Name:        nic.Name,
+			Description: nic.Model,
+			MACAddress:  nic.MAC,
+			SpeedMbps:   nic.SpeedGbps * 1000,
+			LinkStatus:  "LinkUp",
+		}
+		if nic.LinkDown {
+			iface.LinkStatus = "LinkDown"
+		}
+		if ip := net.ParseIP(nic.IP); ip != nil {
+			if ip.To4() != nil {
+				iface.IPv4Addresses = []RedfishIPAddress{{Address: nic.IP}}
+			} else {
+				iface.IPv6Addresses = []RedfishIPAddress{{Address: nic.IP}}
+			}
+		}
+		if nic.VLANID != 0 {
+			iface.VLAN = &RedfishVLAN{VLANEnable: true, VLANID: nic.VLANID}
+		}
+		for _, vlan := range nic.VLANs {
+			iface.VLANs = append(iface.VLANs, RedfishVLAN{VLANEnable: true, VLANID: vlan.ID, Name: vlan.Name})
+		}
+		system.EthernetInterfaces = append(system.EthernetInterfaces, iface)
+	}
+
+	return system
+}
+
+// redfishArchitecture maps the CPU architecture reported by the
+// inspection to the values defined by Redfish
+func redfishArchitecture(arch string) string {
+	switch arch {
+	case "":
+		return ""
+	case "x86_64", "i686", "i386":
+		return "x86"
+	case "aarch64":
+		return "ARM"
+	case "ppc64le", "ppc64":
+		return "Power"
+	case "mips", "mips64":
+		return "MIPS"
+	}
+	return "OEM"
+}
@@ -0,0 +1,154 @@
+package hardware
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestNewRedfishSystem(t *testing.T) {
+	details := &metal3v1alpha1.HardwareDetails{
+		SystemVendor: metal3v1alpha1.HardwareSystemVendor{
+			Manufacturer: "Dell Inc.",
+			ProductName:  "PowerEdge R640",
+			SerialNumber: "ABC123",
+		},
+		Firmware: metal3v1alpha1.Firmware{
+			BIOS: metal3v1alpha1.BIOS{Vendor: "Dell Inc.", Version: "2.1.7"},
+		},
+		RAMMebibytes: 196608,
+		NIC: []metal3v1alpha1.NIC{
+			{
+				Name:      "eno1",
+				Model:     "0x8086 0x1572",
+				MAC:       "00:11:22:33:44:55",
+				IP:        "192.168.111.20",
+				SpeedGbps: 10,
+				VLANID:    100,
+				VLANs:     []metal3v1alpha1.VLAN{{ID: 100, Name: "eno1.100"}},
+				PXE:       true,
+			},
+			{
+				Name:     "eno2",
+				MAC:      "00:11:22:33:44:56",
+				IP:       "fd2e:6f44:5dd8::20",
+				LinkDown: true,
+			},
+		},
+		Storage: []metal3v1alpha1.Storage{
+			{
+				Name:         "/dev/sda",
+				Rotational:   true,
+				SizeBytes:    1000204886016,
+				Vendor:       "ATA",
+				Model:        "ST1000NX0423",
+				SerialNumber: "W470XYZ",
+				WWN:          "0x5000c500a0d1e2f3",
+			},
+			{
+				Name:      "/dev/nvme0n1",
+				SizeBytes: 960197124096,
+				Model:     "Dell Express Flash",
+			},
+		},
+		CPU: metal3v1alpha1.CPU{
+			Arch:           "x86_64",
+			Model:          "Intel(R) Xeon(R) Gold 6130 CPU @ 2.10GHz",
+			ClockMegahertz: 2100,
+			Flags:          []string{"avx", "sse4_2"},
+			Count:          64,
+		},
+		Hostname: "worker-0.example.com",
+	}
+
+	actual, err := json.Marshal(NewRedfishSystem("worker-0", details))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, `{
+		"@odata.type": "#ComputerSystem.v1_13_0.ComputerSystem",
+		"Id": "worker-0",
+		"Name": "worker-0",
+		"HostName": "worker-0.example.com",
+		"Manufacturer": "Dell Inc.",
+		"Model": "PowerEdge R640",
+		"SerialNumber": "ABC123",
+		"BiosVersion": "2.1.7",
+		"ProcessorSummary": {
+			"LogicalProcessorCount": 64,
+			"Model": "Intel(R) Xeon(R) Gold 6130 CPU @ 2.10GHz"
+		},
+		"MemorySummary": {"TotalSystemMemoryGiB": 192},
+		"Processors": [
+			{
+				"Id": "CPU",
+				"ProcessorArchitecture": "x86",
+				"Model": "Intel(R) Xeon(R) Gold 6130 CPU @ 2.10GHz",
+				"MaxSpeedMHz": 2100,
+				"TotalThreads": 64,
+				"Flags": ["avx", "sse4_2"]
+			}
+		],
+		"Drives": [
+			{
+				"Id": "Drive0",
+				"Name": "/dev/sda",
+				"Manufacturer": "ATA",
+				"Model": "ST1000NX0423",
+				"SerialNumber": "W470XYZ",
+				"CapacityBytes": 1000204886016,
+				"MediaType": "HDD",
+				"Identifiers": [{"DurableName": "0x5000c500a0d1e2f3", "DurableNameFormat": "NAA"}]
+			},
+			{
+				"Id": "Drive1",
+				"Name": "/dev/nvme0n1",
+				"Model": "Dell Express Flash",
+				"CapacityBytes": 960197124096,
+				"MediaType": "SSD"
+			}
+		],
+		"EthernetInterfaces": [
+			{
+				"Id": "NIC0",
+				"Name": "eno1",
+				"Description": "0x8086 0x1572",
+				"MACAddress": "00:11:22:33:44:55",
+				"SpeedMbps": 10000,
+				"LinkStatus": "LinkUp",
+				"IPv4Addresses": [{"Address": "192.168.111.20"}],
+				"VLAN": {"VLANEnable": true, "VLANId": 100},
+				"VLANs": [{"VLANEnable": true, "VLANId": 100, "Name": "eno1.100"}]
+			},
+			{
+				"Id": "NIC1",
+				"Name": "eno2",
+				"MACAddress": "00:11:22:33:44:56",
+				"LinkStatus": "LinkDown",
+				"IPv6Addresses": [{"Address": "fd2e:6f44:5dd8::20"}]
+			}
+		]
+	}`, string(actual))
+}
+
+func TestNewRedfishSystemEmpty(t *testing.T) {
+	actual, err := json.Marshal(NewRedfishSystem("host", &metal3v1alpha1.HardwareDetails{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.JSONEq(t, `{
+		"@odata.type": "#ComputerSystem.v1_13_0.ComputerSystem",
+		"Id": "host",
+		"Name": "host",
+		"ProcessorSummary": {"LogicalProcessorCount": 0},
+		"MemorySummary": {"TotalSystemMemoryGiB": 0},
+		"Processors": [{"Id": "CPU", "TotalThreads": 0}],
+		"Drives": [],
+		"EthernetInterfaces": []
+	}`, string(actual))
+}
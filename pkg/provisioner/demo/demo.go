@@ -81,6 +81,12 @@ func (p *demoProvisioner) HasCapacity() (result bool, err error) {
 	return true, nil
 }
 
+// CheckBMCAccess quickly verifies that the BMC is reachable and
+// accepts the credentials.
+func (p *demoProvisioner) CheckBMCAccess() (result provisioner.Result, err error) {
+	return result, nil
+}
+
 // ValidateManagementAccess tests the connection information for the
 // host to verify that the location and credentials work.
 func (p *demoProvisioner) ValidateManagementAccess(data provisioner.ManagementAccessData, credentialsChanged, force bool) (result provisioner.Result, provID string, err error) {
@@ -207,6 +213,20 @@ func (p *demoProvisioner) Prepare(data provisioner.PrepareData, unprepared bool)
 	return result, false, nil
 }
 
+// CheckFirmwareSettings compares the requested firmware settings
+// with the ones reported by the host
+func (p *demoProvisioner) CheckFirmwareSettings(config *metal3v1alpha1.FirmwareConfig) (pending map[string]string, err error) {
+	p.log.Info("checking firmware settings")
+	return
+}
+
+// DiffFirmwareSettings compares the requested firmware settings with
+// the ones reported by the host
+func (p *demoProvisioner) DiffFirmwareSettings(config, previous *metal3v1alpha1.FirmwareConfig) (diff []metal3v1alpha1.BIOSSettingDiff, err error) {
+	p.log.Info("comparing firmware settings")
+	return
+}
+
 // Adopt notifies the provisioner that the state machine believes the host
 // to be currently provisioned, and that it should be managed as such.
 func (p *demoProvisioner) Adopt(data provisioner.AdoptData, force bool) (result provisioner.Result, err error) {
@@ -241,6 +261,12 @@ func (p *demoProvisioner) Provision(data provisioner.ProvisionData) (result prov
 	return result, nil
 }
 
+// AbortDeploy cancels the deploy in progress
+func (p *demoProvisioner) AbortDeploy() (result provisioner.Result, err error) {
+	p.log.Info("aborting deploy")
+	return result, nil
+}
+
 // Deprovision removes the host from the image. It may be called
 // multiple times, and should return true for its dirty flag until the
 // deprovisioning operation is completed.
@@ -340,6 +366,51 @@ func (p *demoProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode) (result
 	// return result, nil
 }
 
+// SetBootDevice sets the device the host boots from
+func (p *demoProvisioner) SetBootDevice(device string, persistent bool) (result provisioner.Result, err error) {
+	p.log.Info("setting boot device", "device", device, "persistent", persistent)
+	return result, nil
+}
+
+// ClearFault clears the fault of the host
+func (p *demoProvisioner) ClearFault() (result provisioner.Result, err error) {
+	p.log.Info("clearing fault")
+	return
+}
+
+// IsImageCached tells whether the image is cached
+func (p *demoProvisioner) IsImageCached(image metal3v1alpha1.Image) (cached *bool, err error) {
+	return
+}
+
+// GetAvailableSteps returns the steps supported by the host
+func (p *demoProvisioner) GetAvailableSteps() (steps provisioner.AvailableSteps, err error) {
+	return
+}
+
+// ResetBMC restarts the BMC of the host
+func (p *demoProvisioner) ResetBMC() (result provisioner.Result, err error) {
+	p.log.Info("resetting BMC")
+	return result, nil
+}
+
+// SyncPowerState makes the provisioner read the power state of the host
+func (p *demoProvisioner) SyncPowerState(online bool) (result provisioner.Result, err error) {
+	p.log.Info("syncing power state")
+	return result, nil
+}
+
+// GetBlockingReasons returns what the host is waiting for
+func (p *demoProvisioner) GetBlockingReasons() (reasons []metal3v1alpha1.BlockingReason, err error) {
+	return nil, nil
+}
+
+// GetSensors reads the fan and temperature sensors of the host
+func (p *demoProvisioner) GetSensors() (sensors *metal3v1alpha1.SensorStatus, err error) {
+	p.log.Info("reading sensors")
+	return nil, nil
+}
+
 // IsReady always returns true for the demo provisioner
 func (p *demoProvisioner) IsReady() (result bool, err error) {
 	return true, nil
@@ -42,6 +42,10 @@ func (cd *fixtureHostConfigData) MetaData() (string, error) {
 	return cd.metaData, nil
 }
 
+func (cd *fixtureHostConfigData) ExtraFiles() (map[string]string, error) {
+	return nil, nil
+}
+
 // fixtureProvisioner implements the provisioning.fixtureProvisioner interface
 // and uses Ironic to manage the host.
 type fixtureProvisioner struct {
@@ -93,6 +97,12 @@ func (p *fixtureProvisioner) HasCapacity() (result bool, err error) {
 	return true, nil
 }
 
+// CheckBMCAccess quickly verifies that the BMC is reachable and
+// accepts the credentials.
+func (p *fixtureProvisioner) CheckBMCAccess() (result provisioner.Result, err error) {
+	return result, nil
+}
+
 // ValidateManagementAccess tests the connection information for the
 // host to verify that the location and credentials work.
 func (p *fixtureProvisioner) ValidateManagementAccess(data provisioner.ManagementAccessData, credentialsChanged, force bool) (result provisioner.Result, provID string, err error) {
@@ -189,6 +199,20 @@ func (p *fixtureProvisioner) Prepare(data provisioner.PrepareData, unprepared bo
 	return
 }
 
+// CheckFirmwareSettings compares the requested firmware settings
+// with the ones reported by the host
+func (p *fixtureProvisioner) CheckFirmwareSettings(config *metal3v1alpha1.FirmwareConfig) (pending map[string]string, err error) {
+	p.log.Info("checking firmware settings")
+	return
+}
+
+// DiffFirmwareSettings compares the requested firmware settings with
+// the ones reported by the host
+func (p *fixtureProvisioner) DiffFirmwareSettings(config, previous *metal3v1alpha1.FirmwareConfig) (diff []metal3v1alpha1.BIOSSettingDiff, err error) {
+	p.log.Info("comparing firmware settings")
+	return
+}
+
 // Adopt notifies the provisioner that the state machine believes the host
 // to be currently provisioned, and that it should be managed as such.
 func (p *fixtureProvisioner) Adopt(data provisioner.AdoptData, force bool) (result provisioner.Result, err error) {
@@ -218,6 +242,12 @@ func (p *fixtureProvisioner) Provision(data provisioner.ProvisionData) (result p
 	return result, nil
 }
 
+// AbortDeploy cancels the deploy in progress
+func (p *fixtureProvisioner) AbortDeploy() (result provisioner.Result, err error) {
+	p.log.Info("aborting deploy")
+	return result, nil
+}
+
 // Deprovision removes the host from the image. It may be called
 // multiple times, and should return true for its dirty flag until the
 // deprovisioning operation is completed.
@@ -300,6 +330,51 @@ func (p *fixtureProvisioner) PowerOff(rebootMode metal3v1alpha1.RebootMode) (res
 	return result, nil
 }
 
+// SetBootDevice sets the device the host boots from
+func (p *fixtureProvisioner) SetBootDevice(device string, persistent bool) (result provisioner.Result, err error) {
+	p.log.Info("setting boot device", "device", device, "persistent", persistent)
+	return result, nil
+}
+
+// ClearFault clears the fault of the host
+func (p *fixtureProvisioner) ClearFault() (result provisioner.Result, err error) {
+	p.log.Info("clearing fault")
+	return
+}
+
+// IsImageCached tells whether the image is cached
+func (p *fixtureProvisioner) IsImageCached(image metal3v1alpha1.Image) (cached *bool, err error) {
+	return
+}
+
+// GetAvailableSteps returns the steps supported by the host
+func (p *fixtureProvisioner) GetAvailableSteps() (steps provisioner.AvailableSteps, err error) {
+	return
+}
+
+// ResetBMC restarts the BMC of the host
+func (p *fixtureProvisioner) ResetBMC() (result provisioner.Result, err error) {
+	p.log.Info("resetting BMC")
+	return result, nil
+}
+
+// SyncPowerState makes the provisioner read the power state of the host
+func (p *fixtureProvisioner) SyncPowerState(online bool) (result provisioner.Result, err error) {
+	p.log.Info("syncing power state")
+	return result, nil
+}
+
+// GetBlockingReasons returns what the host is waiting for
+func (p *fixtureProvisioner) GetBlockingReasons() (reasons []metal3v1alpha1.BlockingReason, err error) {
+	return nil, nil
+}
+
+// GetSensors reads the fan and temperature sensors of the host
+func (p *fixtureProvisioner) GetSensors() (sensors *metal3v1alpha1.SensorStatus, err error) {
+	p.log.Info("reading sensors")
+	return nil, nil
+}
+
 // IsReady returns the current availability status of the provisioner
 func (p *fixtureProvisioner) IsReady() (result bool, err error) {
 	p.log.Info("checking provisioner status")
@@ -0,0 +1,61 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// AbortDeploy cancels the deploy in progress, if any, so that the host
+// can be deprovisioned or provisioned again. A deploy running a step
+// cannot be interrupted and is waited for.
+func (p *ironicProvisioner) AbortDeploy() (result provisioner.Result, err error) {
+	ironicNode, err := p.getNode()
+	if err != nil {
+		return transientError(err)
+	}
+
+	switch nodes.ProvisionState(ironicNode.ProvisionState) {
+	case nodes.DeployWait:
+		return p.abortDeploy(ironicNode)
+	case nodes.Deploying:
+		p.log.Info("waiting for the deploy step to finish before aborting",
+			"deploy step", ironicNode.DeployStep)
+		return operationContinuing(provisionRequeueDelay)
+	default:
+		return operationComplete()
+	}
+}
+
+// abortDeploy stops a deploy waiting for the agent. Versions of Ironic
+// unable to abort a deploy reject the request, in which case the
+// instance is torn down instead, which also cleans the host.
+func (p *ironicProvisioner) abortDeploy(ironicNode *nodes.Node) (result provisioner.Result, err error) {
+	if p.nodeLocked(ironicNode) {
+		return retryAfterDelay(nodeLockedRequeueDelay)
+	}
+
+	p.log.Info("aborting deploy", "deploy step", ironicNode.DeployStep)
+	changeResult := nodes.ChangeProvisionState(p.client, ironicNode.UUID,
+		nodes.ProvisionStateOpts{Target: nodes.TargetAbort})
+	switch changeResult.Err.(type) {
+	case nil:
+		p.publisher("DeployAborted", "Image provisioning aborted")
+		return operationContinuing(provisionRequeueDelay)
+	case gophercloud.ErrDefault409:
+		p.log.Info("could not abort deploy, busy")
+		return retryAfterDelay(provisionRequeueDelay)
+	case gophercloud.ErrDefault400:
+		p.log.Info("aborting deploy not supported, tearing down the instance", "error", changeResult.Err)
+		p.publisher("DeployAborted", "Image provisioning aborted by tearing down the instance")
+		return p.changeNodeProvisionState(ironicNode,
+			nodes.ProvisionStateOpts{Target: nodes.TargetDeleted})
+	default:
+		return transientError(errors.Wrap(changeResult.Err,
+			fmt.Sprintf("failed to change provisioning state to %q", nodes.TargetAbort)))
+	}
+}
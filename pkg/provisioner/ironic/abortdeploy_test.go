@@ -0,0 +1,172 @@
+package ironic
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+// provisionStateHandler records the requested provision state targets
+// and rejects the ones listed as unsupported
+func provisionStateHandler(t *testing.T, unsupported string, targets *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Target string `json:"target"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid provision state request: %s", err)
+		}
+		*targets = append(*targets, body.Target)
+		if body.Target == unsupported {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func TestAbortDeploy(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name        string
+		node        nodes.Node
+		unsupported string
+
+		expectedTargets []string
+		expectedDirty   bool
+	}{
+		{
+			name:            "waiting for the agent",
+			node:            nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.DeployWait)},
+			expectedTargets: []string{"abort"},
+			expectedDirty:   true,
+		},
+		{
+			name:            "abort unsupported",
+			node:            nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.DeployWait)},
+			unsupported:     "abort",
+			expectedTargets: []string{"abort", "deleted"},
+			expectedDirty:   true,
+		},
+		{
+			name:          "locked host",
+			node:          nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.DeployWait), Reservation: "conductor-1"},
+			expectedDirty: true,
+		},
+		{
+			name:          "running a deploy step",
+			node:          nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.Deploying)},
+			expectedDirty: true,
+		},
+		{
+			name: "deploy failed",
+			node: nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.DeployFail)},
+		},
+		{
+			name: "deploy completed",
+			node: nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.Active)},
+		},
+		{
+			name: "not deployed",
+			node: nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.Available)},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var targets []string
+			ironic := testserver.NewIronic(t).Ready().Node(tc.node)
+			ironic.Handler("/v1/nodes/"+nodeUUID+"/states/provision", provisionStateHandler(t, tc.unsupported, &targets))
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.AbortDeploy()
+
+			assert.NoError(t, err)
+			assert.Equal(t, "", result.ErrorMessage)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+			assert.Equal(t, tc.expectedTargets, targets)
+		})
+	}
+}
+
+func TestProvisionImageChangedDuringDeploy(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	image := v1alpha1.Image{URL: "http://example.test/image.qcow2", Checksum: "abcd"}
+	checksum, checksumType, _ := image.GetChecksum()
+	cases := []struct {
+		name            string
+		imageSource     string
+		expectedTargets []string
+	}{
+		{
+			name:        "same image",
+			imageSource: image.URL,
+		},
+		{
+			name:            "image changed",
+			imageSource:     "http://example.test/wrong.qcow2",
+			expectedTargets: []string{"abort"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var targets []string
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				ProvisionState: string(nodes.DeployWait),
+				UUID:           nodeUUID,
+				InstanceInfo: map[string]interface{}{
+					"image_source":        tc.imageSource,
+					"image_os_hash_algo":  checksumType,
+					"image_os_hash_value": checksum,
+				},
+			})
+			ironic.Handler("/v1/nodes/"+nodeUUID+"/states/provision", provisionStateHandler(t, "", &targets))
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.Image = &image
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image:      image,
+				HostConfig: fixture.NewHostConfigData("", "", ""),
+				BootMode:   v1alpha1.DefaultBootMode,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, "", result.ErrorMessage)
+			assert.True(t, result.Dirty)
+			assert.Equal(t, tc.expectedTargets, targets)
+		})
+	}
+}
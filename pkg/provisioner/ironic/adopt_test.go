@@ -1,6 +1,7 @@
 package ironic
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
 	"github.com/stretchr/testify/assert"
 
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
@@ -126,3 +128,78 @@ func TestAdopt(t *testing.T) {
 		})
 	}
 }
+
+func TestAdoptSetsInstanceUUID(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	hostUID := "27720611-e5d1-45d3-ba3a-222dcfaa4ca2"
+
+	cases := []struct {
+		name         string
+		state        nodes.ProvisionState
+		instanceUUID string
+		force        bool
+
+		expectedUpdate bool
+	}{
+		{
+			name:           "manageable",
+			state:          nodes.Manageable,
+			expectedUpdate: true,
+		},
+		{
+			name:           "manageable with another instance",
+			state:          nodes.Manageable,
+			instanceUUID:   "f7c65ea8-1c0e-4a45-8bbb-4dd5b6b0d1b1",
+			expectedUpdate: true,
+		},
+		{
+			name:         "manageable with the instance of the host",
+			state:        nodes.Manageable,
+			instanceUUID: hostUID,
+		},
+		{
+			name:           "retry after failure",
+			state:          nodes.AdoptFail,
+			force:          true,
+			expectedUpdate: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(tc.state),
+				UUID:           nodeUUID,
+				InstanceUUID:   tc.instanceUUID,
+				InstanceInfo:   map[string]interface{}{"image_source": "http://example.test/image.qcow2"},
+			}).WithNodeStatesProvisionUpdate(nodeUUID)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Adopt(provisioner.AdoptData{State: metal3v1alpha1.StateExternallyProvisioned}, tc.force)
+
+			assert.NoError(t, err)
+			assert.True(t, result.Dirty)
+			update, updated := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID, http.MethodPatch)
+			assert.Equal(t, tc.expectedUpdate, updated)
+			if tc.expectedUpdate {
+				assert.Contains(t, update, "/instance_uuid")
+				assert.Contains(t, update, hostUID)
+			}
+			body, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			if assert.True(t, found) {
+				assert.Contains(t, body, `"target":"adopt"`)
+			}
+		})
+	}
+}
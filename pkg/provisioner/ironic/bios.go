@@ -0,0 +1,255 @@
+package ironic
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// biosSetting is a single entry of the node's BIOS settings as
+// returned by the Ironic API.
+type biosSetting struct {
+	Name  string  `json:"name"`
+	Value *string `json:"value"`
+}
+
+// biosRegistryMicroversion is the first API version returning the
+// BIOS registry of the settings along with their values
+const biosRegistryMicroversion = "1.74"
+
+// biosSettingDetails is a single entry of the node's BIOS settings
+// including its definition from the BIOS registry.
+type biosSettingDetails struct {
+	Name            string   `json:"name"`
+	AttributeType   *string  `json:"attribute_type"`
+	AllowableValues []string `json:"allowable_values"`
+	LowerBound      *int     `json:"lower_bound"`
+	UpperBound      *int     `json:"upper_bound"`
+	MinLength       *int     `json:"min_length"`
+	MaxLength       *int     `json:"max_length"`
+	ReadOnly        *bool    `json:"read_only"`
+}
+
+// BMCs report sizes with various spellings of their units, so values
+// with a byte unit are compared in bytes. Bare numbers and other
+// suffixes, such as frequencies or single letters, are not sizes and
+// are compared as they are.
+var biosValueUnits = map[string]int64{
+	"b":   1,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+	"tb":  1 << 40,
+	"tib": 1 << 40,
+}
+
+var biosQuantityRegexp = regexp.MustCompile(`^([0-9]+)\s*([a-z]*)$`)
+
+// normalizeBIOSValue returns a canonical representation of a BIOS
+// setting value, so that values that only differ in case, spacing or
+// size units compare equal.
+func normalizeBIOSValue(value string) string {
+	value = strings.Join(strings.Fields(strings.ToLower(value)), " ")
+
+	match := biosQuantityRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return value
+	}
+	if multiplier, ok := biosValueUnits[match[2]]; ok {
+		if number, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+			return strconv.FormatInt(number*multiplier, 10) + "b"
+		}
+	}
+	return match[1] + match[2]
+}
+
+// pendingBIOSSettings returns the requested settings that do not
+// match the current ones after normalization. Setting names are
+// matched case-insensitively and the name reported by the BMC is used
+// in the result when known.
+func pendingBIOSSettings(config *metal3v1alpha1.FirmwareConfig, current map[string]string) (pending map[string]string) {
+	requested := config.BIOSSettings()
+	if len(requested) == 0 {
+		return
+	}
+
+	currentNames := make(map[string]string, len(current))
+	for name := range current {
+		currentNames[strings.ToLower(name)] = name
+	}
+
+	pending = make(map[string]string)
+	for name, value := range requested {
+		currentName, found := currentNames[strings.ToLower(name)]
+		if !found {
+			pending[name] = value
+			continue
+		}
+		if normalizeBIOSValue(current[currentName]) != normalizeBIOSValue(value) {
+			pending[currentName] = value
+		}
+	}
+	return
+}
+
+// diffBIOSSettings compares the requested settings with the current
+// ones. The settings of the previous configuration that are not
+// requested anymore are reported as removed. The differences are
+// sorted by name.
+func diffBIOSSettings(config, previous *metal3v1alpha1.FirmwareConfig, current map[string]string) (diff []metal3v1alpha1.BIOSSettingDiff) {
+	currentNames := make(map[string]string, len(current))
+	for name := range current {
+		currentNames[strings.ToLower(name)] = name
+	}
+
+	requested := config.BIOSSettings()
+	requestedNames := make(map[string]bool, len(requested))
+	for name, value := range requested {
+		requestedNames[strings.ToLower(name)] = true
+		currentName, found := currentNames[strings.ToLower(name)]
+		switch {
+		case !found:
+			diff = append(diff, metal3v1alpha1.BIOSSettingDiff{
+				Name:      name,
+				Change:    metal3v1alpha1.BIOSSettingAdded,
+				Requested: value,
+			})
+		case normalizeBIOSValue(current[currentName]) != normalizeBIOSValue(value):
+			diff = append(diff, metal3v1alpha1.BIOSSettingDiff{
+				Name:      currentName,
+				Change:    metal3v1alpha1.BIOSSettingChanged,
+				Requested: value,
+				Current:   current[currentName],
+			})
+		}
+	}
+
+	for name := range previous.BIOSSettings() {
+		if requestedNames[strings.ToLower(name)] {
+			continue
+		}
+		entry := metal3v1alpha1.BIOSSettingDiff{
+			Name:   name,
+			Change: metal3v1alpha1.BIOSSettingRemoved,
+		}
+		if currentName, found := currentNames[strings.ToLower(name)]; found {
+			entry.Name = currentName
+			entry.Current = current[currentName]
+		}
+		diff = append(diff, entry)
+	}
+
+	sort.Slice(diff, func(i, j int) bool {
+		return diff[i].Name < diff[j].Name
+	})
+	return
+}
+
+// getBIOSSettings fetches the current BIOS settings of the node
+func (p *ironicProvisioner) getBIOSSettings(ironicNode *nodes.Node) (settings map[string]string, err error) {
+	var body struct {
+		Settings []biosSetting `json:"bios"`
+	}
+	_, err = p.client.Get(p.client.ServiceURL("nodes", ironicNode.UUID, "bios"), &body, nil)
+	if err != nil {
+		return
+	}
+
+	settings = make(map[string]string, len(body.Settings))
+	for _, setting := range body.Settings {
+		if setting.Value != nil {
+			settings[setting.Name] = *setting.Value
+		} else {
+			settings[setting.Name] = ""
+		}
+	}
+	return
+}
+
+// getFirmwareSchema fetches the BIOS registry of the node. Ironic
+// versions that do not report the registry return an empty schema, as
+// do BMCs without one.
+func (p *ironicProvisioner) getFirmwareSchema(ironicNode *nodes.Node) (schema metal3v1alpha1.FirmwareSchema, err error) {
+	// The registry needs a newer API version than the one used for
+	// the rest of the requests.
+	client := *p.client
+	client.Microversion = biosRegistryMicroversion
+
+	var body struct {
+		Settings []biosSettingDetails `json:"bios"`
+	}
+	_, err = client.Get(client.ServiceURL("nodes", ironicNode.UUID, "bios")+"?detail=True", &body, nil)
+	if err != nil {
+		if respErr, ok := err.(gophercloud.ErrUnexpectedResponseCode); ok && respErr.Actual == http.StatusNotAcceptable {
+			p.debugLog.Info("the BIOS registry cannot be read", "error", err)
+			return nil, nil
+		}
+		return
+	}
+
+	schema = make(metal3v1alpha1.FirmwareSchema, len(body.Settings))
+	for _, setting := range body.Settings {
+		if setting.AttributeType == nil {
+			continue
+		}
+		entry := metal3v1alpha1.SettingSchema{
+			AttributeType:   *setting.AttributeType,
+			AllowableValues: setting.AllowableValues,
+			LowerBound:      setting.LowerBound,
+			UpperBound:      setting.UpperBound,
+			MinLength:       setting.MinLength,
+			MaxLength:       setting.MaxLength,
+		}
+		if setting.ReadOnly != nil {
+			entry.ReadOnly = *setting.ReadOnly
+		}
+		schema[setting.Name] = entry
+	}
+	return
+}
+
+// BuildBIOSCleanSteps builds the clean step applying the requested BIOS
+// settings that have not converged yet. No step is returned when the
+// current settings already match the requested values.
+func BuildBIOSCleanSteps(config *metal3v1alpha1.FirmwareConfig, current map[string]string) (cleanSteps []nodes.CleanStep) {
+	pending := pendingBIOSSettings(config, current)
+	if len(pending) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(pending))
+	for name := range pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	settings := make([]map[string]string, 0, len(names))
+	for _, name := range names {
+		settings = append(settings, map[string]string{
+			"name":  name,
+			"value": pending[name],
+		})
+	}
+
+	cleanSteps = append(
+		cleanSteps,
+		nodes.CleanStep{
+			Interface: "bios",
+			Step:      "apply_configuration",
+			Args: map[string]interface{}{
+				"settings": settings,
+			},
+		},
+	)
+	return
+}
@@ -0,0 +1,383 @@
+package ironic
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestNormalizeBIOSValue(t *testing.T) {
+	cases := []struct {
+		requested string
+		reported  string
+		equal     bool
+	}{
+		{requested: "Enabled", reported: "enabled", equal: true},
+		{requested: " Enabled ", reported: "ENABLED", equal: true},
+		{requested: "Max  Performance", reported: "max performance", equal: true},
+		{requested: "1024MB", reported: "1GB", equal: true},
+		{requested: "1024 MB", reported: "1024mb", equal: true},
+		{requested: "2048B", reported: "2KiB", equal: true},
+		{requested: "2400MHz", reported: "2400 mhz", equal: true},
+		{requested: "2048", reported: "2048", equal: true},
+		{requested: "2048", reported: "2KiB", equal: false},
+		{requested: "2k", reported: "2048", equal: false},
+		{requested: "1G", reported: "1024M", equal: false},
+		{requested: "Enabled", reported: "Disabled", equal: false},
+		{requested: "512MB", reported: "1GB", equal: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.requested+"/"+tc.reported, func(t *testing.T) {
+			assert.Equal(t, tc.equal, normalizeBIOSValue(tc.requested) == normalizeBIOSValue(tc.reported))
+		})
+	}
+}
+
+func TestBuildBIOSCleanSteps(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   *metal3v1alpha1.FirmwareConfig
+		current  map[string]string
+		expected []nodes.CleanStep
+	}{
+		{
+			name: "no config",
+			current: map[string]string{
+				"ProcVirtualization": "Enabled",
+			},
+		},
+		{
+			name: "converged after normalization",
+			config: &metal3v1alpha1.FirmwareConfig{
+				Settings: map[string]string{
+					"procvirtualization": "enabled",
+					"MemorySize":         "1024 MB",
+				},
+			},
+			current: map[string]string{
+				"ProcVirtualization": "Enabled",
+				"MemorySize":         "1GB",
+			},
+		},
+		{
+			name: "only pending settings are applied",
+			config: &metal3v1alpha1.FirmwareConfig{
+				Settings: map[string]string{
+					"procvirtualization": "enabled",
+					"ProcHyperthreading": "Disabled",
+					"SriovGlobalEnable":  "Enabled",
+				},
+			},
+			current: map[string]string{
+				"ProcVirtualization": "Enabled",
+				"ProcHyperthreading": "Enabled",
+			},
+			expected: []nodes.CleanStep{
+				{
+					Interface: "bios",
+					Step:      "apply_configuration",
+					Args: map[string]interface{}{
+						"settings": []map[string]string{
+							{"name": "ProcHyperthreading", "value": "Disabled"},
+							{"name": "SriovGlobalEnable", "value": "Enabled"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "boot order",
+			config: &metal3v1alpha1.FirmwareConfig{
+				BootOrder: []string{"Disk.SATAEmbedded.A-1", "NIC.PxeDevice.1-1"},
+			},
+			current: map[string]string{
+				"UefiBootSeq": "NIC.PxeDevice.1-1,Disk.SATAEmbedded.A-1",
+			},
+			expected: []nodes.CleanStep{
+				{
+					Interface: "bios",
+					Step:      "apply_configuration",
+					Args: map[string]interface{}{
+						"settings": []map[string]string{
+							{"name": "UefiBootSeq", "value": "Disk.SATAEmbedded.A-1,NIC.PxeDevice.1-1"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "boot order converged",
+			config: &metal3v1alpha1.FirmwareConfig{
+				BootOrder:        []string{"Disk", "NIC"},
+				BootOrderSetting: "BootSeq",
+			},
+			current: map[string]string{
+				"BootSeq": "Disk,NIC",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, BuildBIOSCleanSteps(tc.config, tc.current))
+		})
+	}
+}
+
+func TestCheckFirmwareSettings(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	config := &metal3v1alpha1.FirmwareConfig{
+		Settings: map[string]string{
+			"ProcVirtualization": "enabled",
+			"ProcHyperthreading": "Disabled",
+		},
+	}
+	cases := []struct {
+		name            string
+		reported        map[string]string
+		expectedPending map[string]string
+	}{
+		{
+			name: "converged",
+			reported: map[string]string{
+				"ProcVirtualization": "Enabled",
+				"ProcHyperthreading": "DISABLED",
+			},
+			expectedPending: map[string]string{},
+		},
+		{
+			name: "not converged",
+			reported: map[string]string{
+				"ProcVirtualization": "Enabled",
+				"ProcHyperthreading": "Enabled",
+			},
+			expectedPending: map[string]string{
+				"ProcHyperthreading": "Disabled",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				ProvisionState: string(nodes.Manageable),
+				UUID:           nodeUUID,
+			}).BIOS(nodeUUID, tc.reported)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			publisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			pending, err := prov.CheckFirmwareSettings(config)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedPending, pending)
+		})
+	}
+}
+
+func TestDiffFirmwareSettings(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	previous := &metal3v1alpha1.FirmwareConfig{
+		Settings: map[string]string{
+			"ProcVirtualization": "Enabled",
+			"SriovGlobalEnable":  "Enabled",
+		},
+	}
+	cases := []struct {
+		name         string
+		config       *metal3v1alpha1.FirmwareConfig
+		previous     *metal3v1alpha1.FirmwareConfig
+		reported     map[string]string
+		expectedDiff []metal3v1alpha1.BIOSSettingDiff
+	}{
+		{
+			name: "no difference",
+			config: &metal3v1alpha1.FirmwareConfig{
+				Settings: map[string]string{"procvirtualization": "enabled"},
+			},
+			reported: map[string]string{"ProcVirtualization": "Enabled"},
+		},
+		{
+			name: "mismatch",
+			config: &metal3v1alpha1.FirmwareConfig{
+				Settings: map[string]string{
+					"ProcVirtualization": "Disabled",
+					"MemTest":            "Enabled",
+					"L2Cache":            "10MB",
+				},
+			},
+			previous: previous,
+			reported: map[string]string{
+				"ProcVirtualization": "Enabled",
+				"SriovGlobalEnable":  "Enabled",
+				"L2Cache":            "10240 KB",
+			},
+			expectedDiff: []metal3v1alpha1.BIOSSettingDiff{
+				{Name: "MemTest", Change: metal3v1alpha1.BIOSSettingAdded, Requested: "Enabled"},
+				{Name: "ProcVirtualization", Change: metal3v1alpha1.BIOSSettingChanged, Requested: "Disabled", Current: "Enabled"},
+				{Name: "SriovGlobalEnable", Change: metal3v1alpha1.BIOSSettingRemoved, Current: "Enabled"},
+			},
+		},
+		{
+			name:     "configuration removed",
+			previous: previous,
+			reported: map[string]string{"ProcVirtualization": "Enabled"},
+			expectedDiff: []metal3v1alpha1.BIOSSettingDiff{
+				{Name: "ProcVirtualization", Change: metal3v1alpha1.BIOSSettingRemoved, Current: "Enabled"},
+				{Name: "SriovGlobalEnable", Change: metal3v1alpha1.BIOSSettingRemoved},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				ProvisionState: string(nodes.Available),
+				UUID:           nodeUUID,
+			}).BIOS(nodeUUID, tc.reported)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			publisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			diff, err := prov.DiffFirmwareSettings(tc.config, tc.previous)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDiff, diff)
+		})
+	}
+}
+
+// sampleBIOSRegistry is the detailed BIOS of a node, including the
+// definition of each setting from the registry.
+const sampleBIOSRegistry = `{"bios": [
+	{"name": "ProcVirtualization", "value": "Disabled", "attribute_type": "Enumeration",
+	 "allowable_values": ["Enabled", "Disabled"], "read_only": false},
+	{"name": "NumCores", "value": "4", "attribute_type": "Integer",
+	 "lower_bound": 1, "upper_bound": 16, "read_only": false},
+	{"name": "SerialNumber", "value": "1234", "attribute_type": "String",
+	 "max_length": 16, "read_only": true},
+	{"name": "AssetTag", "value": "", "attribute_type": null}
+]}`
+
+func TestGetFirmwareSchema(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{UUID: nodeUUID})
+	ironic.AddDefaultResponse("/v1/nodes/"+nodeUUID+"/bios", "GET", http.StatusOK, sampleBIOSRegistry)
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Status.Provisioning.ID = nodeUUID
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	schema, err := prov.getFirmwareSchema(&nodes.Node{UUID: nodeUUID})
+
+	one, sixteen := 1, 16
+	assert.NoError(t, err)
+	assert.Equal(t, metal3v1alpha1.FirmwareSchema{
+		"ProcVirtualization": {AttributeType: "Enumeration", AllowableValues: []string{"Enabled", "Disabled"}},
+		"NumCores":           {AttributeType: "Integer", LowerBound: &one, UpperBound: &sixteen},
+		"SerialNumber":       {AttributeType: "String", MaxLength: &sixteen, ReadOnly: true},
+	}, schema)
+}
+
+func TestPrepareValidatesFirmwareSchema(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name     string
+		settings map[string]string
+
+		expectedStarted      bool
+		expectedErrorMessage string
+	}{
+		{
+			name:            "allowable values",
+			settings:        map[string]string{"ProcVirtualization": "Enabled", "NumCores": "8"},
+			expectedStarted: true,
+		},
+		{
+			name:                 "not an allowable value",
+			settings:             map[string]string{"ProcVirtualization": "Maybe"},
+			expectedErrorMessage: "invalid value \"Maybe\" for BIOS setting ProcVirtualization, it must be one of Enabled, Disabled",
+		},
+		{
+			name:                 "out of range",
+			settings:             map[string]string{"NumCores": "32"},
+			expectedErrorMessage: "invalid value \"32\" for BIOS setting NumCores, it must not be higher than 16",
+		},
+		{
+			name:                 "read-only",
+			settings:             map[string]string{"SerialNumber": "5678"},
+			expectedErrorMessage: "invalid value \"5678\" for BIOS setting SerialNumber, the setting is read-only",
+		},
+		{
+			name:     "read-only setting already converged",
+			settings: map[string]string{"SerialNumber": "1234"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Manageable),
+				UUID:           nodeUUID,
+			})
+			ironic.AddDefaultResponse("/v1/nodes/"+nodeUUID+"/bios", "GET", http.StatusOK, sampleBIOSRegistry)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, started, err := prov.Prepare(provisioner.PrepareData{
+				FirmwareConfig: &metal3v1alpha1.FirmwareConfig{Settings: tc.settings},
+			}, true)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedStarted, started)
+			assert.Equal(t, tc.expectedErrorMessage, result.ErrorMessage)
+		})
+	}
+}
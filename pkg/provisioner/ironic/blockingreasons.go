@@ -0,0 +1,66 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// powerFailureFault is the fault Ironic records when it puts a node in
+// maintenance because its power state cannot be read from the BMC
+const powerFailureFault = "power failure"
+
+// blockingReasons returns the conditions of the node keeping the host
+// from leaving its current state.
+func blockingReasons(ironicNode *nodes.Node) (reasons []metal3v1alpha1.BlockingReason) {
+	switch nodes.ProvisionState(ironicNode.ProvisionState) {
+	case nodes.Inspecting, nodes.InspectWait:
+		reasons = append(reasons, metal3v1alpha1.BlockingReason{
+			Type:    metal3v1alpha1.BlockingReasonInspection,
+			Message: fmt.Sprintf("the node is in the %s state", ironicNode.ProvisionState),
+		})
+	case nodes.Cleaning, nodes.CleanWait:
+		message := fmt.Sprintf("the node is in the %s state", ironicNode.ProvisionState)
+		if step, _ := ironicNode.CleanStep["step"].(string); step != "" {
+			message = fmt.Sprintf("%s, running the %s step", message, step)
+		}
+		reasons = append(reasons, metal3v1alpha1.BlockingReason{
+			Type:    metal3v1alpha1.BlockingReasonCleaning,
+			Message: message,
+		})
+	}
+
+	if ironicNode.Maintenance && ironicNode.Fault == powerFailureFault {
+		message := ironicNode.MaintenanceReason
+		if message == "" {
+			message = "the power state cannot be read from the BMC"
+		}
+		reasons = append(reasons, metal3v1alpha1.BlockingReason{
+			Type:    metal3v1alpha1.BlockingReasonBMCUnreachable,
+			Message: message,
+		})
+	}
+	return reasons
+}
+
+// GetBlockingReasons returns what the node of the host is waiting for.
+// Nothing is reported for hosts not registered yet. The node read by
+// the registration earlier in the reconcile is reused when there is
+// one.
+func (p *ironicProvisioner) GetBlockingReasons() (reasons []metal3v1alpha1.BlockingReason, err error) {
+	ironicNode := p.lastNode
+	if ironicNode == nil {
+		ironicNode, err = p.getNode()
+		if err != nil {
+			if errors.Is(err, provisioner.ErrNeedsRegistration) {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+	return blockingReasons(ironicNode), nil
+}
@@ -0,0 +1,139 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestGetBlockingReasons(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name            string
+		node            nodes.Node
+		expectedReasons []metal3v1alpha1.BlockingReason
+	}{
+		{
+			name: "inspecting",
+			node: nodes.Node{ProvisionState: string(nodes.InspectWait)},
+			expectedReasons: []metal3v1alpha1.BlockingReason{
+				{Type: metal3v1alpha1.BlockingReasonInspection, Message: "the node is in the inspect wait state"},
+			},
+		},
+		{
+			name: "cleaning",
+			node: nodes.Node{
+				ProvisionState: string(nodes.Cleaning),
+				CleanStep:      map[string]interface{}{"interface": "deploy", "step": "erase_devices_metadata"},
+			},
+			expectedReasons: []metal3v1alpha1.BlockingReason{
+				{Type: metal3v1alpha1.BlockingReasonCleaning, Message: "the node is in the cleaning state, running the erase_devices_metadata step"},
+			},
+		},
+		{
+			name: "BMC unreachable while cleaning",
+			node: nodes.Node{
+				ProvisionState:    string(nodes.CleanWait),
+				Maintenance:       true,
+				Fault:             "power failure",
+				MaintenanceReason: "During sync_power_state, max retries exceeded for node",
+			},
+			expectedReasons: []metal3v1alpha1.BlockingReason{
+				{Type: metal3v1alpha1.BlockingReasonCleaning, Message: "the node is in the clean wait state"},
+				{Type: metal3v1alpha1.BlockingReasonBMCUnreachable, Message: "During sync_power_state, max retries exceeded for node"},
+			},
+		},
+		{
+			name: "maintenance for another fault",
+			node: nodes.Node{
+				ProvisionState: string(nodes.Manageable),
+				Maintenance:    true,
+				Fault:          "clean failure",
+			},
+		},
+		{
+			name: "deploying",
+			node: nodes.Node{ProvisionState: string(nodes.DeployWait)},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.node.UUID = nodeUUID
+			ironic := testserver.NewIronic(t).Ready().Node(tc.node)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			reasons, err := prov.GetBlockingReasons()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedReasons, reasons)
+		})
+	}
+}
+
+func TestGetBlockingReasonsNotRegistered(t *testing.T) {
+	host := makeHost()
+	host.Status.Provisioning.ID = ""
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		testserver.NewIronic(t).Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	reasons, err := prov.GetBlockingReasons()
+
+	assert.NoError(t, err)
+	assert.Nil(t, reasons)
+}
+
+func TestGetBlockingReasonsReusesNode(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+		UUID:           nodeUUID,
+		ProvisionState: string(nodes.InspectWait),
+	})
+	ironic.Start()
+
+	host := makeHost()
+	host.Status.Provisioning.ID = nodeUUID
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	// The node read earlier in the reconcile, e.g. by the registration,
+	// is enough to report the reasons
+	_, err = prov.getNode()
+	assert.NoError(t, err)
+	ironic.Stop()
+
+	reasons, err := prov.GetBlockingReasons()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []metal3v1alpha1.BlockingReason{
+		{Type: metal3v1alpha1.BlockingReasonInspection, Message: "the node is in the inspect wait state"},
+	}, reasons)
+}
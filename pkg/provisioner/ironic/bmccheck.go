@@ -0,0 +1,149 @@
+package ironic
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// bmcCheckTimeout limits how long the quick BMC check waits for an
+// answer.
+const bmcCheckTimeout = 10 * time.Second
+
+// bmcCheckRequeueDelay is how long to wait before looking for the
+// result of a BMC check running in the background.
+const bmcCheckRequeueDelay = 2 * time.Second
+
+const redfishSystemsPath = "/redfish/v1/Systems"
+
+// bmcCheck is a BMC check running in the background or its result.
+type bmcCheck struct {
+	// identifies the BMC address and credentials checked
+	fingerprint string
+	done        bool
+	// why the BMC rejected the request, empty if it did not
+	errorMessage string
+}
+
+// bmcChecks holds the BMC checks of each host until their result is
+// read.
+var bmcChecks = struct {
+	sync.Mutex
+	byHost map[string]*bmcCheck
+}{byHost: map[string]*bmcCheck{}}
+
+// CheckBMCAccess quickly verifies that the BMC accepts the credentials
+// before the host is registered. Only BMCs using Redfish are checked
+// directly, other BMCs are verified by Ironic during the registration.
+// The check runs in the background so that the reconcile loop is not
+// blocked on the BMC, and the result is returned by a later call.
+// Failing to reach the BMC is not an error, as the BMC may only be
+// reachable from Ironic which then verifies it during the
+// registration.
+func (p *ironicProvisioner) CheckBMCAccess() (result provisioner.Result, err error) {
+	bmcAccess, err := p.bmcAccess()
+	if err != nil {
+		return operationFailed(err.Error())
+	}
+
+	driverInfo := bmcAccess.DriverInfo(p.bmcCreds)
+	address, isRedfish := driverInfo["redfish_address"].(string)
+	if !isRedfish {
+		p.debugLog.Info("BMC access will be verified during registration", "type", bmcAccess.Type())
+		return operationComplete()
+	}
+
+	path, _ := driverInfo["redfish_system_id"].(string)
+	if path == "" {
+		path = redfishSystemsPath
+	}
+	request, err := http.NewRequest(http.MethodGet, address+path, nil)
+	if err != nil {
+		return operationFailed(fmt.Sprintf("invalid BMC address %s: %s", address, err))
+	}
+	request.SetBasicAuth(p.bmcCreds.Username, p.bmcCreds.Password)
+
+	hash := sha256.Sum256([]byte(address + path + "\x00" + p.bmcCreds.Username + "\x00" + p.bmcCreds.Password))
+	fingerprint := hex.EncodeToString(hash[:])
+	host := p.bmcCheckHost()
+
+	bmcChecks.Lock()
+	defer bmcChecks.Unlock()
+
+	check := bmcChecks.byHost[host]
+	if check == nil || check.fingerprint != fingerprint {
+		check = &bmcCheck{fingerprint: fingerprint}
+		bmcChecks.byHost[host] = check
+		p.log.Info("checking BMC access", "address", address)
+		go p.runBMCCheck(check, redfishClient(driverInfo), request, address, path)
+		return operationContinuing(bmcCheckRequeueDelay)
+	}
+	if !check.done {
+		return operationContinuing(bmcCheckRequeueDelay)
+	}
+
+	delete(bmcChecks.byHost, host)
+	if check.errorMessage != "" {
+		return operationFailed(check.errorMessage)
+	}
+	return operationComplete()
+}
+
+// bmcCheckHost identifies the host in bmcChecks.
+func (p *ironicProvisioner) bmcCheckHost() string {
+	return p.objectMeta.Namespace + "/" + p.objectMeta.Name
+}
+
+// forgetBMCCheck drops the check of a host that is deleted before its
+// result is read. A check still running records its result in the
+// dropped entry only.
+func forgetBMCCheck(host string) {
+	bmcChecks.Lock()
+	defer bmcChecks.Unlock()
+	delete(bmcChecks.byHost, host)
+}
+
+// runBMCCheck sends the request of a BMC check and records its result.
+func (p *ironicProvisioner) runBMCCheck(check *bmcCheck, client *http.Client, request *http.Request, address, path string) {
+	var errorMessage string
+	response, err := client.Do(request)
+	if err != nil {
+		p.log.Info("BMC is not reachable, access will be verified during registration",
+			"address", address, "error", err)
+	} else {
+		defer response.Body.Close()
+		switch {
+		case response.StatusCode == http.StatusUnauthorized || response.StatusCode == http.StatusForbidden:
+			errorMessage = fmt.Sprintf("BMC %s rejected the credentials", address)
+		case response.StatusCode == http.StatusNotFound:
+			errorMessage = fmt.Sprintf("BMC %s has no system at %s", address, path)
+		case response.StatusCode >= http.StatusBadRequest:
+			errorMessage = fmt.Sprintf("BMC %s returned an error: %s", address, response.Status)
+		}
+	}
+
+	bmcChecks.Lock()
+	defer bmcChecks.Unlock()
+	check.done = true
+	check.errorMessage = errorMessage
+}
+
+// redfishClient returns an HTTP client for quick requests to the
+// Redfish service of the BMC, honouring its certificate verification
+// setting.
+func redfishClient(driverInfo map[string]interface{}) *http.Client {
+	client := &http.Client{Timeout: bmcCheckTimeout}
+	if verifyCA, hasVerifyCA := driverInfo["redfish_verify_ca"].(bool); hasVerifyCA && !verifyCA {
+		client.Transport = &http.Transport{
+			// #nosec
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}
+	}
+	return client
+}
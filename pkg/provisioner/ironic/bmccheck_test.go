@@ -0,0 +1,135 @@
+package ironic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestCheckBMCAccess(t *testing.T) {
+	redfish := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		switch {
+		case username != "admin" || password != "pa$$w0rd":
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.URL.Path != "/redfish/v1/Systems/1":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte(`{"Id": "1"}`))
+		}
+	}))
+	defer redfish.Close()
+	redfishHost := strings.TrimPrefix(redfish.URL, "http://")
+
+	// Nothing listens on this address once the server is closed
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachableHost := strings.TrimPrefix(unreachable.URL, "http://")
+	unreachable.Close()
+
+	cases := []struct {
+		name          string
+		address       string
+		password      string
+		expectedError string
+	}{
+		{
+			name:     "valid",
+			address:  "redfish+http://" + redfishHost + "/redfish/v1/Systems/1",
+			password: "pa$$w0rd",
+		},
+		{
+			name:          "bad credentials",
+			address:       "redfish+http://" + redfishHost + "/redfish/v1/Systems/1",
+			password:      "wrong",
+			expectedError: "BMC http://" + redfishHost + " rejected the credentials",
+		},
+		{
+			name:          "unknown system",
+			address:       "redfish+http://" + redfishHost + "/redfish/v1/Systems/2",
+			password:      "pa$$w0rd",
+			expectedError: "BMC http://" + redfishHost + " has no system at /redfish/v1/Systems/2",
+		},
+		{
+			name:     "unreachable",
+			address:  "redfish+http://" + unreachableHost + "/redfish/v1/Systems/1",
+			password: "pa$$w0rd",
+		},
+		{
+			name:     "not checked",
+			address:  "ipmi://" + unreachableHost,
+			password: "wrong",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BMC.Address = tc.address
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: tc.password}, nullEventPublisher,
+				testserver.NewIronic(t).Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			// The check runs in the background, poll for its result
+			result, err := prov.CheckBMCAccess()
+			for i := 0; err == nil && result.Dirty && i < 100; i++ {
+				time.Sleep(10 * time.Millisecond)
+				result, err = prov.CheckBMCAccess()
+			}
+
+			assert.NoError(t, err)
+			assert.False(t, result.Dirty)
+			if tc.expectedError == "" {
+				assert.Equal(t, "", result.ErrorMessage)
+			} else {
+				assert.True(t, strings.HasPrefix(result.ErrorMessage, tc.expectedError), result.ErrorMessage)
+			}
+		})
+	}
+}
+
+func TestDeleteForgetsBMCCheck(t *testing.T) {
+	// The BMC does not answer until the test is over
+	release := make(chan struct{})
+	redfish := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer redfish.Close()
+	defer close(release)
+
+	host := makeHost()
+	host.Spec.BMC.Address = "redfish+" + redfish.URL + "/redfish/v1/Systems/1"
+	host.Status.Provisioning.ID = ""
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "pa$$w0rd"}, nullEventPublisher,
+		testserver.NewIronic(t).Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, err := prov.CheckBMCAccess()
+	assert.NoError(t, err)
+	assert.True(t, result.Dirty)
+
+	result, err = prov.Delete()
+	assert.NoError(t, err)
+	assert.False(t, result.Dirty)
+
+	bmcChecks.Lock()
+	defer bmcChecks.Unlock()
+	assert.NotContains(t, bmcChecks.byHost, prov.bmcCheckHost())
+}
@@ -0,0 +1,137 @@
+package ironic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+const redfishManagersPath = "/redfish/v1/Managers"
+
+// ipmiColdResetCommand is the raw IPMI "Cold Reset" command of the
+// application network function (ipmitool mc reset cold)
+const ipmiColdResetCommand = "0x06 0x02"
+
+// redfishResource holds the parts of a Redfish resource needed to find
+// the manager of a system
+type redfishResource struct {
+	Members []struct {
+		ID string `json:"@odata.id"`
+	} `json:"Members"`
+	Links struct {
+		ManagedBy []struct {
+			ID string `json:"@odata.id"`
+		} `json:"ManagedBy"`
+	} `json:"Links"`
+}
+
+// ResetBMC restarts the BMC of the host. Redfish managers are reset
+// directly and IPMI BMCs through the vendor passthru of Ironic, other
+// BMCs are not supported.
+func (p *ironicProvisioner) ResetBMC() (result provisioner.Result, err error) {
+	bmcAccess, err := p.bmcAccess()
+	if err != nil {
+		return operationFailed(err.Error())
+	}
+
+	driverInfo := bmcAccess.DriverInfo(p.bmcCreds)
+	if address, isRedfish := driverInfo["redfish_address"].(string); isRedfish {
+		return p.resetRedfishManager(driverInfo, address)
+	}
+	if bmcAccess.Driver() == "ipmi" {
+		return p.resetIPMIController()
+	}
+	return operationFailed(fmt.Sprintf("resetting the BMC is not supported for %s", bmcAccess.Type()))
+}
+
+// resetRedfishManager requests a graceful restart of the manager of
+// the system
+func (p *ironicProvisioner) resetRedfishManager(driverInfo map[string]interface{}, address string) (result provisioner.Result, err error) {
+	client := redfishClient(driverInfo)
+
+	manager, err := p.findRedfishManager(client, address, driverInfo)
+	if err != nil {
+		return operationFailed(fmt.Sprintf("could not find the manager of BMC %s: %s", address, err))
+	}
+
+	body, _ := json.Marshal(map[string]string{"ResetType": "GracefulRestart"})
+	request, err := http.NewRequest(http.MethodPost, address+manager+"/Actions/Manager.Reset", bytes.NewReader(body))
+	if err != nil {
+		return operationFailed(fmt.Sprintf("invalid BMC address %s: %s", address, err))
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.SetBasicAuth(p.bmcCreds.Username, p.bmcCreds.Password)
+
+	p.log.Info("resetting BMC", "address", address, "manager", manager)
+	response, err := client.Do(request)
+	if err != nil {
+		return transientError(errors.Wrapf(err, "failed to reset BMC %s", address))
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return operationFailed(fmt.Sprintf("BMC %s refused to reset: %s", address, response.Status))
+	}
+	p.publisher("BMCReset", fmt.Sprintf("BMC manager %s was reset", manager))
+	return operationComplete()
+}
+
+// findRedfishManager returns the path of the manager of the system, or
+// of the only manager of the BMC if no system is configured.
+func (p *ironicProvisioner) findRedfishManager(client *http.Client, address string, driverInfo map[string]interface{}) (manager string, err error) {
+	path, _ := driverInfo["redfish_system_id"].(string)
+	if path == "" {
+		path = redfishManagersPath
+	}
+
+	var resource redfishResource
+	if err = p.redfishGet(client, address, path, &resource); err != nil {
+		return
+	}
+	switch {
+	case len(resource.Links.ManagedBy) > 0:
+		manager = resource.Links.ManagedBy[0].ID
+	case path == redfishManagersPath && len(resource.Members) == 1:
+		manager = resource.Members[0].ID
+	default:
+		err = fmt.Errorf("no single manager listed at %s", path)
+	}
+	return
+}
+
+// resetIPMIController sends a cold reset to the BMC through the
+// ipmitool vendor interface of the node
+func (p *ironicProvisioner) resetIPMIController() (result provisioner.Result, err error) {
+	ironicNode, err := p.getNode()
+	if err != nil {
+		return transientError(err)
+	}
+
+	if p.nodeLocked(ironicNode) {
+		return retryAfterDelay(nodeLockedRequeueDelay)
+	}
+
+	p.log.Info("resetting BMC", "command", ipmiColdResetCommand)
+	url := p.client.ServiceURL("nodes", ironicNode.UUID, "vendor_passthru") + "?method=send_raw"
+	_, err = p.client.Post(url, map[string]string{"raw_bytes": ipmiColdResetCommand}, nil,
+		&gophercloud.RequestOpts{OkCodes: []int{200, 202, 204}})
+
+	switch err.(type) {
+	case nil:
+		p.publisher("BMCReset", "BMC was reset")
+		return operationComplete()
+	case gophercloud.ErrDefault409:
+		p.log.Info("host is locked, trying again after delay", "delay", powerRequeueDelay)
+		return retryAfterDelay(powerRequeueDelay)
+	case gophercloud.ErrDefault400:
+		return operationFailed(fmt.Sprintf("could not reset the BMC: %s", err))
+	default:
+		return transientError(errors.Wrap(err, "failed to reset the BMC"))
+	}
+}
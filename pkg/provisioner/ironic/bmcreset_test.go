@@ -0,0 +1,167 @@
+package ironic
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestResetBMCRedfish(t *testing.T) {
+	var resets []string
+	redfish := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"Id": "1", "Links": {"ManagedBy": [{"@odata.id": "/redfish/v1/Managers/bmc1"}]}}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/redfish/v1/Managers":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/redfish/v1/Managers/bmc0"}]}`))
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/Actions/Manager.Reset"):
+			body, _ := ioutil.ReadAll(r.Body)
+			resets = append(resets, r.URL.Path+" "+string(body))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer redfish.Close()
+	redfishHost := strings.TrimPrefix(redfish.URL, "http://")
+
+	cases := []struct {
+		name          string
+		address       string
+		expectedReset string
+		expectedError string
+	}{
+		{
+			name:          "manager of the system",
+			address:       "redfish+http://" + redfishHost + "/redfish/v1/Systems/1",
+			expectedReset: `/redfish/v1/Managers/bmc1/Actions/Manager.Reset {"ResetType":"GracefulRestart"}`,
+		},
+		{
+			name:          "only manager",
+			address:       "redfish+http://" + redfishHost,
+			expectedReset: `/redfish/v1/Managers/bmc0/Actions/Manager.Reset {"ResetType":"GracefulRestart"}`,
+		},
+		{
+			name:          "unknown system",
+			address:       "redfish+http://" + redfishHost + "/redfish/v1/Systems/2",
+			expectedError: "could not find the manager of BMC http://" + redfishHost + ": /redfish/v1/Systems/2 returned 404 Not Found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resets = nil
+			host := makeHost()
+			host.Spec.BMC.Address = tc.address
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "pa$$w0rd"}, nullEventPublisher,
+				testserver.NewIronic(t).Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.ResetBMC()
+
+			assert.NoError(t, err)
+			assert.False(t, result.Dirty)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			if tc.expectedReset == "" {
+				assert.Empty(t, resets)
+			} else {
+				assert.Equal(t, []string{tc.expectedReset}, resets)
+			}
+		})
+	}
+}
+
+func TestResetBMC(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	passthruPath := "/v1/nodes/" + nodeUUID + "/vendor_passthru"
+	cases := []struct {
+		name    string
+		address string
+		node    *nodes.Node
+		code    int
+
+		expectedRequest      string
+		expectedErrorMessage string
+		expectedDirty        bool
+	}{
+		{
+			name:            "ipmi",
+			address:         "ipmi://192.168.122.1:6233",
+			node:            &nodes.Node{UUID: nodeUUID},
+			code:            http.StatusOK,
+			expectedRequest: `{"raw_bytes":"0x06 0x02"}`,
+		},
+		{
+			name:                 "ipmi vendor interface unsupported",
+			address:              "ipmi://192.168.122.1:6233",
+			node:                 &nodes.Node{UUID: nodeUUID},
+			code:                 http.StatusBadRequest,
+			expectedRequest:      `{"raw_bytes":"0x06 0x02"}`,
+			expectedErrorMessage: "could not reset the BMC",
+		},
+		{
+			name:          "ipmi locked host",
+			address:       "ipmi://192.168.122.1:6233",
+			node:          &nodes.Node{UUID: nodeUUID, Reservation: "conductor-1"},
+			expectedDirty: true,
+		},
+		{
+			name:                 "unsupported",
+			address:              "idrac://192.168.122.1",
+			expectedErrorMessage: "resetting the BMC is not supported for idrac",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready()
+			if tc.node != nil {
+				ironic.Node(*tc.node)
+			}
+			if tc.code != 0 {
+				ironic.WithVendorPassthru(nodeUUID, tc.code)
+			}
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.BMC.Address = tc.address
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.ResetBMC()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+			assert.True(t, strings.HasPrefix(result.ErrorMessage, tc.expectedErrorMessage), result.ErrorMessage)
+			if tc.expectedErrorMessage == "" {
+				assert.Equal(t, "", result.ErrorMessage)
+			}
+			body, found := ironic.GetLastRequestFor(passthruPath, http.MethodPost)
+			assert.Equal(t, tc.expectedRequest != "", found)
+			if found {
+				assert.JSONEq(t, tc.expectedRequest, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package ironic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/pkg/errors"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// ensureBootPort creates a PXE enabled port for the boot MAC address of
+// the host, so that the host can boot from the network as soon as it
+// is registered instead of waiting for an inspection to discover its
+// NICs. Nodes that already have ports, e.g. from an inspection, are
+// left alone. Hosts without a boot MAC address get their ports from
+// the inspection. An address used by the port of another node means
+// that two hosts claim the same NIC, which is reported as a conflict
+// instead of registering the host without its boot port.
+func (p *ironicProvisioner) ensureBootPort(ironicNode *nodes.Node) error {
+	if p.bootMACAddress == "" {
+		return nil
+	}
+
+	hasPort, err := p.nodeHasAssignedPort(ironicNode)
+	if err != nil || hasPort {
+		return err
+	}
+
+	owner, err := p.findNodeIDByMAC(p.bootMACAddress)
+	if err != nil {
+		return err
+	}
+	if owner != "" && owner != ironicNode.UUID {
+		p.log.Info("the boot MAC address is used by another node", "MAC", p.bootMACAddress, "node", owner)
+		return NewMacAddressConflictError(p.bootMACAddress, p.nodeDisplayName(owner))
+	}
+	if owner != "" {
+		return nil
+	}
+
+	return p.createPXEEnabledNodePort(ironicNode.UUID, p.bootMACAddress)
+}
+
+// reconcileBootPorts makes the port of the boot MAC address the only
+// PXE enabled port of a host booting over IPv6. The DHCPv6 boot
+// options are served to every PXE enabled port, and unlike with IPv4
+// the firmware of the other NICs would then try booting from them too,
+// e.g. after an inspection enabled PXE on all of them. Hosts booting
+// over IPv4 keep the ports as they are. The ports are only checked
+// once, right before a deploy starts.
+func (p *ironicProvisioner) reconcileBootPorts(ironicNode *nodes.Node) error {
+	if p.bootNetworkIPVersion != metal3v1alpha1.IPv6 || p.bootMACAddress == "" {
+		return nil
+	}
+
+	pager := ports.List(p.client, ports.ListOpts{
+		Fields:   []string{"uuid", "address", "pxe_enabled"},
+		NodeUUID: ironicNode.UUID,
+	})
+	allPages, err := pager.AllPages()
+	if err != nil {
+		return errors.Wrap(err, "failed to list the ports of the node")
+	}
+	nodePorts, err := ports.ExtractPorts(allPages)
+	if err != nil {
+		return errors.Wrap(err, "failed to list the ports of the node")
+	}
+
+	for _, port := range nodePorts {
+		pxeEnabled := strings.EqualFold(port.Address, p.bootMACAddress)
+		if port.PXEEnabled == pxeEnabled {
+			continue
+		}
+		p.log.Info("updating PXE for IPv6 boot", "MAC", port.Address, "pxeEnabled", pxeEnabled)
+		_, err = ports.Update(p.client, port.UUID, ports.UpdateOpts{
+			ports.UpdateOperation{
+				Op:    ports.ReplaceOp,
+				Path:  "/pxe_enabled",
+				Value: pxeEnabled,
+			},
+		}).Extract()
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("failed to update the port of MAC %s", port.Address))
+		}
+	}
+	return nil
+}
+
+// nodeDisplayName returns the name of the node, which is the namespace
+// and name of its host, falling back to its UUID for nodes without a
+// name or that cannot be read.
+func (p *ironicProvisioner) nodeDisplayName(nodeUUID string) string {
+	ironicNode, err := nodes.Get(p.client, nodeUUID).Extract()
+	if err != nil || ironicNode.Name == "" {
+		return nodeUUID
+	}
+	return ironicNode.Name
+}
@@ -0,0 +1,276 @@
+package ironic
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestRegistrationCreatesBootPort(t *testing.T) {
+	host := makeHost()
+	host.Spec.BootMACAddress = "11:11:11:11:11:11"
+	host.Status.Provisioning.ID = ""
+
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(func(nodes.Node) {}).
+		NoNode(host.Namespace + nameSeparator + host.Name).NoNode(host.Name)
+	ironic.AddDefaultResponse("/v1/nodes/node-0", "PATCH", http.StatusOK, "{}")
+	ironic.AddDefaultResponse("/v1/ports", "GET", http.StatusOK, `{"ports": []}`)
+	ironic.AddDefaultResponse("/v1/ports", "POST", http.StatusCreated, "{}")
+	ironic.Start()
+	defer ironic.Stop()
+	inspector := testserver.NewInspector(t)
+	inspector.Start()
+	defer inspector.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, inspector.Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, provID, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "", result.ErrorMessage)
+	body, found := ironic.GetLastRequestFor("/v1/ports", http.MethodPost)
+	if assert.True(t, found) {
+		var port ports.CreateOpts
+		if err := json.Unmarshal([]byte(body), &port); err != nil {
+			t.Fatalf("could not parse the port: %s", err)
+		}
+		assert.Equal(t, provID, port.NodeUUID)
+		assert.Equal(t, "11:11:11:11:11:11", port.Address)
+		if assert.NotNil(t, port.PXEEnabled) {
+			assert.True(t, *port.PXEEnabled)
+		}
+	}
+	// The port is created without inspecting the host
+	assert.Empty(t, inspector.Requests)
+}
+
+func TestEnsureBootPort(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name           string
+		bootMACAddress string
+		existingPort   *ports.Port
+		expectedCreate bool
+	}{
+		{
+			name:           "no port",
+			bootMACAddress: "11:11:11:11:11:11",
+			expectedCreate: true,
+		},
+		{
+			name:           "existing port",
+			bootMACAddress: "11:11:11:11:11:11",
+			existingPort:   &ports.Port{NodeUUID: nodeUUID, Address: "11:11:11:11:11:11"},
+		},
+		{
+			name:           "ports from inspection",
+			bootMACAddress: "11:11:11:11:11:11",
+			existingPort:   &ports.Port{NodeUUID: nodeUUID, Address: "22:22:22:22:22:22"},
+		},
+		{
+			name: "no boot MAC address",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready()
+			if tc.existingPort != nil {
+				ironic.Port(*tc.existingPort)
+			} else {
+				ironic.AddDefaultResponse("/v1/ports", "GET", http.StatusOK, `{"ports": []}`)
+			}
+			ironic.AddDefaultResponse("/v1/ports", "POST", http.StatusCreated, "{}")
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.BootMACAddress = tc.bootMACAddress
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			err = prov.ensureBootPort(&nodes.Node{UUID: nodeUUID})
+
+			assert.NoError(t, err)
+			body, created := ironic.GetLastRequestFor("/v1/ports", http.MethodPost)
+			assert.Equal(t, tc.expectedCreate, created)
+			if tc.expectedCreate {
+				assert.Contains(t, body, tc.bootMACAddress)
+				assert.Contains(t, body, nodeUUID)
+			}
+		})
+	}
+}
+
+func TestValidateManagementAccessDuplicateMAC(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	otherUUID := "e7b2c8a0-1c37-4f1d-9a53-8e6a4f1d2b90"
+
+	cases := []struct {
+		name      string
+		otherName string
+
+		expectedError string
+	}{
+		{
+			name:          "owned by another host",
+			otherName:     "myns" + nameSeparator + "otherhost",
+			expectedError: "MAC address 11:11:11:11:11:11 conflicts with existing node myns" + nameSeparator + "otherhost",
+		},
+		{
+			name:          "owned by a node without a name",
+			expectedError: "MAC address 11:11:11:11:11:11 conflicts with existing node " + otherUUID,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			portCreated := false
+			ironic := testserver.NewIronic(t).Ready().
+				Node(nodes.Node{UUID: nodeUUID, Name: "myns" + nameSeparator + "myhost"}).
+				Node(nodes.Node{UUID: otherUUID, Name: tc.otherName}).
+				NodeUpdate(nodes.Node{UUID: nodeUUID})
+			// The ports of the other node are only listed when asking
+			// for the address, the host itself has none.
+			ironic.Handler("/v1/ports", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if r.Method == http.MethodPost {
+					portCreated = true
+					w.WriteHeader(http.StatusCreated)
+					w.Write([]byte("{}"))
+					return
+				}
+				response := `{"ports": []}`
+				if r.URL.Query().Get("address") == "11:11:11:11:11:11" {
+					response = `{"ports": [{"node_uuid": "` + otherUUID + `", "address": "11:11:11:11:11:11"}]}`
+				}
+				w.Write([]byte(response))
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.BootMACAddress = "11:11:11:11:11:11"
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			assert.False(t, portCreated)
+		})
+	}
+}
+
+func TestReconcileBootPortsIPv6(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	bootPort := ports.Port{UUID: "boot-port", NodeUUID: nodeUUID, Address: "11:11:11:11:11:11"}
+	otherPort := ports.Port{UUID: "other-port", NodeUUID: nodeUUID, Address: "22:22:22:22:22:22"}
+
+	cases := []struct {
+		name      string
+		ipVersion metal3v1alpha1.IPVersion
+		bootPXE   bool
+		otherPXE  bool
+
+		expectedBootUpdate  bool
+		expectedOtherUpdate bool
+	}{
+		{
+			name:                "ipv6 after inspection",
+			ipVersion:           metal3v1alpha1.IPv6,
+			otherPXE:            true,
+			expectedBootUpdate:  true,
+			expectedOtherUpdate: true,
+		},
+		{
+			name:      "ipv6 aligned",
+			ipVersion: metal3v1alpha1.IPv6,
+			bootPXE:   true,
+		},
+		{
+			name:     "ipv4",
+			otherPXE: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			boot, other := bootPort, otherPort
+			boot.PXEEnabled = tc.bootPXE
+			other.PXEEnabled = tc.otherPXE
+			ironic := testserver.NewIronic(t).Ready()
+			ironic.ResponseJSON("/v1/ports", map[string][]ports.Port{"ports": {boot, other}})
+			ironic.AddDefaultResponse("/v1/ports/boot-port", http.MethodPatch, http.StatusOK, "{}")
+			ironic.AddDefaultResponse("/v1/ports/other-port", http.MethodPatch, http.StatusOK, "{}")
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.BootMACAddress = "11:11:11:11:11:11"
+			host.Spec.BootNetworkIPVersion = tc.ipVersion
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			err = prov.reconcileBootPorts(&nodes.Node{UUID: nodeUUID})
+
+			assert.NoError(t, err)
+			for _, check := range []struct {
+				path     string
+				expected bool
+				value    bool
+			}{
+				{"/v1/ports/boot-port", tc.expectedBootUpdate, true},
+				{"/v1/ports/other-port", tc.expectedOtherUpdate, false},
+			} {
+				body, updated := ironic.GetLastRequestFor(check.path, http.MethodPatch)
+				assert.Equal(t, check.expected, updated, check.path)
+				if updated {
+					var patch []ports.UpdateOperation
+					if err := json.Unmarshal([]byte(body), &patch); err != nil {
+						t.Fatalf("could not parse the port update: %s", err)
+					}
+					assert.Equal(t, []ports.UpdateOperation{
+						{Op: ports.ReplaceOp, Path: "/pxe_enabled", Value: check.value},
+					}, patch)
+				}
+			}
+			_, created := ironic.GetLastRequestFor("/v1/ports", http.MethodPost)
+			assert.False(t, created)
+		})
+	}
+}
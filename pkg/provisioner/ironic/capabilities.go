@@ -0,0 +1,134 @@
+package ironic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// capabilitiesExtraKey is the key of the node's extra field recording
+// the capabilities that were set from the host, so that they can be
+// removed from the node once the host stops setting them.
+const capabilitiesExtraKey = "metal3_capabilities"
+
+// managedCapabilities are the capabilities set from the boot mode of
+// the host, which cannot be set directly
+var managedCapabilities = map[string]bool{
+	"boot_mode":   true,
+	"secure_boot": true,
+}
+
+// capabilityKey returns the key of a capabilities item
+func capabilityKey(item string) string {
+	return strings.SplitN(item, ":", 2)[0]
+}
+
+// validateCapabilities checks that the capabilities of the host are a
+// comma separated list of key:value items that do not conflict with
+// the ones set from the boot mode.
+func validateCapabilities(value string) error {
+	if value == "" {
+		return nil
+	}
+	seen := map[string]bool{}
+	for _, item := range strings.Split(value, ",") {
+		parts := strings.SplitN(item, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return fmt.Errorf("invalid capability %q, expected key:value", item)
+		}
+		if managedCapabilities[parts[0]] {
+			return fmt.Errorf("capability %s is set from the boot mode of the host", parts[0])
+		}
+		if seen[parts[0]] {
+			return fmt.Errorf("capability %s is listed more than once", parts[0])
+		}
+		seen[parts[0]] = true
+	}
+	return nil
+}
+
+// validateInstanceCapabilities checks the instance capabilities of the
+// host, which cannot replace the ones set from the boot mode.
+func validateInstanceCapabilities(capabilities map[string]string) error {
+	for key := range capabilities {
+		if strings.TrimSpace(key) == "" || strings.ContainsAny(key, ":,") {
+			return fmt.Errorf("invalid instance capability %q, keys must not be empty or contain ':' or ','", key)
+		}
+		if managedCapabilities[key] {
+			return fmt.Errorf("instance capability %s is set from the boot mode of the host", key)
+		}
+	}
+	return nil
+}
+
+// mergeCapabilities adds the capabilities of the host to an existing
+// capabilities value. The items of the host replace the existing ones
+// with the same key, and the items previously set from the host that
+// it does not set anymore are removed. Other items, e.g. reported by
+// inspection, are kept, and the ones set from the boot mode are never
+// replaced.
+func mergeCapabilities(existing, custom, previous string) string {
+	replaced := map[string]bool{}
+	for _, value := range []string{custom, previous} {
+		if value == "" {
+			continue
+		}
+		for _, item := range strings.Split(value, ",") {
+			replaced[capabilityKey(item)] = true
+		}
+	}
+
+	var merged []string
+	if existing != "" {
+		for _, item := range strings.Split(existing, ",") {
+			if !replaced[capabilityKey(item)] || managedCapabilities[capabilityKey(item)] {
+				merged = append(merged, item)
+			}
+		}
+	}
+	if custom != "" {
+		for _, item := range strings.Split(custom, ",") {
+			if !managedCapabilities[capabilityKey(item)] {
+				merged = append(merged, item)
+			}
+		}
+	}
+	return strings.Join(merged, ",")
+}
+
+// setCapabilitiesUpdateOpts updates the capabilities of the node from
+// the boot mode and the capabilities of the host. The boot mode is
+// re-applied when the capabilities of the node drifted from it, e.g.
+// when inspection reports another boot mode after BIOS settings were
+// changed. The node is only corrected in stable states.
+func (p *ironicProvisioner) setCapabilitiesUpdateOpts(ironicNode *nodes.Node, bootMode metal3v1alpha1.BootMode, custom string, updater *nodeUpdater) {
+	switch nodes.ProvisionState(ironicNode.ProvisionState) {
+	case nodes.Manageable, nodes.Available, nodes.Active:
+	default:
+		return
+	}
+
+	existing, _ := ironicNode.Properties["capabilities"].(string)
+	value := existing
+	if bootMode != "" && bootModeDrifted(ironicNode, bootMode) {
+		p.log.Info("correcting boot mode capability",
+			"capabilities", ironicNode.Properties["capabilities"],
+			"bootMode", bootMode)
+		value = buildCapabilitiesValue(ironicNode, bootMode)
+	}
+	previous, _ := ironicNode.Extra[capabilitiesExtraKey].(string)
+	value = mergeCapabilities(value, custom, previous)
+
+	if value != existing {
+		updater.SetPropertiesOpts(optionsData{"capabilities": value}, ironicNode)
+	}
+
+	settings := optionsData{capabilitiesExtraKey: nil}
+	if custom != "" {
+		settings[capabilitiesExtraKey] = custom
+	}
+	updater.SetExtraOpts(settings, ironicNode)
+}
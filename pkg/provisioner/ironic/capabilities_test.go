@@ -121,3 +121,151 @@ func TestBuildCapabilitiesValue(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateCapabilities(t *testing.T) {
+	cases := []struct {
+		Scenario      string
+		Value         string
+		ExpectedError string
+	}{
+		{
+			Scenario: "empty",
+		},
+		{
+			Scenario: "valid",
+			Value:    "cpu_vt:true,hugepages_1G:true",
+		},
+		{
+			Scenario:      "missing value",
+			Value:         "cpu_vt:true,hugepages_1G",
+			ExpectedError: "invalid capability \"hugepages_1G\", expected key:value",
+		},
+		{
+			Scenario:      "empty item",
+			Value:         "cpu_vt:true,",
+			ExpectedError: "invalid capability \"\", expected key:value",
+		},
+		{
+			Scenario:      "managed",
+			Value:         "cpu_vt:true,boot_mode:bios",
+			ExpectedError: "capability boot_mode is set from the boot mode of the host",
+		},
+		{
+			Scenario:      "duplicate",
+			Value:         "cpu_vt:true,cpu_vt:false",
+			ExpectedError: "capability cpu_vt is listed more than once",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			err := validateCapabilities(tc.Value)
+			if tc.ExpectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestMergeCapabilities(t *testing.T) {
+	cases := []struct {
+		Scenario      string
+		Existing      string
+		Custom        string
+		Previous      string
+		ExpectedValue string
+	}{
+		{
+			Scenario:      "no custom capabilities",
+			Existing:      "cpu_aes:true,boot_mode:uefi",
+			ExpectedValue: "cpu_aes:true,boot_mode:uefi",
+		},
+		{
+			Scenario:      "added",
+			Existing:      "cpu_aes:true,boot_mode:uefi",
+			Custom:        "cpu_vt:true,hugepages_1G:true",
+			ExpectedValue: "cpu_aes:true,boot_mode:uefi,cpu_vt:true,hugepages_1G:true",
+		},
+		{
+			Scenario:      "no existing capabilities",
+			Custom:        "cpu_vt:true",
+			ExpectedValue: "cpu_vt:true",
+		},
+		{
+			Scenario:      "inspected value replaced",
+			Existing:      "cpu_vt:false,boot_mode:uefi",
+			Custom:        "cpu_vt:true",
+			ExpectedValue: "boot_mode:uefi,cpu_vt:true",
+		},
+		{
+			Scenario:      "unchanged",
+			Existing:      "boot_mode:uefi,cpu_vt:true",
+			Custom:        "cpu_vt:true",
+			Previous:      "cpu_vt:true",
+			ExpectedValue: "boot_mode:uefi,cpu_vt:true",
+		},
+		{
+			Scenario:      "previous capability removed",
+			Existing:      "boot_mode:uefi,cpu_vt:true,hugepages_1G:true",
+			Custom:        "cpu_vt:true",
+			Previous:      "cpu_vt:true,hugepages_1G:true",
+			ExpectedValue: "boot_mode:uefi,cpu_vt:true",
+		},
+		{
+			Scenario:      "managed capabilities kept",
+			Existing:      "boot_mode:uefi,secure_boot:true",
+			Custom:        "boot_mode:bios",
+			ExpectedValue: "boot_mode:uefi,secure_boot:true",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			assert.Equal(t, tc.ExpectedValue, mergeCapabilities(tc.Existing, tc.Custom, tc.Previous))
+		})
+	}
+}
+
+func TestValidateInstanceCapabilities(t *testing.T) {
+	cases := []struct {
+		Scenario      string
+		Capabilities  map[string]string
+		ExpectedError string
+	}{
+		{
+			Scenario: "none",
+		},
+		{
+			Scenario:     "valid",
+			Capabilities: map[string]string{"hugepages": "1G", "cpu_pinning": "true"},
+		},
+		{
+			Scenario:      "empty key",
+			Capabilities:  map[string]string{"": "1G"},
+			ExpectedError: "invalid instance capability \"\", keys must not be empty or contain ':' or ','",
+		},
+		{
+			Scenario:      "separator in key",
+			Capabilities:  map[string]string{"hugepages:1G": "true"},
+			ExpectedError: "invalid instance capability \"hugepages:1G\", keys must not be empty or contain ':' or ','",
+		},
+		{
+			Scenario:      "managed",
+			Capabilities:  map[string]string{"secure_boot": "false"},
+			ExpectedError: "instance capability secure_boot is set from the boot mode of the host",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			err := validateInstanceCapabilities(tc.Capabilities)
+			if tc.ExpectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
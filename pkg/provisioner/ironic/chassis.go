@@ -0,0 +1,113 @@
+package ironic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// redfishChassis holds the parts of a Redfish Chassis resource
+// describing the enclosure of a system
+type redfishChassis struct {
+	Manufacturer string `json:"Manufacturer"`
+	Model        string `json:"Model"`
+	SerialNumber string `json:"SerialNumber"`
+	Location     struct {
+		PartLocation struct {
+			ServiceLabel         string `json:"ServiceLabel"`
+			LocationOrdinalValue *int   `json:"LocationOrdinalValue"`
+		} `json:"PartLocation"`
+		Placement struct {
+			Rack string `json:"Rack"`
+		} `json:"Placement"`
+	} `json:"Location"`
+}
+
+// redfishSystemChassis holds the links of a Redfish System to the
+// chassis containing it
+type redfishSystemChassis struct {
+	Links struct {
+		Chassis []struct {
+			ID string `json:"@odata.id"`
+		} `json:"Chassis"`
+	} `json:"Links"`
+}
+
+// toChassis converts the Redfish chassis, preferring the label of the
+// slot to its number
+func (c *redfishChassis) toChassis() *metal3v1alpha1.Chassis {
+	slot := c.Location.PartLocation.ServiceLabel
+	if slot == "" && c.Location.PartLocation.LocationOrdinalValue != nil {
+		slot = strconv.Itoa(*c.Location.PartLocation.LocationOrdinalValue)
+	}
+	return &metal3v1alpha1.Chassis{
+		Manufacturer: c.Manufacturer,
+		Model:        c.Model,
+		SerialNumber: c.SerialNumber,
+		Slot:         slot,
+		Rack:         c.Location.Placement.Rack,
+	}
+}
+
+// redfishGet reads a resource of the Redfish service of the BMC
+func (p *ironicProvisioner) redfishGet(client *http.Client, address, path string, resource interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, address+path, nil)
+	if err != nil {
+		return err
+	}
+	request.SetBasicAuth(p.bmcCreds.Username, p.bmcCreds.Password)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("%s returned %s", path, response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(resource)
+}
+
+// getChassis returns the chassis containing the system of the host. It
+// is only known for BMCs using Redfish with a system configured, nil
+// is returned for the others and for systems not linked to a chassis.
+func (p *ironicProvisioner) getChassis() (*metal3v1alpha1.Chassis, error) {
+	bmcAccess, err := p.bmcAccess()
+	if err != nil {
+		return nil, err
+	}
+	driverInfo := bmcAccess.DriverInfo(p.bmcCreds)
+	address, isRedfish := driverInfo["redfish_address"].(string)
+	path, _ := driverInfo["redfish_system_id"].(string)
+	if !isRedfish || path == "" {
+		return nil, nil
+	}
+	client := redfishClient(driverInfo)
+
+	chassisPath, err := p.getChassisPath(client, address, path)
+	if err != nil || chassisPath == "" {
+		return nil, err
+	}
+
+	var chassis redfishChassis
+	if err := p.redfishGet(client, address, chassisPath, &chassis); err != nil {
+		return nil, err
+	}
+	return chassis.toChassis(), nil
+}
+
+// getChassisPath returns the path of the chassis containing the
+// Redfish system, or an empty string if it is not linked to one.
+func (p *ironicProvisioner) getChassisPath(client *http.Client, address, systemPath string) (string, error) {
+	var system redfishSystemChassis
+	if err := p.redfishGet(client, address, systemPath, &system); err != nil {
+		return "", err
+	}
+	if len(system.Links.Chassis) == 0 {
+		return "", nil
+	}
+	return system.Links.Chassis[0].ID, nil
+}
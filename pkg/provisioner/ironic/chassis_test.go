@@ -0,0 +1,121 @@
+package ironic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+const sampleRedfishChassis = `{
+	"@odata.id": "/redfish/v1/Chassis/Blade1",
+	"Id": "Blade1",
+	"ChassisType": "Blade",
+	"Manufacturer": "Contoso",
+	"Model": "3500 Blade",
+	"SerialNumber": "2M220100SL",
+	"Location": {
+		"PartLocation": {
+			"ServiceLabel": "Slot 3",
+			"LocationType": "Slot",
+			"LocationOrdinalValue": 3
+		},
+		"Placement": {
+			"Rack": "WEB43",
+			"RackOffset": 12
+		}
+	}
+}`
+
+func TestGetChassis(t *testing.T) {
+	redfish := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"Id": "1", "Links": {"Chassis": [{"@odata.id": "/redfish/v1/Chassis/Blade1"}]}}`))
+		case "/redfish/v1/Systems/2":
+			w.Write([]byte(`{"Id": "2", "Links": {"Chassis": [{"@odata.id": "/redfish/v1/Chassis/Blade2"}]}}`))
+		case "/redfish/v1/Systems/3":
+			w.Write([]byte(`{"Id": "3", "Links": {}}`))
+		case "/redfish/v1/Chassis/Blade1":
+			w.Write([]byte(sampleRedfishChassis))
+		case "/redfish/v1/Chassis/Blade2":
+			w.Write([]byte(`{"Id": "Blade2", "Model": "3500 Blade", "Location": {"PartLocation": {"LocationOrdinalValue": 4}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer redfish.Close()
+	redfishHost := strings.TrimPrefix(redfish.URL, "http://")
+
+	cases := []struct {
+		name            string
+		address         string
+		expectedChassis *metal3v1alpha1.Chassis
+		expectedError   string
+	}{
+		{
+			name:    "chassis",
+			address: "redfish+http://" + redfishHost + "/redfish/v1/Systems/1",
+			expectedChassis: &metal3v1alpha1.Chassis{
+				Manufacturer: "Contoso",
+				Model:        "3500 Blade",
+				SerialNumber: "2M220100SL",
+				Slot:         "Slot 3",
+				Rack:         "WEB43",
+			},
+		},
+		{
+			name:            "slot number only",
+			address:         "redfish+http://" + redfishHost + "/redfish/v1/Systems/2",
+			expectedChassis: &metal3v1alpha1.Chassis{Model: "3500 Blade", Slot: "4"},
+		},
+		{
+			name:    "no chassis",
+			address: "redfish+http://" + redfishHost + "/redfish/v1/Systems/3",
+		},
+		{
+			name:    "no system",
+			address: "redfish+http://" + redfishHost,
+		},
+		{
+			name:          "unknown system",
+			address:       "redfish+http://" + redfishHost + "/redfish/v1/Systems/4",
+			expectedError: "/redfish/v1/Systems/4 returned 404 Not Found",
+		},
+		{
+			name:    "not redfish",
+			address: "ipmi://192.168.122.1:6233",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BMC.Address = tc.address
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "pa$$w0rd"}, nullEventPublisher,
+				testserver.NewIronic(t).Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			chassis, err := prov.getChassis()
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedChassis, chassis)
+		})
+	}
+}
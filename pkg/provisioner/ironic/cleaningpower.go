@@ -0,0 +1,86 @@
+package ironic
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// cleaningPowerOffTimeout bounds the time spent waiting for a node to
+// report being powered off before its manual cleaning is started.
+const cleaningPowerOffTimeout = time.Minute * 2
+
+// powerOffWaits holds when the nodes started waiting to report being
+// powered off before their manual cleaning. Like the other in-process
+// state, it is lost on restart, which only restarts the wait.
+var powerOffWaits = struct {
+	sync.Mutex
+	started map[string]time.Time
+}{started: map[string]time.Time{}}
+
+// powerOffWaitExpired records the node waiting to be powered off and
+// returns whether it has waited longer than cleaningPowerOffTimeout.
+func powerOffWaitExpired(nodeUUID string, now time.Time) bool {
+	powerOffWaits.Lock()
+	defer powerOffWaits.Unlock()
+	started, ok := powerOffWaits.started[nodeUUID]
+	if !ok {
+		powerOffWaits.started[nodeUUID] = now
+		return false
+	}
+	return now.Sub(started) >= cleaningPowerOffTimeout
+}
+
+// forgetPowerOffWait stops tracking the wait of a node.
+func forgetPowerOffWait(nodeUUID string) {
+	powerOffWaits.Lock()
+	defer powerOffWaits.Unlock()
+	delete(powerOffWaits.started, nodeUUID)
+}
+
+// waitForPowerOff checks that the node reports power_state=off before
+// its manual cleaning starts, since cleaning a node that is still
+// powering down may fail, and powers it off if nothing else is doing
+// so. The node is polled again after a short delay until then, for at
+// most cleaningPowerOffTimeout, after which the cleaning is started
+// anyway. Nodes with an unknown power state are not waited for.
+// Deprovisioning does not need the check, Ironic powers the node off
+// itself before cleaning it.
+func (p *ironicProvisioner) waitForPowerOff(ironicNode *nodes.Node) (wait bool, result provisioner.Result, err error) {
+	if ironicNode.PowerState == powerOff || ironicNode.PowerState == "" {
+		forgetPowerOffWait(ironicNode.UUID)
+		return false, result, nil
+	}
+	if powerOffWaitExpired(ironicNode.UUID, time.Now()) {
+		p.log.Info("host still not powered off, cleaning anyway",
+			"power state", ironicNode.PowerState,
+			"timeout", cleaningPowerOffTimeout)
+		forgetPowerOffWait(ironicNode.UUID)
+		return false, result, nil
+	}
+
+	switch ironicNode.TargetPowerState {
+	case powerOff, softPowerOff:
+		p.log.Info("waiting for the host to power off before cleaning",
+			"power state", ironicNode.PowerState,
+			"target power state", ironicNode.TargetPowerState)
+		result, err = operationContinuing(powerRequeueDelay)
+		return true, result, err
+	}
+
+	p.log.Info("powering off the host before cleaning", "power state", ironicNode.PowerState)
+	result, err = p.changePower(ironicNode, nodes.PowerOff)
+	switch err.(type) {
+	case nil:
+		result, err = operationContinuing(powerRequeueDelay)
+	case HostLockedError:
+		err = nil
+	default:
+		result, err = transientError(errors.Wrap(err, "failed to power off host before cleaning"))
+	}
+	return true, result, err
+}
@@ -0,0 +1,153 @@
+package ironic
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestPowerOffWaitExpired(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	defer forgetPowerOffWait(nodeUUID)
+	now := time.Now()
+
+	assert.False(t, powerOffWaitExpired(nodeUUID, now))
+	assert.False(t, powerOffWaitExpired(nodeUUID, now.Add(cleaningPowerOffTimeout-time.Second)))
+	assert.True(t, powerOffWaitExpired(nodeUUID, now.Add(cleaningPowerOffTimeout)))
+
+	forgetPowerOffWait(nodeUUID)
+	assert.False(t, powerOffWaitExpired(nodeUUID, now.Add(cleaningPowerOffTimeout)))
+}
+
+func TestCleaningWaitsForPowerOff(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name           string
+		provisionState nodes.ProvisionState
+		powerState     string
+		targetState    string
+		waitExpired    bool
+		waitStarted    bool
+
+		expectedCleaning bool
+		expectedPowerOff bool
+	}{
+		{
+			name:           "prepare while powering off",
+			provisionState: nodes.Manageable,
+			powerState:     powerOn,
+			targetState:    powerOff,
+		},
+		{
+			name:             "prepare once powered off",
+			provisionState:   nodes.Manageable,
+			powerState:       powerOff,
+			expectedCleaning: true,
+		},
+		{
+			name:             "prepare while powered on",
+			provisionState:   nodes.Manageable,
+			powerState:       powerOn,
+			expectedPowerOff: true,
+		},
+		{
+			name:             "prepare with unknown power state",
+			provisionState:   nodes.Manageable,
+			expectedCleaning: true,
+		},
+		{
+			name:             "prepare after the wait expired",
+			provisionState:   nodes.Manageable,
+			powerState:       powerOn,
+			targetState:      powerOff,
+			waitExpired:      true,
+			expectedCleaning: true,
+		},
+		{
+			name:             "deprovision while powering off",
+			provisionState:   nodes.Active,
+			powerState:       powerOn,
+			targetState:      softPowerOff,
+			expectedCleaning: true,
+		},
+		{
+			name:             "deprovision during the wait",
+			provisionState:   nodes.Active,
+			powerState:       powerOn,
+			targetState:      powerOff,
+			waitStarted:      true,
+			expectedCleaning: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer forgetPowerOffWait(nodeUUID)
+			if tc.waitExpired {
+				powerOffWaitExpired(nodeUUID, time.Now().Add(-cleaningPowerOffTimeout))
+			}
+			if tc.waitStarted {
+				powerOffWaitExpired(nodeUUID, time.Now())
+			}
+
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState:   string(tc.provisionState),
+				PowerState:       tc.powerState,
+				TargetPowerState: tc.targetState,
+				UUID:             nodeUUID,
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.BMC.Address = "irmc://test.bmc/"
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			var result provisioner.Result
+			if tc.provisionState == nodes.Manageable {
+				var started bool
+				result, started, err = prov.Prepare(provisioner.PrepareData{
+					RAIDConfig: &metal3v1alpha1.RAIDConfig{
+						HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{{Name: "root", Level: "1"}},
+					},
+				}, true)
+				assert.Equal(t, tc.expectedCleaning, started)
+			} else {
+				result, err = prov.Deprovision(false)
+				powerOffWaits.Lock()
+				assert.NotContains(t, powerOffWaits.started, nodeUUID)
+				powerOffWaits.Unlock()
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, result.Dirty)
+			_, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			assert.Equal(t, tc.expectedCleaning, found)
+			body, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/power", http.MethodPut)
+			assert.Equal(t, tc.expectedPowerOff, found)
+			if tc.expectedPowerOff {
+				assert.Contains(t, body, powerOff)
+			}
+			if !tc.expectedCleaning {
+				assert.Equal(t, powerRequeueDelay, result.RequeueAfter)
+			}
+		})
+	}
+}
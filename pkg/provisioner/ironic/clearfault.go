@@ -0,0 +1,48 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// ClearFault takes the node out of the maintenance mode Ironic puts it
+// in when it detects a fault, e.g. when the BMC stops responding, and
+// moves a failed node back to a state from which the failed operation
+// can be retried: inspection and cleaning failures go back to
+// manageable, while failed deploys and tear downs are cleaned again.
+func (p *ironicProvisioner) ClearFault() (result provisioner.Result, err error) {
+	ironicNode, err := p.getNode()
+	if err != nil {
+		return transientError(err)
+	}
+
+	if ironicNode.Maintenance && ironicNode.Fault != "" {
+		p.log.Info("clearing maintenance flag", "fault", ironicNode.Fault,
+			"reason", ironicNode.MaintenanceReason)
+		p.publisher("FaultCleared", fmt.Sprintf("Fault %s cleared", ironicNode.Fault))
+		return p.setMaintenanceFlag(ironicNode, false)
+	}
+
+	switch nodes.ProvisionState(ironicNode.ProvisionState) {
+	case nodes.InspectFail, nodes.CleanFail:
+		if ironicNode.Maintenance {
+			return p.clearMaintenanceFlag(ironicNode)
+		}
+		p.log.Info("retrying after failure", "state", ironicNode.ProvisionState,
+			"lastError", ironicNode.LastError)
+		return p.changeNodeProvisionState(ironicNode,
+			nodes.ProvisionStateOpts{Target: nodes.TargetManage})
+
+	case nodes.DeployFail, nodes.Error:
+		p.log.Info("retrying after failure", "state", ironicNode.ProvisionState,
+			"lastError", ironicNode.LastError)
+		return p.changeNodeProvisionState(ironicNode,
+			nodes.ProvisionStateOpts{Target: nodes.TargetDeleted})
+
+	default:
+		return operationComplete()
+	}
+}
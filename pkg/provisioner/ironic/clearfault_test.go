@@ -0,0 +1,101 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestClearFault(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name string
+		node nodes.Node
+
+		expectedTargets []string
+		expectedUpdates []nodes.UpdateOperation
+		expectedDirty   bool
+		expectedError   string
+	}{
+		{
+			name: "power failure",
+			node: nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.Active),
+				Maintenance: true, Fault: "power failure"},
+			expectedUpdates: []nodes.UpdateOperation{{Op: nodes.AddOp, Path: "/maintenance", Value: false}},
+			expectedDirty:   true,
+		},
+		{
+			name:            "inspection failed",
+			node:            nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.InspectFail)},
+			expectedTargets: []string{"manage"},
+			expectedDirty:   true,
+		},
+		{
+			name:            "cleaning failed",
+			node:            nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.CleanFail)},
+			expectedTargets: []string{"manage"},
+			expectedDirty:   true,
+		},
+		{
+			name:            "cleaning failed in maintenance",
+			node:            nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.CleanFail), Maintenance: true},
+			expectedUpdates: []nodes.UpdateOperation{{Op: nodes.AddOp, Path: "/maintenance", Value: false}},
+			expectedDirty:   true,
+		},
+		{
+			name: "cleaning failed in maintenance because of a hardware fault",
+			node: nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.CleanFail), Maintenance: true,
+				MaintenanceReason: "fan failure"},
+			expectedError: "host in maintenance because of a hardware fault: fan failure",
+		},
+		{
+			name:            "deploy failed",
+			node:            nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.DeployFail)},
+			expectedTargets: []string{"deleted"},
+			expectedDirty:   true,
+		},
+		{
+			name:            "tear down failed",
+			node:            nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.Error)},
+			expectedTargets: []string{"deleted"},
+			expectedDirty:   true,
+		},
+		{
+			name: "no fault",
+			node: nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.Manageable)},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var targets []string
+			ironic := testserver.NewIronic(t).Ready().Node(tc.node).NodeUpdate(nodes.Node{UUID: nodeUUID})
+			ironic.Handler("/v1/nodes/"+nodeUUID+"/states/provision", provisionStateHandler(t, "", &targets))
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.ClearFault()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+			assert.Equal(t, tc.expectedTargets, targets)
+			assert.Equal(t, tc.expectedUpdates, ironic.GetLastNodeUpdateRequestFor(nodeUUID))
+		})
+	}
+}
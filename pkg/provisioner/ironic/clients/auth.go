@@ -80,6 +80,12 @@ func LoadAuth() (ironicAuth, inspectorAuth AuthConfig, err error) {
 	return
 }
 
+// LoadInspectionDataAuth loads the configuration of the external
+// storage of the inspection data from the environment
+func LoadInspectionDataAuth() (AuthConfig, error) {
+	return load("inspection-data")
+}
+
 // ConfigFromEndpointURL returns an endpoint and an auth config from an
 // endpoint URL that may contain HTTP basic auth credentials.
 func ConfigFromEndpointURL(endpointURL string) (endpoint string, auth AuthConfig, err error) {
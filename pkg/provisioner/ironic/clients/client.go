@@ -16,6 +16,49 @@ import (
 
 var tlsConnectionTimeout = time.Second * 30
 
+const (
+	// DefaultRequestTimeout limits the requests to the Ironic services
+	// when no timeout is configured
+	DefaultRequestTimeout = time.Second * 60
+	// DefaultMaxIdleConnsPerHost is the number of kept-alive
+	// connections to each Ironic service when none is configured,
+	// higher than the Go default of 2 so that concurrent reconciles
+	// reuse connections.
+	DefaultMaxIdleConnsPerHost = 10
+	// DefaultIdleConnTimeout closes the kept-alive connections unused
+	// for this long when no timeout is configured
+	DefaultIdleConnTimeout = time.Second * 90
+)
+
+// ConnectionConfig contains the HTTP settings of the connections to
+// the Ironic services. Zero values use the defaults.
+type ConnectionConfig struct {
+	// RequestTimeout limits the duration of each request, including
+	// reading the response.
+	RequestTimeout time.Duration
+	// MaxIdleConnsPerHost is the size of the pool of kept-alive
+	// connections to each service.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes the kept-alive connections unused for
+	// this long.
+	IdleConnTimeout time.Duration
+}
+
+// withDefaults returns the configuration with the unset values
+// replaced by the defaults
+func (conf ConnectionConfig) withDefaults() ConnectionConfig {
+	if conf.RequestTimeout <= 0 {
+		conf.RequestTimeout = DefaultRequestTimeout
+	}
+	if conf.MaxIdleConnsPerHost <= 0 {
+		conf.MaxIdleConnsPerHost = DefaultMaxIdleConnsPerHost
+	}
+	if conf.IdleConnTimeout <= 0 {
+		conf.IdleConnTimeout = DefaultIdleConnTimeout
+	}
+	return conf
+}
+
 // TLSConfig contains the TLS configuration for the Ironic connection.
 // Using Go default values for this will result in no additional trusted
 // CA certificates and a secure connection.
@@ -29,7 +72,9 @@ type TLSConfig struct {
 	SkipClientSANVerify   bool
 }
 
-func updateHTTPClient(client *gophercloud.ServiceClient, tlsConf TLSConfig) (*gophercloud.ServiceClient, error) {
+// HTTPClient creates an HTTP client with the TLS and connection
+// settings, for the services other than Ironic the operator uses.
+func HTTPClient(tlsConf TLSConfig, connConf ConnectionConfig) (*http.Client, error) {
 	tlsInfo := transport.TLSInfo{
 		TrustedCAFile:       tlsConf.TrustedCAFile,
 		CertFile:            tlsConf.ClientCertificateFile,
@@ -41,21 +86,21 @@ func updateHTTPClient(client *gophercloud.ServiceClient, tlsConf TLSConfig) (*go
 		if os.IsNotExist(err) {
 			tlsInfo.TrustedCAFile = ""
 		} else {
-			return client, err
+			return nil, err
 		}
 	}
 	if _, err := os.Stat(tlsConf.ClientCertificateFile); err != nil {
 		if os.IsNotExist(err) {
 			tlsInfo.CertFile = ""
 		} else {
-			return client, err
+			return nil, err
 		}
 	}
 	if _, err := os.Stat(tlsConf.ClientPrivateKeyFile); err != nil {
 		if os.IsNotExist(err) {
 			tlsInfo.KeyFile = ""
 		} else {
-			return client, err
+			return nil, err
 		}
 	}
 	if tlsInfo.CertFile != "" && tlsInfo.KeyFile != "" {
@@ -64,17 +109,28 @@ func updateHTTPClient(client *gophercloud.ServiceClient, tlsConf TLSConfig) (*go
 
 	tlsTransport, err := transport.NewTransport(tlsInfo, tlsConnectionTimeout)
 	if err != nil {
-		return client, err
+		return nil, err
 	}
-	c := http.Client{
+	connConf = connConf.withDefaults()
+	tlsTransport.MaxIdleConnsPerHost = connConf.MaxIdleConnsPerHost
+	tlsTransport.IdleConnTimeout = connConf.IdleConnTimeout
+	return &http.Client{
 		Transport: tlsTransport,
+		Timeout:   connConf.RequestTimeout,
+	}, nil
+}
+
+func updateHTTPClient(client *gophercloud.ServiceClient, tlsConf TLSConfig, connConf ConnectionConfig) (*gophercloud.ServiceClient, error) {
+	c, err := HTTPClient(tlsConf, connConf)
+	if err != nil {
+		return client, err
 	}
-	client.HTTPClient = c
+	client.HTTPClient = *c
 	return client, nil
 }
 
 // IronicClient creates a client for Ironic
-func IronicClient(ironicEndpoint string, auth AuthConfig, tls TLSConfig) (client *gophercloud.ServiceClient, err error) {
+func IronicClient(ironicEndpoint string, auth AuthConfig, tls TLSConfig, conn ConnectionConfig) (client *gophercloud.ServiceClient, err error) {
 	switch auth.Type {
 	case NoAuth:
 		client, err = noauth.NewBareMetalNoAuth(noauth.EndpointOpts{
@@ -92,11 +148,11 @@ func IronicClient(ironicEndpoint string, auth AuthConfig, tls TLSConfig) (client
 	if err != nil {
 		return
 	}
-	return updateHTTPClient(client, tls)
+	return updateHTTPClient(client, tls, conn)
 }
 
 // InspectorClient creates a client for Ironic Inspector
-func InspectorClient(inspectorEndpoint string, auth AuthConfig, tls TLSConfig) (client *gophercloud.ServiceClient, err error) {
+func InspectorClient(inspectorEndpoint string, auth AuthConfig, tls TLSConfig, conn ConnectionConfig) (client *gophercloud.ServiceClient, err error) {
 	switch auth.Type {
 	case NoAuth:
 		client, err = noauthintrospection.NewBareMetalIntrospectionNoAuth(
@@ -115,5 +171,5 @@ func InspectorClient(inspectorEndpoint string, auth AuthConfig, tls TLSConfig) (
 	if err != nil {
 		return
 	}
-	return updateHTTPClient(client, tls)
+	return updateHTTPClient(client, tls, conn)
 }
@@ -0,0 +1,52 @@
+package clients
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionConfigDefaults(t *testing.T) {
+	conf := ConnectionConfig{}.withDefaults()
+	assert.Equal(t, DefaultRequestTimeout, conf.RequestTimeout)
+	assert.Equal(t, DefaultMaxIdleConnsPerHost, conf.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultIdleConnTimeout, conf.IdleConnTimeout)
+
+	custom := ConnectionConfig{
+		RequestTimeout:      time.Second,
+		MaxIdleConnsPerHost: 3,
+		IdleConnTimeout:     time.Minute,
+	}
+	assert.Equal(t, custom, custom.withDefaults())
+}
+
+func TestIronicClientRequestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(done)
+
+	client, err := IronicClient(server.URL+"/v1/", AuthConfig{Type: NoAuth}, TLSConfig{},
+		ConnectionConfig{RequestTimeout: 100 * time.Millisecond, MaxIdleConnsPerHost: 3})
+	if err != nil {
+		t.Fatalf("could not create the client: %s", err)
+	}
+	assert.Equal(t, 100*time.Millisecond, client.HTTPClient.Timeout)
+	transport := client.HTTPClient.Transport.(*http.Transport)
+	assert.Equal(t, 3, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, DefaultIdleConnTimeout, transport.IdleConnTimeout)
+
+	start := time.Now()
+	_, err = client.Get(client.ServiceURL("nodes"), nil, nil)
+	assert.Error(t, err)
+	assert.Less(t, int64(time.Since(start)), int64(2*time.Second))
+}
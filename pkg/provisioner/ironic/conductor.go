@@ -0,0 +1,80 @@
+package ironic
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// conductor holds the conductor details returned by the Ironic API
+type conductor struct {
+	Hostname string `json:"hostname"`
+	Alive    bool   `json:"alive"`
+}
+
+// getNodeConductor returns the name of the conductor currently
+// managing the node. The name is empty if no live conductor manages
+// the node, and found is false if the API does not report it.
+func (p *ironicProvisioner) getNodeConductor(ironicNode *nodes.Node) (hostname string, found bool, err error) {
+	fields, err := p.getNodeFields(ironicNode)
+	if err != nil {
+		return
+	}
+
+	found = fields.Conductor != nil
+	if !found {
+		return
+	}
+	// A null value means that no conductor is available
+	var name *string
+	err = json.Unmarshal(fields.Conductor, &name)
+	if err == nil && name != nil {
+		hostname = *name
+	}
+	return
+}
+
+// getConductorStatus returns the conductor serving the node. Nothing is
+// returned if the API does not report the conductor and the node is
+// neither in a conductor group nor locked.
+func (p *ironicProvisioner) getConductorStatus(ironicNode *nodes.Node) (status *metal3v1alpha1.ConductorStatus, err error) {
+	hostname, found, err := p.getNodeConductor(ironicNode)
+	if err != nil {
+		return
+	}
+	if !found && ironicNode.ConductorGroup == "" && ironicNode.Reservation == "" {
+		return
+	}
+	return &metal3v1alpha1.ConductorStatus{
+		Name:        hostname,
+		Group:       ironicNode.ConductorGroup,
+		Reservation: ironicNode.Reservation,
+	}, nil
+}
+
+// checkConductor returns an error wrapping provisioner.ErrConductorDown
+// if the conductor managing the node is not alive.
+func (p *ironicProvisioner) checkConductor(ironicNode *nodes.Node) error {
+	hostname, found, err := p.getNodeConductor(ironicNode)
+	if err != nil || !found {
+		return err
+	}
+	if hostname == "" {
+		return fmt.Errorf("%w: no conductor available in group %q",
+			provisioner.ErrConductorDown, ironicNode.ConductorGroup)
+	}
+
+	var info conductor
+	_, err = p.client.Get(p.client.ServiceURL("conductors", hostname), &info, nil)
+	if err != nil {
+		return err
+	}
+	if !info.Alive {
+		return fmt.Errorf("%w: %s", provisioner.ErrConductorDown, hostname)
+	}
+	return nil
+}
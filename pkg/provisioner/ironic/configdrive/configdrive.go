@@ -0,0 +1,122 @@
+package configdrive
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"path"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// MaxFilesSize is the maximum total size in bytes of the extra
+	// files, which have to fit in the provisioning request sent to
+	// Ironic.
+	MaxFilesSize = 64 * 1024
+
+	// cloudConfigMergeType makes cloud-init append the lists, e.g. the
+	// write_files, to the ones of the cloud-config of the user data,
+	// while the other settings added by the operator replace its values.
+	cloudConfigMergeType = "list(append)+dict(replace,recurse_array)+str()"
+)
+
+// ValidateFiles checks the paths and the total size of the extra files
+// of a config drive.
+func ValidateFiles(files map[string]string) error {
+	size := 0
+	for filePath, content := range files {
+		if !path.IsAbs(filePath) || path.Clean(filePath) != filePath || filePath == "/" {
+			return fmt.Errorf("config drive file path %q must be a clean absolute path", filePath)
+		}
+		size += len(content)
+	}
+	if size > MaxFilesSize {
+		return fmt.Errorf("config drive files are too large (%d bytes), the limit is %d bytes",
+			size, MaxFilesSize)
+	}
+	return nil
+}
+
+// AddFiles lists the extra files in the write_files of the cloud-config,
+// for cloud-init to write them to the host on first boot. The content
+// is base64 encoded so that any data survives the YAML document.
+func AddFiles(cloudConfig map[string]interface{}, files map[string]string) {
+	paths := make([]string, 0, len(files))
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	entries := make([]map[string]interface{}, 0, len(paths))
+	for _, filePath := range paths {
+		entries = append(entries, map[string]interface{}{
+			"path":     filePath,
+			"encoding": "b64",
+			"content":  base64.StdEncoding.EncodeToString([]byte(files[filePath])),
+		})
+	}
+	cloudConfig["write_files"] = entries
+}
+
+// UserData returns the user data of the host extended with the
+// cloud-config settings added by the operator, so that Ironic builds
+// the config drive as usual. Both are combined in a MIME multi-part
+// document, in which cloud-init merges the settings with any
+// cloud-config of the user data. The user data is returned unchanged
+// if there are no settings. Only cloud-init user data is supported: the
+// formats it reads as text all start with "#", while other user data,
+// such as the JSON of Ignition, would be corrupted by the MIME document.
+func UserData(userData string, cloudConfig map[string]interface{}) (string, error) {
+	if len(cloudConfig) == 0 {
+		return userData, nil
+	}
+	if strings.HasPrefix(userData, "Content-Type:") || strings.HasPrefix(userData, "\x1f\x8b") {
+		return "", fmt.Errorf("config drive files and time settings cannot be added to MIME multi-part or compressed user data")
+	}
+	if userData != "" && !strings.HasPrefix(userData, "#") {
+		return "", fmt.Errorf("config drive files and time settings can only be added to cloud-init user data starting with #, not to Ignition or other formats")
+	}
+
+	settings, err := yaml.Marshal(cloudConfig)
+	if err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if userData != "" {
+		// cloud-init finds the type of a text/plain part from its
+		// first line, as it does for user data that is not MIME.
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {`text/plain; charset="utf-8"`},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return "", err
+		}
+		if _, err = part.Write([]byte(base64.StdEncoding.EncodeToString([]byte(userData)))); err != nil {
+			return "", err
+		}
+	}
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {`text/cloud-config; charset="utf-8"`},
+		"Merge-Type":   {cloudConfigMergeType},
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, err = part.Write(append([]byte("#cloud-config\n"), settings...)); err != nil {
+		return "", err
+	}
+	if err = writer.Close(); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q\nMIME-Version: 1.0\n\n%s",
+		writer.Boundary(), body.String()), nil
+}
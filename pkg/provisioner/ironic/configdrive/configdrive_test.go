@@ -0,0 +1,279 @@
+package configdrive
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/yaml"
+)
+
+// userDataPart is a part of MIME multi-part user data
+type userDataPart struct {
+	contentType string
+	mergeType   string
+	content     string
+}
+
+// parseUserData splits MIME multi-part user data the way cloud-init
+// does it.
+func parseUserData(t *testing.T, userData string) (parts []userDataPart) {
+	message, err := mail.ReadMessage(strings.NewReader(userData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if part.Header.Get("Content-Transfer-Encoding") == "base64" {
+			if content, err = base64.StdEncoding.DecodeString(string(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		parts = append(parts, userDataPart{
+			contentType: part.Header.Get("Content-Type"),
+			mergeType:   part.Header.Get("Merge-Type"),
+			content:     string(content),
+		})
+	}
+	return
+}
+
+// cloudConfig returns the settings of a cloud-config part
+func cloudConfig(t *testing.T, part userDataPart) (settings map[string]interface{}) {
+	assert.True(t, strings.HasPrefix(part.content, "#cloud-config\n"))
+	if err := yaml.Unmarshal([]byte(part.content), &settings); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestAddFiles(t *testing.T) {
+	settings := map[string]interface{}{}
+	AddFiles(settings, map[string]string{
+		"/var/lib/app/token": "",
+		"/etc/motd":          "Welcome\n",
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"write_files": []map[string]interface{}{
+			{"path": "/etc/motd", "encoding": "b64", "content": "V2VsY29tZQo="},
+			{"path": "/var/lib/app/token", "encoding": "b64", "content": ""},
+		},
+	}, settings)
+}
+
+func TestUserData(t *testing.T) {
+	settings := map[string]interface{}{}
+	AddFiles(settings, map[string]string{"/etc/motd": "Welcome\n"})
+
+	cases := []struct {
+		name     string
+		userData string
+	}{
+		{
+			name:     "script",
+			userData: "#!/bin/sh\necho hello\n",
+		},
+		{
+			name:     "cloud-config",
+			userData: "#cloud-config\nwrite_files:\n- path: /etc/issue\n  content: Hello\n",
+		},
+		{
+			name: "no user data",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			userData, err := UserData(tc.userData, settings)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			parts := parseUserData(t, userData)
+			if tc.userData != "" {
+				if !assert.Len(t, parts, 2) {
+					return
+				}
+				assert.Equal(t, `text/plain; charset="utf-8"`, parts[0].contentType)
+				assert.Equal(t, tc.userData, parts[0].content)
+				parts = parts[1:]
+			} else if !assert.Len(t, parts, 1) {
+				return
+			}
+
+			assert.Equal(t, `text/cloud-config; charset="utf-8"`, parts[0].contentType)
+			assert.Equal(t, cloudConfigMergeType, parts[0].mergeType)
+			assert.Equal(t, map[string]interface{}{
+				"write_files": []interface{}{
+					map[string]interface{}{"path": "/etc/motd", "encoding": "b64", "content": "V2VsY29tZQo="},
+				},
+			}, cloudConfig(t, parts[0]))
+		})
+	}
+}
+
+func TestUserDataUnchanged(t *testing.T) {
+	userData, err := UserData("#!/bin/sh\n", map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", userData)
+}
+
+func TestUserDataUnsupported(t *testing.T) {
+	settings := map[string]interface{}{"timezone": "UTC"}
+	for _, userData := range []string{
+		"Content-Type: multipart/mixed; boundary=\"x\"\n\n--x--\n",
+		"\x1f\x8b\x08\x00",
+	} {
+		_, err := UserData(userData, settings)
+		assert.EqualError(t, err, "config drive files and time settings cannot be added to MIME multi-part or compressed user data")
+	}
+	for _, userData := range []string{
+		`{"ignition": {"version": "3.2.0"}}`,
+		"\n#cloud-config\n",
+		"hostname: myhost\n",
+	} {
+		_, err := UserData(userData, settings)
+		assert.EqualError(t, err, "config drive files and time settings can only be added to cloud-init user data starting with #, not to Ignition or other formats")
+	}
+}
+
+func TestUserDataUnchangedIgnition(t *testing.T) {
+	ignition := `{"ignition": {"version": "3.2.0"}}`
+	userData, err := UserData(ignition, map[string]interface{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, ignition, userData)
+}
+
+func TestValidateFiles(t *testing.T) {
+	cases := []struct {
+		name          string
+		files         map[string]string
+		expectedError string
+	}{
+		{
+			name: "valid",
+			files: map[string]string{
+				"/etc/motd":          "Welcome",
+				"/var/lib/app/token": "",
+			},
+		},
+		{
+			name:          "relative",
+			files:         map[string]string{"etc/motd": "Welcome"},
+			expectedError: "config drive file path \"etc/motd\" must be a clean absolute path",
+		},
+		{
+			name:          "not clean",
+			files:         map[string]string{"/etc/../motd": "Welcome"},
+			expectedError: "config drive file path \"/etc/../motd\" must be a clean absolute path",
+		},
+		{
+			name:          "root",
+			files:         map[string]string{"/": "Welcome"},
+			expectedError: "config drive file path \"/\" must be a clean absolute path",
+		},
+		{
+			name: "too large",
+			files: map[string]string{
+				"/etc/first":  strings.Repeat("x", MaxFilesSize/2),
+				"/etc/second": strings.Repeat("x", MaxFilesSize/2+1),
+			},
+			expectedError: "config drive files are too large (65537 bytes), the limit is 65536 bytes",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateFiles(tc.files)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestValidateTimeSettings(t *testing.T) {
+	cases := []struct {
+		name       string
+		ntpServers []string
+		timezone   string
+		expected   string
+	}{
+		{
+			name:       "valid",
+			ntpServers: []string{"192.168.111.1", "fd2e:6f44:5dd8::1", "NTP.example.com", "pool"},
+			timezone:   "America/Argentina/Buenos_Aires",
+		},
+		{
+			name:     "etc timezone",
+			timezone: "Etc/GMT+5",
+		},
+		{
+			name: "empty",
+		},
+		{
+			name:       "invalid server",
+			ntpServers: []string{"ntp.example.com", "ntp server"},
+			expected:   `NTP server "ntp server" must be an IP address or a host name`,
+		},
+		{
+			name:       "server with port",
+			ntpServers: []string{"192.168.111.1:123"},
+			expected:   `NTP server "192.168.111.1:123" must be an IP address or a host name`,
+		},
+		{
+			name:     "invalid timezone",
+			timezone: "../../etc/passwd",
+			expected: `invalid time zone "../../etc/passwd", expected a name like UTC or Europe/Berlin`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateTimeSettings(tc.ntpServers, tc.timezone)
+			if tc.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestAddTimeSettings(t *testing.T) {
+	settings := map[string]interface{}{}
+	AddTimeSettings(settings, []string{"192.168.111.1", "ntp.example.com"}, "Europe/Berlin")
+	assert.Equal(t, map[string]interface{}{
+		"ntp": map[string]interface{}{
+			"enabled": true,
+			"servers": []string{"192.168.111.1", "ntp.example.com"},
+		},
+		"timezone": "Europe/Berlin",
+	}, settings)
+
+	settings = map[string]interface{}{}
+	AddTimeSettings(settings, nil, "")
+	assert.Empty(t, settings)
+}
@@ -0,0 +1,47 @@
+package configdrive
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// The time zone database is not available in every image, so only the
+// format of the names is checked, e.g. UTC or America/New_York.
+var timezoneRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+-]*(/[A-Za-z0-9_+-]+)*$`)
+
+// ValidateTimeSettings checks that the NTP servers are IP addresses or
+// host names and that the time zone looks like a name of the IANA time
+// zone database.
+func ValidateTimeSettings(ntpServers []string, timezone string) error {
+	for _, server := range ntpServers {
+		if net.ParseIP(server) != nil {
+			continue
+		}
+		if errs := validation.IsDNS1123Subdomain(strings.ToLower(server)); len(errs) != 0 {
+			return fmt.Errorf("NTP server %q must be an IP address or a host name", server)
+		}
+	}
+	if timezone != "" && !timezoneRegexp.MatchString(timezone) {
+		return fmt.Errorf("invalid time zone %q, expected a name like UTC or Europe/Berlin", timezone)
+	}
+	return nil
+}
+
+// AddTimeSettings sets the NTP servers and the time zone in the
+// cloud-config, using the ntp and timezone modules of cloud-init.
+// Empty settings are not added.
+func AddTimeSettings(cloudConfig map[string]interface{}, ntpServers []string, timezone string) {
+	if len(ntpServers) != 0 {
+		cloudConfig["ntp"] = map[string]interface{}{
+			"enabled": true,
+			"servers": append([]string{}, ntpServers...),
+		}
+	}
+	if timezone != "" {
+		cloudConfig["timezone"] = timezone
+	}
+}
@@ -0,0 +1,60 @@
+package ironic
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// socatConsoleType is the type of console exposing the serial-over-LAN
+// console of the node through a TCP proxy, as opposed to e.g. the
+// shellinabox web console.
+const socatConsoleType = "socat"
+
+// consoleInfo holds the console details returned by the Ironic API
+type consoleInfo struct {
+	Enabled bool `json:"console_enabled"`
+	Info    *struct {
+		Type string `json:"type"`
+		URL  string `json:"url"`
+	} `json:"console_info"`
+}
+
+// getSerialConsole returns the details of the serial-over-LAN console
+// of the node. Nothing is returned when the node uses a console that
+// does not provide one.
+func (p *ironicProvisioner) getSerialConsole(ironicNode *nodes.Node) (serialConsole *metal3v1alpha1.SerialConsole, err error) {
+	var body consoleInfo
+	_, err = p.client.Get(p.client.ServiceURL("nodes", ironicNode.UUID, "states", "console"), &body, nil)
+	if err != nil {
+		return
+	}
+	if !body.Enabled || body.Info == nil {
+		return
+	}
+	if body.Info.Type != socatConsoleType {
+		p.debugLog.Info("console does not provide serial-over-LAN access",
+			"type", body.Info.Type)
+		return
+	}
+
+	consoleURL, err := url.Parse(body.Info.URL)
+	if err != nil {
+		return
+	}
+	port, err := strconv.Atoi(consoleURL.Port())
+	if err != nil || consoleURL.Hostname() == "" {
+		err = fmt.Errorf("invalid serial console address %q", body.Info.URL)
+		return
+	}
+
+	serialConsole = &metal3v1alpha1.SerialConsole{
+		Host: consoleURL.Hostname(),
+		Port: port,
+	}
+	return
+}
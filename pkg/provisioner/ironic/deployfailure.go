@@ -0,0 +1,56 @@
+package ironic
+
+import (
+	"strings"
+)
+
+// recoverableDeployErrors lists fragments of the Ironic error messages
+// of deploy failures caused by transient problems, such as a network
+// boot that did not complete or an image server that was briefly
+// unavailable. Deploying again is expected to succeed for these.
+var recoverableDeployErrors = []string{
+	"timeout reached while waiting for callback",
+	"timed out waiting for a reply",
+	"failed to download image",
+	"error downloading image",
+	"connection refused",
+	"connection reset by peer",
+	"service unavailable",
+	"temporary failure in name resolution",
+}
+
+// recoverableCleanErrors lists fragments of the Ironic error messages
+// of clean step failures caused by transient problems, such as the
+// agent or the BMC being briefly unreachable.
+var recoverableCleanErrors = []string{
+	"timeout reached while waiting for callback",
+	"timed out waiting for a reply",
+	"connection refused",
+	"connection reset by peer",
+	"service unavailable",
+	"temporary failure in name resolution",
+}
+
+// containsErrorFragment returns whether the error message contains
+// one of the fragments, ignoring case.
+func containsErrorFragment(lastError string, fragments []string) bool {
+	lastError = strings.ToLower(lastError)
+	for _, fragment := range fragments {
+		if strings.Contains(lastError, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRecoverableDeployError returns whether the error recorded by Ironic
+// for a failed deploy is one that can be retried automatically.
+func isRecoverableDeployError(lastError string) bool {
+	return containsErrorFragment(lastError, recoverableDeployErrors)
+}
+
+// isRecoverableCleanError returns whether the error recorded by Ironic
+// for a failed manual cleaning is one that can be retried automatically.
+func isRecoverableCleanError(lastError string) bool {
+	return containsErrorFragment(lastError, recoverableCleanErrors)
+}
@@ -0,0 +1,42 @@
+package ironic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// agentCheckedIn returns whether the deploy ramdisk of the node has
+// sent a heartbeat since the deploy started
+func agentCheckedIn(ironicNode *nodes.Node) bool {
+	return ironicNode.DriverInternalInfo["agent_url"] != nil ||
+		ironicNode.DriverInternalInfo["agent_last_heartbeat"] != nil
+}
+
+// deployTimedOut checks the time limits of a deploy waiting for the
+// agent. The network boot limit only applies until the deploy ramdisk
+// checks in, from the moment the node started waiting for it. The
+// returned reason and message tell which limit was exceeded.
+func (p *ironicProvisioner) deployTimedOut(ironicNode *nodes.Node, data provisioner.ProvisionData) (reason, message string, err error) {
+	if data.NetworkBootTimeout > 0 && !agentCheckedIn(ironicNode) {
+		fields, err := p.getNodeFields(ironicNode)
+		if err != nil {
+			return "", "", err
+		}
+		waitingSince := fields.ProvisionUpdatedAt
+		if waitingSince != nil && time.Since(*waitingSince) > data.NetworkBootTimeout {
+			return "NetworkBootTimedOut", fmt.Sprintf(
+				"the deploy ramdisk did not boot from the network within %s (networkBootSeconds)",
+				data.NetworkBootTimeout), nil
+		}
+	}
+	if data.DeployTimeout > 0 && !data.ProvisionStarted.IsZero() &&
+		time.Since(data.ProvisionStarted) > data.DeployTimeout {
+		return "DeployTimedOut", fmt.Sprintf("the deploy did not finish within %s (deploySeconds)",
+			data.DeployTimeout), nil
+	}
+	return "", "", nil
+}
@@ -0,0 +1,118 @@
+package ironic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestProvisionDeployTimeouts(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	image := v1alpha1.Image{URL: "http://example.test/image.qcow2", Checksum: "abcd"}
+	checksum, checksumType, _ := image.GetChecksum()
+	heartbeat := map[string]interface{}{"agent_url": "http://172.22.0.10:9999"}
+	cases := []struct {
+		name               string
+		driverInternalInfo map[string]interface{}
+		waitingFor         time.Duration
+		provisioningFor    time.Duration
+		networkBootTimeout time.Duration
+		deployTimeout      time.Duration
+
+		expectedTargets      []string
+		expectedErrorMessage string
+	}{
+		{
+			name:               "network boot timed out",
+			waitingFor:         time.Minute * 20,
+			provisioningFor:    time.Minute * 25,
+			networkBootTimeout: time.Minute * 15,
+			deployTimeout:      time.Hour,
+			expectedTargets:    []string{"abort"},
+			expectedErrorMessage: "Image provisioning failed: the deploy ramdisk did not boot from the network " +
+				"within 15m0s (networkBootSeconds)",
+		},
+		{
+			name:               "waiting for the network boot",
+			waitingFor:         time.Minute * 10,
+			provisioningFor:    time.Minute * 15,
+			networkBootTimeout: time.Minute * 15,
+			deployTimeout:      time.Hour,
+		},
+		{
+			name:               "agent checked in",
+			driverInternalInfo: heartbeat,
+			waitingFor:         time.Minute * 20,
+			provisioningFor:    time.Minute * 40,
+			networkBootTimeout: time.Minute * 15,
+			deployTimeout:      time.Hour,
+		},
+		{
+			name:                 "deploy timed out",
+			driverInternalInfo:   heartbeat,
+			waitingFor:           time.Minute * 5,
+			provisioningFor:      time.Minute * 70,
+			networkBootTimeout:   time.Minute * 15,
+			deployTimeout:        time.Hour,
+			expectedTargets:      []string{"abort"},
+			expectedErrorMessage: "Image provisioning failed: the deploy did not finish within 1h0m0s (deploySeconds)",
+		},
+		{
+			name:            "no timeouts",
+			waitingFor:      time.Hour * 5,
+			provisioningFor: time.Hour * 6,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var targets []string
+			ironic := testserver.NewIronic(t).Ready().NodeWithProvisionUpdatedAt(nodes.Node{
+				ProvisionState: string(nodes.DeployWait),
+				UUID:           nodeUUID,
+				InstanceInfo: map[string]interface{}{
+					"image_source":        image.URL,
+					"image_os_hash_algo":  checksumType,
+					"image_os_hash_value": checksum,
+				},
+				DriverInternalInfo: tc.driverInternalInfo,
+			}, time.Now().Add(-tc.waitingFor))
+			ironic.Handler("/v1/nodes/"+nodeUUID+"/states/provision", provisionStateHandler(t, "", &targets))
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.Image = &image
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image:              image,
+				HostConfig:         fixture.NewHostConfigData("", "", ""),
+				BootMode:           v1alpha1.DefaultBootMode,
+				NetworkBootTimeout: tc.networkBootTimeout,
+				DeployTimeout:      tc.deployTimeout,
+				ProvisionStarted:   time.Now().Add(-tc.provisioningFor),
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedErrorMessage, result.ErrorMessage)
+			assert.Equal(t, tc.expectedTargets, targets)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package ironic
+
+import (
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+)
+
+// getNodeDescription returns the description of the node, which is not
+// part of the node structure of the client library.
+func (p *ironicProvisioner) getNodeDescription(ironicNode *nodes.Node) (description string, err error) {
+	fields, err := p.getNodeFields(ironicNode)
+	if err == nil && fields.Description != nil {
+		description = *fields.Description
+	}
+	return
+}
+
+// setDescriptionUpdateOpts replaces the description of the node with
+// the one of the host, removing it when the host has none.
+func (p *ironicProvisioner) setDescriptionUpdateOpts(ironicNode *nodes.Node, description string, updater *nodeUpdater) {
+	current, err := p.getNodeDescription(ironicNode)
+	if err != nil {
+		p.log.Info("could not read the node description", "error", err)
+		return
+	}
+	if current == description {
+		return
+	}
+
+	var desired interface{}
+	if description != "" {
+		desired = description
+	}
+	updater.SetTopLevelOpt("description", desired, current)
+}
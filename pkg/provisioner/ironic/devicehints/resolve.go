@@ -0,0 +1,181 @@
+package devicehints
+
+import (
+	"fmt"
+	"strings"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// hintMatcher tells whether a hint is set and whether a storage device
+// matches it.
+type hintMatcher struct {
+	set   func(source *metal3v1alpha1.RootDeviceHints) bool
+	match func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool
+}
+
+// hintPrecedence lists the matchers of the hints in the order they are
+// tried in the "any" match mode.
+var hintPrecedence = []hintMatcher{
+	{
+		set: func(source *metal3v1alpha1.RootDeviceHints) bool { return source.DeviceName != "" },
+		match: func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool {
+			return source.DeviceName == disk.Name
+		},
+	},
+	{
+		set: func(source *metal3v1alpha1.RootDeviceHints) bool { return source.HCTL != "" },
+		match: func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool {
+			return source.HCTL == disk.HCTL
+		},
+	},
+	{
+		set: func(source *metal3v1alpha1.RootDeviceHints) bool { return source.Model != "" },
+		match: func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool {
+			return strings.Contains(disk.Model, source.Model)
+		},
+	},
+	{
+		set: func(source *metal3v1alpha1.RootDeviceHints) bool { return source.Vendor != "" },
+		match: func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool {
+			return strings.Contains(disk.Vendor, source.Vendor)
+		},
+	},
+	{
+		set: func(source *metal3v1alpha1.RootDeviceHints) bool { return source.SerialNumber != "" },
+		match: func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool {
+			return source.SerialNumber == disk.SerialNumber
+		},
+	},
+	{
+		set: func(source *metal3v1alpha1.RootDeviceHints) bool { return source.MinSizeGigabytes != 0 },
+		match: func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool {
+			return disk.SizeBytes >= metal3v1alpha1.Capacity(source.MinSizeGigabytes)*metal3v1alpha1.GibiByte
+		},
+	},
+	{
+		set: func(source *metal3v1alpha1.RootDeviceHints) bool { return source.WWN != "" },
+		match: func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool {
+			return source.WWN == disk.WWN
+		},
+	},
+	{
+		set: func(source *metal3v1alpha1.RootDeviceHints) bool { return source.WWNWithExtension != "" },
+		match: func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool {
+			return source.WWNWithExtension == disk.WWNWithExtension
+		},
+	},
+	{
+		set: func(source *metal3v1alpha1.RootDeviceHints) bool { return source.WWNVendorExtension != "" },
+		match: func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool {
+			return source.WWNVendorExtension == disk.WWNVendorExtension
+		},
+	},
+	{
+		set: func(source *metal3v1alpha1.RootDeviceHints) bool { return source.Rotational != nil },
+		match: func(source *metal3v1alpha1.RootDeviceHints, disk *metal3v1alpha1.Storage) bool {
+			return *source.Rotational == disk.Rotational
+		},
+	},
+}
+
+// ValidateMatchMode checks that the match mode of the hints is known
+func ValidateMatchMode(source *metal3v1alpha1.RootDeviceHints) error {
+	if source == nil {
+		return nil
+	}
+	switch source.MatchMode {
+	case "", metal3v1alpha1.RootDeviceHintsMatchAll, metal3v1alpha1.RootDeviceHintsMatchAny:
+		return nil
+	}
+	return fmt.Errorf("invalid root device hints match mode %q, expected %q or %q",
+		source.MatchMode, metal3v1alpha1.RootDeviceHintsMatchAll, metal3v1alpha1.RootDeviceHintsMatchAny)
+}
+
+// Resolve returns the hints to pass to ironic, which always requires a
+// device to match all hints. Hints in the "any" match mode are
+// resolved against the inspected storage devices into a single hint
+// identifying the selected device, other hints are returned unchanged.
+func Resolve(source *metal3v1alpha1.RootDeviceHints, details *metal3v1alpha1.HardwareDetails) (*metal3v1alpha1.RootDeviceHints, error) {
+	if err := ValidateMatchMode(source); err != nil {
+		return nil, err
+	}
+	if source == nil || source.MatchMode != metal3v1alpha1.RootDeviceHintsMatchAny {
+		return source, nil
+	}
+	if *source == (metal3v1alpha1.RootDeviceHints{MatchMode: source.MatchMode}) {
+		// No hints besides the match mode, any device will do
+		return nil, nil
+	}
+	if details == nil {
+		return nil, fmt.Errorf("root device hints with match mode %q require the hardware details of the host", source.MatchMode)
+	}
+
+	for _, matcher := range hintPrecedence {
+		if !matcher.set(source) {
+			continue
+		}
+		for i := range details.Storage {
+			if matcher.match(source, &details.Storage[i]) {
+				return diskHint(&details.Storage[i]), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no storage device matches any of the root device hints")
+}
+
+// diskHint returns a hint identifying the device by its most stable
+// property.
+func diskHint(disk *metal3v1alpha1.Storage) *metal3v1alpha1.RootDeviceHints {
+	switch {
+	case disk.SerialNumber != "":
+		return &metal3v1alpha1.RootDeviceHints{SerialNumber: disk.SerialNumber}
+	case disk.WWN != "":
+		return &metal3v1alpha1.RootDeviceHints{WWN: disk.WWN}
+	case disk.HCTL != "":
+		return &metal3v1alpha1.RootDeviceHints{HCTL: disk.HCTL}
+	}
+	return &metal3v1alpha1.RootDeviceHints{DeviceName: disk.Name}
+}
+
+// minRootDeviceSize is the size of the smallest device Ironic selects
+// as the root device when there are no hints
+const minRootDeviceSize = 4 * metal3v1alpha1.GibiByte
+
+// RootDevice returns the storage device Ironic selects as the root
+// device given hints that must all match: the first device matching
+// them or, without hints, the smallest device of at least 4 GiB. It
+// returns nil if no device is selected.
+func RootDevice(source *metal3v1alpha1.RootDeviceHints, details *metal3v1alpha1.HardwareDetails) *metal3v1alpha1.Storage {
+	if details == nil {
+		return nil
+	}
+
+	var matchers []hintMatcher
+	if source != nil {
+		for _, matcher := range hintPrecedence {
+			if matcher.set(source) {
+				matchers = append(matchers, matcher)
+			}
+		}
+	}
+
+	var selected *metal3v1alpha1.Storage
+	for i := range details.Storage {
+		disk := &details.Storage[i]
+		if len(matchers) == 0 {
+			if disk.SizeBytes >= minRootDeviceSize && (selected == nil || disk.SizeBytes < selected.SizeBytes) {
+				selected = disk
+			}
+			continue
+		}
+		matches := true
+		for _, matcher := range matchers {
+			matches = matches && matcher.match(source, disk)
+		}
+		if matches {
+			return disk
+		}
+	}
+	return selected
+}
@@ -0,0 +1,199 @@
+package devicehints
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestResolve(t *testing.T) {
+	rotational := true
+	details := &metal3v1alpha1.HardwareDetails{
+		Storage: []metal3v1alpha1.Storage{
+			{
+				Name:       "/dev/sda",
+				Rotational: true,
+				SizeBytes:  500 * metal3v1alpha1.GibiByte,
+				Model:      "ST1000NX0423",
+				WWN:        "0x5000c500a0d1e2f3",
+			},
+			{
+				Name:         "/dev/sdb",
+				SizeBytes:    200 * metal3v1alpha1.GibiByte,
+				Model:        "Samsung SSD 860",
+				SerialNumber: "S3Z9NB0K",
+			},
+			{
+				Name:      "/dev/sdc",
+				SizeBytes: 1000 * metal3v1alpha1.GibiByte,
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		Scenario string
+		Hints    *metal3v1alpha1.RootDeviceHints
+		Details  *metal3v1alpha1.HardwareDetails
+		Expected *metal3v1alpha1.RootDeviceHints
+		Error    string
+	}{
+		{
+			Scenario: "no hints",
+			Details:  details,
+		},
+		{
+			Scenario: "all hints are passed unchanged",
+			Hints: &metal3v1alpha1.RootDeviceHints{
+				SerialNumber: "unknown",
+				Rotational:   &rotational,
+			},
+			Details: details,
+			Expected: &metal3v1alpha1.RootDeviceHints{
+				SerialNumber: "unknown",
+				Rotational:   &rotational,
+			},
+		},
+		{
+			Scenario: "explicit all mode is passed unchanged",
+			Hints: &metal3v1alpha1.RootDeviceHints{
+				Model:     "Samsung",
+				MatchMode: metal3v1alpha1.RootDeviceHintsMatchAll,
+			},
+			Expected: &metal3v1alpha1.RootDeviceHints{
+				Model:     "Samsung",
+				MatchMode: metal3v1alpha1.RootDeviceHintsMatchAll,
+			},
+		},
+		{
+			Scenario: "any mode prefers the earlier hint",
+			Hints: &metal3v1alpha1.RootDeviceHints{
+				SerialNumber: "S3Z9NB0K",
+				Rotational:   &rotational,
+				MatchMode:    metal3v1alpha1.RootDeviceHintsMatchAny,
+			},
+			Details:  details,
+			Expected: &metal3v1alpha1.RootDeviceHints{SerialNumber: "S3Z9NB0K"},
+		},
+		{
+			Scenario: "any mode falls back to the later hint",
+			Hints: &metal3v1alpha1.RootDeviceHints{
+				SerialNumber: "unknown",
+				Rotational:   &rotational,
+				MatchMode:    metal3v1alpha1.RootDeviceHintsMatchAny,
+			},
+			Details:  details,
+			Expected: &metal3v1alpha1.RootDeviceHints{WWN: "0x5000c500a0d1e2f3"},
+		},
+		{
+			Scenario: "any mode with minimum size",
+			Hints: &metal3v1alpha1.RootDeviceHints{
+				Vendor:           "unknown",
+				MinSizeGigabytes: 600,
+				MatchMode:        metal3v1alpha1.RootDeviceHintsMatchAny,
+			},
+			Details:  details,
+			Expected: &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/sdc"},
+		},
+		{
+			Scenario: "any mode with only the mode set",
+			Hints:    &metal3v1alpha1.RootDeviceHints{MatchMode: metal3v1alpha1.RootDeviceHintsMatchAny},
+			Details:  details,
+		},
+		{
+			Scenario: "any mode without a match",
+			Hints: &metal3v1alpha1.RootDeviceHints{
+				DeviceName: "/dev/vda",
+				Model:      "unknown",
+				MatchMode:  metal3v1alpha1.RootDeviceHintsMatchAny,
+			},
+			Details: details,
+			Error:   "no storage device matches any of the root device hints",
+		},
+		{
+			Scenario: "any mode without hardware details",
+			Hints: &metal3v1alpha1.RootDeviceHints{
+				DeviceName: "/dev/sda",
+				MatchMode:  metal3v1alpha1.RootDeviceHintsMatchAny,
+			},
+			Error: "root device hints with match mode \"any\" require the hardware details of the host",
+		},
+		{
+			Scenario: "invalid mode",
+			Hints: &metal3v1alpha1.RootDeviceHints{
+				DeviceName: "/dev/sda",
+				MatchMode:  "first",
+			},
+			Details: details,
+			Error:   "invalid root device hints match mode \"first\", expected \"all\" or \"any\"",
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			actual, err := Resolve(tc.Hints, tc.Details)
+			if tc.Error != "" {
+				assert.EqualError(t, err, tc.Error)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.Expected, actual)
+		})
+	}
+}
+
+func TestRootDevice(t *testing.T) {
+	rotational := true
+	details := &metal3v1alpha1.HardwareDetails{
+		Storage: []metal3v1alpha1.Storage{
+			{Name: "/dev/sda", SizeBytes: 960 * metal3v1alpha1.GigaByte, Rotational: true, Model: "ST1000NX0423"},
+			{Name: "/dev/sdb", SizeBytes: 2 * metal3v1alpha1.GibiByte, Model: "USB Flash"},
+			{Name: "/dev/nvme0n1", SizeBytes: 480 * metal3v1alpha1.GigaByte, Model: "Dell Express Flash"},
+			{Name: "/dev/nvme1n1", SizeBytes: 480 * metal3v1alpha1.GigaByte, Model: "Dell Express Flash"},
+		},
+	}
+
+	for _, tc := range []struct {
+		Scenario string
+		Hints    *metal3v1alpha1.RootDeviceHints
+		Details  *metal3v1alpha1.HardwareDetails
+		Expected string
+	}{
+		{
+			Scenario: "no hints selects the smallest usable device",
+			Details:  details,
+			Expected: "/dev/nvme0n1",
+		},
+		{
+			Scenario: "first device matching all hints",
+			Hints:    &metal3v1alpha1.RootDeviceHints{Model: "Dell", MinSizeGigabytes: 100},
+			Details:  details,
+			Expected: "/dev/nvme0n1",
+		},
+		{
+			Scenario: "rotational hint",
+			Hints:    &metal3v1alpha1.RootDeviceHints{Rotational: &rotational},
+			Details:  details,
+			Expected: "/dev/sda",
+		},
+		{
+			Scenario: "no match",
+			Hints:    &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/sda", Model: "Dell"},
+			Details:  details,
+		},
+		{
+			Scenario: "no hardware details",
+			Hints:    &metal3v1alpha1.RootDeviceHints{DeviceName: "/dev/sda"},
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			disk := RootDevice(tc.Hints, tc.Details)
+			if tc.Expected == "" {
+				assert.Nil(t, disk)
+				return
+			}
+			if assert.NotNil(t, disk) {
+				assert.Equal(t, tc.Expected, disk.Name)
+			}
+		})
+	}
+}
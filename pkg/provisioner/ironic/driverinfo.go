@@ -0,0 +1,97 @@
+package ironic
+
+import (
+	"sort"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// maskedDriverInfoValue is the value Ironic returns instead of the
+// secrets of the driver_info, e.g. the BMC password
+const maskedDriverInfoValue = "******"
+
+// buildDriverInfo returns the driver_info of the node computed from the
+// BMC details and credentials of the host and its settings.
+func (p *ironicProvisioner) buildDriverInfo(bmcAccess bmc.AccessDetails, data provisioner.ManagementAccessData) (driverInfo map[string]interface{}, err error) {
+	driverInfo = bmcAccess.DriverInfo(p.bmcCreds)
+	// FIXME(dhellmann): We need to get our IP on the
+	// provisioning network from somewhere.
+	driverInfo["deploy_kernel"] = deployKernelURL
+	driverInfo["deploy_ramdisk"] = deployRamdiskURL
+	if data.PreprovisioningImage != nil {
+		driverInfo["deploy_kernel"] = data.PreprovisioningImage.KernelURL
+		driverInfo["deploy_ramdisk"] = data.PreprovisioningImage.ImageURL
+	}
+	if err = validateDeployNetworks(data.DeployNetworks); err != nil {
+		return nil, err
+	}
+	for field, value := range deployNetworkFields(data.DeployNetworks) {
+		if value != nil {
+			driverInfo[field] = value
+		}
+	}
+	if err = validateRedfishAuthType(data.RedfishAuthType, driverInfo); err != nil {
+		return nil, err
+	}
+	for field, value := range redfishAuthTypeFields(data.RedfishAuthType, driverInfo) {
+		if value != nil {
+			driverInfo[field] = value
+		}
+	}
+	for field, value := range fastTrackFields(data.FastTrack) {
+		if value != nil {
+			driverInfo[field] = value
+		}
+	}
+	return driverInfo, nil
+}
+
+// driverInfoRepairs returns the driver_info fields of the node that are
+// missing or differ from the expected ones. Only the expected fields
+// are managed, the other fields of the node are left alone, and masked
+// secrets cannot be compared so they are only restored when missing.
+func driverInfoRepairs(ironicNode *nodes.Node, expected map[string]interface{}) optionsData {
+	repairs := optionsData{}
+	for name, value := range expected {
+		if value == nil {
+			continue
+		}
+		if current, present := ironicNode.DriverInfo[name]; present {
+			if current == maskedDriverInfoValue || optionValueEqual(deref(current), deref(value)) {
+				continue
+			}
+		}
+		repairs[name] = value
+	}
+	return repairs
+}
+
+// setDriverInfoRepairsUpdateOpts restores the driver_info fields of the
+// node computed from the host and its BMC credentials that are missing
+// or were changed behind our back, e.g. by a manual edit, which
+// otherwise cause confusing failures later on. The other fields are
+// preserved. It returns the names of the repaired fields, leaving out
+// the ones the updater already changes.
+func setDriverInfoRepairsUpdateOpts(ironicNode *nodes.Node, expected map[string]interface{}, updater *nodeUpdater) (repaired []string) {
+	pending := map[string]bool{}
+	for _, update := range updater.pendingUpdates() {
+		if op, ok := update.(nodes.UpdateOperation); ok {
+			pending[op.Path] = true
+		}
+	}
+
+	repairs := driverInfoRepairs(ironicNode, expected)
+	for name := range repairs {
+		if pending[updater.path("/driver_info", name)] {
+			delete(repairs, name)
+			continue
+		}
+		repaired = append(repaired, name)
+	}
+	sort.Strings(repaired)
+	updater.SetDriverInfoOpts(repairs, ironicNode)
+	return
+}
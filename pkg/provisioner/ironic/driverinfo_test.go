@@ -0,0 +1,134 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestValidateManagementAccessRepairDriverInfo(t *testing.T) {
+	complete := map[string]interface{}{
+		"ipmi_address":   "192.168.122.1",
+		"ipmi_port":      "623",
+		"ipmi_username":  "admin",
+		"ipmi_password":  "******",
+		"deploy_kernel":  deployKernelURL,
+		"deploy_ramdisk": deployRamdiskURL,
+	}
+	withChanges := func(changes map[string]interface{}) map[string]interface{} {
+		driverInfo := map[string]interface{}{}
+		for name, value := range complete {
+			driverInfo[name] = value
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(driverInfo, name)
+			} else {
+				driverInfo[name] = value
+			}
+		}
+		return driverInfo
+	}
+
+	cases := []struct {
+		name       string
+		driverInfo map[string]interface{}
+		node       nodes.Node
+
+		expectedUpdates []nodes.UpdateOperation
+		expectedDirty   bool
+	}{
+		{
+			name:       "complete",
+			driverInfo: complete,
+		},
+		{
+			name:       "unmanaged fields preserved",
+			driverInfo: withChanges(map[string]interface{}{"ipmi_priv_level": "ADMINISTRATOR"}),
+		},
+		{
+			name:       "missing fields restored",
+			driverInfo: withChanges(map[string]interface{}{"ipmi_address": nil, "ipmi_password": nil}),
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.AddOp, Path: "/driver_info/ipmi_address", Value: "192.168.122.1"},
+				{Op: nodes.AddOp, Path: "/driver_info/ipmi_password", Value: "pa$$w0rd"},
+			},
+		},
+		{
+			name:       "changed field restored",
+			driverInfo: withChanges(map[string]interface{}{"deploy_kernel": "http://other.test/ipa.kernel"}),
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.AddOp, Path: "/driver_info/deploy_kernel", Value: deployKernelURL},
+			},
+		},
+		{
+			name:          "locked host",
+			driverInfo:    withChanges(map[string]interface{}{"ipmi_address": nil}),
+			node:          nodes.Node{Reservation: "conductor-1"},
+			expectedDirty: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clean := true
+			node := tc.node
+			node.Name = "myns" + nameSeparator + "myhost"
+			node.UUID = "uuid"
+			node.ProvisionState = string(nodes.Manageable)
+			node.AutomatedClean = &clean
+			node.DriverInfo = tc.driverInfo
+			ironic := testserver.NewIronic(t).Ready().Node(node).NodeUpdate(nodes.Node{UUID: "uuid"})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.BMC.Address = "ipmi://192.168.122.1"
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "pa$$w0rd"}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "", result.ErrorMessage)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+			assert.ElementsMatch(t, tc.expectedUpdates, ironic.GetLastNodeUpdateRequestFor("uuid"))
+		})
+	}
+}
+
+// registeredDriverInfo returns the driver_info of a node registered with
+// the BMC details, with the changes applied on top of it, so that the
+// tests of an existing node only see the updates they expect. A nil
+// value removes the field.
+func registeredDriverInfo(t *testing.T, details metal3v1alpha1.BMCDetails, changes map[string]interface{}) map[string]interface{} {
+	bmcAccess, err := bmc.NewAccessDetails(details.Address, details.DisableCertificateVerification)
+	if err != nil {
+		t.Fatalf("could not parse the BMC address: %s", err)
+	}
+	driverInfo := bmcAccess.DriverInfo(bmc.Credentials{})
+	driverInfo["deploy_kernel"] = deployKernelURL
+	driverInfo["deploy_ramdisk"] = deployRamdiskURL
+	for name, value := range changes {
+		if value == nil {
+			delete(driverInfo, name)
+		} else {
+			driverInfo[name] = value
+		}
+	}
+	return driverInfo
+}
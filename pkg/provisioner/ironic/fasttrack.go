@@ -0,0 +1,13 @@
+package ironic
+
+// fastTrackFields returns the driver_info field overriding whether the
+// conductor keeps the agent of the node running between cleaning and
+// deployment, so that the host does not boot the ramdisk again. It is
+// removed when unset so that the configuration of the conductor
+// applies.
+func fastTrackFields(fastTrack *bool) optionsData {
+	if fastTrack == nil {
+		return optionsData{"fast_track": nil}
+	}
+	return optionsData{"fast_track": *fastTrack}
+}
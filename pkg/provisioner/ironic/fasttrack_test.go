@@ -0,0 +1,143 @@
+package ironic
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestValidateManagementAccessFastTrackCreate(t *testing.T) {
+	host := makeHost()
+	host.Spec.BootMACAddress = "11:11:11:11:11:11"
+	host.Status.Provisioning.ID = ""
+
+	var createdNode *nodes.Node
+	createCallback := func(node nodes.Node) {
+		createdNode = &node
+	}
+
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).NoNode(host.Namespace + nameSeparator + host.Name).NoNode(host.Name)
+	ironic.AddDefaultResponse("/v1/nodes/node-0", "PATCH", http.StatusOK, "{}")
+	ironic.AddDefaultResponse("/v1/ports", "GET", http.StatusOK, `{"ports": []}`)
+	ironic.AddDefaultResponse("/v1/ports", "POST", http.StatusCreated, "{}")
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	fastTrack := true
+	result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{
+		FastTrack: &fastTrack,
+	}, false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	if assert.NotNil(t, createdNode) {
+		assert.Equal(t, true, createdNode.DriverInfo["fast_track"])
+	}
+}
+
+func TestValidateManagementAccessFastTrack(t *testing.T) {
+	enabled := true
+	disabled := false
+
+	cases := []struct {
+		name       string
+		driverInfo map[string]interface{}
+		fastTrack  *bool
+
+		expectedUpdates []nodes.UpdateOperation
+	}{
+		{
+			name:      "enabled",
+			fastTrack: &enabled,
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.AddOp, Path: "/driver_info/fast_track", Value: true},
+			},
+		},
+		{
+			name:       "disabled",
+			driverInfo: map[string]interface{}{"fast_track": true},
+			fastTrack:  &disabled,
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.AddOp, Path: "/driver_info/fast_track", Value: false},
+			},
+		},
+		{
+			name:       "unchanged",
+			driverInfo: map[string]interface{}{"fast_track": false},
+			fastTrack:  &disabled,
+		},
+		{
+			name:       "conductor default restored",
+			driverInfo: map[string]interface{}{"fast_track": true},
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.RemoveOp, Path: "/driver_info/fast_track"},
+			},
+		},
+		{
+			name: "not set",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = "11:11:11:11:11:11"
+			host.Status.Provisioning.ID = "uuid"
+
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				ProvisionState: string(nodes.Manageable),
+				DriverInfo:     tc.driverInfo,
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			}).Port(ports.Port{
+				NodeUUID: "uuid",
+				Address:  "11:11:11:11:11:11",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{
+				FastTrack: tc.fastTrack,
+			}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, "", result.ErrorMessage)
+
+			var updates []nodes.UpdateOperation
+			for _, update := range ironic.GetLastNodeUpdateRequestFor("uuid") {
+				if update.Path == "/driver_info/fast_track" {
+					updates = append(updates, update)
+				}
+			}
+			assert.ElementsMatch(t, tc.expectedUpdates, updates)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package ironic
+
+import (
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+)
+
+const (
+	// firmwareComponentsMicroversion is the first API version
+	// exposing the firmware components of a node.
+	firmwareComponentsMicroversion = "1.86"
+
+	// bmcFirmwareComponent is the name of the firmware component of
+	// the BMC, as read from the Redfish manager of the node.
+	bmcFirmwareComponent = "bmc"
+)
+
+// firmwareComponent is a firmware component of the node as returned by
+// the Ironic API
+type firmwareComponent struct {
+	Component      string `json:"component"`
+	CurrentVersion string `json:"current_version"`
+}
+
+// getBMCFirmwareVersion returns the firmware version of the BMC of the
+// node. The version is empty if the firmware interface of the node does
+// not report it.
+func (p *ironicProvisioner) getBMCFirmwareVersion(ironicNode *nodes.Node) (version string, err error) {
+	// The firmware components need a newer API version than the one
+	// used for the rest of the requests.
+	client := *p.client
+	client.Microversion = firmwareComponentsMicroversion
+
+	var body struct {
+		Firmware []firmwareComponent `json:"firmware"`
+	}
+	_, err = client.Get(client.ServiceURL("nodes", ironicNode.UUID, "firmware"), &body, nil)
+	if err != nil {
+		return
+	}
+
+	for _, component := range body.Firmware {
+		if component.Component == bmcFirmwareComponent {
+			version = component.CurrentVersion
+			break
+		}
+	}
+	return
+}
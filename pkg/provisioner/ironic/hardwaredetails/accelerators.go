@@ -0,0 +1,120 @@
+package hardwaredetails
+
+import (
+	"sort"
+	"strings"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// PCIInventory holds the PCI devices collected by the agent, which the
+// client library does not parse. The IDs and the class are hexadecimal
+// strings, e.g. "10de", "20b0" and "030200".
+type PCIInventory struct {
+	PCIDevices []struct {
+		VendorID  string `json:"vendor_id"`
+		ProductID string `json:"product_id"`
+		Class     string `json:"class"`
+	} `json:"pci_devices"`
+}
+
+// gpuVendors lists the PCI vendors whose VGA controllers are GPUs,
+// unlike the ones of the BMCs and of the integrated graphics
+var gpuVendors = map[string]string{
+	"10de": "NVIDIA",
+	"1002": "AMD",
+}
+
+// acceleratorVendors names the vendors of the other accelerators
+var acceleratorVendors = map[string]string{
+	"10de": "NVIDIA",
+	"1002": "AMD",
+	"8086": "Intel",
+	"1da3": "Habana Labs",
+}
+
+// acceleratorModels names the common data center accelerators by
+// vendor and device ID
+var acceleratorModels = map[string]string{
+	"10de:1db4": "Tesla V100 PCIe 16GB",
+	"10de:1db6": "Tesla V100 PCIe 32GB",
+	"10de:1eb8": "Tesla T4",
+	"10de:20b0": "A100 SXM4 40GB",
+	"10de:20b2": "A100 SXM4 80GB",
+	"10de:20b5": "A100 PCIe 80GB",
+	"10de:20f1": "A100 PCIe 40GB",
+	"10de:2236": "A10",
+	"10de:2330": "H100 SXM5 80GB",
+	"10de:2331": "H100 PCIe",
+	"1002:738c": "Instinct MI100",
+	"1002:740c": "Instinct MI250X/MI250",
+	"1002:74a1": "Instinct MI300X",
+}
+
+// normalizePCIValue strips the optional 0x prefix of the hexadecimal
+// values reported for a PCI device
+func normalizePCIValue(value string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), "0x")
+}
+
+// acceleratorType returns whether a PCI device of the class is a GPU
+// or another accelerator, or an empty type for other devices.
+// Display controllers other than VGA ones, e.g. 3D controllers, are
+// GPUs, VGA controllers only when made by a GPU vendor.
+func acceleratorType(vendorID, class string) metal3v1alpha1.AcceleratorType {
+	switch {
+	case strings.HasPrefix(class, "0300"):
+		if _, isGPUVendor := gpuVendors[vendorID]; isGPUVendor {
+			return metal3v1alpha1.AcceleratorTypeGPU
+		}
+	case strings.HasPrefix(class, "03"):
+		return metal3v1alpha1.AcceleratorTypeGPU
+	case strings.HasPrefix(class, "12"):
+		return metal3v1alpha1.AcceleratorTypeOther
+	}
+	return ""
+}
+
+// GetAccelerators returns the GPUs and other processing accelerators
+// among the PCI devices of the host, grouped by vendor and device ID
+// and sorted by them.
+func GetAccelerators(inventory *PCIInventory) []metal3v1alpha1.Accelerator {
+	accelerators := map[string]*metal3v1alpha1.Accelerator{}
+	for _, device := range inventory.PCIDevices {
+		vendorID := normalizePCIValue(device.VendorID)
+		deviceID := normalizePCIValue(device.ProductID)
+		kind := acceleratorType(vendorID, normalizePCIValue(device.Class))
+		if kind == "" {
+			continue
+		}
+
+		key := vendorID + ":" + deviceID
+		if accelerator, ok := accelerators[key]; ok {
+			accelerator.Count++
+			continue
+		}
+		accelerators[key] = &metal3v1alpha1.Accelerator{
+			Type:     kind,
+			VendorID: vendorID,
+			DeviceID: deviceID,
+			Vendor:   acceleratorVendors[vendorID],
+			Model:    acceleratorModels[key],
+			Count:    1,
+		}
+	}
+
+	if len(accelerators) == 0 {
+		return nil
+	}
+	result := make([]metal3v1alpha1.Accelerator, 0, len(accelerators))
+	for _, accelerator := range accelerators {
+		result = append(result, *accelerator)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].VendorID != result[j].VendorID {
+			return result[i].VendorID < result[j].VendorID
+		}
+		return result[i].DeviceID < result[j].DeviceID
+	})
+	return result
+}
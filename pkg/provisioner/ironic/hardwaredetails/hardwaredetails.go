@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
@@ -21,6 +22,8 @@ func GetHardwareDetails(data *introspection.Data) *metal3v1alpha1.HardwareDetail
 	details.Storage = getStorageDetails(data.Inventory.Disks)
 	details.CPU = getCPUDetails(&data.Inventory.CPU)
 	details.Hostname = data.Inventory.Hostname
+	details.TPM = getTPMDetails(data.Extra.System)
+	details.MemoryModules = getMemoryModules(data.Extra.Memory)
 	return details
 }
 
@@ -56,6 +59,13 @@ func getNICSpeedGbps(intfExtradata introspection.ExtraHardwareData) (speedGbps i
 	return
 }
 
+// getNICLinkDown returns true if the extra hardware data reports that
+// the NIC has no link. NICs without link data are not flagged.
+func getNICLinkDown(intfExtradata introspection.ExtraHardwareData) bool {
+	link, ok := intfExtradata["link"].(string)
+	return ok && link == "no"
+}
+
 func getNICDetails(ifdata []introspection.InterfaceType,
 	basedata map[string]introspection.BaseInterfaceType,
 	extradata introspection.ExtraHardwareDataSection) []metal3v1alpha1.NIC {
@@ -76,6 +86,7 @@ func getNICDetails(ifdata []introspection.InterfaceType,
 				VLANID:    vlanid,
 				SpeedGbps: getNICSpeedGbps(extradata[intf.Name]),
 				PXE:       baseIntf.PXE,
+				LinkDown:  getNICLinkDown(extradata[intf.Name]),
 			})
 		}
 		if intf.IPV6Address != "" {
@@ -89,6 +100,7 @@ func getNICDetails(ifdata []introspection.InterfaceType,
 				VLANID:    vlanid,
 				SpeedGbps: getNICSpeedGbps(extradata[intf.Name]),
 				PXE:       baseIntf.PXE,
+				LinkDown:  getNICLinkDown(extradata[intf.Name]),
 			})
 		}
 	}
@@ -157,3 +169,35 @@ func getFirmwareDetails(firmwaredata introspection.ExtraHardwareDataSection) met
 	}
 
 }
+
+// getTPMDetails reads the "tpm" entry of the system section of the
+// extra hardware data, which has the "present" and "version" fields.
+// A TPM with a version is assumed to be present, and nil is returned
+// if the entry is missing.
+func getTPMDetails(systemdata introspection.ExtraHardwareDataSection) *metal3v1alpha1.TPM {
+	tpmdata, ok := systemdata["tpm"]
+	if !ok {
+		return nil
+	}
+
+	tpm := new(metal3v1alpha1.TPM)
+	switch version := tpmdata["version"].(type) {
+	case string:
+		tpm.Version = version
+	case float64:
+		tpm.Version = strconv.FormatFloat(version, 'f', 1, 64)
+	}
+
+	switch present := tpmdata["present"].(type) {
+	case bool:
+		tpm.Present = present
+	case string:
+		tpm.Present, _ = strconv.ParseBool(present)
+	default:
+		tpm.Present = tpm.Version != ""
+	}
+	if !tpm.Present {
+		tpm.Version = ""
+	}
+	return tpm
+}
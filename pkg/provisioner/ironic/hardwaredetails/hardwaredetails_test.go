@@ -1,6 +1,7 @@
 package hardwaredetails
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 
@@ -184,6 +185,10 @@ func TestGetNICDetails(t *testing.T) {
 		introspection.ExtraHardwareDataSection{
 			"eth1": introspection.ExtraHardwareData{
 				"speed": "1Gbps",
+				"link":  "yes",
+			},
+			"eth46": introspection.ExtraHardwareData{
+				"link": "no",
 			},
 		})
 
@@ -212,16 +217,18 @@ func TestGetNICDetails(t *testing.T) {
 		t.Errorf("Unexpected NIC data")
 	}
 	if (!reflect.DeepEqual(nics[2], metal3v1alpha1.NIC{
-		Name: "eth46",
-		MAC:  "00:11:22:33:44:66",
-		IP:   "192.0.2.2",
+		Name:     "eth46",
+		MAC:      "00:11:22:33:44:66",
+		IP:       "192.0.2.2",
+		LinkDown: true,
 	})) {
 		t.Errorf("Unexpected NIC data")
 	}
 	if (!reflect.DeepEqual(nics[3], metal3v1alpha1.NIC{
-		Name: "eth46",
-		MAC:  "00:11:22:33:44:66",
-		IP:   "2001:db8::2",
+		Name:     "eth46",
+		MAC:      "00:11:22:33:44:66",
+		IP:       "2001:db8::2",
+		LinkDown: true,
 	})) {
 		t.Errorf("Unexpected NIC data")
 	}
@@ -261,6 +268,18 @@ func TestGetNICSpeedGbps(t *testing.T) {
 	}
 }
 
+func TestGetNICLinkDown(t *testing.T) {
+	if !getNICLinkDown(introspection.ExtraHardwareData{"link": "no"}) {
+		t.Errorf("Expected link to be down")
+	}
+	if getNICLinkDown(introspection.ExtraHardwareData{"link": "yes"}) {
+		t.Errorf("Expected link to be up")
+	}
+	if getNICLinkDown(introspection.ExtraHardwareData{}) {
+		t.Errorf("Expected a NIC without link data not to be flagged")
+	}
+}
+
 func TestGetFirmwareDetails(t *testing.T) {
 	// Test full (known) firmware payload
 	firmware := getFirmwareDetails(introspection.ExtraHardwareDataSection{
@@ -304,3 +323,239 @@ func TestGetFirmwareDetails(t *testing.T) {
 	}
 
 }
+
+func TestGetTPMDetails(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		Payload  string
+		Expected *metal3v1alpha1.TPM
+	}{
+		{
+			Scenario: "no extra data",
+			Payload:  `{}`,
+		},
+		{
+			Scenario: "no tpm entry",
+			Payload:  `{"extra": {"system": {"product": {"name": "PowerEdge R640"}}}}`,
+		},
+		{
+			Scenario: "tpm 2.0",
+			Payload:  `{"extra": {"system": {"tpm": {"present": true, "version": "2.0"}}}}`,
+			Expected: &metal3v1alpha1.TPM{Present: true, Version: "2.0"},
+		},
+		{
+			Scenario: "numeric version",
+			Payload:  `{"extra": {"system": {"tpm": {"version": 1.2}}}}`,
+			Expected: &metal3v1alpha1.TPM{Present: true, Version: "1.2"},
+		},
+		{
+			Scenario: "string presence",
+			Payload:  `{"extra": {"system": {"tpm": {"present": "true"}}}}`,
+			Expected: &metal3v1alpha1.TPM{Present: true},
+		},
+		{
+			Scenario: "absent",
+			Payload:  `{"extra": {"system": {"tpm": {"present": false}}}}`,
+			Expected: &metal3v1alpha1.TPM{},
+		},
+		{
+			Scenario: "absent with stale version",
+			Payload:  `{"extra": {"system": {"tpm": {"present": "false", "version": "2.0"}}}}`,
+			Expected: &metal3v1alpha1.TPM{},
+		},
+		{
+			Scenario: "unexpected types",
+			Payload:  `{"extra": {"system": {"tpm": {"present": [1], "version": {"major": 2}}}}}`,
+			Expected: &metal3v1alpha1.TPM{},
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			var data introspection.Data
+			if err := json.Unmarshal([]byte(tc.Payload), &data); err != nil {
+				t.Fatal(err)
+			}
+
+			tpm := GetHardwareDetails(&data).TPM
+
+			if !reflect.DeepEqual(tc.Expected, tpm) {
+				t.Errorf("expected TPM %+v, got %+v", tc.Expected, tpm)
+			}
+		})
+	}
+}
+
+func TestGetRAIDControllers(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		Payload  string
+		Expected []metal3v1alpha1.RAIDController
+	}{
+		{
+			Scenario: "no controllers",
+			Payload:  `{"extra": {"disk": {"sda": {"size": 500, "model": "QEMU HARDDISK"}}}}`,
+		},
+		{
+			Scenario: "megaraid",
+			Payload: `{"extra": {
+				"megaraid": {
+					"Controller_0": {"ProductName": "PERC H730P Mini", "SerialNo": "5A30003"}
+				},
+				"pdisk": {
+					"disk1": {"ctrl": "0", "id": "32:1", "type": "SAS", "size": 600},
+					"disk0": {"ctrl": 0, "id": "32:0", "type": "SATA", "size": "479.5", "serial_number": "S3EVNX0K"},
+					"disk9": {"ctrl": "1", "id": "32:9", "type": "SAS", "size": 600}
+				}
+			}}`,
+			Expected: []metal3v1alpha1.RAIDController{
+				{
+					Name:         "Controller_0",
+					Model:        "PERC H730P Mini",
+					SerialNumber: "5A30003",
+					PhysicalDisks: []metal3v1alpha1.RAIDPhysicalDisk{
+						{Slot: "32:0", Type: "SATA", SizeBytes: 479500000000, SerialNumber: "S3EVNX0K"},
+						{Slot: "32:1", Type: "SAS", SizeBytes: 600000000000},
+					},
+				},
+			},
+		},
+		{
+			Scenario: "smart array",
+			Payload: `{"extra": {
+				"hpa": {
+					"slot_0": {"product_name": "Smart Array P440ar", "serial_number": "PDNLH0BRH8Q1TW"},
+					"slot_3": {"product_name": "Smart Array P840"}
+				},
+				"disk": {
+					"sda": {"size": 1200, "model": "LOGICAL VOLUME"},
+					"1I:1:2": {"slot": "0", "type": "SAS", "size": "300", "model": "EG0300FBVFL"},
+					"1I:1:1": {"slot": 0, "type": "SAS", "size": 300, "model": "EG0300FBVFL"}
+				}
+			}}`,
+			Expected: []metal3v1alpha1.RAIDController{
+				{
+					Name:         "slot_0",
+					Model:        "Smart Array P440ar",
+					SerialNumber: "PDNLH0BRH8Q1TW",
+					PhysicalDisks: []metal3v1alpha1.RAIDPhysicalDisk{
+						{Slot: "1I:1:1", Type: "SAS", SizeBytes: 300000000000, Model: "EG0300FBVFL"},
+						{Slot: "1I:1:2", Type: "SAS", SizeBytes: 300000000000, Model: "EG0300FBVFL"},
+					},
+				},
+				{
+					Name:  "slot_3",
+					Model: "Smart Array P840",
+				},
+			},
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			var inventory RAIDInventory
+			if err := json.Unmarshal([]byte(tc.Payload), &inventory); err != nil {
+				t.Fatal(err)
+			}
+
+			controllers := GetRAIDControllers(&inventory)
+
+			if !reflect.DeepEqual(tc.Expected, controllers) {
+				t.Errorf("expected RAID controllers %+v, got %+v", tc.Expected, controllers)
+			}
+		})
+	}
+}
+
+func TestGetAccelerators(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		Payload  string
+		Expected []metal3v1alpha1.Accelerator
+	}{
+		{
+			Scenario: "no PCI devices",
+			Payload:  `{"inventory": {}}`,
+		},
+		{
+			Scenario: "BMC display only",
+			Payload: `{"pci_devices": [
+				{"vendor_id": "1a03", "product_id": "2000", "class": "030000"},
+				{"vendor_id": "8086", "product_id": "1572", "class": "020000"}
+			]}`,
+		},
+		{
+			Scenario: "GPUs and accelerators",
+			Payload: `{"pci_devices": [
+				{"vendor_id": "1a03", "product_id": "2000", "class": "030000"},
+				{"vendor_id": "10de", "product_id": "20b0", "class": "030200"},
+				{"vendor_id": "0x10de", "product_id": "0x20B0", "class": "0x030200"},
+				{"vendor_id": "10de", "product_id": "20b0", "class": "030200"},
+				{"vendor_id": "10de", "product_id": "1eb8", "class": "030200"},
+				{"vendor_id": "1002", "product_id": "7300", "class": "030000"},
+				{"vendor_id": "1da3", "product_id": "1020", "class": "120000"}
+			]}`,
+			Expected: []metal3v1alpha1.Accelerator{
+				{Type: metal3v1alpha1.AcceleratorTypeGPU, VendorID: "1002", DeviceID: "7300", Vendor: "AMD", Count: 1},
+				{Type: metal3v1alpha1.AcceleratorTypeGPU, VendorID: "10de", DeviceID: "1eb8", Vendor: "NVIDIA", Model: "Tesla T4", Count: 1},
+				{Type: metal3v1alpha1.AcceleratorTypeGPU, VendorID: "10de", DeviceID: "20b0", Vendor: "NVIDIA", Model: "A100 SXM4 40GB", Count: 3},
+				{Type: metal3v1alpha1.AcceleratorTypeOther, VendorID: "1da3", DeviceID: "1020", Vendor: "Habana Labs", Count: 1},
+			},
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			var inventory PCIInventory
+			if err := json.Unmarshal([]byte(tc.Payload), &inventory); err != nil {
+				t.Fatal(err)
+			}
+
+			accelerators := GetAccelerators(&inventory)
+
+			if !reflect.DeepEqual(tc.Expected, accelerators) {
+				t.Errorf("expected accelerators %+v, got %+v", tc.Expected, accelerators)
+			}
+		})
+	}
+}
+
+func TestGetMemoryModules(t *testing.T) {
+	for _, tc := range []struct {
+		Scenario string
+		Payload  string
+		Expected []metal3v1alpha1.MemoryModule
+	}{
+		{
+			Scenario: "no memory section",
+			Payload:  `{"extra": {}}`,
+		},
+		{
+			Scenario: "DIMMs",
+			Payload: `{"extra": {"memory": {
+				"total": {"size": 34359738368},
+				"bank:1": {"description": "DIMM DDR4 Synchronous Registered (Buffered) 2666 MHz (0.4 ns)",
+					"size": "17179869184", "clock": "2666000000", "slot": "DIMM_B1",
+					"vendor": "Samsung", "product": "M393A2K43BB1-CTD", "serial": "0x12345678"},
+				"bank:0": {"description": "DIMM DDR4 Synchronous Registered (Buffered) 2666 MHz (0.4 ns)",
+					"size": 17179869184, "clock": 2666000000, "slot": "DIMM_A1",
+					"vendor": "Samsung", "product": "M393A2K43BB1-CTD", "serial": "0x87654321"},
+				"bank:2": {"description": "[empty]", "slot": "DIMM_C1"},
+				"bank:3": {"description": "DIMM Synchronous", "size": "8589934592"}
+			}}}`,
+			Expected: []metal3v1alpha1.MemoryModule{
+				{Slot: "DIMM_A1", SizeMebibytes: 16384, SpeedMHz: 2666, Type: "DDR4",
+					Manufacturer: "Samsung", PartNumber: "M393A2K43BB1-CTD", SerialNumber: "0x87654321"},
+				{Slot: "DIMM_B1", SizeMebibytes: 16384, SpeedMHz: 2666, Type: "DDR4",
+					Manufacturer: "Samsung", PartNumber: "M393A2K43BB1-CTD", SerialNumber: "0x12345678"},
+				{Slot: "bank:3", SizeMebibytes: 8192},
+			},
+		},
+	} {
+		t.Run(tc.Scenario, func(t *testing.T) {
+			var data introspection.Data
+			if err := json.Unmarshal([]byte(tc.Payload), &data); err != nil {
+				t.Fatal(err)
+			}
+			modules := getMemoryModules(data.Extra.Memory)
+
+			if !reflect.DeepEqual(tc.Expected, modules) {
+				t.Errorf("expected memory modules %+v, got %+v", tc.Expected, modules)
+			}
+		})
+	}
+}
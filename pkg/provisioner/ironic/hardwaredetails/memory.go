@@ -0,0 +1,52 @@
+package hardwaredetails
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// memoryTypeRegexp finds the memory technology in the description of a
+// memory bank, e.g. "DIMM DDR4 Synchronous Registered (Buffered) 2666
+// MHz (0.4 ns)"
+var memoryTypeRegexp = regexp.MustCompile(`\b(LP)?DDR[0-9]*\b`)
+
+// getMemoryModules converts the memory banks of the extra hardware
+// data into the memory modules of the host, sorted by slot. The banks
+// are listed as "bank:<index>", with their size in bytes and their
+// clock in Hz, and the empty slots have no size.
+func getMemoryModules(memory introspection.ExtraHardwareDataSection) []metal3v1alpha1.MemoryModule {
+	var modules []metal3v1alpha1.MemoryModule
+	for name, data := range memory {
+		if !strings.HasPrefix(name, "bank:") {
+			continue
+		}
+		size, _ := strconv.ParseInt(extraString(data, "size"), 10, 64)
+		if size <= 0 {
+			continue
+		}
+		slot := extraString(data, "slot")
+		if slot == "" {
+			slot = name
+		}
+		clock, _ := strconv.ParseInt(extraString(data, "clock"), 10, 64)
+		modules = append(modules, metal3v1alpha1.MemoryModule{
+			Slot:          slot,
+			SizeMebibytes: int(size / (1 << 20)),
+			SpeedMHz:      int(clock / 1000000),
+			Type:          memoryTypeRegexp.FindString(extraString(data, "description")),
+			Manufacturer:  extraString(data, "vendor"),
+			PartNumber:    extraString(data, "product"),
+			SerialNumber:  extraString(data, "serial"),
+		})
+	}
+	sort.Slice(modules, func(i, j int) bool {
+		return modules[i].Slot < modules[j].Slot
+	})
+	return modules
+}
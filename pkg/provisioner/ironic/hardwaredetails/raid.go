@@ -0,0 +1,116 @@
+package hardwaredetails
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// RAIDInventory holds the sections of the extra hardware data that
+// describe RAID controllers, which the client library does not parse.
+// MegaRAID controllers are listed in the "megaraid" section, as
+// "Controller_<index>", and their disks in the "pdisk" section. HP
+// Smart Array controllers are listed in the "hpa" section, as
+// "slot_<slot>", and their disks in the "disk" section along with the
+// other block devices.
+type RAIDInventory struct {
+	Extra struct {
+		Disk     introspection.ExtraHardwareDataSection `json:"disk"`
+		HPA      introspection.ExtraHardwareDataSection `json:"hpa"`
+		MegaRAID introspection.ExtraHardwareDataSection `json:"megaraid"`
+		PDisk    introspection.ExtraHardwareDataSection `json:"pdisk"`
+	} `json:"extra"`
+}
+
+// GetRAIDControllers converts the RAID inventory of the introspection
+// data into the RAID controllers of the host, sorted by name.
+func GetRAIDControllers(inventory *RAIDInventory) []metal3v1alpha1.RAIDController {
+	controllers := map[string]*metal3v1alpha1.RAIDController{}
+	addController := func(name string, data introspection.ExtraHardwareData, modelKey, serialKey string) {
+		controllers[name] = &metal3v1alpha1.RAIDController{
+			Name:         name,
+			Model:        extraString(data, modelKey),
+			SerialNumber: extraString(data, serialKey),
+		}
+	}
+	for name, data := range inventory.Extra.MegaRAID {
+		addController(name, data, "ProductName", "SerialNo")
+	}
+	for name, data := range inventory.Extra.HPA {
+		addController(name, data, "product_name", "serial_number")
+	}
+
+	for name, data := range inventory.Extra.PDisk {
+		controller, ok := controllers["Controller_"+extraString(data, "ctrl")]
+		if !ok {
+			continue
+		}
+		slot := extraString(data, "id")
+		if slot == "" {
+			slot = name
+		}
+		controller.PhysicalDisks = append(controller.PhysicalDisks, getRAIDPhysicalDisk(slot, data))
+	}
+	for name, data := range inventory.Extra.Disk {
+		// Block devices have no slot, only disks behind a controller do
+		slot := extraString(data, "slot")
+		if slot == "" {
+			continue
+		}
+		controller, ok := controllers["slot_"+slot]
+		if !ok {
+			continue
+		}
+		controller.PhysicalDisks = append(controller.PhysicalDisks, getRAIDPhysicalDisk(name, data))
+	}
+
+	if len(controllers) == 0 {
+		return nil
+	}
+	result := make([]metal3v1alpha1.RAIDController, 0, len(controllers))
+	for _, controller := range controllers {
+		sort.Slice(controller.PhysicalDisks, func(i, j int) bool {
+			return controller.PhysicalDisks[i].Slot < controller.PhysicalDisks[j].Slot
+		})
+		result = append(result, *controller)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// getRAIDPhysicalDisk reads a physical disk of a RAID controller. The
+// size is reported in GB.
+func getRAIDPhysicalDisk(slot string, data introspection.ExtraHardwareData) metal3v1alpha1.RAIDPhysicalDisk {
+	disk := metal3v1alpha1.RAIDPhysicalDisk{
+		Slot:         slot,
+		Type:         extraString(data, "type"),
+		Model:        extraString(data, "model"),
+		SerialNumber: extraString(data, "serial_number"),
+	}
+	if size, err := strconv.ParseFloat(extraString(data, "size"), 64); err == nil {
+		disk.SizeBytes = metal3v1alpha1.Capacity(size * float64(metal3v1alpha1.GigaByte))
+	}
+	return disk
+}
+
+// extraString returns a field of the extra hardware data as a string.
+// The fields are not typed, so numbers are formatted.
+func extraString(data introspection.ExtraHardwareData, key string) string {
+	switch value := data[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(value)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,115 @@
+package ironic
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+const (
+	// nodeHistoryMicroversion is the first API version exposing the
+	// node history.
+	nodeHistoryMicroversion = "1.78"
+
+	// maxHistoryEvents is the number of the most recent node history
+	// events reported in the host status.
+	maxHistoryEvents = 10
+
+	// historyRefreshInterval is how often the node history is read
+	// again while the state of the node does not change.
+	historyRefreshInterval = 10 * time.Minute
+)
+
+// historyRead is the last time the history of a node was read and the
+// state of the node then.
+type historyRead struct {
+	nodeState string
+	at        time.Time
+}
+
+// historyReads holds the last history read of each node, so that the
+// history is not fetched on every reconcile of every host. Like the
+// other in-process state, it is lost on restart, which only causes
+// another read.
+var historyReads = struct {
+	sync.Mutex
+	byNode map[string]historyRead
+}{byNode: map[string]historyRead{}}
+
+// historyReadNeeded returns whether the history of the node should be
+// read, which is when the state of the node changed or when it was
+// last read longer than historyRefreshInterval ago, and records the
+// read. Failed reads are recorded too, so that they are not retried
+// on every reconcile either.
+func historyReadNeeded(ironicNode *nodes.Node, now time.Time) bool {
+	nodeState := fmt.Sprintf("%s/%s/%s/%s/%t/%s/%s", ironicNode.ProvisionState, ironicNode.TargetProvisionState,
+		ironicNode.PowerState, ironicNode.TargetPowerState, ironicNode.Maintenance, ironicNode.Fault, ironicNode.LastError)
+
+	historyReads.Lock()
+	defer historyReads.Unlock()
+	last, found := historyReads.byNode[ironicNode.UUID]
+	if found && last.nodeState == nodeState && now.Sub(last.at) < historyRefreshInterval {
+		return false
+	}
+	historyReads.byNode[ironicNode.UUID] = historyRead{nodeState: nodeState, at: now}
+	return true
+}
+
+// forgetNodeHistory drops the last history read of a node that is no
+// longer managed.
+func forgetNodeHistory(nodeUUID string) {
+	historyReads.Lock()
+	defer historyReads.Unlock()
+	delete(historyReads.byNode, nodeUUID)
+}
+
+// historyEvent is a node history entry as returned by the Ironic API
+type historyEvent struct {
+	CreatedAt time.Time `json:"created_at"`
+	Severity  string    `json:"severity"`
+	EventType string    `json:"event_type"`
+	Event     string    `json:"event"`
+}
+
+// getNodeHistory returns the most recent events of the node history,
+// oldest first.
+func (p *ironicProvisioner) getNodeHistory(ironicNode *nodes.Node) (events []metal3v1alpha1.HistoryEvent, err error) {
+	// The history needs a newer API version than the one used for
+	// the rest of the requests.
+	client := *p.client
+	client.Microversion = nodeHistoryMicroversion
+
+	var body struct {
+		History []historyEvent `json:"history"`
+	}
+	_, err = client.Get(client.ServiceURL("nodes", ironicNode.UUID, "history")+"?detail=true", &body, nil)
+	if err != nil {
+		return
+	}
+
+	history := body.History
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].CreatedAt.Before(history[j].CreatedAt)
+	})
+	if len(history) > maxHistoryEvents {
+		history = history[len(history)-maxHistoryEvents:]
+	}
+
+	events = make([]metal3v1alpha1.HistoryEvent, 0, len(history))
+	for _, entry := range history {
+		events = append(events, metal3v1alpha1.HistoryEvent{
+			// The status only keeps a precision of a second
+			Time:      metav1.NewTime(entry.CreatedAt.Truncate(time.Second)),
+			Severity:  entry.Severity,
+			EventType: entry.EventType,
+			Event:     entry.Event,
+		})
+	}
+	return
+}
@@ -0,0 +1,18 @@
+package ironic
+
+import (
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// IsImageCached tells whether the local image cache already holds the
+// image, in which case Ironic deploys it from the cache. Ironic does
+// not report the images cached by its conductors, so nil is returned
+// when no local image cache is configured or the image cannot be
+// cached.
+func (p *ironicProvisioner) IsImageCached(image metal3v1alpha1.Image) (cached *bool, err error) {
+	if localImageCacheSource(&image) == "" {
+		return nil, nil
+	}
+	found := p.localImageCached(&image)
+	return &found, nil
+}
@@ -0,0 +1,84 @@
+package ironic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+func TestIsImageCached(t *testing.T) {
+	defer func(value string) { localImageCacheURL = value }(localImageCacheURL)
+
+	var cachedContent string
+	cache := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cachedContent == "" || r.URL.Path != "/"+cachedChecksum+"/image.qcow2.sha256" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(cachedContent))
+	}))
+	defer cache.Close()
+
+	yes, no := true, false
+	cases := []struct {
+		name          string
+		cacheURL      string
+		cachedContent string
+		checksum      string
+
+		expectedCached *bool
+	}{
+		{
+			name:           "cached",
+			cacheURL:       cache.URL,
+			cachedContent:  cachedChecksum,
+			checksum:       cachedChecksum,
+			expectedCached: &yes,
+		},
+		{
+			name:           "not cached",
+			cacheURL:       cache.URL,
+			checksum:       cachedChecksum,
+			expectedCached: &no,
+		},
+		{
+			name:     "no local image cache",
+			checksum: cachedChecksum,
+		},
+		{
+			name:     "checksum URL",
+			cacheURL: cache.URL,
+			checksum: "http://example.test/image.qcow2.sha256sum",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			localImageCacheURL = tc.cacheURL
+			cachedContent = tc.cachedContent
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(makeHost(), bmc.Credentials{}, nullEventPublisher,
+				"https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			cached, err := prov.IsImageCached(metal3v1alpha1.Image{
+				URL:          "http://example.test/image.qcow2",
+				Checksum:     tc.checksum,
+				ChecksumType: metal3v1alpha1.SHA256,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedCached, cached)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package ironic
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// imageDownloads holds the nodes fetching an image from the image
+// server, or allowed to start doing so, counted against
+// maxImageDownloads. It is kept in the process, like the other
+// provisioning limits, and rebuilt from the state of the nodes as their
+// hosts are reconciled after a restart.
+var imageDownloads = struct {
+	sync.Mutex
+	nodes map[string]bool
+}{nodes: map[string]bool{}}
+
+// isDownloadingImage returns whether a node is deploying an image that
+// has to be fetched from the image server. Images deployed from the
+// local image cache do not load the image server.
+func isDownloadingImage(ironicNode *nodes.Node) bool {
+	switch nodes.ProvisionState(ironicNode.ProvisionState) {
+	case nodes.Deploying, nodes.DeployWait:
+	default:
+		return false
+	}
+	source, _ := ironicNode.InstanceInfo["image_source"].(string)
+	if localImageCacheURL != "" && strings.HasPrefix(source, localImageCacheURL+"/") {
+		return false
+	}
+	return true
+}
+
+// trackImageDownload records whether the node is fetching an image,
+// releasing its download slot once the deploy is over.
+func trackImageDownload(ironicNode *nodes.Node) {
+	imageDownloads.Lock()
+	defer imageDownloads.Unlock()
+	if isDownloadingImage(ironicNode) {
+		imageDownloads.nodes[ironicNode.UUID] = true
+	} else {
+		delete(imageDownloads.nodes, ironicNode.UUID)
+	}
+}
+
+// releaseImageDownload releases the download slot of a node that is
+// no longer managed.
+func releaseImageDownload(nodeUUID string) {
+	imageDownloads.Lock()
+	defer imageDownloads.Unlock()
+	delete(imageDownloads.nodes, nodeUUID)
+}
+
+// imageDownloadAllowed returns whether the node may start deploying the
+// image without exceeding the number of simultaneous image downloads,
+// taking a download slot if so. Images found in the local image cache
+// are not downloaded and always proceed.
+func (p *ironicProvisioner) imageDownloadAllowed(ironicNode *nodes.Node, image metal3v1alpha1.Image) bool {
+	if maxImageDownloads <= 0 {
+		return true
+	}
+
+	source, _ := ironicNode.InstanceInfo["image_source"].(string)
+	if cached := localImageCacheSource(&image); cached != "" && source == cached {
+		return true
+	}
+
+	imageDownloads.Lock()
+	defer imageDownloads.Unlock()
+	if imageDownloads.nodes[ironicNode.UUID] {
+		return true
+	}
+	if downloading := len(imageDownloads.nodes); downloading >= maxImageDownloads {
+		p.log.Info("too many image downloads in progress, deferring the deploy",
+			"downloading", downloading, "limit", maxImageDownloads)
+		return false
+	}
+	imageDownloads.nodes[ironicNode.UUID] = true
+	return true
+}
@@ -0,0 +1,179 @@
+package ironic
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestProvisionImageDownloadLimit(t *testing.T) {
+	defer func(value int) { maxImageDownloads = value }(maxImageDownloads)
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name        string
+		limit       int
+		downloading int
+		holdsSlot   bool
+
+		expectedDeploy bool
+	}{
+		{
+			name:           "no limit",
+			downloading:    5,
+			expectedDeploy: true,
+		},
+		{
+			name:           "below the limit",
+			limit:          3,
+			downloading:    2,
+			expectedDeploy: true,
+		},
+		{
+			name:        "limit reached",
+			limit:       2,
+			downloading: 2,
+		},
+		{
+			name:           "slot already taken",
+			limit:          2,
+			downloading:    1,
+			holdsSlot:      true,
+			expectedDeploy: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			maxImageDownloads = tc.limit
+			imageDownloads.nodes = map[string]bool{}
+			defer func() { imageDownloads.nodes = map[string]bool{} }()
+			for n := 0; n < tc.downloading; n++ {
+				imageDownloads.nodes[fmt.Sprintf("node-%d", n)] = true
+			}
+			if tc.holdsSlot {
+				imageDownloads.nodes[nodeUUID] = true
+			}
+
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Available),
+				UUID:           nodeUUID,
+			}).WithNodeStatesProvisionUpdate(nodeUUID)
+			ironic.ResponseJSON("/v1/nodes/"+nodeUUID+"/validate", nodes.NodeValidation{
+				Boot:   nodes.DriverValidation{Result: true},
+				Deploy: nodes.DriverValidation{Result: true},
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image:      *host.Spec.Image,
+				HostConfig: fixture.NewHostConfigData("testUserData", "test: NetworkData", "test: Meta"),
+				BootMode:   v1alpha1.DefaultBootMode,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, "", result.ErrorMessage)
+			_, deployed := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			assert.Equal(t, tc.expectedDeploy, deployed)
+			if tc.limit > 0 {
+				assert.Equal(t, tc.expectedDeploy, imageDownloads.nodes[nodeUUID])
+			}
+			if !tc.expectedDeploy {
+				assert.True(t, result.Dirty)
+				assert.Equal(t, provisionRequeueDelay, result.RequeueAfter)
+			}
+		})
+	}
+}
+
+func TestTrackImageDownload(t *testing.T) {
+	defer func() { imageDownloads.nodes = map[string]bool{} }()
+	imageDownloads.nodes = map[string]bool{}
+	node := nodes.Node{
+		UUID:           "33ce8659-7400-4c68-9535-d10766f07a58",
+		ProvisionState: string(nodes.DeployWait),
+		InstanceInfo:   map[string]interface{}{"image_source": "http://example.test/image.qcow2"},
+	}
+
+	// A deploy in progress found after a restart takes its slot back
+	trackImageDownload(&node)
+	assert.True(t, imageDownloads.nodes[node.UUID])
+
+	node.ProvisionState = string(nodes.Active)
+	trackImageDownload(&node)
+	assert.False(t, imageDownloads.nodes[node.UUID])
+
+	imageDownloads.nodes[node.UUID] = true
+	releaseImageDownload(node.UUID)
+	assert.Empty(t, imageDownloads.nodes)
+}
+
+func TestIsDownloadingImage(t *testing.T) {
+	defer func(value string) { localImageCacheURL = value }(localImageCacheURL)
+	localImageCacheURL = "http://cache.test/images"
+
+	cases := []struct {
+		name        string
+		state       nodes.ProvisionState
+		imageSource string
+		expected    bool
+	}{
+		{
+			name:        "deploying from the image server",
+			state:       nodes.Deploying,
+			imageSource: "http://example.test/image.qcow2",
+			expected:    true,
+		},
+		{
+			name:        "waiting for the deploy from the image server",
+			state:       nodes.DeployWait,
+			imageSource: "http://example.test/image.qcow2",
+			expected:    true,
+		},
+		{
+			name:        "deploying from the local image cache",
+			state:       nodes.DeployWait,
+			imageSource: "http://cache.test/images/abc/image.qcow2",
+		},
+		{
+			name:        "deployed",
+			state:       nodes.Active,
+			imageSource: "http://example.test/image.qcow2",
+		},
+		{
+			name:  "cleaning",
+			state: nodes.Cleaning,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := nodes.Node{
+				ProvisionState: string(tc.state),
+				InstanceInfo:   map[string]interface{}{"image_source": tc.imageSource},
+			}
+			assert.Equal(t, tc.expected, isDownloadingImage(&node))
+		})
+	}
+}
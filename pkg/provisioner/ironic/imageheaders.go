@@ -0,0 +1,21 @@
+package ironic
+
+import (
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+)
+
+// imageHeadersKey is the instance_info field holding the HTTP headers
+// the deploy agent sends when downloading the image.
+const imageHeadersKey = "image_extra_headers"
+
+// setImageHeadersUpdateOpts sets the HTTP headers of the image download
+// in the instance_info of the node, or removes them if the image has
+// none, so that headers meant for a previous image are not sent to the
+// server of the next one.
+func setImageHeadersUpdateOpts(ironicNode *nodes.Node, headers map[string]string, updater *nodeUpdater) {
+	settings := optionsData{imageHeadersKey: nil}
+	if len(headers) != 0 {
+		settings[imageHeadersKey] = headers
+	}
+	updater.SetInstanceInfoOpts(settings, ironicNode)
+}
@@ -0,0 +1,99 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestProvisionImageHeaders(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name         string
+		headers      map[string]string
+		instanceInfo map[string]interface{}
+
+		expectedUpdate *nodes.UpdateOperation
+	}{
+		{
+			name:    "headers set",
+			headers: map[string]string{"Authorization": "Bearer abc"},
+			expectedUpdate: &nodes.UpdateOperation{
+				Op:    nodes.AddOp,
+				Path:  "/instance_info/image_extra_headers",
+				Value: map[string]interface{}{"Authorization": "Bearer abc"},
+			},
+		},
+		{
+			name:         "headers unchanged",
+			headers:      map[string]string{"Authorization": "Bearer abc"},
+			instanceInfo: map[string]interface{}{"image_extra_headers": map[string]interface{}{"Authorization": "Bearer abc"}},
+		},
+		{
+			name:         "headers removed",
+			instanceInfo: map[string]interface{}{"image_extra_headers": map[string]interface{}{"Authorization": "Bearer abc"}},
+			expectedUpdate: &nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/instance_info/image_extra_headers",
+			},
+		},
+		{
+			name: "no headers",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := nodes.Node{
+				ProvisionState: string(nodes.Available),
+				UUID:           nodeUUID,
+				InstanceInfo:   tc.instanceInfo,
+			}
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(node).NodeUpdate(node).
+				WithNodeStatesProvisionUpdate(nodeUUID)
+			ironic.ResponseJSON("/v1/nodes/"+nodeUUID+"/validate", nodes.NodeValidation{
+				Boot:   nodes.DriverValidation{Result: true},
+				Deploy: nodes.DriverValidation{Result: true},
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image:        *host.Spec.Image,
+				HostConfig:   fixture.NewHostConfigData("testUserData", "test: NetworkData", "test: Meta"),
+				BootMode:     metal3v1alpha1.DefaultBootMode,
+				ImageHeaders: tc.headers,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, "", result.ErrorMessage)
+			var update *nodes.UpdateOperation
+			for _, op := range ironic.GetLastNodeUpdateRequestFor(nodeUUID) {
+				if op.Path == "/instance_info/image_extra_headers" {
+					op := op
+					update = &op
+				}
+			}
+			assert.Equal(t, tc.expectedUpdate, update)
+		})
+	}
+}
@@ -229,3 +229,89 @@ func TestInspectHardware(t *testing.T) {
 		})
 	}
 }
+
+func TestInspectHardwareBMCFirmware(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name            string
+		ironic          *testserver.IronicMock
+		expectedVersion string
+	}{
+		{
+			name: "bmc-version",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}).FirmwareComponents(nodeUUID, []map[string]interface{}{
+				{
+					"component":            "bios",
+					"initial_version":      "U30 v2.54",
+					"current_version":      "U30 v2.56",
+					"last_version_flashed": "U30 v2.56",
+				},
+				{
+					"component":            "bmc",
+					"initial_version":      "iLO 5 v2.72",
+					"current_version":      "iLO 5 v2.78",
+					"last_version_flashed": nil,
+				},
+			}),
+			expectedVersion: "iLO 5 v2.78",
+		},
+		{
+			name: "no-bmc-component",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}).FirmwareComponents(nodeUUID, []map[string]interface{}{
+				{
+					"component":       "bios",
+					"current_version": "U30 v2.56",
+				},
+			}),
+		},
+		{
+			name: "firmware-not-supported",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			}),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.ironic.Start()
+			defer tc.ironic.Stop()
+
+			inspector := testserver.NewInspector(t).Ready().
+				WithIntrospection(nodeUUID, introspection.Introspection{
+					Finished: true,
+				}).
+				WithIntrospectionData(nodeUUID, introspection.Data{})
+			inspector.Start()
+			defer inspector.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				tc.ironic.Endpoint(), auth, inspector.Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, details, err := prov.InspectHardware(
+				provisioner.InspectData{BootMode: metal3v1alpha1.DefaultBootMode},
+				false, false)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "", result.ErrorMessage)
+			if assert.NotNil(t, details) {
+				assert.Equal(t, tc.expectedVersion, details.Firmware.BMCVersion)
+			}
+		})
+	}
+}
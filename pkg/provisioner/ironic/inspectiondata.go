@@ -0,0 +1,109 @@
+package ironic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+// inspectionDataTimeout limits the time spent storing the inspection
+// data externally, so that an unavailable storage does not block the
+// reconcile loop.
+const inspectionDataTimeout = 30 * time.Second
+
+// inspectionDataStorage keeps the full inspection data of the nodes,
+// of which the hardware details of the hosts are only a summary.
+type inspectionDataStorage interface {
+	// store saves the inspection data of the node and returns a
+	// reference to it, or an empty string if no reference is kept.
+	store(nodeUUID string, data interface{}) (string, error)
+}
+
+// inlineInspectionData keeps only the hardware details in the host,
+// which is the default.
+type inlineInspectionData struct{}
+
+func (inlineInspectionData) store(string, interface{}) (string, error) {
+	return "", nil
+}
+
+// externalInspectionData uploads the inspection data of each node to
+// <baseURL>/<node UUID>.json, e.g. to an object storage bucket, and
+// references it from the hardware details of the host.
+type externalInspectionData struct {
+	baseURL string
+	auth    clients.AuthConfig
+	tls     clients.TLSConfig
+}
+
+func (s externalInspectionData) store(nodeUUID string, data interface{}) (string, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	location := s.baseURL + "/" + nodeUUID + ".json"
+
+	request, err := http.NewRequest(http.MethodPut, location, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if s.auth.Type == clients.HTTPBasicAuth {
+		request.SetBasicAuth(s.auth.Username, s.auth.Password)
+	}
+	client, err := clients.HTTPClient(s.tls, clients.ConnectionConfig{RequestTimeout: inspectionDataTimeout})
+	if err != nil {
+		return "", err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return "", fmt.Errorf("unexpected status %s when uploading to %s", response.Status, location)
+	}
+	return location, nil
+}
+
+// getInspectionDataStorage returns the storage configured with
+// INSPECTION_DATA_URL.
+func getInspectionDataStorage() inspectionDataStorage {
+	if inspectionDataURL == "" {
+		return inlineInspectionData{}
+	}
+	return externalInspectionData{
+		baseURL: inspectionDataURL,
+		auth:    inspectionDataAuth,
+		tls:     inspectionDataTLS,
+	}
+}
+
+// storeInspectionData saves the full inspection data of the node. Once
+// it is stored externally, the hardware details only keep a summary
+// and the reference to the data: the memory modules and accelerators,
+// which are only informative, are left out. A failed upload does not
+// fail the inspection, the host then keeps its full hardware details.
+func (p *ironicProvisioner) storeInspectionData(ironicNode *nodes.Node, data interface{}, details *metal3v1alpha1.HardwareDetails) {
+	dataURL, err := getInspectionDataStorage().store(ironicNode.UUID, data)
+	if err != nil {
+		p.log.Info("could not store the inspection data", "error", err)
+		p.publisher("InspectionDataNotStored",
+			fmt.Sprintf("Keeping the full hardware details, failed to store the inspection data: %s", err))
+		return
+	}
+	if dataURL == "" {
+		return
+	}
+
+	details.InspectionDataURL = dataURL
+	details.MemoryModules = nil
+	details.Accelerators = nil
+}
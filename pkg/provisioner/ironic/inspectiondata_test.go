@@ -0,0 +1,204 @@
+package ironic
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestInspectHardwareInspectionData(t *testing.T) {
+	defer func(value string, auth clients.AuthConfig, tls clients.TLSConfig) {
+		inspectionDataURL, inspectionDataAuth, inspectionDataTLS = value, auth, tls
+	}(inspectionDataURL, inspectionDataAuth, inspectionDataTLS)
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name         string
+		external     bool
+		secure       bool
+		auth         clients.AuthConfig
+		uploadStatus int
+
+		expectedUpload bool
+		expectedURL    bool
+		expectedEvent  string
+	}{
+		{
+			name: "inline",
+		},
+		{
+			name:           "reference",
+			external:       true,
+			uploadStatus:   http.StatusCreated,
+			expectedUpload: true,
+			expectedURL:    true,
+		},
+		{
+			name:           "reference with authentication",
+			external:       true,
+			auth:           clients.AuthConfig{Type: clients.HTTPBasicAuth, Username: "user", Password: "secret"},
+			uploadStatus:   http.StatusCreated,
+			expectedUpload: true,
+			expectedURL:    true,
+		},
+		{
+			name:           "reference over TLS",
+			external:       true,
+			secure:         true,
+			uploadStatus:   http.StatusCreated,
+			expectedUpload: true,
+			expectedURL:    true,
+		},
+		{
+			name:           "upload failed",
+			external:       true,
+			uploadStatus:   http.StatusForbidden,
+			expectedUpload: true,
+			expectedEvent:  "InspectionDataNotStored",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var uploadPath, uploadType string
+			var uploaded introspection.Data
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPut {
+					w.WriteHeader(http.StatusMethodNotAllowed)
+					return
+				}
+				if tc.auth.Type == clients.HTTPBasicAuth {
+					if user, password, ok := r.BasicAuth(); !ok || user != tc.auth.Username || password != tc.auth.Password {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+				}
+				uploadPath = r.URL.Path
+				uploadType = r.Header.Get("Content-Type")
+				body, _ := ioutil.ReadAll(r.Body)
+				json.Unmarshal(body, &uploaded)
+				w.WriteHeader(tc.uploadStatus)
+			})
+			var storage *httptest.Server
+			if tc.secure {
+				storage = httptest.NewTLSServer(handler)
+			} else {
+				storage = httptest.NewServer(handler)
+			}
+			defer storage.Close()
+			inspectionDataURL = ""
+			if tc.external {
+				inspectionDataURL = storage.URL + "/inspection"
+			}
+			inspectionDataAuth = tc.auth
+			inspectionDataTLS = clients.TLSConfig{InsecureSkipVerify: tc.secure}
+
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+			})
+			ironic.Start()
+			defer ironic.Stop()
+			inspector := testserver.NewInspector(t).Ready().
+				WithIntrospection(nodeUUID, introspection.Introspection{
+					Finished: true,
+				}).
+				WithIntrospectionData(nodeUUID, introspection.Data{
+					Inventory: introspection.InventoryType{
+						Hostname: "node-0",
+					},
+				})
+			inspector.Start()
+			defer inspector.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			var events []string
+			publisher := func(reason, message string) {
+				events = append(events, reason)
+			}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, inspector.Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			_, details, err := prov.InspectHardware(
+				provisioner.InspectData{BootMode: metal3v1alpha1.DefaultBootMode},
+				false, false)
+
+			if tc.expectedUpload {
+				assert.Equal(t, "/inspection/"+nodeUUID+".json", uploadPath)
+				assert.Equal(t, "application/json", uploadType)
+				assert.Equal(t, "node-0", uploaded.Inventory.Hostname)
+			} else {
+				assert.Empty(t, uploadPath)
+			}
+			// A failed upload does not fail the inspection
+			assert.NoError(t, err)
+			if tc.expectedEvent != "" {
+				assert.Contains(t, events, tc.expectedEvent)
+			}
+			if assert.NotNil(t, details) {
+				// The hardware details are kept in both modes
+				assert.Equal(t, "node-0", details.Hostname)
+				if tc.expectedURL {
+					assert.Equal(t, storage.URL+"/inspection/"+nodeUUID+".json", details.InspectionDataURL)
+				} else {
+					assert.Empty(t, details.InspectionDataURL)
+				}
+			}
+		})
+	}
+}
+
+func TestStoreInspectionDataSummary(t *testing.T) {
+	defer func(value string) { inspectionDataURL = value }(inspectionDataURL)
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer storage.Close()
+
+	newDetails := func() *metal3v1alpha1.HardwareDetails {
+		return &metal3v1alpha1.HardwareDetails{
+			Hostname:      "node-0",
+			RAMMebibytes:  16384,
+			NIC:           []metal3v1alpha1.NIC{{Name: "eth0", MAC: "00:11:22:33:44:55"}},
+			Storage:       []metal3v1alpha1.Storage{{Name: "/dev/sda"}},
+			Accelerators:  []metal3v1alpha1.Accelerator{{VendorID: "10de", DeviceID: "20b0"}},
+			MemoryModules: []metal3v1alpha1.MemoryModule{{Slot: "DIMM_A1"}},
+		}
+	}
+	prov := &ironicProvisioner{log: log, publisher: nullEventPublisher}
+	node := &nodes.Node{UUID: "33ce8659-7400-4c68-9535-d10766f07a58"}
+
+	// Inline, the details are kept in full
+	inspectionDataURL = ""
+	details := newDetails()
+	prov.storeInspectionData(node, map[string]interface{}{}, details)
+	assert.Equal(t, newDetails(), details)
+
+	// With a reference, only the informative lists are left out
+	inspectionDataURL = storage.URL
+	details = newDetails()
+	prov.storeInspectionData(node, map[string]interface{}{}, details)
+	expected := newDetails()
+	expected.Accelerators = nil
+	expected.MemoryModules = nil
+	expected.InspectionDataURL = storage.URL + "/" + node.UUID + ".json"
+	assert.Equal(t, expected, details)
+}
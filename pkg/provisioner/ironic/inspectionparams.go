@@ -0,0 +1,63 @@
+package ironic
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+)
+
+// inspectionKernelParamsExtraKey is the key of the node's extra field
+// recording the kernel parameters set for the inspection, so that they
+// are only removed from the driver_info when they were set by us.
+const inspectionKernelParamsExtraKey = "metal3_inspection_kernel_params"
+
+// defaultKernelParams is replaced by Ironic with the kernel parameters
+// configured for the conductor, which the ramdisk still needs.
+const defaultKernelParams = "%default%"
+
+// maxInspectionKernelParamsLength keeps the kernel command line of the
+// ramdisk well within the limits of the boot loaders.
+const maxInspectionKernelParamsLength = 1024
+
+// validateInspectionKernelParams checks that the kernel parameters of
+// the inspection ramdisk fit on a single kernel command line.
+func validateInspectionKernelParams(params string) error {
+	if len(params) > maxInspectionKernelParamsLength {
+		return fmt.Errorf("inspectionKernelParams must not be longer than %d characters", maxInspectionKernelParamsLength)
+	}
+	for _, char := range params {
+		if unicode.IsControl(char) && char != ' ' {
+			return fmt.Errorf("inspectionKernelParams must not contain control characters such as newlines")
+		}
+	}
+	if strings.Contains(params, defaultKernelParams) {
+		return fmt.Errorf("inspectionKernelParams must not contain %s, the default parameters are always kept", defaultKernelParams)
+	}
+	return nil
+}
+
+// setInspectionKernelParamsUpdateOpts sets the kernel parameters of the
+// ramdisk booted for the inspection, in addition to the default ones,
+// or removes the ones set for a previous inspection.
+func setInspectionKernelParamsUpdateOpts(ironicNode *nodes.Node, params string, updater *nodeUpdater) {
+	params = strings.TrimSpace(params)
+	if params == "" {
+		clearInspectionKernelParamsUpdateOpts(ironicNode, updater)
+		return
+	}
+	updater.SetDriverInfoOpts(optionsData{"kernel_append_params": defaultKernelParams + " " + params}, ironicNode)
+	updater.SetExtraOpts(optionsData{inspectionKernelParamsExtraKey: params}, ironicNode)
+}
+
+// clearInspectionKernelParamsUpdateOpts removes the kernel parameters
+// set for the inspection, so that the ramdisk booted for cleaning and
+// deploying does not use them. Kernel parameters not set by us are kept.
+func clearInspectionKernelParamsUpdateOpts(ironicNode *nodes.Node, updater *nodeUpdater) {
+	if _, present := ironicNode.Extra[inspectionKernelParamsExtraKey]; !present {
+		return
+	}
+	updater.SetDriverInfoOpts(optionsData{"kernel_append_params": nil}, ironicNode)
+	updater.SetExtraOpts(optionsData{inspectionKernelParamsExtraKey: nil}, ironicNode)
+}
@@ -0,0 +1,259 @@
+package ironic
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestValidateInspectionKernelParams(t *testing.T) {
+	cases := []struct {
+		name          string
+		params        string
+		expectedError string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:   "valid",
+			params: "modprobe.blacklist=megaraid_sas console=ttyS0,115200",
+		},
+		{
+			name:          "newline",
+			params:        "modprobe.blacklist=megaraid_sas\nconsole=ttyS0",
+			expectedError: "inspectionKernelParams must not contain control characters such as newlines",
+		},
+		{
+			name:          "default placeholder",
+			params:        "%default% nomodeset",
+			expectedError: "inspectionKernelParams must not contain %default%, the default parameters are always kept",
+		},
+		{
+			name:          "too long",
+			params:        strings.Repeat("a", 1025),
+			expectedError: "inspectionKernelParams must not be longer than 1024 characters",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateInspectionKernelParams(tc.params)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+// inspectionKernelParamsPatches returns the values of the patches
+// touching the kernel parameters, indexed by path, nil for removals
+func inspectionKernelParamsPatches(t *testing.T, body string) map[string]interface{} {
+	var patches []nodes.UpdateOperation
+	if err := json.Unmarshal([]byte(body), &patches); err != nil {
+		t.Fatalf("could not parse the node update: %s", err)
+	}
+	values := map[string]interface{}{}
+	for _, patch := range patches {
+		if patch.Path == "/driver_info/kernel_append_params" || patch.Path == "/extra/"+inspectionKernelParamsExtraKey {
+			values[patch.Path] = patch.Value
+		}
+	}
+	return values
+}
+
+func TestInspectionKernelParamsStartInspection(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name            string
+		node            nodes.Node
+		params          string
+		expectedPatches map[string]interface{}
+		expectedError   string
+	}{
+		{
+			name:   "params",
+			node:   nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.Manageable)},
+			params: "modprobe.blacklist=megaraid_sas",
+			expectedPatches: map[string]interface{}{
+				"/driver_info/kernel_append_params":        "%default% modprobe.blacklist=megaraid_sas",
+				"/extra/" + inspectionKernelParamsExtraKey: "modprobe.blacklist=megaraid_sas",
+			},
+		},
+		{
+			name: "params removed",
+			node: nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+				DriverInfo:     map[string]interface{}{"kernel_append_params": "%default% nomodeset"},
+				Extra:          map[string]interface{}{inspectionKernelParamsExtraKey: "nomodeset"},
+			},
+			expectedPatches: map[string]interface{}{
+				"/driver_info/kernel_append_params":        nil,
+				"/extra/" + inspectionKernelParamsExtraKey: nil,
+			},
+		},
+		{
+			name: "params not set by us",
+			node: nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.Manageable),
+				DriverInfo:     map[string]interface{}{"kernel_append_params": "nomodeset"},
+			},
+			expectedPatches: map[string]interface{}{},
+		},
+		{
+			name:          "invalid params",
+			node:          nodes.Node{UUID: nodeUUID, ProvisionState: string(nodes.Manageable)},
+			params:        "nomodeset\n",
+			expectedError: "inspectionKernelParams must not contain control characters such as newlines",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(tc.node)
+			ironic.Start()
+			defer ironic.Stop()
+			inspector := testserver.NewInspector(t).Ready().WithIntrospectionFailed(nodeUUID, http.StatusNotFound)
+			inspector.Start()
+			defer inspector.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, inspector.Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.InspectHardware(
+				provisioner.InspectData{BootMode: metal3v1alpha1.DefaultBootMode, KernelParams: tc.params},
+				false, false)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			body, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID, http.MethodPatch)
+			if tc.expectedError != "" {
+				assert.False(t, found)
+				return
+			}
+			assert.True(t, found)
+			assert.Equal(t, tc.expectedPatches, inspectionKernelParamsPatches(t, body))
+		})
+	}
+}
+
+func TestInspectionKernelParamsClearedAfterInspection(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+		UUID:           nodeUUID,
+		ProvisionState: string(nodes.Manageable),
+		DriverInfo:     map[string]interface{}{"kernel_append_params": "%default% nomodeset"},
+		Extra:          map[string]interface{}{inspectionKernelParamsExtraKey: "nomodeset"},
+	})
+	ironic.Start()
+	defer ironic.Stop()
+	inspector := testserver.NewInspector(t).Ready().
+		WithIntrospection(nodeUUID, introspection.Introspection{Finished: true}).
+		WithIntrospectionData(nodeUUID, introspection.Data{})
+	inspector.Start()
+	defer inspector.Stop()
+
+	host := makeHost()
+	host.Status.Provisioning.ID = nodeUUID
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, inspector.Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	_, details, err := prov.InspectHardware(
+		provisioner.InspectData{BootMode: metal3v1alpha1.DefaultBootMode, KernelParams: "nomodeset"},
+		false, false)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, details)
+	body, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID, http.MethodPatch)
+	assert.True(t, found)
+	assert.Equal(t, map[string]interface{}{
+		"/driver_info/kernel_append_params":        nil,
+		"/extra/" + inspectionKernelParamsExtraKey: nil,
+	}, inspectionKernelParamsPatches(t, body))
+}
+
+func TestInspectionKernelParamsNotUsedForDeploy(t *testing.T) {
+	cases := []struct {
+		name            string
+		node            nodes.Node
+		expectedPatches map[string]interface{}
+	}{
+		{
+			name: "left from inspection",
+			node: nodes.Node{
+				DriverInfo: map[string]interface{}{"kernel_append_params": "%default% nomodeset"},
+				Extra:      map[string]interface{}{inspectionKernelParamsExtraKey: "nomodeset"},
+			},
+			expectedPatches: map[string]interface{}{
+				"/driver_info/kernel_append_params":        nil,
+				"/extra/" + inspectionKernelParamsExtraKey: nil,
+			},
+		},
+		{
+			name: "not set by us",
+			node: nodes.Node{
+				DriverInfo: map[string]interface{}{"kernel_append_params": "nomodeset"},
+			},
+			expectedPatches: map[string]interface{}{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				"https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			hwProf, _ := hardware.GetProfile("libvirt")
+			patches := prov.getUpdateOptsForNode(&tc.node, provisioner.ProvisionData{
+				Image:           *host.Spec.Image,
+				BootMode:        metal3v1alpha1.DefaultBootMode,
+				HardwareProfile: hwProf,
+			}).updates
+
+			values := map[string]interface{}{}
+			for _, patch := range patches {
+				update := patch.(nodes.UpdateOperation)
+				if update.Path == "/driver_info/kernel_append_params" || update.Path == "/extra/"+inspectionKernelParamsExtraKey {
+					values[update.Path] = update.Value
+				}
+			}
+			assert.Equal(t, tc.expectedPatches, values)
+		})
+	}
+}
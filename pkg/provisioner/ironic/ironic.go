@@ -11,6 +11,7 @@ import (
 
 	"github.com/go-logr/logr"
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/drivers"
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
 	"github.com/gophercloud/gophercloud/openstack/baremetalintrospection/v1/introspection"
@@ -22,6 +23,7 @@ import (
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/configdrive"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/devicehints"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/hardwaredetails"
 )
@@ -32,9 +34,14 @@ var (
 	provisionRequeueDelay     = time.Second * 10
 	powerRequeueDelay         = time.Second * 10
 	introspectionRequeueDelay = time.Second * 15
+	nodeLockedRequeueDelay    = time.Second * 5
 	softPowerOffTimeout       = time.Second * 180
 	deployKernelURL           string
 	deployRamdiskURL          string
+	localImageCacheURL        string
+	inspectionDataURL         string
+	inspectionDataAuth        clients.AuthConfig
+	inspectionDataTLS         clients.TLSConfig
 	ironicEndpoint            string
 	inspectorEndpoint         string
 	ironicTrustedCAFile       string
@@ -44,7 +51,10 @@ var (
 	ironicSkipClientSANVerify bool
 	ironicAuth                clients.AuthConfig
 	inspectorAuth             clients.AuthConfig
+	ironicConnectionConfig    clients.ConnectionConfig
 	maxBusyHosts              int = 20
+	maxBusyHostsPerGroup      int
+	maxImageDownloads         int
 
 	// Keep pointers to ironic and inspector clients configured with
 	// the global auth settings to reuse the connection between
@@ -92,6 +102,11 @@ func init() {
 		fmt.Fprintf(os.Stderr, "Cannot start: %s\n", authErr)
 		os.Exit(1)
 	}
+	inspectionDataAuth, authErr = clients.LoadInspectionDataAuth()
+	if authErr != nil {
+		fmt.Fprintf(os.Stderr, "Cannot start: %s\n", authErr)
+		os.Exit(1)
+	}
 
 	deployKernelURL = os.Getenv("DEPLOY_KERNEL_URL")
 	if deployKernelURL == "" {
@@ -103,6 +118,12 @@ func init() {
 		fmt.Fprintf(os.Stderr, "Cannot start: No DEPLOY_RAMDISK_URL variable set\n")
 		os.Exit(1)
 	}
+	localImageCacheURL = strings.TrimSuffix(os.Getenv("LOCAL_IMAGE_CACHE_URL"), "/")
+	inspectionDataURL = strings.TrimSuffix(os.Getenv("INSPECTION_DATA_URL"), "/")
+	inspectionDataTLS = clients.TLSConfig{
+		TrustedCAFile:      os.Getenv("INSPECTION_DATA_CACERT_FILE"),
+		InsecureSkipVerify: strings.ToLower(os.Getenv("INSPECTION_DATA_INSECURE")) == "true",
+	}
 	ironicEndpoint = os.Getenv("IRONIC_ENDPOINT")
 	if ironicEndpoint == "" {
 		fmt.Fprintf(os.Stderr, "Cannot start: No IRONIC_ENDPOINT variable set\n")
@@ -134,6 +155,28 @@ func init() {
 		ironicSkipClientSANVerify = true
 	}
 
+	for name, value := range map[string]*time.Duration{
+		"IRONIC_REQUEST_TIMEOUT":   &ironicConnectionConfig.RequestTimeout,
+		"IRONIC_IDLE_CONN_TIMEOUT": &ironicConnectionConfig.IdleConnTimeout,
+	} {
+		if secondsStr := os.Getenv(name); secondsStr != "" {
+			seconds, err := strconv.Atoi(secondsStr)
+			if err != nil || seconds <= 0 {
+				fmt.Fprintf(os.Stderr, "Cannot start: Invalid value set for variable %s=%s", name, secondsStr)
+				os.Exit(1)
+			}
+			*value = time.Second * time.Duration(seconds)
+		}
+	}
+	if connsStr := os.Getenv("IRONIC_MAX_IDLE_CONNS_PER_HOST"); connsStr != "" {
+		value, err := strconv.Atoi(connsStr)
+		if err != nil || value <= 0 {
+			fmt.Fprintf(os.Stderr, "Cannot start: Invalid value set for variable IRONIC_MAX_IDLE_CONNS_PER_HOST=%s", connsStr)
+			os.Exit(1)
+		}
+		ironicConnectionConfig.MaxIdleConnsPerHost = value
+	}
+
 	if maxHostsStr := os.Getenv("PROVISIONING_LIMIT"); maxHostsStr != "" {
 		value, err := strconv.Atoi(maxHostsStr)
 		if err != nil {
@@ -142,6 +185,24 @@ func init() {
 		}
 		maxBusyHosts = value
 	}
+
+	if maxHostsStr := os.Getenv("PROVISIONING_LIMIT_PER_CONDUCTOR_GROUP"); maxHostsStr != "" {
+		value, err := strconv.Atoi(maxHostsStr)
+		if err != nil || value < 0 {
+			fmt.Fprintf(os.Stderr, "Cannot start: Invalid value set for variable PROVISIONING_LIMIT_PER_CONDUCTOR_GROUP=%s", maxHostsStr)
+			os.Exit(1)
+		}
+		maxBusyHostsPerGroup = value
+	}
+
+	if maxDownloadsStr := os.Getenv("IMAGE_DOWNLOAD_LIMIT"); maxDownloadsStr != "" {
+		value, err := strconv.Atoi(maxDownloadsStr)
+		if err != nil || value < 0 {
+			fmt.Fprintf(os.Stderr, "Cannot start: Invalid value set for variable IMAGE_DOWNLOAD_LIMIT=%s", maxDownloadsStr)
+			os.Exit(1)
+		}
+		maxImageDownloads = value
+	}
 }
 
 // Provisioner implements the provisioning.Provisioner interface
@@ -159,6 +220,8 @@ type ironicProvisioner struct {
 	bmcCreds bmc.Credentials
 	// the MAC address of the PXE boot interface
 	bootMACAddress string
+	// the IP version of the network the host boots from
+	bootNetworkIPVersion metal3v1alpha1.IPVersion
 	// a client for talking to ironic
 	client *gophercloud.ServiceClient
 	// a client for talking to ironic-inspector
@@ -169,6 +232,10 @@ type ironicProvisioner struct {
 	debugLog logr.Logger
 	// an event publisher for recording significant events
 	publisher provisioner.EventPublisher
+	// the node last read, and its fields that the client library
+	// does not support
+	lastNode   *nodes.Node
+	nodeFields *nodeFields
 }
 
 // LogStartup produces useful logging information that we only want to
@@ -196,12 +263,13 @@ func newProvisionerWithSettings(host metal3v1alpha1.BareMetalHost, bmcCreds bmc.
 		InsecureSkipVerify:    ironicInsecure,
 		SkipClientSANVerify:   ironicSkipClientSANVerify,
 	}
-	clientIronic, err := clients.IronicClient(ironicURL, ironicAuthSettings, tlsConf)
+	connConf := ironicConnectionConfig
+	clientIronic, err := clients.IronicClient(ironicURL, ironicAuthSettings, tlsConf, connConf)
 	if err != nil {
 		return nil, err
 	}
 
-	clientInspector, err := clients.InspectorClient(inspectorURL, inspectorAuthSettings, tlsConf)
+	clientInspector, err := clients.InspectorClient(inspectorURL, inspectorAuthSettings, tlsConf, connConf)
 	if err != nil {
 		return nil, err
 	}
@@ -224,6 +292,7 @@ func newProvisionerWithIronicClients(hostData provisioner.HostData, publisher pr
 		bmcAddress:              hostData.BMCAddress,
 		disableCertVerification: hostData.DisableCertificateVerification,
 		bootMACAddress:          hostData.BootMACAddress,
+		bootNetworkIPVersion:    hostData.BootNetworkIPVersion,
 		client:                  clientIronic,
 		inspector:               clientInspector,
 		log:                     provisionerLogger,
@@ -246,14 +315,15 @@ func New(hostData provisioner.HostData, publisher provisioner.EventPublisher) (p
 			InsecureSkipVerify:    ironicInsecure,
 			SkipClientSANVerify:   ironicSkipClientSANVerify,
 		}
+		connConf := ironicConnectionConfig
 		clientIronicSingleton, err = clients.IronicClient(
-			ironicEndpoint, ironicAuth, tlsConf)
+			ironicEndpoint, ironicAuth, tlsConf, connConf)
 		if err != nil {
 			return nil, err
 		}
 
 		clientInspectorSingleton, err = clients.InspectorClient(
-			inspectorEndpoint, inspectorAuth, tlsConf)
+			inspectorEndpoint, inspectorAuth, tlsConf, connConf)
 		if err != nil {
 			return nil, err
 		}
@@ -323,10 +393,19 @@ func (p *ironicProvisioner) getNode() (*nodes.Node, error) {
 		return nil, provisioner.ErrNeedsRegistration
 	}
 
-	ironicNode, err := nodes.Get(p.client, p.nodeID).Extract()
+	result := nodes.Get(p.client, p.nodeID)
+	ironicNode, err := result.Extract()
 	switch err.(type) {
 	case nil:
 		p.debugLog.Info("found existing node by ID")
+		// Keep the fields the client library does not support from
+		// the same response for the rest of the reconcile.
+		fields := &nodeFields{}
+		if err = result.ExtractInto(fields); err != nil {
+			return nil, errors.Wrap(err, "failed to read the node fields")
+		}
+		p.lastNode = ironicNode
+		p.nodeFields = fields
 		return ironicNode, nil
 	case gophercloud.ErrDefault404:
 		// Look by ID failed, trying to lookup by hostname in case it was
@@ -366,20 +445,21 @@ func (p *ironicProvisioner) nodeHasAssignedPort(ironicNode *nodes.Node) (bool, e
 	return true, nil
 }
 
-// Verify that MAC is already allocated to some node port.
-func (p *ironicProvisioner) isAddressAllocatedToPort(address string) (bool, error) {
+// findNodeIDByMAC returns the UUID of the node owning the port with
+// the address, or an empty string when no port has it.
+func (p *ironicProvisioner) findNodeIDByMAC(address string) (nodeUUID string, err error) {
 	allPorts, err := p.listAllPorts(address)
 	if err != nil {
-		return false, errors.Wrap(err, fmt.Sprintf("failed to list ports for %s", address))
+		return "", errors.Wrap(err, fmt.Sprintf("failed to list ports for %s", address))
 	}
 
 	if len(allPorts) == 0 {
 		p.debugLog.Info("address does not have allocated ports", "address", address)
-		return false, nil
+		return "", nil
 	}
 
-	p.debugLog.Info("address is allocated to port", "address", address)
-	return true, nil
+	p.debugLog.Info("address is allocated to port", "address", address, "node", allPorts[0].NodeUUID)
+	return allPorts[0].NodeUUID, nil
 }
 
 // Look for an existing registration for the host in Ironic.
@@ -414,15 +494,13 @@ func (p *ironicProvisioner) findExistingHost(bootMACAddress string) (ironicNode
 
 	// Try to load the node by port address
 	p.log.Info("looking for existing node by MAC", "MAC", bootMACAddress)
-	allPorts, err := p.listAllPorts(bootMACAddress)
-
+	nodeUUID, err := p.findNodeIDByMAC(bootMACAddress)
 	if err != nil {
 		p.log.Info("failed to find an existing port with address", "MAC", bootMACAddress)
 		return nil, nil
 	}
 
-	if len(allPorts) > 0 {
-		nodeUUID := allPorts[0].NodeUUID
+	if nodeUUID != "" {
 		ironicNode, err = nodes.Get(p.client, nodeUUID).Extract()
 		switch err.(type) {
 		case nil:
@@ -507,15 +585,34 @@ func (p *ironicProvisioner) ValidateManagementAccess(data provisioner.Management
 		return
 	}
 
-	driverInfo := bmcAccess.DriverInfo(p.bmcCreds)
-	// FIXME(dhellmann): We need to get our IP on the
-	// provisioning network from somewhere.
-	driverInfo["deploy_kernel"] = deployKernelURL
-	driverInfo["deploy_ramdisk"] = deployRamdiskURL
+	driverInfo, err := p.buildDriverInfo(bmcAccess, data)
+	if err != nil {
+		result, err = operationFailed(err.Error())
+		return
+	}
+	if err = validateCapabilities(data.Capabilities); err != nil {
+		result, err = operationFailed(err.Error())
+		return
+	}
+
+	managementInterface := bmcAccess.ManagementInterface()
+	if data.ManagementInterface != "" {
+		managementInterface = data.ManagementInterface
+	}
+
+	// The driver_info is only set from scratch for a new node or new
+	// credentials, otherwise what went missing is restored
+	repairDriverInfo := ironicNode != nil && !credentialsChanged
 
 	// If we have not found a node yet, we need to create one
 	if ironicNode == nil {
-		p.log.Info("registering host in ironic")
+		if p.nodeID != "" {
+			// The node was registered before, so it has been deleted
+			// from Ironic behind our back or the database was lost.
+			p.log.Info("registered node not found, registering host again", "previousID", p.nodeID)
+		} else {
+			p.log.Info("registering host in ironic")
+		}
 
 		if data.BootMode == metal3v1alpha1.UEFISecureBoot && !bmcAccess.SupportsSecureBoot() {
 			msg := fmt.Sprintf("BMC driver %s does not support secure boot", bmcAccess.Type())
@@ -524,6 +621,19 @@ func (p *ironicProvisioner) ValidateManagementAccess(data provisioner.Management
 			return
 		}
 
+		if data.ManagementInterface != "" {
+			var msg string
+			msg, err = p.validateManagementInterface(bmcAccess.Driver(), managementInterface)
+			if err != nil {
+				result, err = transientError(err)
+				return
+			}
+			if msg != "" {
+				result, err = operationFailed(msg)
+				return
+			}
+		}
+
 		ironicNode, err = nodes.Create(
 			p.client,
 			nodes.CreateOpts{
@@ -533,12 +643,12 @@ func (p *ironicProvisioner) ValidateManagementAccess(data provisioner.Management
 				DriverInfo:          driverInfo,
 				DeployInterface:     p.deployInterface(data.CurrentImage),
 				InspectInterface:    "inspector",
-				ManagementInterface: bmcAccess.ManagementInterface(),
+				ManagementInterface: managementInterface,
 				PowerInterface:      bmcAccess.PowerInterface(),
 				RAIDInterface:       bmcAccess.RAIDInterface(),
 				VendorInterface:     bmcAccess.VendorInterface(),
 				Properties: map[string]interface{}{
-					"capabilities": bootModeCapabilities[data.BootMode],
+					"capabilities": mergeCapabilities(bootModeCapabilities[data.BootMode], data.Capabilities, ""),
 				},
 			}).Extract()
 		// FIXME(dhellmann): Handle 409 and 503? errors here.
@@ -546,20 +656,27 @@ func (p *ironicProvisioner) ValidateManagementAccess(data provisioner.Management
 			result, err = transientError(errors.Wrap(err, "failed to register host in ironic"))
 			return
 		}
-		p.publisher("Registered", "Registered new host")
+		if p.nodeID != "" {
+			p.publisher("Registered", fmt.Sprintf("Registered host again, node %s was not found", p.nodeID))
+		} else {
+			p.publisher("Registered", "Registered new host")
+		}
 
 		// Store the ID so other methods can assume it is set and so
 		// we can find the node again later.
 		provID = ironicNode.UUID
-
-		// If we know the MAC, create a port. Otherwise we will have
-		// to do this after we run the introspection step.
-		if p.bootMACAddress != "" {
-			err = p.createPXEEnabledNodePort(ironicNode.UUID, p.bootMACAddress)
-			if err != nil {
+		p.nodeID = provID
+
+		// If we know the MAC, create the boot port right away.
+		// Otherwise the ports are created by the inspection.
+		if err = p.ensureBootPort(ironicNode); err != nil {
+			switch err.(type) {
+			case macAddressConflictError:
+				result, err = operationFailed(err.Error())
+			default:
 				result, err = transientError(err)
-				return
 			}
+			return
 		}
 	} else {
 		// FIXME(dhellmann): At this point we have found an existing
@@ -570,31 +687,37 @@ func (p *ironicProvisioner) ValidateManagementAccess(data provisioner.Management
 
 		updater.SetTopLevelOpt("name", ironicNodeName(p.objectMeta), ironicNode.Name)
 
-		// When node exists but has no assigned port to it by Ironic and actuall address (MAC) is present
-		// in host config and is not allocated to different node lets try to create port for this node.
-		if p.bootMACAddress != "" {
-			var nodeHasAssignedPort, addressIsAllocatedToPort bool
+		// When the node exists but has no port, e.g. because it was
+		// registered without a boot MAC address, create the boot port.
+		if err = p.ensureBootPort(ironicNode); err != nil {
+			switch err.(type) {
+			case macAddressConflictError:
+				result, err = operationFailed(err.Error())
+			default:
+				result, err = transientError(err)
+			}
+			return
+		}
 
-			nodeHasAssignedPort, err = p.nodeHasAssignedPort(ironicNode)
+		if managementInterface != "" && managementInterface != ironicNode.ManagementInterface {
+			var msg string
+			msg, err = p.validateManagementInterface(ironicNode.Driver, managementInterface)
 			if err != nil {
 				result, err = transientError(err)
 				return
 			}
+			if msg != "" {
+				result, err = operationFailed(msg)
+				return
+			}
 
-			if !nodeHasAssignedPort {
-				addressIsAllocatedToPort, err = p.isAddressAllocatedToPort(p.bootMACAddress)
-				if err != nil {
-					result, err = transientError(err)
-					return
-				}
-
-				if !addressIsAllocatedToPort {
-					err = p.createPXEEnabledNodePort(ironicNode.UUID, p.bootMACAddress)
-					if err != nil {
-						result, err = transientError(err)
-						return
-					}
-				}
+			if interfaceUpdateAllowed(ironicNode) {
+				updater.SetTopLevelOpt("management_interface", managementInterface, ironicNode.ManagementInterface)
+			} else {
+				p.log.Info("cannot change the management interface in the current state",
+					"state", ironicNode.ProvisionState,
+					"current", ironicNode.ManagementInterface,
+					"requested", managementInterface)
 			}
 		}
 
@@ -602,6 +725,18 @@ func (p *ironicProvisioner) ValidateManagementAccess(data provisioner.Management
 		// and now the credentials have changed.
 		if credentialsChanged {
 			updater.SetTopLevelOpt("driver_info", driverInfo, nil)
+		} else if data.PreprovisioningImage != nil || ironicNode.DriverInfo["deploy_ramdisk"] != nil {
+			// Boot the ramdisk of the selected preprovisioning
+			// image, or go back to the default one
+			updater.SetDriverInfoOpts(optionsData{
+				"deploy_kernel":  driverInfo["deploy_kernel"],
+				"deploy_ramdisk": driverInfo["deploy_ramdisk"],
+			}, ironicNode)
+		}
+		if port, isIPMI := driverInfo["ipmi_port"]; isIPMI && !credentialsChanged &&
+			ironicNode.DriverInfo["ipmi_port"] != nil {
+			// The port of the BMC may change without the credentials
+			updater.SetDriverInfoOpts(optionsData{"ipmi_port": port}, ironicNode)
 		}
 
 		// We don't return here because we also have to set the
@@ -609,17 +744,82 @@ func (p *ironicProvisioner) ValidateManagementAccess(data provisioner.Management
 		// below.
 	}
 	if data.CurrentImage != nil {
-		p.getImageUpdateOptsForNode(ironicNode, data.CurrentImage, data.BootMode, updater)
+		if err = validateInstanceCapabilities(data.InstanceCapabilities); err != nil {
+			result, err = operationFailed(err.Error())
+			return
+		}
+		p.getImageUpdateOptsForNode(ironicNode, data.CurrentImage, data.BootMode, data.InstanceCapabilities, updater)
+	}
+	p.setCapabilitiesUpdateOpts(ironicNode, data.BootMode, data.Capabilities, updater)
+	if err = validateAutomatedCleaningMode(data.AutomatedCleaningMode); err != nil {
+		result, err = operationFailed(err.Error())
+		return
+	}
+	p.setAutomatedCleanUpdateOpts(ironicNode, data, updater)
+	setDeployNetworksUpdateOpts(ironicNode, data.DeployNetworks, updater)
+	setRedfishAuthTypeUpdateOpts(ironicNode, data.RedfishAuthType, driverInfo, updater)
+	updater.SetDriverInfoOpts(fastTrackFields(data.FastTrack), ironicNode)
+	var repairedDriverInfo []string
+	if repairDriverInfo {
+		repairedDriverInfo = setDriverInfoRepairsUpdateOpts(ironicNode, driverInfo, updater)
+		if len(repairedDriverInfo) != 0 {
+			p.log.Info("repairing driver_info", "fields", repairedDriverInfo)
+		}
+	}
+	setTagsUpdateOpts(ironicNode, data.Tags, updater)
+	p.setDescriptionUpdateOpts(ironicNode, data.Description, updater)
+	if err = validateTraits(data.Traits); err != nil {
+		result, err = operationFailed(err.Error())
+		return
+	}
+	if traits, changed := buildNodeTraits(ironicNode, data.Traits); changed {
+		if p.nodeLocked(ironicNode) {
+			result, err = retryAfterDelay(nodeLockedRequeueDelay)
+			return
+		}
+		p.log.Info("updating node traits", "traits", traits)
+		if err = p.setNodeTraits(ironicNode, traits); err != nil {
+			if _, isBadRequest := err.(gophercloud.ErrDefault400); isBadRequest {
+				result, err = operationFailed(fmt.Sprintf("invalid traits: %s", err))
+				return
+			}
+			result, err = transientError(errors.Wrap(err, "failed to set node traits"))
+			return
+		}
 	}
-	updater.SetTopLevelOpt("automated_clean",
-		data.AutomatedCleaningMode != metal3v1alpha1.CleaningModeDisabled,
-		ironicNode.AutomatedClean)
+	setTraitsUpdateOpts(ironicNode, data.Traits, updater)
+	if err = validateShard(data.Shard); err != nil {
+		result, err = operationFailed(err.Error())
+		return
+	}
+	var updateShard bool
+	if updateShard, err = p.shardNeedsUpdate(ironicNode, data.Shard); err == nil && updateShard {
+		if p.nodeLocked(ironicNode) {
+			result, err = retryAfterDelay(nodeLockedRequeueDelay)
+			return
+		}
+		err = p.setNodeShard(ironicNode, data.Shard)
+	}
+	if err != nil {
+		switch err.(type) {
+		case unsupportedShardError, gophercloud.ErrDefault400:
+			result, err = operationFailed(fmt.Sprintf("invalid shard: %s", err))
+		default:
+			result, err = transientError(errors.Wrap(err, "failed to set the node shard"))
+		}
+		return
+	}
+	setNodePropertiesUpdateOpts(ironicNode, data.NodeProperties, updater)
 
 	var success bool
 	success, result, err = p.tryUpdateNode(ironicNode, updater)
 	if !success {
 		return
 	}
+	if len(repairedDriverInfo) != 0 {
+		p.publisher("DriverInfoRepaired", fmt.Sprintf("Restored the driver_info fields %s",
+			strings.Join(repairedDriverInfo, ", ")))
+	}
 	// ironicNode, err = nodes.Get(p.client, p.status.ID).Extract()
 	// if err != nil {
 	// 	return result, errors.Wrap(err, "failed to get provisioning state in ironic")
@@ -681,14 +881,93 @@ func (p *ironicProvisioner) ValidateManagementAccess(data provisioner.Management
 	}
 }
 
+// validateManagementInterface checks that the management interface is
+// enabled for the driver, returning an error message if it is not.
+func (p *ironicProvisioner) validateManagementInterface(driver, managementInterface string) (message string, err error) {
+	details, err := drivers.GetDriverDetails(p.client, driver).Extract()
+	if err != nil {
+		return "", errors.Wrap(err, fmt.Sprintf("failed to get details of driver %s", driver))
+	}
+
+	for _, enabled := range details.EnabledManagementInterfaces {
+		if enabled == managementInterface {
+			return "", nil
+		}
+	}
+	return fmt.Sprintf("management interface %q is not enabled for driver %s, expected one of %s",
+		managementInterface, driver, strings.Join(details.EnabledManagementInterfaces, ", ")), nil
+}
+
+// interfaceUpdateAllowed returns whether Ironic allows changing the
+// hardware interfaces of the node in its current state.
+func interfaceUpdateAllowed(ironicNode *nodes.Node) bool {
+	switch nodes.ProvisionState(ironicNode.ProvisionState) {
+	case nodes.Enroll, nodes.Manageable, nodes.Available,
+		nodes.Verifying, nodes.InspectFail, nodes.CleanFail, nodes.DeployFail:
+		return true
+	}
+	return ironicNode.Maintenance
+}
+
+// validateAutomatedCleaningMode rejects the cleaning modes the
+// provisioner does not know, which would otherwise be treated as the
+// metadata mode.
+func validateAutomatedCleaningMode(mode metal3v1alpha1.AutomatedCleaningMode) error {
+	switch mode {
+	case metal3v1alpha1.CleaningModeMetadata, metal3v1alpha1.CleaningModeFull,
+		metal3v1alpha1.CleaningModeDisabled, "":
+		return nil
+	}
+	return fmt.Errorf("unsupported automated cleaning mode %q", mode)
+}
+
+// setAutomatedCleanUpdateOpts enables automated cleaning of the node
+// unless the cleaning mode of the host disables it. Once the host is
+// registered, a node that disagrees with the host, e.g. because its
+// automated_clean was changed directly in Ironic, is reported before
+// being corrected.
+func (p *ironicProvisioner) setAutomatedCleanUpdateOpts(ironicNode *nodes.Node, data provisioner.ManagementAccessData, updater *nodeUpdater) {
+	desired := data.AutomatedCleaningMode != metal3v1alpha1.CleaningModeDisabled
+	if ironicNode.AutomatedClean != nil && *ironicNode.AutomatedClean != desired {
+		switch data.State {
+		case metal3v1alpha1.StateNone, metal3v1alpha1.StateRegistering:
+		default:
+			p.log.Info("correcting automated_clean of the node",
+				"automatedClean", *ironicNode.AutomatedClean,
+				"automatedCleaningMode", data.AutomatedCleaningMode)
+			p.publisher("AutomatedCleaningCorrected",
+				fmt.Sprintf("The automated_clean setting of the node was %t, resetting it to %t for cleaning mode %q",
+					*ironicNode.AutomatedClean, desired, data.AutomatedCleaningMode))
+		}
+	}
+	updater.SetTopLevelOpt("automated_clean", desired, ironicNode.AutomatedClean)
+}
+
+// nodeLocked returns whether a conductor holds the lock of the node,
+// in which case any change to the node would be rejected.
+func (p *ironicProvisioner) nodeLocked(ironicNode *nodes.Node) bool {
+	if ironicNode.Reservation == "" {
+		return false
+	}
+	p.log.Info("node is locked by a conductor, trying again after delay",
+		"reservation", ironicNode.Reservation, "delay", nodeLockedRequeueDelay)
+	return true
+}
+
 func (p *ironicProvisioner) tryUpdateNode(ironicNode *nodes.Node, updater *nodeUpdater) (success bool, result provisioner.Result, err error) {
-	if len(updater.Updates) == 0 {
+	updates := updater.pendingUpdates()
+	if len(updates) == 0 {
 		success = true
 		return
 	}
 
+	if p.nodeLocked(ironicNode) {
+		result, err = retryAfterDelay(nodeLockedRequeueDelay)
+		return
+	}
+
 	p.log.Info("updating node settings in ironic")
-	_, err = nodes.Update(p.client, ironicNode.UUID, updater.Updates).Extract()
+	_, err = nodes.Update(p.client, ironicNode.UUID, updates).Extract()
 	switch err.(type) {
 	case nil:
 		success = true
@@ -708,7 +987,20 @@ func (p *ironicProvisioner) tryChangeNodeProvisionState(ironicNode *nodes.Node,
 		"new target", opts.Target,
 	)
 
-	changeResult := nodes.ChangeProvisionState(p.client, ironicNode.UUID, opts)
+	if p.nodeLocked(ironicNode) {
+		result, err = retryAfterDelay(nodeLockedRequeueDelay)
+		return
+	}
+
+	client := p.client
+	if len(opts.DeploySteps) != 0 {
+		// Deploy steps need a newer API version than the one used
+		// for the rest of the requests.
+		withSteps := *p.client
+		withSteps.Microversion = deployStepsMicroversion
+		client = &withSteps
+	}
+	changeResult := nodes.ChangeProvisionState(client, ironicNode.UUID, opts)
 	switch changeResult.Err.(type) {
 	case nil:
 		success = true
@@ -764,14 +1056,18 @@ func (p *ironicProvisioner) InspectHardware(data provisioner.InspectData, force,
 				}
 				fallthrough
 			default:
+				if err = validateInspectionKernelParams(data.KernelParams); err != nil {
+					result, err = operationFailed(err.Error())
+					return
+				}
+				updater := updateOptsBuilder(p.debugLog).
+					SetPropertiesOpts(optionsData{
+						"capabilities": buildCapabilitiesValue(ironicNode, data.BootMode),
+					}, ironicNode)
+				setInspectionKernelParamsUpdateOpts(ironicNode, data.KernelParams, updater)
+
 				var success bool
-				success, result, err = p.tryUpdateNode(
-					ironicNode,
-					updateOptsBuilder(p.debugLog).
-						SetPropertiesOpts(optionsData{
-							"capabilities": buildCapabilitiesValue(ironicNode, data.BootMode),
-						}, ironicNode),
-				)
+				success, result, err = p.tryUpdateNode(ironicNode, updater)
 				if !success {
 					return
 				}
@@ -801,7 +1097,15 @@ func (p *ironicProvisioner) InspectHardware(data provisioner.InspectData, force,
 		return
 	}
 
-	// Introspection is done
+	// Introspection is done, the following ramdisk boots must not use
+	// the kernel parameters of the inspection
+	updater := updateOptsBuilder(p.debugLog)
+	clearInspectionKernelParamsUpdateOpts(ironicNode, updater)
+	if success, updateResult, updateErr := p.tryUpdateNode(ironicNode, updater); !success {
+		result, err = updateResult, updateErr
+		return
+	}
+
 	p.log.Info("getting hardware details from inspection")
 	response := introspection.GetIntrospectionData(p.inspector, ironicNode.UUID)
 	introData, err := response.Extract()
@@ -812,6 +1116,36 @@ func (p *ironicProvisioner) InspectHardware(data provisioner.InspectData, force,
 	p.log.Info("received introspection data", "data", response.Body)
 
 	details = hardwaredetails.GetHardwareDetails(introData)
+	var raidInventory hardwaredetails.RAIDInventory
+	if err = response.ExtractInto(&raidInventory); err != nil {
+		p.log.Info("could not read the RAID inventory", "error", err)
+		err = nil
+	} else {
+		details.RAIDControllers = hardwaredetails.GetRAIDControllers(&raidInventory)
+	}
+	var pciInventory hardwaredetails.PCIInventory
+	if err = response.ExtractInto(&pciInventory); err != nil {
+		p.log.Info("could not read the PCI devices", "error", err)
+		err = nil
+	} else {
+		details.Accelerators = hardwaredetails.GetAccelerators(&pciInventory)
+	}
+	// The BMC firmware is not part of the inspection data and is only
+	// known to Ironic if its firmware interface supports it.
+	bmcVersion, err := p.getBMCFirmwareVersion(ironicNode)
+	if err != nil {
+		p.log.Info("could not read the BMC firmware version", "error", err)
+		err = nil
+	}
+	details.Firmware.BMCVersion = bmcVersion
+	// The chassis is only informative, so the host is inspected
+	// without it if the BMC does not report it.
+	details.Chassis, err = p.getChassis()
+	if err != nil {
+		p.log.Info("could not read the chassis details", "error", err)
+		err = nil
+	}
+	p.storeInspectionData(ironicNode, response.Body, details)
 	p.publisher("InspectionComplete", "Hardware inspection completed")
 	result, err = operationComplete()
 	return
@@ -835,9 +1169,66 @@ func (p *ironicProvisioner) UpdateHardwareState() (hwState provisioner.HardwareS
 		hwState.PoweredOn = &discoveredVal
 	case powerNone:
 		p.log.Info("could not determine power state", "value", ironicNode.PowerState)
+		// The host may not be unreachable at all if nothing is
+		// able to talk to its BMC.
+		if conductorErr := p.checkConductor(ironicNode); errors.Is(conductorErr, provisioner.ErrConductorDown) {
+			err = conductorErr
+		} else if conductorErr != nil {
+			p.log.Info("could not check the conductor", "error", conductorErr)
+		}
 	default:
 		p.log.Info("unknown power state", "value", ironicNode.PowerState)
 	}
+
+	// The history is only informative and not available in older
+	// versions of Ironic, so errors are not fatal. It is only read
+	// again when the node changed or after a while, the host keeps
+	// the events read last otherwise.
+	if historyReadNeeded(ironicNode, time.Now()) {
+		history, historyErr := p.getNodeHistory(ironicNode)
+		if historyErr != nil {
+			p.debugLog.Info("could not read the node history", "error", historyErr)
+		} else {
+			hwState.History = history
+		}
+	}
+
+	if ironicNode.ConsoleEnabled {
+		serialConsole, consoleErr := p.getSerialConsole(ironicNode)
+		if consoleErr != nil {
+			p.log.Info("could not read the console details", "error", consoleErr)
+			hwState.SerialConsoleUnknown = true
+		} else {
+			hwState.SerialConsole = serialConsole
+		}
+	}
+
+	allocation, allocationErr := p.getAllocationStatus(ironicNode)
+	if allocationErr != nil {
+		p.log.Info("could not read the allocation details", "error", allocationErr)
+		hwState.AllocationUnknown = true
+	} else {
+		hwState.Allocation = allocation
+	}
+	hwState.Scheduling = getSchedulingStatus(ironicNode)
+	conductorStatus, conductorErr := p.getConductorStatus(ironicNode)
+	if conductorErr != nil {
+		p.log.Info("could not read the conductor of the node", "error", conductorErr)
+		hwState.ConductorUnknown = true
+	} else {
+		hwState.Conductor = conductorStatus
+	}
+	hwState.HardwareFault = hardwareFault(ironicNode)
+	hwState.BootInterface = ironicNode.BootInterface
+
+	fields, fieldsErr := p.getNodeFields(ironicNode)
+	if fieldsErr != nil {
+		p.log.Info("could not read the node timestamps", "error", fieldsErr)
+		hwState.TimestampsUnknown = true
+	} else {
+		hwState.LastInspected = statusTime(fields.InspectionFinishedAt)
+		hwState.NodeCreatedAt = statusTime(fields.CreatedAt)
+	}
 	return
 }
 
@@ -850,6 +1241,8 @@ func (p *ironicProvisioner) setLiveIsoUpdateOptsForNode(ironicNode *nodes.Node,
 		"image_os_hash_value": nil,
 		"image_os_hash_algo":  nil,
 		"image_checksum":      nil,
+
+		"image_download_source": nil,
 	}
 	updater.
 		SetInstanceInfoOpts(optValues, ironicNode).
@@ -874,22 +1267,75 @@ func (p *ironicProvisioner) setDirectDeployUpdateOptsForNode(ironicNode *nodes.N
 		legacyChecksum = &checksum
 	}
 
+	// Leave the choice to the Ironic configuration unless the host
+	// overrides it
+	var downloadSource *string
+	if imageData.DownloadSource != "" {
+		value := string(imageData.DownloadSource)
+		downloadSource = &value
+	}
+
 	optValues := optionsData{
 		// Remove any boot_iso field
 		"boot_iso": nil,
 
-		"image_source":        imageData.URL,
-		"image_os_hash_algo":  checksumType,
-		"image_os_hash_value": checksum,
-		"image_checksum":      legacyChecksum,
-		"image_disk_format":   imageData.DiskFormat,
+		"image_source":          p.getImageSource(ironicNode, imageData),
+		"image_os_hash_algo":    checksumType,
+		"image_os_hash_value":   checksum,
+		"image_checksum":        legacyChecksum,
+		"image_disk_format":     imageData.DiskFormat,
+		"image_download_source": downloadSource,
+	}
+	if checksum == "" {
+		// Ironic resolves OCI references and their digest itself
+		optValues["image_os_hash_algo"] = nil
+		optValues["image_os_hash_value"] = nil
+	}
+	// Partition images are written to a root partition and booted with
+	// their own kernel and ramdisk, otherwise the image is written to
+	// the whole disk. Ironic detects the kind unless the host sets it.
+	optValues["root_gb"] = nil
+	optValues["image_type"] = nil
+	optValues["kernel"] = nil
+	optValues["ramdisk"] = nil
+	switch {
+	case imageData.GetKind() == metal3v1alpha1.ImageKindPartition:
+		if imageData.RootPartitionSizeGibibytes != nil {
+			optValues["root_gb"] = *imageData.RootPartitionSizeGibibytes
+		}
+		optValues["image_type"] = "partition"
+		if imageData.KernelURL != "" {
+			optValues["kernel"] = imageData.KernelURL
+			optValues["ramdisk"] = imageData.RamdiskURL
+		}
+	case imageData.Kind == metal3v1alpha1.ImageKindWholeDisk:
+		optValues["image_type"] = "whole-disk"
 	}
 	updater.
 		SetInstanceInfoOpts(optValues, ironicNode).
 		SetTopLevelOpt("deploy_interface", "direct", ironicNode.DeployInterface)
 }
 
-func (p *ironicProvisioner) getImageUpdateOptsForNode(ironicNode *nodes.Node, imageData *metal3v1alpha1.Image, bootMode metal3v1alpha1.BootMode, updater *nodeUpdater) {
+// validateRootPartitionSize checks the root partition size of the image
+// and, if the hardware details of the host are known, that the
+// partition fits on the root device.
+func validateRootPartitionSize(image *metal3v1alpha1.Image, rootDeviceHints *metal3v1alpha1.RootDeviceHints, details *metal3v1alpha1.HardwareDetails) error {
+	if err := image.ValidateRootPartitionSize(); err != nil || image.RootPartitionSizeGibibytes == nil {
+		return err
+	}
+	rootDevice := devicehints.RootDevice(rootDeviceHints, details)
+	if rootDevice == nil {
+		return nil
+	}
+	size := metal3v1alpha1.Capacity(*image.RootPartitionSizeGibibytes) * metal3v1alpha1.GibiByte
+	if size > rootDevice.SizeBytes {
+		return fmt.Errorf("the root partition of %d GiB does not fit on the root device %s of %d GiB",
+			*image.RootPartitionSizeGibibytes, rootDevice.Name, rootDevice.SizeBytes/metal3v1alpha1.GibiByte)
+	}
+	return nil
+}
+
+func (p *ironicProvisioner) getImageUpdateOptsForNode(ironicNode *nodes.Node, imageData *metal3v1alpha1.Image, bootMode metal3v1alpha1.BootMode, instanceCapabilities map[string]string, updater *nodeUpdater) {
 	// instance_uuid
 	updater.SetTopLevelOpt("instance_uuid", string(p.objectMeta.UID), ironicNode.InstanceUUID)
 
@@ -899,14 +1345,26 @@ func (p *ironicProvisioner) getImageUpdateOptsForNode(ironicNode *nodes.Node, im
 
 	// Instance info capabilities were invented later and
 	// use a normal JSON mapping instead of a custom
-	// string value.
+	// string value. The capabilities of the host, e.g. for huge pages
+	// or CPU pinning, are added to the managed ones.
 	capabilitiesII := map[string]string{}
+	for key, value := range instanceCapabilities {
+		capabilitiesII[key] = value
+	}
 	if bootMode == metal3v1alpha1.UEFISecureBoot {
 		capabilitiesII["secure_boot"] = "true"
 	}
 
 	updater.SetInstanceInfoOpts(optionsData{"capabilities": capabilitiesII}, ironicNode)
 
+	// Keep deploying from the mirror the node was set up with, if any
+	sources := imageData.Sources()
+	if index := p.imageSourceIndex(ironicNode, *imageData); index > 0 {
+		imageData = &sources[index]
+	} else {
+		imageData = &sources[0]
+	}
+
 	if imageData.DiskFormat != nil && *imageData.DiskFormat == "live-iso" {
 		// Set live-iso format options
 		p.setLiveIsoUpdateOptsForNode(ironicNode, imageData, updater)
@@ -919,7 +1377,16 @@ func (p *ironicProvisioner) getImageUpdateOptsForNode(ironicNode *nodes.Node, im
 func (p *ironicProvisioner) getUpdateOptsForNode(ironicNode *nodes.Node, data provisioner.ProvisionData) *nodeUpdater {
 	updater := updateOptsBuilder(p.debugLog)
 
-	p.getImageUpdateOptsForNode(ironicNode, &data.Image, data.BootMode, updater)
+	p.getImageUpdateOptsForNode(ironicNode, &data.Image, data.BootMode, data.InstanceCapabilities, updater)
+
+	var displayName interface{}
+	if data.DeploymentID != "" {
+		displayName = data.DeploymentID
+	}
+	updater.SetInstanceInfoOpts(optionsData{"display_name": displayName}, ironicNode)
+	setInstanceTraitsUpdateOpts(ironicNode, data.InstanceTraits, updater)
+	setImageHeadersUpdateOpts(ironicNode, data.ImageHeaders, updater)
+	clearInspectionKernelParamsUpdateOpts(ironicNode, updater)
 
 	opts := optionsData{
 		"root_device": devicehints.MakeHintMap(data.RootDeviceHints),
@@ -936,6 +1403,27 @@ func (p *ironicProvisioner) getUpdateOptsForNode(ironicNode *nodes.Node, data pr
 	return updater
 }
 
+// parseCapabilities splits a capabilities value into its items
+func parseCapabilities(value string) map[string]string {
+	capabilities := map[string]string{}
+	for _, item := range strings.Split(value, ",") {
+		if parts := strings.SplitN(item, ":", 2); len(parts) == 2 {
+			capabilities[parts[0]] = parts[1]
+		}
+	}
+	return capabilities
+}
+
+// bootModeDrifted returns whether the boot mode capabilities of the
+// node disagree with the boot mode of the host.
+func bootModeDrifted(ironicNode *nodes.Node, bootMode metal3v1alpha1.BootMode) bool {
+	existing, _ := ironicNode.Properties["capabilities"].(string)
+	current := parseCapabilities(existing)
+	desired := parseCapabilities(bootModeCapabilities[bootMode])
+	return current["boot_mode"] != desired["boot_mode"] ||
+		(current["secure_boot"] == "true") != (desired["secure_boot"] == "true")
+}
+
 // We can't just replace the capabilities because we need to keep the
 // values provided by inspection. We can't replace only the boot_mode
 // because the API isn't fine-grained enough for that. So we have to
@@ -1040,22 +1528,12 @@ func (p *ironicProvisioner) Adopt(data provisioner.AdoptData, force bool) (resul
 			p.log.Info("no image info; not adopting", "state", ironicNode.ProvisionState)
 			return operationComplete()
 		}
-		return p.changeNodeProvisionState(
-			ironicNode,
-			nodes.ProvisionStateOpts{
-				Target: nodes.TargetAdopt,
-			},
-		)
+		return p.startAdoption(ironicNode)
 	case nodes.Adopting:
 		return operationContinuing(provisionRequeueDelay)
 	case nodes.AdoptFail:
 		if force {
-			return p.changeNodeProvisionState(
-				ironicNode,
-				nodes.ProvisionStateOpts{
-					Target: nodes.TargetAdopt,
-				},
-			)
+			return p.startAdoption(ironicNode)
 		}
 		return operationFailed(fmt.Sprintf("Host adoption failed: %s",
 			ironicNode.LastError))
@@ -1065,7 +1543,41 @@ func (p *ironicProvisioner) Adopt(data provisioner.AdoptData, force bool) (resul
 	return operationComplete()
 }
 
+// startAdoption moves the node to active without deploying it. The
+// instance_uuid is set to the UID of the host first, as it is for
+// provisioned hosts, so that Ironic treats the adopted node as being
+// in use by this host.
+func (p *ironicProvisioner) startAdoption(ironicNode *nodes.Node) (result provisioner.Result, err error) {
+	updater := updateOptsBuilder(p.debugLog)
+	updater.SetTopLevelOpt("instance_uuid", string(p.objectMeta.UID), ironicNode.InstanceUUID)
+	if success, result, err := p.tryUpdateNode(ironicNode, updater); !success {
+		return result, err
+	}
+
+	return p.changeNodeProvisionState(
+		ironicNode,
+		nodes.ProvisionStateOpts{
+			Target: nodes.TargetAdopt,
+		},
+	)
+}
+
 func (p *ironicProvisioner) ironicHasSameImage(ironicNode *nodes.Node, image metal3v1alpha1.Image) (sameImage bool) {
+	return p.imageSourceIndex(ironicNode, image) >= 0
+}
+
+// imageSourceIndex returns the index in image.Sources() of the location
+// the node is configured to deploy from, or -1 if it uses none of them.
+func (p *ironicProvisioner) imageSourceIndex(ironicNode *nodes.Node, image metal3v1alpha1.Image) int {
+	for i, source := range image.Sources() {
+		if p.ironicHasImageSource(ironicNode, source) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *ironicProvisioner) ironicHasImageSource(ironicNode *nodes.Node, image metal3v1alpha1.Image) (sameImage bool) {
 	// To make it easier to test if ironic is configured with
 	// the same image we are trying to provision to the host.
 	if image.DiskFormat != nil && *image.DiskFormat == "live-iso" {
@@ -1074,9 +1586,15 @@ func (p *ironicProvisioner) ironicHasSameImage(ironicNode *nodes.Node, image met
 			"boot_iso", ironicNode.InstanceInfo["boot_iso"],
 			"same", sameImage,
 			"provisionState", ironicNode.ProvisionState)
+	} else if image.IsOCI() && image.Checksum == "" {
+		sameImage = (ironicNode.InstanceInfo["image_source"] == image.URL)
+		p.log.Info("checking image settings",
+			"source", ironicNode.InstanceInfo["image_source"],
+			"same", sameImage,
+			"provisionState", ironicNode.ProvisionState)
 	} else {
 		checksum, checksumType, _ := image.GetChecksum()
-		sameImage = (ironicNode.InstanceInfo["image_source"] == image.URL &&
+		sameImage = (isImageSource(ironicNode.InstanceInfo["image_source"], &image) &&
 			ironicNode.InstanceInfo["image_os_hash_algo"] == checksumType &&
 			ironicNode.InstanceInfo["image_os_hash_value"] == checksum)
 		p.log.Info("checking image settings",
@@ -1089,20 +1607,43 @@ func (p *ironicProvisioner) ironicHasSameImage(ironicNode *nodes.Node, image met
 	return sameImage
 }
 
-func (p *ironicProvisioner) buildManualCleaningSteps(bmcAccess bmc.AccessDetails, data provisioner.PrepareData) (cleanSteps []nodes.CleanStep, err error) {
+func (p *ironicProvisioner) buildManualCleaningSteps(bmcAccess bmc.AccessDetails, ironicNode *nodes.Node, data provisioner.PrepareData, biosSettings map[string]string) (cleanSteps []nodes.CleanStep, err error) {
+	// Automated cleaning only erases the disk metadata, as configured
+	// in the conductor, so the disks are overwritten manually for a
+	// full clean, before they are configured
+	if data.EraseDevices {
+		cleanSteps = append(cleanSteps, nodes.CleanStep{
+			Interface: "deploy",
+			Step:      "erase_devices",
+		})
+	}
+
 	// Build raid clean steps
 	if bmcAccess.RAIDInterface() != "no-raid" {
-		cleanSteps = append(cleanSteps, BuildRAIDCleanSteps(data.RAIDConfig)...)
+		if data.RAIDConfig != nil {
+			if err = validateHardwareRAIDVolumes(data.RAIDConfig.HardwareRAIDVolumes, data.HardwareDetails); err != nil {
+				return nil, errors.Wrap(err, "invalid RAID settings")
+			}
+		}
+		raidSteps := buildNodeRAIDCleanSteps(ironicNode, data.RAIDConfig)
+		if len(raidSteps) == 0 {
+			p.log.Info("the requested RAID configuration is already applied")
+		}
+		cleanSteps = append(cleanSteps, raidSteps...)
 	} else if data.RAIDConfig != nil {
 		return nil, fmt.Errorf("RAID settings are defined, but the node's driver %s does not support RAID", bmcAccess.Driver())
 	}
 
-	// TODO: Add manual cleaning steps for host configuration
+	// Build bios clean steps
+	if err = data.FirmwareConfig.ValidateBootOrder(); err != nil {
+		return nil, errors.Wrap(err, "invalid firmware settings")
+	}
+	cleanSteps = append(cleanSteps, BuildBIOSCleanSteps(data.FirmwareConfig, biosSettings)...)
 
 	return
 }
 
-func (p *ironicProvisioner) startManualCleaning(bmcAccess bmc.AccessDetails, ironicNode *nodes.Node, data provisioner.PrepareData) (success bool, result provisioner.Result, err error) {
+func (p *ironicProvisioner) startManualCleaning(bmcAccess bmc.AccessDetails, ironicNode *nodes.Node, data provisioner.PrepareData, biosSettings map[string]string) (success bool, result provisioner.Result, err error) {
 	if bmcAccess.RAIDInterface() != "no-raid" {
 		// Set raid configuration
 		err = setTargetRAIDCfg(p, ironicNode, data)
@@ -1113,7 +1654,7 @@ func (p *ironicProvisioner) startManualCleaning(bmcAccess bmc.AccessDetails, iro
 	}
 
 	// Build manual clean steps
-	cleanSteps, err := p.buildManualCleaningSteps(bmcAccess, data)
+	cleanSteps, err := p.buildManualCleaningSteps(bmcAccess, ironicNode, data, biosSettings)
 	if err != nil {
 		result, err = operationFailed(err.Error())
 		return
@@ -1121,6 +1662,9 @@ func (p *ironicProvisioner) startManualCleaning(bmcAccess bmc.AccessDetails, iro
 
 	// Start manual clean
 	if len(cleanSteps) != 0 {
+		if wait, waitResult, waitErr := p.waitForPowerOff(ironicNode); wait {
+			return false, waitResult, waitErr
+		}
 		p.log.Info("remove existing configuration and set new configuration", "steps", cleanSteps)
 		return p.tryChangeNodeProvisionState(
 			ironicNode,
@@ -1149,10 +1693,35 @@ func (p *ironicProvisioner) Prepare(data provisioner.PrepareData, unprepared boo
 		return
 	}
 
+	// Only the BIOS settings that have not converged yet are applied,
+	// so that a host already reporting the requested values does not
+	// go through another cleaning cycle.
+	var biosSettings map[string]string
+	if unprepared && len(data.FirmwareConfig.BIOSSettings()) != 0 {
+		biosSettings, err = p.getBIOSSettings(ironicNode)
+		if err != nil {
+			result, err = transientError(errors.Wrap(err, "failed to read the BIOS settings"))
+			return
+		}
+
+		// Settings the BIOS registry does not allow would only fail
+		// the cleaning, so they are rejected before starting it.
+		var schema metal3v1alpha1.FirmwareSchema
+		schema, err = p.getFirmwareSchema(ironicNode)
+		if err != nil {
+			result, err = transientError(errors.Wrap(err, "failed to read the BIOS registry"))
+			return
+		}
+		if err = schema.ValidateSettings(pendingBIOSSettings(data.FirmwareConfig, biosSettings)); err != nil {
+			result, err = operationFailed(err.Error())
+			return
+		}
+	}
+
 	switch nodes.ProvisionState(ironicNode.ProvisionState) {
 	case nodes.Available:
 		var cleanSteps []nodes.CleanStep
-		cleanSteps, err = p.buildManualCleaningSteps(bmcAccess, data)
+		cleanSteps, err = p.buildManualCleaningSteps(bmcAccess, ironicNode, data, biosSettings)
 		if err != nil {
 			result, err = operationFailed(err.Error())
 			return
@@ -1164,33 +1733,49 @@ func (p *ironicProvisioner) Prepare(data provisioner.PrepareData, unprepared boo
 			)
 			return
 		}
-		result, err = operationComplete()
+		result, err = p.ejectVirtualMedia(ironicNode)
 
 	case nodes.Manageable:
 		if unprepared {
-			started, result, err = p.startManualCleaning(bmcAccess, ironicNode, data)
+			started, result, err = p.startManualCleaning(bmcAccess, ironicNode, data, biosSettings)
 			return
 		}
-		// Manual clean finished
-		result, err = operationComplete()
+		// Manual clean finished, the agent may have been booted from
+		// virtual media
+		result, err = p.ejectVirtualMedia(ironicNode)
 
 	case nodes.CleanFail:
 		// When clean failed, we need to clean host provisioning settings.
 		// If unprepared is false, means the settings aren't cleared.
 		// So we can't set the node's state to manageable, until the settings are cleared.
-		if !unprepared {
+		// A transient failure is retried by moving the node back to
+		// manageable, the controller then submits the settings again.
+		retry := !unprepared && data.RetryRecoverableFailure && isRecoverableCleanError(ironicNode.LastError)
+		if !unprepared && !retry {
 			result, err = operationFailed(ironicNode.LastError)
 			return
 		}
 		if ironicNode.Maintenance {
-			p.log.Info("clearing maintenance flag")
-			result, err = p.setMaintenanceFlag(ironicNode, false)
+			result, err = p.clearMaintenanceFlag(ironicNode)
 			return
 		}
-		result, err = p.changeNodeProvisionState(
+		if retry {
+			p.log.Info("retrying cleaning after recoverable failure", "msg", ironicNode.LastError)
+		}
+		var success bool
+		success, result, err = p.tryChangeNodeProvisionState(
 			ironicNode,
 			nodes.ProvisionStateOpts{Target: nodes.TargetManage},
 		)
+		result.Retried = retry && success
+
+	case nodes.Active:
+		// Cleaning would delete the volumes holding the instance
+		if unprepared {
+			result, err = operationFailed("the RAID and firmware settings of a provisioned host cannot be changed")
+			return
+		}
+		result, err = operationComplete()
 
 	case nodes.Cleaning, nodes.CleanWait:
 		p.log.Info("waiting for host to become manageable",
@@ -1204,6 +1789,41 @@ func (p *ironicProvisioner) Prepare(data provisioner.PrepareData, unprepared boo
 	return
 }
 
+// CheckFirmwareSettings compares the requested firmware settings with
+// the BIOS settings reported by the node and returns the ones that
+// have not been applied yet.
+func (p *ironicProvisioner) CheckFirmwareSettings(config *metal3v1alpha1.FirmwareConfig) (pending map[string]string, err error) {
+	ironicNode, err := p.getNode()
+	if err != nil {
+		return
+	}
+
+	current, err := p.getBIOSSettings(ironicNode)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the BIOS settings")
+	}
+
+	return pendingBIOSSettings(config, current), nil
+}
+
+// DiffFirmwareSettings compares the requested firmware settings with
+// the BIOS settings reported by the node, reporting the settings of
+// the previous configuration that are not requested anymore as
+// removed.
+func (p *ironicProvisioner) DiffFirmwareSettings(config, previous *metal3v1alpha1.FirmwareConfig) (diff []metal3v1alpha1.BIOSSettingDiff, err error) {
+	ironicNode, err := p.getNode()
+	if err != nil {
+		return
+	}
+
+	current, err := p.getBIOSSettings(ironicNode)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the BIOS settings")
+	}
+
+	return diffBIOSSettings(config, previous, current), nil
+}
+
 // Provision writes the image from the host spec to the host. It may
 // be called multiple times, and should return true for its dirty flag
 // until the deprovisioning operation is completed.
@@ -1214,6 +1834,38 @@ func (p *ironicProvisioner) Provision(data provisioner.ProvisionData) (result pr
 	}
 
 	p.log.Info("provisioning image to host", "state", ironicNode.ProvisionState)
+	trackImageDownload(ironicNode)
+
+	if err = data.Image.ValidateOCIReference(); err != nil {
+		return operationFailed(err.Error())
+	}
+	if err = data.Image.ValidateLiveISO(); err != nil {
+		return operationFailed(err.Error())
+	}
+	if err = data.Image.ValidateDownloadSource(); err != nil {
+		return operationFailed(err.Error())
+	}
+	if err = data.Image.ValidateMirrors(); err != nil {
+		return operationFailed(err.Error())
+	}
+	if data.RootDeviceHints, err = devicehints.Resolve(data.RootDeviceHints, data.HardwareDetails); err != nil {
+		return operationFailed(err.Error())
+	}
+	if err = validateRootPartitionSize(&data.Image, data.RootDeviceHints, data.HardwareDetails); err != nil {
+		return operationFailed(err.Error())
+	}
+	if err = data.Image.ValidateKind(); err != nil {
+		return operationFailed(err.Error())
+	}
+	if err = validateInstanceCapabilities(data.InstanceCapabilities); err != nil {
+		return operationFailed(err.Error())
+	}
+	if err = validateInstanceTraits(data.InstanceTraits, ironicNode); err != nil {
+		return operationFailed(err.Error())
+	}
+	if err = validatePostDeployScript(data.PostDeployScriptURL); err != nil {
+		return operationFailed(err.Error())
+	}
 
 	ironicHasSameImage := p.ironicHasSameImage(ironicNode, data.Image)
 
@@ -1233,6 +1885,35 @@ func (p *ironicProvisioner) Provision(data provisioner.ProvisionData) (result pr
 				p.log.Info("failed but error message not available")
 				return retryAfterDelay(0)
 			}
+			sources := data.Image.Sources()
+			if index := p.imageSourceIndex(ironicNode, data.Image); index+1 < len(sources) {
+				p.log.Info("deploying from the next image mirror", "msg", ironicNode.LastError,
+					"failed", sources[index].URL, "next", sources[index+1].URL)
+				p.publisher("ImageMirrorFallback",
+					fmt.Sprintf("Deploying from %s failed, trying %s", sources[index].URL, sources[index+1].URL))
+				data.Image = sources[index+1]
+				if provResult, err := p.setUpForProvisioning(ironicNode, data); err != nil || provResult.Dirty || provResult.ErrorMessage != "" {
+					return provResult, err
+				}
+				return p.changeNodeProvisionState(ironicNode,
+					nodes.ProvisionStateOpts{
+						Target:      nodes.TargetActive,
+						DeploySteps: buildDeploySteps(data.PostDeployScriptURL),
+					})
+			}
+			if data.RetryRecoverableFailure && isRecoverableDeployError(ironicNode.LastError) {
+				p.log.Info("retrying after recoverable failure", "msg", ironicNode.LastError)
+				if provResult, err := p.setUpForProvisioning(ironicNode, data); err != nil || provResult.Dirty || provResult.ErrorMessage != "" {
+					return provResult, err
+				}
+				success, result, err := p.tryChangeNodeProvisionState(ironicNode,
+					nodes.ProvisionStateOpts{
+						Target:      nodes.TargetActive,
+						DeploySteps: buildDeploySteps(data.PostDeployScriptURL),
+					})
+				result.Retried = success
+				return result, err
+			}
 			p.log.Info("found error", "msg", ironicNode.LastError)
 			return operationFailed(fmt.Sprintf("Image provisioning failed: %s",
 				ironicNode.LastError))
@@ -1243,7 +1924,10 @@ func (p *ironicProvisioner) Provision(data provisioner.ProvisionData) (result pr
 		}
 
 		return p.changeNodeProvisionState(ironicNode,
-			nodes.ProvisionStateOpts{Target: nodes.TargetActive})
+			nodes.ProvisionStateOpts{
+				Target:      nodes.TargetActive,
+				DeploySteps: buildDeploySteps(data.PostDeployScriptURL),
+			})
 
 	case nodes.Manageable:
 		return p.changeNodeProvisionState(ironicNode,
@@ -1251,8 +1935,7 @@ func (p *ironicProvisioner) Provision(data provisioner.ProvisionData) (result pr
 
 	case nodes.CleanFail:
 		if ironicNode.Maintenance {
-			p.log.Info("clearing maintenance flag")
-			return p.setMaintenanceFlag(ironicNode, false)
+			return p.clearMaintenanceFlag(ironicNode)
 		}
 		return p.changeNodeProvisionState(
 			ironicNode,
@@ -1264,6 +1947,12 @@ func (p *ironicProvisioner) Provision(data provisioner.ProvisionData) (result pr
 			return provResult, err
 		}
 
+		// Hosts fetching an image wait for a download slot, so that
+		// many simultaneous deploys do not saturate the image server.
+		if !p.imageDownloadAllowed(ironicNode, data.Image) {
+			return operationContinuing(provisionRequeueDelay)
+		}
+
 		// After it is available, we need to start provisioning by
 		// setting the state to "active".
 		p.log.Info("making host active")
@@ -1302,6 +1991,31 @@ func (p *ironicProvisioner) Provision(data provisioner.ProvisionData) (result pr
 				return transientError(errors.Wrap(err, "failed to unmarshal metadata from secret"))
 			}
 		}
+		extraFiles, err := data.HostConfig.ExtraFiles()
+		if err != nil {
+			return transientError(errors.Wrap(err, "could not retrieve config drive files"))
+		}
+
+		// Ironic cannot add files to the config drive it builds and
+		// cloud-init does not read the time settings from the meta
+		// data, so both are passed as cloud-config in the user data
+		cloudConfig := map[string]interface{}{}
+		if data.TimeSettings != nil {
+			if err = configdrive.ValidateTimeSettings(data.TimeSettings.NTPServers, data.TimeSettings.Timezone); err != nil {
+				return operationFailed(err.Error())
+			}
+			configdrive.AddTimeSettings(cloudConfig, data.TimeSettings.NTPServers, data.TimeSettings.Timezone)
+		}
+		if len(extraFiles) != 0 {
+			if err = configdrive.ValidateFiles(extraFiles); err != nil {
+				return operationFailed(err.Error())
+			}
+			configdrive.AddFiles(cloudConfig, extraFiles)
+		}
+		userData, err = configdrive.UserData(userData, cloudConfig)
+		if err != nil {
+			return operationFailed(err.Error())
+		}
 
 		var configDrive nodes.ConfigDrive
 		if userData != "" {
@@ -1310,22 +2024,48 @@ func (p *ironicProvisioner) Provision(data provisioner.ProvisionData) (result pr
 				MetaData:    metaData,
 				NetworkData: networkData,
 			}
-			if err != nil {
-				return transientError(errors.Wrap(err, "failed to build config drive"))
-			}
 			p.log.Info("triggering provisioning with config drive")
 		} else {
 			p.log.Info("triggering provisioning without config drive")
 		}
 
+		// The inspection may have enabled PXE on other ports since
+		// the registration.
+		if err := p.reconcileBootPorts(ironicNode); err != nil {
+			return transientError(err)
+		}
+
 		return p.changeNodeProvisionState(
 			ironicNode,
 			nodes.ProvisionStateOpts{
 				Target:      nodes.TargetActive,
 				ConfigDrive: configDrive,
+				DeploySteps: buildDeploySteps(data.PostDeployScriptURL),
 			},
 		)
 
+	case nodes.DeployWait:
+		// The image requested was changed while the previous one is
+		// being written, there is no point in waiting for the end
+		if !ironicHasSameImage && (ironicNode.InstanceInfo["image_source"] != nil || ironicNode.InstanceInfo["boot_iso"] != nil) {
+			p.log.Info("image changed during deploy", "image", data.Image.URL)
+			return p.abortDeploy(ironicNode)
+		}
+		reason, message, err := p.deployTimedOut(ironicNode, data)
+		if err != nil {
+			return transientError(errors.Wrap(err, "failed to check the deploy timeouts"))
+		}
+		if message != "" {
+			p.log.Info("deploy timed out", "reason", reason)
+			p.publisher(reason, message)
+			if result, err := p.abortDeploy(ironicNode); err != nil || result.ErrorMessage != "" {
+				return result, err
+			}
+			return operationFailed(fmt.Sprintf("Image provisioning failed: %s", message))
+		}
+		p.log.Info("waiting for deploy", "deploy step", ironicNode.DeployStep)
+		return operationContinuing(provisionRequeueDelay)
+
 	case nodes.Active:
 		// provisioning is done
 		p.publisher("ProvisioningComplete",
@@ -1373,6 +2113,9 @@ func (p *ironicProvisioner) Deprovision(force bool) (result provisioner.Result,
 		"deploy step", ironicNode.DeployStep,
 		"instance_info", ironicNode.InstanceInfo,
 	)
+	trackImageDownload(ironicNode)
+	// A manual cleaning waiting for the node to power off is abandoned.
+	forgetPowerOffWait(ironicNode.UUID)
 
 	switch nodes.ProvisionState(ironicNode.ProvisionState) {
 	case nodes.Error:
@@ -1395,8 +2138,7 @@ func (p *ironicProvisioner) Deprovision(force bool) (result provisioner.Result,
 	case nodes.CleanFail:
 		p.log.Info("cleaning failed")
 		if ironicNode.Maintenance {
-			p.log.Info("clearing maintenance flag")
-			return p.setMaintenanceFlag(ironicNode, false)
+			return p.clearMaintenanceFlag(ironicNode)
 		}
 		// This will return us to the manageable state without completing
 		// cleaning. Because cleaning happens in the process of moving from
@@ -1413,9 +2155,12 @@ func (p *ironicProvisioner) Deprovision(force bool) (result provisioner.Result,
 		// get cleaned before we provision it again. Therefore, just declare
 		// deprovisioning complete.
 		p.log.Info("deprovisioning node is in manageable state")
-		return operationComplete()
+		return p.ejectVirtualMedia(ironicNode)
 
 	case nodes.Available:
+		if provResult, err := p.ejectVirtualMedia(ironicNode); err != nil || provResult.Dirty || provResult.ErrorMessage != "" {
+			return provResult, err
+		}
 		p.publisher("DeprovisioningComplete", "Image deprovisioning completed")
 		return operationComplete()
 
@@ -1451,6 +2196,10 @@ func (p *ironicProvisioner) Deprovision(force bool) (result provisioner.Result,
 // called multiple times, and should return true for its dirty flag
 // until the deprovisioning operation is completed.
 func (p *ironicProvisioner) Delete() (result provisioner.Result, err error) {
+	// The host may be deleted while its BMC is being checked, before
+	// it is registered.
+	forgetBMCCheck(p.bmcCheckHost())
+
 	ironicNode, err := p.getNode()
 	if err != nil {
 		if errors.Is(err, provisioner.ErrNeedsRegistration) {
@@ -1497,11 +2246,19 @@ func (p *ironicProvisioner) Delete() (result provisioner.Result, err error) {
 	switch err.(type) {
 	case nil:
 		p.log.Info("removed")
+		releaseImageDownload(ironicNode.UUID)
+		forgetNodeShard(ironicNode.UUID)
+		forgetPowerOffWait(ironicNode.UUID)
+		forgetNodeHistory(ironicNode.UUID)
 	case gophercloud.ErrDefault409:
 		p.log.Info("could not remove host, busy")
 		return retryAfterDelay(provisionRequeueDelay)
 	case gophercloud.ErrDefault404:
 		p.log.Info("did not find host to delete, OK")
+		releaseImageDownload(ironicNode.UUID)
+		forgetNodeShard(ironicNode.UUID)
+		forgetPowerOffWait(ironicNode.UUID)
+		forgetNodeHistory(ironicNode.UUID)
 	default:
 		return transientError(errors.Wrap(err, "failed to remove host"))
 	}
@@ -1530,6 +2287,11 @@ func (p *ironicProvisioner) changePower(ironicNode *nodes.Node, target nodes.Tar
 		return operationContinuing(powerRequeueDelay)
 	}
 
+	if p.nodeLocked(ironicNode) {
+		result, _ = retryAfterDelay(nodeLockedRequeueDelay)
+		return result, HostLockedError{}
+	}
+
 	powerStateOpts := nodes.PowerStateOpts{
 		Target: target,
 	}
@@ -1556,6 +2318,9 @@ func (p *ironicProvisioner) changePower(ironicNode *nodes.Node, target nodes.Tar
 		return result, SoftPowerOffUnsupportedError{}
 	default:
 		p.log.Info("power change error", "message", changeResult.Err)
+		if err = p.checkConductor(ironicNode); errors.Is(err, provisioner.ErrConductorDown) {
+			return transientError(err)
+		}
 		return transientError(errors.Wrap(changeResult.Err, "failed to change power state"))
 	}
 }
@@ -1582,6 +2347,7 @@ func (p *ironicProvisioner) PowerOn() (result provisioner.Result, err error) {
 		switch err.(type) {
 		case nil:
 		case HostLockedError:
+			return result, nil
 		default:
 			return transientError(errors.Wrap(err, "failed to power on host"))
 		}
@@ -1631,7 +2397,11 @@ func (p *ironicProvisioner) hardPowerOff() (result provisioner.Result, err error
 			return operationContinuing(powerRequeueDelay)
 		}
 		result, err = p.changePower(ironicNode, nodes.PowerOff)
-		if err != nil {
+		switch err.(type) {
+		case nil:
+		case HostLockedError:
+			return result, nil
+		default:
 			return transientError(errors.Wrap(err, "failed to power off host"))
 		}
 		p.publisher("PowerOff", "Host powered off")
@@ -1676,6 +2446,59 @@ func (p *ironicProvisioner) softPowerOff() (result provisioner.Result, err error
 	return result, nil
 }
 
+// SetBootDevice sets the device the host boots from. The device is
+// checked against the ones supported by the BMC when they can be read.
+func (p *ironicProvisioner) SetBootDevice(device string, persistent bool) (result provisioner.Result, err error) {
+	p.log.Info("setting boot device", "device", device, "persistent", persistent)
+
+	ironicNode, err := p.getNode()
+	if err != nil {
+		return transientError(err)
+	}
+
+	supported, err := nodes.GetSupportedBootDevices(p.client, ironicNode.UUID).Extract()
+	if err != nil {
+		p.log.Info("could not read the supported boot devices", "error", err)
+	} else if !bootDeviceSupported(device, supported) {
+		return operationFailed(fmt.Sprintf("boot device %q is not supported, expected one of %s",
+			device, strings.Join(supported, ", ")))
+	}
+
+	if p.nodeLocked(ironicNode) {
+		return retryAfterDelay(nodeLockedRequeueDelay)
+	}
+
+	bootDeviceResult := nodes.SetBootDevice(
+		p.client,
+		ironicNode.UUID,
+		nodes.BootDeviceOpts{
+			BootDevice: device,
+			Persistent: persistent,
+		})
+
+	switch bootDeviceResult.Err.(type) {
+	case nil:
+		p.publisher("BootDeviceSet", fmt.Sprintf("Boot device set to %s", device))
+		return operationComplete()
+	case gophercloud.ErrDefault409:
+		p.log.Info("host is locked, trying again after delay", "delay", powerRequeueDelay)
+		return retryAfterDelay(powerRequeueDelay)
+	case gophercloud.ErrDefault400:
+		return operationFailed(fmt.Sprintf("could not set boot device: %s", bootDeviceResult.Err))
+	default:
+		return transientError(errors.Wrap(bootDeviceResult.Err, "failed to set boot device"))
+	}
+}
+
+func bootDeviceSupported(device string, supported []string) bool {
+	for _, dev := range supported {
+		if dev == device {
+			return true
+		}
+	}
+	return false
+}
+
 func ironicNodeName(objMeta metav1.ObjectMeta) string {
 	return objMeta.Namespace + nameSeparator + objMeta.Name
 }
@@ -1690,7 +2513,7 @@ func (p *ironicProvisioner) IsReady() (result bool, err error) {
 
 func (p *ironicProvisioner) HasCapacity() (result bool, err error) {
 
-	hosts, err := p.loadBusyHosts()
+	hosts, conductorGroup, err := p.loadBusyHosts()
 	if err != nil {
 		p.log.Error(err, "Unable to get hosts for determining current provisioner capacity")
 		return false, err
@@ -1701,36 +2524,62 @@ func (p *ironicProvisioner) HasCapacity() (result bool, err error) {
 		return true, nil
 	}
 
-	return len(hosts) < maxBusyHosts, nil
+	if len(hosts) >= maxBusyHosts {
+		return false, nil
+	}
+
+	// Hosts of the same conductor group are handled by the same
+	// conductors, so they may also be limited on their own.
+	if maxBusyHostsPerGroup > 0 {
+		busyInGroup := 0
+		for _, group := range hosts {
+			if group == conductorGroup {
+				busyInGroup++
+			}
+		}
+		if busyInGroup >= maxBusyHostsPerGroup {
+			p.log.Info("no provisioning capacity left in conductor group",
+				"group", conductorGroup, "busy", busyInGroup)
+			return false, nil
+		}
+	}
+
+	return true, nil
 }
 
-func (p *ironicProvisioner) loadBusyHosts() (hosts map[string]struct{}, err error) {
+// loadBusyHosts returns the names of the hosts currently being
+// (de)provisioned with their conductor group, as well as the
+// conductor group of the current host.
+func (p *ironicProvisioner) loadBusyHosts() (hosts map[string]string, conductorGroup string, err error) {
 
-	hosts = make(map[string]struct{})
+	hosts = make(map[string]string)
 	pager := nodes.List(p.client, nodes.ListOpts{
-		Fields: []string{"uuid,name,provision_state,driver_internal_info,target_provision_state"},
+		Fields: []string{"uuid,name,provision_state,driver_internal_info,target_provision_state,conductor_group"},
 	})
 
 	page, err := pager.AllPages()
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	allNodes, err := nodes.ExtractNodes(page)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	for _, node := range allNodes {
+		if node.Name == ironicNodeName(p.objectMeta) {
+			conductorGroup = node.ConductorGroup
+		}
 
 		switch nodes.ProvisionState(node.ProvisionState) {
 		case nodes.Cleaning, nodes.CleanWait,
 			nodes.Inspecting, nodes.InspectWait,
 			nodes.Deploying, nodes.DeployWait,
 			nodes.Deleting:
-			hosts[node.Name] = struct{}{}
+			hosts[node.Name] = node.ConductorGroup
 		}
 	}
 
-	return hosts, nil
+	return hosts, conductorGroup, nil
 }
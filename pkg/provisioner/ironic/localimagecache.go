@@ -0,0 +1,122 @@
+package ironic
+
+import (
+	"bufio"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// localImageCacheTimeout limits the time spent asking the local image
+// cache whether it holds an image, so that an unavailable cache does
+// not block the reconcile loop.
+const localImageCacheTimeout = 10 * time.Second
+
+// localImageCacheKey returns the path of the image in the local image
+// cache, which is keyed by the checksum of the image so that a new
+// checksum for the same URL never matches a stale entry. Only HTTP
+// images with a literal checksum can be cached.
+func localImageCacheKey(image *metal3v1alpha1.Image) string {
+	if localImageCacheURL == "" || image == nil || image.IsOCI() {
+		return ""
+	}
+	if image.DiskFormat != nil && *image.DiskFormat == "live-iso" {
+		return ""
+	}
+	checksum, _, ok := image.GetChecksum()
+	if !ok || checksum == "" || strings.Contains(checksum, "/") {
+		return ""
+	}
+	source, err := url.Parse(image.URL)
+	if err != nil || (source.Scheme != "http" && source.Scheme != "https") {
+		return ""
+	}
+	name := path.Base(source.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return "/" + checksum + "/" + name
+}
+
+// localImageCacheSource returns the URL of the image in the local image
+// cache, whether it is cached or not.
+func localImageCacheSource(image *metal3v1alpha1.Image) string {
+	key := localImageCacheKey(image)
+	if key == "" {
+		return ""
+	}
+	return localImageCacheURL + key
+}
+
+// isImageSource returns whether the image_source of a node points to
+// the image, either at its own URL or in the local image cache.
+func isImageSource(source interface{}, image *metal3v1alpha1.Image) bool {
+	if source == image.URL {
+		return true
+	}
+	cached := localImageCacheSource(image)
+	return cached != "" && source == cached
+}
+
+// localImageCached returns whether the local image cache holds the
+// image. The cache publishes the checksum of each image next to it,
+// once it is completely downloaded and verified, in a file named after
+// the image and the checksum type, e.g. image.qcow2.sha256.
+func (p *ironicProvisioner) localImageCached(image *metal3v1alpha1.Image) bool {
+	cached := localImageCacheSource(image)
+	if cached == "" {
+		return false
+	}
+	checksum, checksumType, _ := image.GetChecksum()
+
+	client := &http.Client{Timeout: localImageCacheTimeout}
+	response, err := client.Get(cached + "." + checksumType)
+	if err != nil {
+		p.log.Info("could not reach the local image cache", "url", cached, "error", err)
+		return false
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		p.debugLog.Info("image not in the local image cache", "url", cached, "status", response.Status)
+		return false
+	}
+
+	// Accept both a bare checksum and the output of e.g. sha256sum
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Split(bufio.ScanWords)
+	if !scanner.Scan() || !strings.EqualFold(scanner.Text(), checksum) {
+		p.log.Info("ignoring the local image cache entry with another checksum", "url", cached)
+		return false
+	}
+	return true
+}
+
+// getImageSource returns the image_source to set for the image. Images
+// found in the local image cache are deployed from it, the others from
+// their own URL. A source already pointing to the cache is kept, as is
+// the source of deployed nodes, so that the cache is not checked again
+// on every reconcile.
+func (p *ironicProvisioner) getImageSource(ironicNode *nodes.Node, image *metal3v1alpha1.Image) string {
+	cached := localImageCacheSource(image)
+	if cached == "" {
+		return image.URL
+	}
+	current := ironicNode.InstanceInfo["image_source"]
+	if current == cached {
+		return cached
+	}
+	if current == image.URL && nodes.ProvisionState(ironicNode.ProvisionState) == nodes.Active {
+		return image.URL
+	}
+	if p.localImageCached(image) {
+		p.log.Info("deploying the image from the local image cache", "url", cached)
+		return cached
+	}
+	return image.URL
+}
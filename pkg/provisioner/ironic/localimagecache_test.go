@@ -0,0 +1,167 @@
+package ironic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/hardware"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+)
+
+const cachedChecksum = "1fd8b7c8e5ef2a2dcf1ebd7ca4a9cd2a7c0b3c3f1f1e7e2bb6b0b5d5f5a0c2e1"
+
+func TestLocalImageCacheKey(t *testing.T) {
+	defer func(value string) { localImageCacheURL = value }(localImageCacheURL)
+	liveISO := "live-iso"
+
+	cases := []struct {
+		name        string
+		cacheURL    string
+		image       metal3v1alpha1.Image
+		expectedKey string
+	}{
+		{
+			name:        "cached",
+			cacheURL:    "http://cache.test",
+			image:       metal3v1alpha1.Image{URL: "http://example.com/images/image.qcow2?version=2", Checksum: cachedChecksum, ChecksumType: metal3v1alpha1.SHA256},
+			expectedKey: "/" + cachedChecksum + "/image.qcow2",
+		},
+		{
+			name:     "no cache",
+			image:    metal3v1alpha1.Image{URL: "http://example.com/image.qcow2", Checksum: cachedChecksum, ChecksumType: metal3v1alpha1.SHA256},
+			cacheURL: "",
+		},
+		{
+			name:     "checksum URL",
+			cacheURL: "http://cache.test",
+			image:    metal3v1alpha1.Image{URL: "http://example.com/image.qcow2", Checksum: "http://example.com/image.qcow2.md5sum"},
+		},
+		{
+			name:     "live ISO",
+			cacheURL: "http://cache.test",
+			image:    metal3v1alpha1.Image{URL: "http://example.com/image.iso", DiskFormat: &liveISO},
+		},
+		{
+			name:     "OCI",
+			cacheURL: "http://cache.test",
+			image:    metal3v1alpha1.Image{URL: "oci://quay.example.com/images/image:1", Checksum: cachedChecksum, ChecksumType: metal3v1alpha1.SHA256},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			localImageCacheURL = tc.cacheURL
+			assert.Equal(t, tc.expectedKey, localImageCacheKey(&tc.image))
+		})
+	}
+}
+
+func TestGetUpdateOptsForNodeLocalImageCache(t *testing.T) {
+	defer func(value string) { localImageCacheURL = value }(localImageCacheURL)
+
+	var cachedContent string
+	var requests int
+	cache := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if cachedContent == "" || r.URL.Path != "/"+cachedChecksum+"/image.qcow2.sha256" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(cachedContent))
+	}))
+	defer cache.Close()
+	cachedURL := cache.URL + "/" + cachedChecksum + "/image.qcow2"
+
+	cases := []struct {
+		name           string
+		cachedContent  string
+		node           nodes.Node
+		expectedSource string
+		expectedChecks int
+	}{
+		{
+			name:           "checksum matches",
+			cachedContent:  cachedChecksum + "  image.qcow2\n",
+			expectedSource: cachedURL,
+			expectedChecks: 1,
+		},
+		{
+			name:           "other checksum",
+			cachedContent:  "0000000000000000000000000000000000000000000000000000000000000000",
+			expectedSource: "http://example.com/image.qcow2",
+			expectedChecks: 1,
+		},
+		{
+			name:           "not cached",
+			expectedSource: "http://example.com/image.qcow2",
+			expectedChecks: 1,
+		},
+		{
+			name:           "already from the cache",
+			node:           nodes.Node{InstanceInfo: map[string]interface{}{"image_source": cachedURL}},
+			expectedSource: cachedURL,
+		},
+		{
+			name:          "deployed from the image URL",
+			cachedContent: cachedChecksum,
+			node: nodes.Node{
+				ProvisionState: string(nodes.Active),
+				InstanceInfo:   map[string]interface{}{"image_source": "http://example.com/image.qcow2"},
+			},
+			expectedSource: "http://example.com/image.qcow2",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			localImageCacheURL = cache.URL
+			cachedContent = tc.cachedContent
+			requests = 0
+
+			host := makeHost()
+			host.Spec.Image = &metal3v1alpha1.Image{
+				URL:          "http://example.com/image.qcow2",
+				Checksum:     cachedChecksum,
+				ChecksumType: metal3v1alpha1.SHA256,
+			}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				"https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			hwProf, _ := hardware.GetProfile("libvirt")
+			ironicNode := tc.node
+			patches := prov.getUpdateOptsForNode(&ironicNode, provisioner.ProvisionData{
+				Image:           *host.Spec.Image,
+				BootMode:        metal3v1alpha1.DefaultBootMode,
+				HardwareProfile: hwProf,
+			}).updates
+
+			source := ironicNode.InstanceInfo["image_source"]
+			for _, patch := range patches {
+				update := patch.(nodes.UpdateOperation)
+				if update.Path == "/instance_info/image_source" {
+					source = update.Value
+				}
+			}
+			assert.Equal(t, tc.expectedSource, source)
+			assert.Equal(t, tc.expectedChecks, requests)
+			ironicNode.InstanceInfo = map[string]interface{}{
+				"image_source":        source,
+				"image_os_hash_algo":  "sha256",
+				"image_os_hash_value": cachedChecksum,
+			}
+			assert.True(t, prov.ironicHasImageSource(&ironicNode, *host.Spec.Image))
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// hardwareFault returns the reason the node was put in maintenance
+// mode because of a hardware fault, or an empty string. Ironic records
+// the faults it detects itself in the fault field and the operator
+// sets the maintenance mode without a reason, so a reason without a
+// fault comes from an external health check, e.g. one acting on the
+// sensor alerts of the BMC.
+func hardwareFault(ironicNode *nodes.Node) string {
+	if !ironicNode.Maintenance || ironicNode.Fault != "" {
+		return ""
+	}
+	return ironicNode.MaintenanceReason
+}
+
+// clearMaintenanceFlag takes the node out of maintenance mode, unless
+// it was set because of a hardware fault that has to be repaired first.
+func (p *ironicProvisioner) clearMaintenanceFlag(ironicNode *nodes.Node) (result provisioner.Result, err error) {
+	if reason := hardwareFault(ironicNode); reason != "" {
+		p.log.Info("not clearing the maintenance flag set because of a hardware fault", "reason", reason)
+		return operationFailed(fmt.Sprintf("host in maintenance because of a hardware fault: %s", reason))
+	}
+	p.log.Info("clearing maintenance flag")
+	return p.setMaintenanceFlag(ironicNode, false)
+}
@@ -0,0 +1,95 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestHardwareFault(t *testing.T) {
+	cases := []struct {
+		name     string
+		node     nodes.Node
+		expected string
+	}{
+		{
+			name: "not in maintenance",
+			node: nodes.Node{MaintenanceReason: "fan failure"},
+		},
+		{
+			name: "set by the operator",
+			node: nodes.Node{Maintenance: true},
+		},
+		{
+			name: "fault detected by ironic",
+			node: nodes.Node{Maintenance: true, Fault: "power failure", MaintenanceReason: "BMC unreachable"},
+		},
+		{
+			name:     "hardware fault",
+			node:     nodes.Node{Maintenance: true, MaintenanceReason: "PSU 1 failed"},
+			expected: "PSU 1 failed",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hardwareFault(&tc.node))
+		})
+	}
+}
+
+func TestClearMaintenanceFlag(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name string
+		node nodes.Node
+
+		expectedUpdates []nodes.UpdateOperation
+		expectedError   string
+	}{
+		{
+			name:            "set by the operator",
+			node:            nodes.Node{UUID: nodeUUID, Maintenance: true},
+			expectedUpdates: []nodes.UpdateOperation{{Op: nodes.AddOp, Path: "/maintenance", Value: false}},
+		},
+		{
+			name:            "fault detected by ironic",
+			node:            nodes.Node{UUID: nodeUUID, Maintenance: true, Fault: "clean failure", MaintenanceReason: "timeout"},
+			expectedUpdates: []nodes.UpdateOperation{{Op: nodes.AddOp, Path: "/maintenance", Value: false}},
+		},
+		{
+			name:          "hardware fault",
+			node:          nodes.Node{UUID: nodeUUID, Maintenance: true, MaintenanceReason: "PSU 1 failed"},
+			expectedError: "host in maintenance because of a hardware fault: PSU 1 failed",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().Node(tc.node).NodeUpdate(nodes.Node{UUID: nodeUUID})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.clearMaintenanceFlag(&tc.node)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			assert.Equal(t, tc.expectedUpdates, ironic.GetLastNodeUpdateRequestFor(nodeUUID))
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package ironic
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// networkUUIDRegexp matches the UUIDs of the networks known to neutron
+var networkUUIDRegexp = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// deployNetworkFields returns the deploy networks of the host by name
+// of the driver_info field overriding the conductor defaults
+func deployNetworkFields(networks *metal3v1alpha1.DeployNetworks) optionsData {
+	fields := optionsData{
+		"provisioning_network": nil,
+		"cleaning_network":     nil,
+	}
+	if networks == nil {
+		return fields
+	}
+	if networks.ProvisioningNetwork != "" {
+		fields["provisioning_network"] = networks.ProvisioningNetwork
+	}
+	if networks.CleaningNetwork != "" {
+		fields["cleaning_network"] = networks.CleaningNetwork
+	}
+	return fields
+}
+
+// validateDeployNetworks checks that the deploy networks are UUIDs
+func validateDeployNetworks(networks *metal3v1alpha1.DeployNetworks) error {
+	for field, value := range deployNetworkFields(networks) {
+		if value == nil {
+			continue
+		}
+		if !networkUUIDRegexp.MatchString(value.(string)) {
+			return fmt.Errorf("invalid %s %q, expected a network UUID", field, value)
+		}
+	}
+	return nil
+}
+
+// setDeployNetworksUpdateOpts sets the deploy networks of the host in
+// the driver_info of the node, or removes them so that the defaults
+// of the conductor are used.
+func setDeployNetworksUpdateOpts(ironicNode *nodes.Node, networks *metal3v1alpha1.DeployNetworks, updater *nodeUpdater) {
+	updater.SetDriverInfoOpts(deployNetworkFields(networks), ironicNode)
+}
@@ -0,0 +1,49 @@
+package ironic
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// nodeFields holds the fields of the node that the client library does
+// not support. They are part of the same response as the node. The
+// conductor is kept raw to tell a null value from a missing one.
+type nodeFields struct {
+	UUID                 string          `json:"uuid"`
+	Description          *string         `json:"description"`
+	AllocationUUID       *string         `json:"allocation_uuid"`
+	Conductor            json.RawMessage `json:"conductor"`
+	ProvisionUpdatedAt   *time.Time      `json:"provision_updated_at"`
+	InspectionFinishedAt *time.Time      `json:"inspection_finished_at"`
+	CreatedAt            *time.Time      `json:"created_at"`
+}
+
+// getNodeFields returns the fields of the node missing from the client
+// library. The fields read with the node by getNode are reused, and the
+// node is only read again if it came from somewhere else.
+func (p *ironicProvisioner) getNodeFields(ironicNode *nodes.Node) (*nodeFields, error) {
+	if p.nodeFields != nil && p.nodeFields.UUID == ironicNode.UUID {
+		return p.nodeFields, nil
+	}
+
+	fields := &nodeFields{}
+	if _, err := p.client.Get(p.client.ServiceURL("nodes", ironicNode.UUID), fields, nil); err != nil {
+		return nil, err
+	}
+	p.nodeFields = fields
+	return fields, nil
+}
+
+// statusTime converts a time reported by Ironic for the status. The
+// status only stores seconds, keeping the fraction would make it look
+// changed on every update.
+func statusTime(value *time.Time) *metav1.Time {
+	if value == nil {
+		return nil
+	}
+	converted := metav1.NewTime(value.Truncate(time.Second))
+	return &converted
+}
@@ -0,0 +1,59 @@
+package ironic
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+)
+
+const (
+	// deployStepsMicroversion is the first API version accepting
+	// deploy steps when deploying a node.
+	deployStepsMicroversion = "1.69"
+
+	// postDeployScriptStep is the deploy step of the deploy ramdisk
+	// running the post-deploy script. It is not part of the agent and
+	// must be provided by a custom hardware manager.
+	postDeployScriptStep = "run_post_deploy_script"
+
+	// postDeployScriptPriority runs the script after the image is
+	// written (priority 80) and the boot loader installed (60), and
+	// before the agent is torn down (40).
+	postDeployScriptPriority = 50
+)
+
+// validatePostDeployScript checks that the post-deploy script can be
+// downloaded by the deploy ramdisk.
+func validatePostDeployScript(scriptURL string) error {
+	if scriptURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(scriptURL)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("invalid post-deploy script URL %q", scriptURL)
+	}
+	switch parsed.Scheme {
+	case "http", "https":
+		return nil
+	default:
+		return fmt.Errorf("unsupported scheme %q for post-deploy script %q, expected http or https",
+			parsed.Scheme, scriptURL)
+	}
+}
+
+// buildDeploySteps returns the custom deploy steps of the deployment,
+// running the post-deploy script if any.
+func buildDeploySteps(scriptURL string) (deploySteps []nodes.DeployStep) {
+	if scriptURL == "" {
+		return
+	}
+	return append(deploySteps, nodes.DeployStep{
+		Interface: nodes.InterfaceDeploy,
+		Step:      postDeployScriptStep,
+		Args: map[string]interface{}{
+			"url": scriptURL,
+		},
+		Priority: postDeployScriptPriority,
+	})
+}
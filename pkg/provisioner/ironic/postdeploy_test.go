@@ -0,0 +1,138 @@
+package ironic
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestValidatePostDeployScript(t *testing.T) {
+	cases := []struct {
+		url           string
+		expectedError string
+	}{
+		{url: ""},
+		{url: "http://example.test/register.sh"},
+		{url: "https://example.test/register.sh"},
+		{
+			url:           "ftp://example.test/register.sh",
+			expectedError: "unsupported scheme \"ftp\" for post-deploy script \"ftp://example.test/register.sh\", expected http or https",
+		},
+		{
+			url:           "/register.sh",
+			expectedError: "invalid post-deploy script URL \"/register.sh\"",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.url, func(t *testing.T) {
+			err := validatePostDeployScript(tc.url)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestProvisionPostDeployScript(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name                 string
+		scriptURL            string
+		expectedSteps        []nodes.DeployStep
+		expectedMicroversion string
+		expectedError        string
+	}{
+		{
+			name:                 "no script",
+			expectedMicroversion: "1.56",
+		},
+		{
+			name:      "script",
+			scriptURL: "https://example.test/register.sh",
+			expectedSteps: []nodes.DeployStep{
+				{
+					Interface: nodes.InterfaceDeploy,
+					Step:      "run_post_deploy_script",
+					Args:      map[string]interface{}{"url": "https://example.test/register.sh"},
+					Priority:  50,
+				},
+			},
+			expectedMicroversion: "1.69",
+		},
+		{
+			name:          "invalid script",
+			scriptURL:     "file:///register.sh",
+			expectedError: "invalid post-deploy script URL \"file:///register.sh\"",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests int
+			var steps []nodes.DeployStep
+			var microversion string
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Available),
+				UUID:           nodeUUID,
+			})
+			ironic.ResponseJSON("/v1/nodes/"+nodeUUID+"/validate", nodes.NodeValidation{
+				Boot:   nodes.DriverValidation{Result: true},
+				Deploy: nodes.DriverValidation{Result: true},
+			})
+			ironic.Handler("/v1/nodes/"+nodeUUID+"/states/provision", func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					DeploySteps []nodes.DeployStep `json:"deploy_steps"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("invalid provision state request: %s", err)
+				}
+				requests++
+				steps = body.DeploySteps
+				microversion = r.Header.Get("X-OpenStack-Ironic-API-Version")
+				w.WriteHeader(http.StatusAccepted)
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image:               *host.Spec.Image,
+				HostConfig:          fixture.NewHostConfigData("", "", ""),
+				BootMode:            v1alpha1.DefaultBootMode,
+				PostDeployScriptURL: tc.scriptURL,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			if tc.expectedError != "" {
+				assert.Zero(t, requests)
+				return
+			}
+			assert.Equal(t, 1, requests)
+			assert.Equal(t, tc.expectedSteps, steps)
+			assert.Equal(t, tc.expectedMicroversion, microversion)
+		})
+	}
+}
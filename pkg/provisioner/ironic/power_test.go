@@ -64,6 +64,17 @@ func TestPowerOn(t *testing.T) {
 			expectedRequestAfter: 10,
 			expectedDirty:        true,
 		},
+		{
+			name: "power-on wait for reserved node",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				PowerState:       powerOff,
+				TargetPowerState: powerOff,
+				Reservation:      "conductor-1",
+				UUID:             nodeUUID,
+			}),
+			expectedRequestAfter: 5,
+			expectedDirty:        true,
+		},
 		{
 			name: "power-on wait for locked host",
 			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
@@ -176,6 +187,17 @@ func TestPowerOff(t *testing.T) {
 			expectedRequestAfter: 10,
 			expectedDirty:        true,
 		},
+		{
+			name: "power-off wait for reserved node",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				PowerState:       powerOn,
+				TargetPowerState: powerOn,
+				Reservation:      "conductor-1",
+				UUID:             nodeUUID,
+			}),
+			expectedRequestAfter: 10,
+			expectedDirty:        true,
+		},
 		{
 			name: "power-off wait for locked host",
 			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
@@ -226,3 +248,91 @@ func TestPowerOff(t *testing.T) {
 		})
 	}
 }
+
+func TestSetBootDevice(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name       string
+		device     string
+		persistent bool
+		ironic     *testserver.IronicMock
+
+		expectedRequest      string
+		expectedErrorMessage string
+		expectedDirty        bool
+	}{
+		{
+			name:   "one-time pxe",
+			device: "pxe",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID: nodeUUID,
+			}).SupportedBootDevices(nodeUUID, []string{"pxe", "disk"}).WithBootDeviceUpdate(nodeUUID, http.StatusNoContent),
+			expectedRequest: `{"boot_device":"pxe","persistent":false}`,
+		},
+		{
+			name:       "persistent disk",
+			device:     "disk",
+			persistent: true,
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID: nodeUUID,
+			}).SupportedBootDevices(nodeUUID, []string{"pxe", "disk"}).WithBootDeviceUpdate(nodeUUID, http.StatusNoContent),
+			expectedRequest: `{"boot_device":"disk","persistent":true}`,
+		},
+		{
+			name:   "supported devices unknown",
+			device: "pxe",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID: nodeUUID,
+			}).WithBootDeviceUpdate(nodeUUID, http.StatusNoContent),
+			expectedRequest: `{"boot_device":"pxe","persistent":false}`,
+		},
+		{
+			name:   "unsupported device",
+			device: "cdrom",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID: nodeUUID,
+			}).SupportedBootDevices(nodeUUID, []string{"pxe", "disk"}).WithBootDeviceUpdate(nodeUUID, http.StatusNoContent),
+			expectedErrorMessage: "boot device \"cdrom\" is not supported, expected one of pxe, disk",
+		},
+		{
+			name:   "locked host",
+			device: "pxe",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID: nodeUUID,
+			}).SupportedBootDevices(nodeUUID, []string{"pxe", "disk"}).WithBootDeviceUpdate(nodeUUID, http.StatusConflict),
+			expectedRequest: `{"boot_device":"pxe","persistent":false}`,
+			expectedDirty:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.ironic.Start()
+			defer tc.ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			publisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				tc.ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.SetBootDevice(tc.device, tc.persistent)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedErrorMessage, result.ErrorMessage)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+
+			request, found := tc.ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/management/boot_device", http.MethodPut)
+			if tc.expectedRequest == "" {
+				assert.False(t, found)
+			} else {
+				assert.True(t, found)
+				assert.JSONEq(t, tc.expectedRequest, request)
+			}
+		})
+	}
+}
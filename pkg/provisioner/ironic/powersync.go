@@ -0,0 +1,55 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// SyncPowerState makes Ironic read the power state of the host from
+// the BMC, without waiting for its periodic power sync, by requesting
+// the power state the host should be in. Ironic reads the actual state
+// before acting, and only records it when the host is already in the
+// requested state.
+func (p *ironicProvisioner) SyncPowerState(online bool) (result provisioner.Result, err error) {
+	ironicNode, err := p.getNode()
+	if err != nil {
+		return transientError(err)
+	}
+
+	if ironicNode.TargetPowerState != "" || ironicNode.TargetProvisionState != "" {
+		p.log.Info("host in state that does not allow syncing the power state, try again after delay",
+			"state", ironicNode.ProvisionState,
+			"target power state", ironicNode.TargetPowerState,
+			"target state", ironicNode.TargetProvisionState,
+		)
+		return operationContinuing(powerRequeueDelay)
+	}
+	if p.nodeLocked(ironicNode) {
+		return retryAfterDelay(nodeLockedRequeueDelay)
+	}
+
+	target := nodes.PowerOff
+	if online {
+		target = nodes.PowerOn
+	}
+	p.log.Info("syncing power state", "recorded", ironicNode.PowerState, "target", target)
+	changeResult := nodes.ChangePowerState(p.client, ironicNode.UUID, nodes.PowerStateOpts{Target: target})
+
+	switch changeResult.Err.(type) {
+	case nil:
+		p.publisher("PowerStateSynced", "Power state sync was requested")
+		return operationComplete()
+	case gophercloud.ErrDefault409:
+		p.log.Info("host is locked, trying again after delay", "delay", powerRequeueDelay)
+		return retryAfterDelay(powerRequeueDelay)
+	case gophercloud.ErrDefault400:
+		return operationFailed(fmt.Sprintf("could not sync the power state: %s", changeResult.Err))
+	default:
+		return transientError(errors.Wrap(changeResult.Err, "failed to sync the power state"))
+	}
+}
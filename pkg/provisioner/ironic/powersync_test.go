@@ -0,0 +1,118 @@
+package ironic
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestSyncPowerState(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	powerPath := "/v1/nodes/" + nodeUUID + "/states/power"
+
+	cases := []struct {
+		name        string
+		online      bool
+		node        nodes.Node
+		powerStatus int
+
+		expectedRequest      string
+		expectedErrorMessage string
+		expectedDirty        bool
+		expectedRequeueAfter time.Duration
+		expectedError        bool
+	}{
+		{
+			name:            "online",
+			online:          true,
+			node:            nodes.Node{UUID: nodeUUID, PowerState: powerOn},
+			powerStatus:     http.StatusAccepted,
+			expectedRequest: `{"target":"power on"}`,
+		},
+		{
+			name:            "offline",
+			node:            nodes.Node{UUID: nodeUUID, PowerState: powerOn},
+			powerStatus:     http.StatusAccepted,
+			expectedRequest: `{"target":"power off"}`,
+		},
+		{
+			name:                 "power change in progress",
+			online:               true,
+			node:                 nodes.Node{UUID: nodeUUID, PowerState: powerOff, TargetPowerState: powerOn},
+			expectedDirty:        true,
+			expectedRequeueAfter: powerRequeueDelay,
+		},
+		{
+			name:                 "locked host",
+			online:               true,
+			node:                 nodes.Node{UUID: nodeUUID, PowerState: powerOff, Reservation: "conductor-1"},
+			expectedDirty:        true,
+			expectedRequeueAfter: nodeLockedRequeueDelay,
+		},
+		{
+			name:                 "conflict",
+			online:               true,
+			node:                 nodes.Node{UUID: nodeUUID, PowerState: powerOn},
+			powerStatus:          http.StatusConflict,
+			expectedRequest:      `{"target":"power on"}`,
+			expectedDirty:        true,
+			expectedRequeueAfter: powerRequeueDelay,
+		},
+		{
+			name:                 "rejected",
+			online:               true,
+			node:                 nodes.Node{UUID: nodeUUID, PowerState: powerOn},
+			powerStatus:          http.StatusBadRequest,
+			expectedRequest:      `{"target":"power on"}`,
+			expectedErrorMessage: "could not sync the power state",
+		},
+		{
+			name:            "failed",
+			online:          true,
+			node:            nodes.Node{UUID: nodeUUID, PowerState: powerOn},
+			powerStatus:     http.StatusInternalServerError,
+			expectedRequest: `{"target":"power on"}`,
+			expectedError:   true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().Node(tc.node).WithNodeStatesPowerUpdate(nodeUUID, tc.powerStatus)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "pa$$w0rd"}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.SyncPowerState(tc.online)
+
+			assert.Equal(t, tc.expectedError, err != nil, err)
+			assert.Equal(t, tc.expectedDirty, result.Dirty)
+			assert.Equal(t, tc.expectedRequeueAfter, result.RequeueAfter)
+			assert.Contains(t, result.ErrorMessage, tc.expectedErrorMessage)
+			if tc.expectedErrorMessage == "" {
+				assert.Equal(t, "", result.ErrorMessage)
+			}
+			body, found := ironic.GetLastRequestFor(powerPath, http.MethodPut)
+			assert.Equal(t, tc.expectedRequest != "", found)
+			if found {
+				assert.JSONEq(t, tc.expectedRequest, body)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package ironic
 
 import (
+	"encoding/json"
+	"net/http"
 	"testing"
 	"time"
 
@@ -22,9 +24,11 @@ func TestPrepare(t *testing.T) {
 		ironic               *testserver.IronicMock
 		unprepared           bool
 		existRaidConfig      bool
+		firmwareConfig       *metal3v1alpha1.FirmwareConfig
 		expectedStarted      bool
 		expectedDirty        bool
 		expectedError        bool
+		expectedErrorMessage string
 		expectedRequestAfter int
 	}{
 		{
@@ -50,6 +54,93 @@ func TestPrepare(t *testing.T) {
 			expectedRequestAfter: 10,
 			expectedDirty:        true,
 		},
+		{
+			name: "manageable state(raid configuration applied)",
+			ironic: testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Manageable),
+				UUID:           nodeUUID,
+				RAIDConfig: map[string]interface{}{
+					"logical_disks": []interface{}{
+						map[string]interface{}{"raid_level": "1", "volume_name": "root", "size_gb": 446},
+						map[string]interface{}{"raid_level": "1", "volume_name": "v1", "size_gb": 446},
+					},
+				},
+			}),
+			unprepared:           true,
+			existRaidConfig:      true,
+			expectedStarted:      false,
+			expectedRequestAfter: 0,
+			expectedDirty:        false,
+		},
+		{
+			name: "active state(settings changed)",
+			ironic: testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Active),
+				UUID:           nodeUUID,
+			}),
+			unprepared:           true,
+			existRaidConfig:      true,
+			expectedStarted:      false,
+			expectedRequestAfter: 0,
+			expectedDirty:        false,
+			expectedErrorMessage: "the RAID and firmware settings of a provisioned host cannot be changed",
+		},
+		{
+			name: "manageable state(bios settings converged)",
+			ironic: testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Manageable),
+				UUID:           nodeUUID,
+			}).BIOS(nodeUUID, map[string]string{
+				"ProcVirtualization": "Enabled",
+				"MemorySize":         "1GB",
+			}),
+			unprepared: true,
+			firmwareConfig: &metal3v1alpha1.FirmwareConfig{
+				Settings: map[string]string{
+					"ProcVirtualization": "enabled",
+					"MemorySize":         "1024 MB",
+				},
+			},
+			expectedStarted:      false,
+			expectedRequestAfter: 0,
+			expectedDirty:        false,
+		},
+		{
+			name: "manageable state(bios settings pending)",
+			ironic: testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Manageable),
+				UUID:           nodeUUID,
+			}).BIOS(nodeUUID, map[string]string{
+				"ProcVirtualization": "Disabled",
+			}),
+			unprepared: true,
+			firmwareConfig: &metal3v1alpha1.FirmwareConfig{
+				Settings: map[string]string{
+					"ProcVirtualization": "Enabled",
+				},
+			},
+			expectedStarted:      true,
+			expectedRequestAfter: 10,
+			expectedDirty:        true,
+		},
+		{
+			name: "available state(bios settings converged)",
+			ironic: testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Available),
+				UUID:           nodeUUID,
+			}).BIOS(nodeUUID, map[string]string{
+				"ProcVirtualization": "Enabled",
+			}),
+			unprepared: true,
+			firmwareConfig: &metal3v1alpha1.FirmwareConfig{
+				Settings: map[string]string{
+					"ProcVirtualization": "ENABLED",
+				},
+			},
+			expectedStarted:      false,
+			expectedRequestAfter: 0,
+			expectedDirty:        false,
+		},
 		{
 			name: "cleanFail state(cleaned provision settings)",
 			ironic: testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
@@ -122,7 +213,9 @@ func TestPrepare(t *testing.T) {
 
 			host := makeHost()
 			host.Status.Provisioning.ID = nodeUUID
-			prepData := provisioner.PrepareData{}
+			prepData := provisioner.PrepareData{
+				FirmwareConfig: tc.firmwareConfig,
+			}
 			if tc.existRaidConfig {
 				host.Spec.BMC.Address = "irmc://test.bmc/"
 				prepData.RAIDConfig = &metal3v1alpha1.RAIDConfig{
@@ -153,6 +246,7 @@ func TestPrepare(t *testing.T) {
 			assert.Equal(t, tc.expectedStarted, started)
 			assert.Equal(t, tc.expectedDirty, result.Dirty)
 			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
+			assert.Equal(t, tc.expectedErrorMessage, result.ErrorMessage)
 			if !tc.expectedError {
 				assert.NoError(t, err)
 			} else {
@@ -161,3 +255,152 @@ func TestPrepare(t *testing.T) {
 		})
 	}
 }
+
+func TestPrepareCleanFailRetry(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name      string
+		lastError string
+		retry     bool
+
+		expectedTargets      []string
+		expectedRetried      bool
+		expectedErrorMessage string
+	}{
+		{
+			name:            "transient failure",
+			lastError:       "Timeout reached while waiting for callback for node",
+			retry:           true,
+			expectedTargets: []string{"manage"},
+			expectedRetried: true,
+		},
+		{
+			name:                 "no retries left",
+			lastError:            "Timeout reached while waiting for callback for node",
+			expectedErrorMessage: "Timeout reached while waiting for callback for node",
+		},
+		{
+			name:                 "permanent failure",
+			lastError:            "RAID controller not found",
+			retry:                true,
+			expectedErrorMessage: "RAID controller not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var targets []string
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				ProvisionState: string(nodes.CleanFail),
+				LastError:      tc.lastError,
+			})
+			ironic.Handler("/v1/nodes/"+nodeUUID+"/states/provision", provisionStateHandler(t, "", &targets))
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, started, err := prov.Prepare(provisioner.PrepareData{RetryRecoverableFailure: tc.retry}, false)
+
+			assert.NoError(t, err)
+			assert.False(t, started)
+			assert.Equal(t, tc.expectedErrorMessage, result.ErrorMessage)
+			assert.Equal(t, tc.expectedRetried, result.Retried)
+			assert.Equal(t, tc.expectedTargets, targets)
+		})
+	}
+}
+
+func TestPrepareFullCleaning(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name  string
+		erase bool
+		raid  *metal3v1alpha1.RAIDConfig
+
+		expectedSteps []nodes.CleanStep
+	}{
+		{
+			name: "no erase",
+		},
+		{
+			name:  "erase",
+			erase: true,
+			expectedSteps: []nodes.CleanStep{
+				{Interface: "deploy", Step: "erase_devices"},
+			},
+		},
+		{
+			name: "raid only",
+			raid: &metal3v1alpha1.RAIDConfig{
+				HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{{Name: "root", Level: "1"}},
+			},
+			expectedSteps: []nodes.CleanStep{
+				{Interface: "raid", Step: "delete_configuration"},
+				{Interface: "raid", Step: "create_configuration"},
+			},
+		},
+		{
+			name:  "erase and raid",
+			erase: true,
+			raid: &metal3v1alpha1.RAIDConfig{
+				HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{{Name: "root", Level: "1"}},
+			},
+			expectedSteps: []nodes.CleanStep{
+				{Interface: "deploy", Step: "erase_devices"},
+				{Interface: "raid", Step: "delete_configuration"},
+				{Interface: "raid", Step: "create_configuration"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Manageable),
+				UUID:           nodeUUID,
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			if tc.raid != nil {
+				// RAID needs a driver supporting it
+				host.Spec.BMC.Address = "irmc://test.bmc/"
+			}
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			_, started, err := prov.Prepare(provisioner.PrepareData{EraseDevices: tc.erase, RAIDConfig: tc.raid}, true)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedSteps != nil, started)
+			body, requested := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			if tc.expectedSteps == nil {
+				assert.False(t, requested)
+				return
+			}
+			var request nodes.ProvisionStateOpts
+			if err := json.Unmarshal([]byte(body), &request); err != nil {
+				t.Fatalf("invalid provision state request: %s", err)
+			}
+			assert.Equal(t, nodes.TargetClean, request.Target)
+			assert.Equal(t, tc.expectedSteps, request.CleanSteps)
+		})
+	}
+}
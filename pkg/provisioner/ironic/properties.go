@@ -0,0 +1,85 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// propertiesExtraKey is the key of the node's extra field recording the
+// properties that were set from the host's node properties, so that
+// they can be told apart from the ones reported by inspection.
+const propertiesExtraKey = "metal3_properties"
+
+// desiredNodeProperties returns the node properties requested for the
+// host, by name of the Ironic property
+func desiredNodeProperties(override *metal3v1alpha1.NodeProperties) map[string]interface{} {
+	desired := map[string]interface{}{}
+	if override == nil {
+		return desired
+	}
+	if override.CPUArch != "" {
+		desired["cpu_arch"] = override.CPUArch
+	}
+	if override.CPUs != nil {
+		desired["cpus"] = *override.CPUs
+	}
+	if override.MemoryMiB != nil {
+		desired["memory_mb"] = *override.MemoryMiB
+	}
+	if override.LocalGiB != nil {
+		desired["local_gb"] = *override.LocalGiB
+	}
+	return desired
+}
+
+// samePropertyValue compares property values regardless of their type,
+// as Ironic accepts numbers and strings and JSON decodes numbers as
+// floats
+func samePropertyValue(a, b interface{}) bool {
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// buildNodeProperties merges the node properties requested for the host
+// into the properties of the node. A property is only set if the node
+// does not have it, if it was previously set from the host or if the
+// override is forced, so that values reported by inspection are kept.
+// It returns the properties to change, and the ones set from the host
+// to record in the node's extra field.
+func buildNodeProperties(ironicNode *nodes.Node, override *metal3v1alpha1.NodeProperties) (changed optionsData, managed map[string]interface{}) {
+	previous, _ := ironicNode.Extra[propertiesExtraKey].(map[string]interface{})
+
+	changed = optionsData{}
+	managed = map[string]interface{}{}
+	for name, value := range desiredNodeProperties(override) {
+		current, present := ironicNode.Properties[name]
+		previousValue, wasManaged := previous[name]
+		if present && !override.Force && !(wasManaged && samePropertyValue(current, previousValue)) {
+			// Reported by inspection or changed in Ironic
+			continue
+		}
+		managed[name] = fmt.Sprint(value)
+		if !present || !samePropertyValue(current, value) {
+			changed[name] = value
+		}
+	}
+	return
+}
+
+// setNodePropertiesUpdateOpts updates the properties of the node from
+// the node properties of the host. Properties are never removed: once
+// the host stops setting them, they are left to inspection.
+func setNodePropertiesUpdateOpts(ironicNode *nodes.Node, override *metal3v1alpha1.NodeProperties, updater *nodeUpdater) {
+	changed, managed := buildNodeProperties(ironicNode, override)
+	if len(changed) != 0 {
+		updater.SetPropertiesOpts(changed, ironicNode)
+	}
+
+	settings := optionsData{propertiesExtraKey: nil}
+	if len(managed) != 0 {
+		settings[propertiesExtraKey] = managed
+	}
+	updater.SetExtraOpts(settings, ironicNode)
+}
@@ -0,0 +1,128 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+func TestBuildNodeProperties(t *testing.T) {
+	size := func(value int) *int { return &value }
+	cases := []struct {
+		name       string
+		properties map[string]interface{}
+		extra      map[string]interface{}
+		override   *metal3v1alpha1.NodeProperties
+
+		expectedChanged optionsData
+		expectedManaged map[string]interface{}
+	}{
+		{
+			name:            "no override",
+			properties:      map[string]interface{}{"cpus": float64(8)},
+			expectedChanged: optionsData{},
+			expectedManaged: map[string]interface{}{},
+		},
+		{
+			name:     "not inspected",
+			override: &metal3v1alpha1.NodeProperties{CPUArch: "x86_64", CPUs: size(8), MemoryMiB: size(16384), LocalGiB: size(100)},
+			expectedChanged: optionsData{
+				"cpu_arch":  "x86_64",
+				"cpus":      8,
+				"memory_mb": 16384,
+				"local_gb":  100,
+			},
+			expectedManaged: map[string]interface{}{
+				"cpu_arch":  "x86_64",
+				"cpus":      "8",
+				"memory_mb": "16384",
+				"local_gb":  "100",
+			},
+		},
+		{
+			name:       "inspected values kept",
+			properties: map[string]interface{}{"cpus": float64(16), "cpu_arch": "x86_64"},
+			override:   &metal3v1alpha1.NodeProperties{CPUArch: "aarch64", CPUs: size(8), LocalGiB: size(100)},
+			expectedChanged: optionsData{
+				"local_gb": 100,
+			},
+			expectedManaged: map[string]interface{}{
+				"local_gb": "100",
+			},
+		},
+		{
+			name:       "forced",
+			properties: map[string]interface{}{"cpus": float64(16), "local_gb": "100"},
+			override:   &metal3v1alpha1.NodeProperties{CPUs: size(8), LocalGiB: size(100), Force: true},
+			expectedChanged: optionsData{
+				"cpus": 8,
+			},
+			expectedManaged: map[string]interface{}{
+				"cpus":     "8",
+				"local_gb": "100",
+			},
+		},
+		{
+			name:            "previously set value changed",
+			properties:      map[string]interface{}{"local_gb": float64(100)},
+			extra:           map[string]interface{}{propertiesExtraKey: map[string]interface{}{"local_gb": "100"}},
+			override:        &metal3v1alpha1.NodeProperties{LocalGiB: size(200)},
+			expectedChanged: optionsData{"local_gb": 200},
+			expectedManaged: map[string]interface{}{"local_gb": "200"},
+		},
+		{
+			name:            "previously set value unchanged",
+			properties:      map[string]interface{}{"local_gb": float64(100)},
+			extra:           map[string]interface{}{propertiesExtraKey: map[string]interface{}{"local_gb": "100"}},
+			override:        &metal3v1alpha1.NodeProperties{LocalGiB: size(100)},
+			expectedChanged: optionsData{},
+			expectedManaged: map[string]interface{}{"local_gb": "100"},
+		},
+		{
+			name:            "previously set value replaced by inspection",
+			properties:      map[string]interface{}{"local_gb": float64(120)},
+			extra:           map[string]interface{}{propertiesExtraKey: map[string]interface{}{"local_gb": "100"}},
+			override:        &metal3v1alpha1.NodeProperties{LocalGiB: size(100)},
+			expectedChanged: optionsData{},
+			expectedManaged: map[string]interface{}{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironicNode := &nodes.Node{Properties: tc.properties, Extra: tc.extra}
+
+			changed, managed := buildNodeProperties(ironicNode, tc.override)
+
+			assert.Equal(t, tc.expectedChanged, changed)
+			assert.Equal(t, tc.expectedManaged, managed)
+		})
+	}
+}
+
+func TestSetNodePropertiesUpdateOpts(t *testing.T) {
+	cpus := 8
+	ironicNode := &nodes.Node{
+		Properties: map[string]interface{}{"cpus": float64(16)},
+		Extra:      map[string]interface{}{propertiesExtraKey: map[string]interface{}{"local_gb": "100"}},
+	}
+
+	updater := updateOptsBuilder(nil)
+	setNodePropertiesUpdateOpts(ironicNode, &metal3v1alpha1.NodeProperties{CPUs: &cpus, Force: true}, updater)
+
+	assert.ElementsMatch(t, nodes.UpdateOpts{
+		nodes.UpdateOperation{Op: nodes.AddOp, Path: "/properties/cpus", Value: 8},
+		nodes.UpdateOperation{Op: nodes.AddOp, Path: "/extra/metal3_properties", Value: map[string]interface{}{"cpus": "8"}},
+	}, updater.updates)
+
+	// Removing the override stops managing the properties
+	updater = updateOptsBuilder(nil)
+	setNodePropertiesUpdateOpts(ironicNode, nil, updater)
+
+	assert.Equal(t, nodes.UpdateOpts{
+		nodes.UpdateOperation{Op: nodes.RemoveOp, Path: "/extra/metal3_properties"},
+	}, updater.updates)
+}
@@ -1,6 +1,10 @@
 package ironic
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -130,6 +134,573 @@ func TestProvision(t *testing.T) {
 	}
 }
 
+func TestProvisionInvalidImage(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	liveISO := "live-iso"
+	cases := []struct {
+		name          string
+		image         v1alpha1.Image
+		expectedError string
+	}{
+		{
+			name:          "invalid oci reference",
+			image:         v1alpha1.Image{URL: "oci://quay.io/Example/image:v1"},
+			expectedError: "invalid OCI image reference \"oci://quay.io/Example/image:v1\"",
+		},
+		{
+			name:          "live iso without host",
+			image:         v1alpha1.Image{URL: "boot.iso", DiskFormat: &liveISO},
+			expectedError: "invalid live ISO URL \"boot.iso\"",
+		},
+		{
+			name:          "live iso with unsupported scheme",
+			image:         v1alpha1.Image{URL: "ftp://example.com/boot.iso", DiskFormat: &liveISO},
+			expectedError: "unsupported scheme \"ftp\" for live ISO \"ftp://example.com/boot.iso\", expected http, https or file",
+		},
+		{
+			name:          "unsupported download source",
+			image:         v1alpha1.Image{URL: "http://example.com/image.qcow2", DownloadSource: "ftp"},
+			expectedError: "unsupported download source \"ftp\" for image \"http://example.com/image.qcow2\", expected swift, http or local",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Available),
+				UUID:           nodeUUID,
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image:      tc.image,
+				HostConfig: fixture.NewHostConfigData("testUserData", "test: NetworkData", "test: Meta"),
+				BootMode:   v1alpha1.DefaultBootMode,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			_, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			assert.False(t, found)
+		})
+	}
+}
+
+func TestProvisionRetryRecoverableFailure(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	image := v1alpha1.Image{URL: "http://example.test/image.qcow2", Checksum: "abcd"}
+	checksum, checksumType, _ := image.GetChecksum()
+	cases := []struct {
+		name            string
+		lastError       string
+		retry           bool
+		expectedRetried bool
+		expectedError   string
+	}{
+		{
+			name:            "recoverable",
+			lastError:       "Timeout reached while waiting for callback for node " + nodeUUID,
+			retry:           true,
+			expectedRetried: true,
+		},
+		{
+			name:          "recoverable without retries left",
+			lastError:     "Timeout reached while waiting for callback for node " + nodeUUID,
+			expectedError: "Image provisioning failed: Timeout reached while waiting for callback for node " + nodeUUID,
+		},
+		{
+			name:          "not recoverable",
+			lastError:     "No suitable device was found for deployment",
+			retry:         true,
+			expectedError: "Image provisioning failed: No suitable device was found for deployment",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.DeployFail),
+				UUID:           nodeUUID,
+				LastError:      tc.lastError,
+				InstanceInfo: map[string]interface{}{
+					"image_source":        image.URL,
+					"image_os_hash_algo":  checksumType,
+					"image_os_hash_value": checksum,
+				},
+			}).WithNodeStatesProvisionUpdate(nodeUUID)
+			ironic.ResponseJSON("/v1/nodes/"+nodeUUID+"/validate", nodes.NodeValidation{
+				Boot:   nodes.DriverValidation{Result: true},
+				Deploy: nodes.DriverValidation{Result: true},
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.Image = &image
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image:                   image,
+				HostConfig:              fixture.NewHostConfigData("", "", ""),
+				BootMode:                v1alpha1.DefaultBootMode,
+				RetryRecoverableFailure: tc.retry,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedRetried, result.Retried)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			body, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			assert.Equal(t, tc.expectedRetried, found)
+			if found {
+				assert.Contains(t, body, "\"target\":\"active\"")
+			}
+		})
+	}
+}
+
+func TestProvisionImageMirrors(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	image := v1alpha1.Image{
+		URL:          "http://primary.test/image.qcow2",
+		Checksum:     "aaaa",
+		ChecksumType: v1alpha1.SHA256,
+		Mirrors: []v1alpha1.ImageMirror{
+			{URL: "http://mirror1.test/image.raw", Checksum: "bbbb", ChecksumType: v1alpha1.SHA512},
+			{URL: "http://mirror2.test/image.qcow2"},
+		},
+	}
+	cases := []struct {
+		name             string
+		current          v1alpha1.Image
+		expectedURL      string
+		expectedChecksum string
+		expectedAlgo     string
+		expectedError    string
+	}{
+		{
+			name:             "primary failed",
+			current:          image.Sources()[0],
+			expectedURL:      "http://mirror1.test/image.raw",
+			expectedChecksum: "bbbb",
+			expectedAlgo:     "sha512",
+		},
+		{
+			name:             "first mirror failed",
+			current:          image.Sources()[1],
+			expectedURL:      "http://mirror2.test/image.qcow2",
+			expectedChecksum: "aaaa",
+			expectedAlgo:     "sha256",
+		},
+		{
+			name:          "all mirrors failed",
+			current:       image.Sources()[2],
+			expectedError: "Image provisioning failed: Failed to download image",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			checksum, checksumType, _ := tc.current.GetChecksum()
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.DeployFail),
+				UUID:           nodeUUID,
+				LastError:      "Failed to download image",
+				InstanceInfo: map[string]interface{}{
+					"image_source":        tc.current.URL,
+					"image_os_hash_algo":  checksumType,
+					"image_os_hash_value": checksum,
+				},
+			}).NodeUpdate(nodes.Node{
+				UUID: nodeUUID,
+			}).WithNodeStatesProvisionUpdate(nodeUUID)
+			ironic.ResponseJSON("/v1/nodes/"+nodeUUID+"/validate", nodes.NodeValidation{
+				Boot:   nodes.DriverValidation{Result: true},
+				Deploy: nodes.DriverValidation{Result: true},
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.Image = &image
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image:      image,
+				HostConfig: fixture.NewHostConfigData("", "", ""),
+				BootMode:   v1alpha1.DefaultBootMode,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			_, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			assert.Equal(t, tc.expectedError == "", found)
+			if tc.expectedError != "" {
+				return
+			}
+
+			updates := map[string]interface{}{}
+			for _, update := range ironic.GetLastNodeUpdateRequestFor(nodeUUID) {
+				updates[update.Path] = update.Value
+			}
+			assert.Equal(t, tc.expectedURL, updates["/instance_info/image_source"])
+			assert.Equal(t, tc.expectedChecksum, updates["/instance_info/image_os_hash_value"])
+			assert.Equal(t, tc.expectedAlgo, updates["/instance_info/image_os_hash_algo"])
+		})
+	}
+}
+
+// extraFilesHostConfigData adds config drive files to the fixture data
+type extraFilesHostConfigData struct {
+	provisioner.HostConfigData
+	files map[string]string
+}
+
+func (cd extraFilesHostConfigData) ExtraFiles() (map[string]string, error) {
+	return cd.files, nil
+}
+
+func TestProvisionExtraFiles(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name          string
+		files         map[string]string
+		userData      string
+		expectedError string
+		expectedFiles bool
+	}{
+		{
+			name: "no extra files",
+		},
+		{
+			name:          "extra files",
+			files:         map[string]string{"/etc/motd": "Welcome"},
+			expectedFiles: true,
+		},
+		{
+			name:          "invalid path",
+			files:         map[string]string{"etc/motd": "Welcome"},
+			expectedError: "config drive file path \"etc/motd\" must be a clean absolute path",
+		},
+		{
+			name:          "ignition user data",
+			files:         map[string]string{"/etc/motd": "Welcome"},
+			userData:      `{"ignition": {"version": "3.2.0"}}`,
+			expectedError: "config drive files and time settings can only be added to cloud-init user data starting with #, not to Ignition or other formats",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Available),
+				UUID:           nodeUUID,
+			}).WithNodeStatesProvisionUpdate(nodeUUID)
+			ironic.ResponseJSON("/v1/nodes/"+nodeUUID+"/validate", nodes.NodeValidation{
+				Boot:   nodes.DriverValidation{Result: true},
+				Deploy: nodes.DriverValidation{Result: true},
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			userData := tc.userData
+			if userData == "" {
+				userData = "#cloud-config\nhostname: test\n"
+			}
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image: *host.Spec.Image,
+				HostConfig: extraFilesHostConfigData{
+					HostConfigData: fixture.NewHostConfigData(userData, "test: NetworkData", "test: Meta"),
+					files:          tc.files,
+				},
+				BootMode: v1alpha1.DefaultBootMode,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			body, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			if tc.expectedError != "" {
+				assert.False(t, found)
+				return
+			}
+			assert.True(t, found)
+
+			var opts struct {
+				ConfigDrive struct {
+					UserData string `json:"user_data"`
+				} `json:"configdrive"`
+			}
+			if err = json.Unmarshal([]byte(body), &opts); err != nil {
+				t.Fatal(err)
+			}
+			userData = opts.ConfigDrive.UserData
+			if tc.expectedFiles {
+				assert.True(t, strings.HasPrefix(userData, "Content-Type: multipart/mixed;"))
+				assert.Contains(t, userData, base64.StdEncoding.EncodeToString([]byte("#cloud-config\nhostname: test\n")))
+				assert.Contains(t, userData, "path: /etc/motd")
+				assert.Contains(t, userData, base64.StdEncoding.EncodeToString([]byte("Welcome")))
+			} else {
+				assert.Equal(t, "#cloud-config\nhostname: test\n", userData)
+			}
+		})
+	}
+}
+
+func TestProvisionTimeSettings(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name             string
+		userData         string
+		settings         *v1alpha1.TimeSettings
+		expectedError    string
+		expectedUserData []string
+	}{
+		{
+			name:     "ntp servers and timezone",
+			userData: "#cloud-config\nhostname: test\n",
+			settings: &v1alpha1.TimeSettings{
+				NTPServers: []string{"192.168.111.1", "ntp.example.com"},
+				Timezone:   "Europe/Berlin",
+			},
+			expectedUserData: []string{
+				base64.StdEncoding.EncodeToString([]byte("#cloud-config\nhostname: test\n")),
+				"ntp:\n  enabled: true\n  servers:\n  - 192.168.111.1\n  - ntp.example.com\n",
+				"timezone: Europe/Berlin\n",
+			},
+		},
+		{
+			name: "no user data",
+			settings: &v1alpha1.TimeSettings{
+				Timezone: "UTC",
+			},
+			expectedUserData: []string{"#cloud-config\ntimezone: UTC\n"},
+		},
+		{
+			name:             "no settings",
+			userData:         "#cloud-config\nhostname: test\n",
+			expectedUserData: []string{"#cloud-config\nhostname: test\n"},
+		},
+		{
+			name:     "invalid ntp server",
+			userData: "#cloud-config\nhostname: test\n",
+			settings: &v1alpha1.TimeSettings{
+				NTPServers: []string{"ntp_server"},
+			},
+			expectedError: "NTP server \"ntp_server\" must be an IP address or a host name",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Available),
+				UUID:           nodeUUID,
+			}).WithNodeStatesProvisionUpdate(nodeUUID)
+			ironic.ResponseJSON("/v1/nodes/"+nodeUUID+"/validate", nodes.NodeValidation{
+				Boot:   nodes.DriverValidation{Result: true},
+				Deploy: nodes.DriverValidation{Result: true},
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image:        *host.Spec.Image,
+				HostConfig:   fixture.NewHostConfigData(tc.userData, "", "test: Meta"),
+				BootMode:     v1alpha1.DefaultBootMode,
+				TimeSettings: tc.settings,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			body, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			if tc.expectedError != "" {
+				assert.False(t, found)
+				return
+			}
+			assert.True(t, found)
+
+			var opts struct {
+				ConfigDrive struct {
+					UserData string                 `json:"user_data"`
+					MetaData map[string]interface{} `json:"meta_data"`
+				} `json:"configdrive"`
+			}
+			if err = json.Unmarshal([]byte(body), &opts); err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range tc.expectedUserData {
+				assert.Contains(t, opts.ConfigDrive.UserData, expected)
+			}
+			assert.Equal(t, "Meta", opts.ConfigDrive.MetaData["test"])
+			assert.NotContains(t, opts.ConfigDrive.MetaData, "timezone")
+			assert.NotContains(t, opts.ConfigDrive.MetaData, "ntp_servers")
+		})
+	}
+}
+
+func TestValidateRootPartitionSize(t *testing.T) {
+	size := func(gib int) *int { return &gib }
+	details := &v1alpha1.HardwareDetails{
+		Storage: []v1alpha1.Storage{
+			{Name: "/dev/sda", SizeBytes: 100 * v1alpha1.GibiByte},
+			{Name: "/dev/sdb", SizeBytes: 20 * v1alpha1.GibiByte},
+		},
+	}
+	cases := []struct {
+		name          string
+		size          *int
+		hints         *v1alpha1.RootDeviceHints
+		details       *v1alpha1.HardwareDetails
+		expectedError string
+	}{
+		{
+			name: "whole disk image",
+		},
+		{
+			name:    "fits",
+			size:    size(20),
+			details: details,
+		},
+		{
+			name:          "too large for the smallest disk",
+			size:          size(40),
+			details:       details,
+			expectedError: "the root partition of 40 GiB does not fit on the root device /dev/sdb of 20 GiB",
+		},
+		{
+			name:    "fits on the hinted disk",
+			size:    size(40),
+			hints:   &v1alpha1.RootDeviceHints{DeviceName: "/dev/sda"},
+			details: details,
+		},
+		{
+			name: "no hardware details",
+			size: size(400),
+		},
+		{
+			name:          "not positive",
+			size:          size(0),
+			expectedError: "the root partition size of image \"http://example.com/image.qcow2\" must be positive, got 0",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			image := &v1alpha1.Image{
+				URL:                        "http://example.com/image.qcow2",
+				RootPartitionSizeGibibytes: tc.size,
+			}
+
+			err := validateRootPartitionSize(image, tc.hints, tc.details)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestProvisionReservedNode(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	cases := []struct {
+		name                 string
+		reservation          string
+		expectedRequest      bool
+		expectedRequestAfter int
+	}{
+		{
+			name:                 "reserved",
+			reservation:          "conductor-1",
+			expectedRequestAfter: 5,
+		},
+		{
+			name:                 "not reserved",
+			expectedRequest:      true,
+			expectedRequestAfter: 10,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(nodes.Node{
+				ProvisionState: string(nodes.Manageable),
+				Reservation:    tc.reservation,
+				UUID:           nodeUUID,
+			}).WithNodeStatesProvisionUpdate(nodeUUID)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				HostConfig: fixture.NewHostConfigData("", "", ""),
+				BootMode:   v1alpha1.DefaultBootMode,
+			})
+
+			assert.NoError(t, err)
+			assert.True(t, result.Dirty)
+			assert.Equal(t, "", result.ErrorMessage)
+			assert.Equal(t, time.Second*time.Duration(tc.expectedRequestAfter), result.RequeueAfter)
+			_, found := ironic.GetLastRequestFor("/v1/nodes/"+nodeUUID+"/states/provision", http.MethodPut)
+			assert.Equal(t, tc.expectedRequest, found)
+		})
+	}
+}
+
 func TestDeprovision(t *testing.T) {
 
 	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
@@ -331,6 +902,26 @@ func TestIronicHasSameImage(t *testing.T) {
 			hostChecksum:     "thechecksum",
 			hostChecksumType: v1alpha1.SHA512,
 		},
+		{
+			name:     "oci image same",
+			expected: true,
+			node: nodes.Node{
+				InstanceInfo: map[string]interface{}{
+					"image_source": "oci://quay.io/example/image:v1",
+				},
+			},
+			hostImage: "oci://quay.io/example/image:v1",
+		},
+		{
+			name:     "oci image different",
+			expected: false,
+			node: nodes.Node{
+				InstanceInfo: map[string]interface{}{
+					"image_source": "oci://quay.io/example/image:v1",
+				},
+			},
+			hostImage: "oci://quay.io/example/image:v2",
+		},
 		{
 			name:      "live image same",
 			liveImage: true,
@@ -103,3 +103,93 @@ func TestHasCapacity(t *testing.T) {
 		})
 	}
 }
+
+func TestHasCapacityPerConductorGroup(t *testing.T) {
+
+	cases := []struct {
+		name       string
+		groupLimit int
+		nodeGroups []string
+		hostGroup  string
+
+		expectedHasCapacity bool
+	}{
+		{
+			name:       "no-group-limit",
+			nodeGroups: []string{"rack1", "rack1", "rack1"},
+			hostGroup:  "rack1",
+
+			expectedHasCapacity: true,
+		},
+		{
+			name:       "no-capacity-in-group",
+			groupLimit: 2,
+			nodeGroups: []string{"rack1", "rack1", "rack2"},
+			hostGroup:  "rack1",
+
+			expectedHasCapacity: false,
+		},
+		{
+			name:       "enough-capacity-in-group",
+			groupLimit: 2,
+			nodeGroups: []string{"rack1", "rack2", "rack2"},
+			hostGroup:  "rack1",
+
+			expectedHasCapacity: true,
+		},
+		{
+			name:       "no-capacity-in-default-group",
+			groupLimit: 1,
+			nodeGroups: []string{"", "rack1"},
+			hostGroup:  "",
+
+			expectedHasCapacity: false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			host := makeHost()
+
+			allNodes := []nodes.Node{
+				{
+					Name:           host.Namespace + nameSeparator + host.Name,
+					ProvisionState: string(nodes.Manageable),
+					ConductorGroup: tc.hostGroup,
+				},
+			}
+			for n, group := range tc.nodeGroups {
+				allNodes = append(allNodes, nodes.Node{
+					Name:           fmt.Sprintf("myns%snode-%d", nameSeparator, n),
+					ProvisionState: string(nodes.Deploying),
+					ConductorGroup: group,
+				})
+			}
+
+			ironic := testserver.NewIronic(t).Nodes(allNodes).Start()
+			defer ironic.Stop()
+
+			inspector := testserver.NewInspector(t).Start()
+			defer inspector.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+
+			maxBusyHosts = 20
+			maxBusyHostsPerGroup = tc.groupLimit
+			defer func() { maxBusyHostsPerGroup = 0 }()
+
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, inspector.Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.HasCapacity()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedHasCapacity, result)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package ironic
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
 
@@ -22,8 +23,8 @@ func setTargetRAIDCfg(p *ironicProvisioner, ironicNode *nodes.Node, data provisi
 		return
 	}
 
-	// set root volume
-	if data.RootDeviceHints == nil {
+	// set root volume, passthrough disks are not volumes
+	if data.RootDeviceHints == nil && logicalDisks[0].RAIDLevel != nodes.JBOD {
 		logicalDisks[0].IsRootVolume = new(bool)
 		*logicalDisks[0].IsRootVolume = true
 	} else {
@@ -53,8 +54,8 @@ func BuildTargetRAIDCfg(raid *metal3v1alpha1.RAIDConfig) (logicalDisks []nodes.L
 	}
 
 	// build logicalDisks
-	if len(raid.HardwareRAIDVolumes) != 0 {
-		logicalDisks, err = buildTargetHardwareRAIDCfg(raid.HardwareRAIDVolumes)
+	if len(raid.HardwareRAIDVolumes) != 0 || len(raid.PassthroughDisks) != 0 {
+		logicalDisks, err = buildTargetHardwareRAIDCfg(raid.HardwareRAIDVolumes, raid.PassthroughDisks)
 	} else if len(raid.SoftwareRAIDVolumes) != 0 {
 		logicalDisks, err = buildTargetSoftwareRAIDCfg(raid.SoftwareRAIDVolumes)
 	}
@@ -62,14 +63,16 @@ func BuildTargetRAIDCfg(raid *metal3v1alpha1.RAIDConfig) (logicalDisks []nodes.L
 	return
 }
 
-// A private method to build hardware RAID disks
-func buildTargetHardwareRAIDCfg(volumes []metal3v1alpha1.HardwareRAIDVolume) (logicalDisks []nodes.LogicalDisk, err error) {
+// A private method to build hardware RAID disks, the passthrough disks
+// are added after the volumes
+func buildTargetHardwareRAIDCfg(volumes []metal3v1alpha1.HardwareRAIDVolume, passthroughDisks []metal3v1alpha1.PassthroughDisk) (logicalDisks []nodes.LogicalDisk, err error) {
 	var (
 		logicalDisk    nodes.LogicalDisk
-		nameCheckFlags map[string]int = make(map[string]int)
+		nameCheckFlags map[string]int                         = make(map[string]int)
+		diskCheckFlags map[metal3v1alpha1.PassthroughDisk]int = make(map[metal3v1alpha1.PassthroughDisk]int)
 	)
 
-	if len(volumes) == 0 {
+	if len(volumes) == 0 && len(passthroughDisks) == 0 {
 		return
 	}
 
@@ -98,13 +101,137 @@ func buildTargetHardwareRAIDCfg(volumes []metal3v1alpha1.HardwareRAIDVolume) (lo
 		if volume.NumberOfPhysicalDisks != nil {
 			logicalDisk.NumberOfPhysicalDisks = *volume.NumberOfPhysicalDisks
 		}
+		// Check and build the volume's physical disks
+		if len(volume.PhysicalDisks) != 0 && volume.Controller == "" {
+			return nil, errors.Errorf("the controller of volume[%d] must be set to use its physical disks", index)
+		}
+		logicalDisk.Controller = volume.Controller
+		for _, physicalDisk := range volume.PhysicalDisks {
+			disk := metal3v1alpha1.PassthroughDisk{Controller: volume.Controller, PhysicalDisk: physicalDisk}
+			if _, exist := diskCheckFlags[disk]; exist {
+				return nil, errors.Errorf("the physical disk %s of controller %s is used more than once", physicalDisk, volume.Controller)
+			}
+			diskCheckFlags[disk] = index
+			logicalDisk.PhysicalDisks = append(logicalDisk.PhysicalDisks, physicalDisk)
+		}
 		// Add to logicalDisks
 		logicalDisks = append(logicalDisks, logicalDisk)
 	}
 
+	for _, disk := range passthroughDisks {
+		if disk.Controller == "" || disk.PhysicalDisk == "" {
+			return nil, errors.Errorf("both the controller and the physical disk of passthrough disks must be set")
+		}
+		if i, exist := diskCheckFlags[disk]; exist {
+			if i < len(volumes) {
+				return nil, errors.Errorf("the physical disk %s of controller %s is both passed through and part of volume[%d]", disk.PhysicalDisk, disk.Controller, i)
+			}
+			return nil, errors.Errorf("the physical disk %s of controller %s is used more than once", disk.PhysicalDisk, disk.Controller)
+		}
+		diskCheckFlags[disk] = len(volumes)
+		logicalDisks = append(logicalDisks, nodes.LogicalDisk{
+			RAIDLevel:     nodes.JBOD,
+			Controller:    disk.Controller,
+			PhysicalDisks: []interface{}{disk.PhysicalDisk},
+		})
+	}
+
 	return
 }
 
+// raidLevelMinDisks is the minimum number of physical disks of each
+// hardware RAID level, as enforced by Ironic
+var raidLevelMinDisks = map[nodes.RAIDLevel]int{
+	nodes.RAID0:  1,
+	nodes.RAID1:  2,
+	nodes.RAID2:  3,
+	nodes.RAID5:  3,
+	nodes.RAID6:  4,
+	nodes.RAID10: 4,
+	nodes.RAID50: 6,
+	nodes.RAID60: 8,
+}
+
+// raidDataDisks returns how many of the physical disks of a volume
+// hold data rather than mirrors or parity
+func raidDataDisks(level nodes.RAIDLevel, disks int) int {
+	switch level {
+	case nodes.RAID1:
+		return 1
+	case nodes.RAID2, nodes.RAID5:
+		return disks - 1
+	case nodes.RAID6, nodes.RAID50:
+		return disks - 2
+	case nodes.RAID10:
+		return disks / 2
+	case nodes.RAID60:
+		return disks - 4
+	}
+	return disks
+}
+
+// validateHardwareRAIDVolumes checks that the physical disks requested
+// for the volumes suit their RAID level and, if the inspection found
+// the disks attached to RAID controllers, that they are enough for
+// the volumes, both each on its own and all of them together. Volumes
+// not setting the number of disks or the disks themselves use the
+// minimum of their level.
+func validateHardwareRAIDVolumes(volumes []metal3v1alpha1.HardwareRAIDVolume, details *metal3v1alpha1.HardwareDetails) error {
+	var (
+		availableDisks int
+		requiredDisks  int
+		availableBytes metal3v1alpha1.Capacity
+		requiredBytes  metal3v1alpha1.Capacity
+	)
+	if details != nil {
+		for _, controller := range details.RAIDControllers {
+			for _, disk := range controller.PhysicalDisks {
+				availableDisks++
+				availableBytes += disk.SizeBytes
+			}
+		}
+	}
+
+	for index, volume := range volumes {
+		level := nodes.RAIDLevel(volume.Level)
+		minDisks := raidLevelMinDisks[level]
+		disks := minDisks
+		if len(volume.PhysicalDisks) != 0 {
+			disks = len(volume.PhysicalDisks)
+		}
+		if volume.NumberOfPhysicalDisks != nil {
+			disks = *volume.NumberOfPhysicalDisks
+		}
+		if disks < minDisks {
+			return errors.Errorf("volume[%d] of RAID level %s needs at least %d physical disks, got %d", index, level, minDisks, disks)
+		}
+		if level == nodes.RAID10 && disks%2 != 0 {
+			return errors.Errorf("volume[%d] of RAID level %s needs an even number of physical disks, got %d", index, level, disks)
+		}
+		if availableDisks == 0 {
+			continue
+		}
+		if disks > availableDisks {
+			return errors.Errorf("volume[%d] needs %d physical disks, but only %d were detected", index, disks, availableDisks)
+		}
+		requiredDisks += disks
+		if volume.SizeGibibytes != nil && *volume.SizeGibibytes > 0 {
+			requiredBytes += metal3v1alpha1.Capacity(*volume.SizeGibibytes) * metal3v1alpha1.GibiByte *
+				metal3v1alpha1.Capacity(disks) / metal3v1alpha1.Capacity(raidDataDisks(level, disks))
+		}
+	}
+
+	if availableDisks != 0 && requiredDisks > availableDisks {
+		return errors.Errorf("the RAID volumes need %d physical disks, but only %d were detected",
+			requiredDisks, availableDisks)
+	}
+	if availableBytes != 0 && requiredBytes > availableBytes {
+		return errors.Errorf("the RAID volumes need %d GiB of physical disks, but only %d GiB were detected",
+			requiredBytes/metal3v1alpha1.GibiByte, availableBytes/metal3v1alpha1.GibiByte)
+	}
+	return nil
+}
+
 // A private method to build software RAID disks
 func buildTargetSoftwareRAIDCfg(volumes []metal3v1alpha1.SoftwareRAIDVolume) (logicalDisks []nodes.LogicalDisk, err error) {
 	var (
@@ -137,6 +264,65 @@ func buildTargetSoftwareRAIDCfg(volumes []metal3v1alpha1.SoftwareRAIDVolume) (lo
 	return
 }
 
+// raidConfigApplied checks whether the current RAID configuration of
+// the node already has the requested logical disks, in any order. Only
+// the properties set in the request are compared.
+func raidConfigApplied(ironicNode *nodes.Node, requested []nodes.LogicalDisk) bool {
+	current, _ := ironicNode.RAIDConfig["logical_disks"].([]interface{})
+	if len(requested) == 0 || len(current) != len(requested) {
+		return false
+	}
+
+	used := make([]bool, len(current))
+	for _, disk := range requested {
+		found := false
+		for i := range current {
+			if !used[i] && logicalDiskMatches(disk, current[i]) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// logicalDiskMatches compares a requested logical disk with one of the
+// RAID configuration of the node
+func logicalDiskMatches(disk nodes.LogicalDisk, current interface{}) bool {
+	actual, ok := current.(map[string]interface{})
+	if !ok || actual["raid_level"] != string(disk.RAIDLevel) {
+		return false
+	}
+	if disk.VolumeName != "" && actual["volume_name"] != disk.VolumeName {
+		return false
+	}
+	if disk.Controller != "" && actual["controller"] != disk.Controller {
+		return false
+	}
+	if disk.SizeGB != nil && *disk.SizeGB != 0 {
+		if size, ok := actual["size_gb"].(float64); !ok || int(size) != *disk.SizeGB {
+			return false
+		}
+	}
+	if len(disk.PhysicalDisks) != 0 && !reflect.DeepEqual(disk.PhysicalDisks, actual["physical_disks"]) {
+		return false
+	}
+	return true
+}
+
+// buildNodeRAIDCleanSteps builds the clean steps for the RAID
+// configuration, skipping them if the node already has the requested
+// logical disks so that existing volumes and their data are kept.
+func buildNodeRAIDCleanSteps(ironicNode *nodes.Node, raid *metal3v1alpha1.RAIDConfig) []nodes.CleanStep {
+	if logicalDisks, err := BuildTargetRAIDCfg(raid); err == nil && raidConfigApplied(ironicNode, logicalDisks) {
+		return nil
+	}
+	return BuildRAIDCleanSteps(raid)
+}
+
 // BuildRAIDCleanSteps build the clean steps for RAID configuration from BaremetalHost spec
 func BuildRAIDCleanSteps(raid *metal3v1alpha1.RAIDConfig) (cleanSteps []nodes.CleanStep) {
 	// Add ‘delete_configuration’ before ‘create_configuration’ to make sure
@@ -149,10 +335,10 @@ func BuildRAIDCleanSteps(raid *metal3v1alpha1.RAIDConfig) (cleanSteps []nodes.Cl
 		},
 	)
 	// If not configure raid, only need to clear old configuration
-	if raid == nil || (len(raid.HardwareRAIDVolumes) == 0 && len(raid.SoftwareRAIDVolumes) == 0) {
+	if raid == nil || (len(raid.HardwareRAIDVolumes) == 0 && len(raid.SoftwareRAIDVolumes) == 0 && len(raid.PassthroughDisks) == 0) {
 		return
 	}
-	if len(raid.HardwareRAIDVolumes) == 0 && len(raid.SoftwareRAIDVolumes) != 0 {
+	if len(raid.HardwareRAIDVolumes) == 0 && len(raid.PassthroughDisks) == 0 {
 		cleanSteps = append(
 			cleanSteps,
 			nodes.CleanStep{
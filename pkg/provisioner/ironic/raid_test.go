@@ -1,6 +1,7 @@
 package ironic
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 
@@ -96,6 +97,107 @@ func TestBuildTargetRAIDCfg(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "hardware raid and passthrough disks",
+			raid: &metal3v1alpha1.RAIDConfig{
+				HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+					{
+						Name:          "root",
+						Level:         "1",
+						Controller:    "RAID.Integrated.1-1",
+						PhysicalDisks: []string{"Disk.Bay.0", "Disk.Bay.1"},
+					},
+				},
+				PassthroughDisks: []metal3v1alpha1.PassthroughDisk{
+					{Controller: "RAID.Integrated.1-1", PhysicalDisk: "Disk.Bay.2"},
+					{Controller: "RAID.Integrated.1-1", PhysicalDisk: "Disk.Bay.3"},
+				},
+			},
+			expected: []nodes.LogicalDisk{
+				{
+					RAIDLevel:     "1",
+					VolumeName:    "root",
+					Controller:    "RAID.Integrated.1-1",
+					PhysicalDisks: []interface{}{"Disk.Bay.0", "Disk.Bay.1"},
+				},
+				{
+					RAIDLevel:     nodes.JBOD,
+					Controller:    "RAID.Integrated.1-1",
+					PhysicalDisks: []interface{}{"Disk.Bay.2"},
+				},
+				{
+					RAIDLevel:     nodes.JBOD,
+					Controller:    "RAID.Integrated.1-1",
+					PhysicalDisks: []interface{}{"Disk.Bay.3"},
+				},
+			},
+		},
+		{
+			name: "passthrough disk in a volume",
+			raid: &metal3v1alpha1.RAIDConfig{
+				HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+					{
+						Level:         "1",
+						Controller:    "RAID.Integrated.1-1",
+						PhysicalDisks: []string{"Disk.Bay.0", "Disk.Bay.1"},
+					},
+				},
+				PassthroughDisks: []metal3v1alpha1.PassthroughDisk{
+					{Controller: "RAID.Integrated.1-1", PhysicalDisk: "Disk.Bay.1"},
+				},
+			},
+			expectedError: "the physical disk Disk.Bay.1 of controller RAID.Integrated.1-1 is both passed through and part of volume[0]",
+		},
+		{
+			name: "same disk in two volumes",
+			raid: &metal3v1alpha1.RAIDConfig{
+				HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+					{
+						Level:         "1",
+						Controller:    "RAID.Integrated.1-1",
+						PhysicalDisks: []string{"Disk.Bay.0", "Disk.Bay.1"},
+					},
+					{
+						Level:         "1",
+						Controller:    "RAID.Integrated.1-1",
+						PhysicalDisks: []string{"Disk.Bay.1", "Disk.Bay.2"},
+					},
+				},
+			},
+			expectedError: "the physical disk Disk.Bay.1 of controller RAID.Integrated.1-1 is used more than once",
+		},
+		{
+			name: "physical disks without controller",
+			raid: &metal3v1alpha1.RAIDConfig{
+				HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+					{
+						Level:         "1",
+						PhysicalDisks: []string{"Disk.Bay.0", "Disk.Bay.1"},
+					},
+				},
+			},
+			expectedError: "the controller of volume[0] must be set to use its physical disks",
+		},
+		{
+			name: "only passthrough disks",
+			raid: &metal3v1alpha1.RAIDConfig{
+				PassthroughDisks: []metal3v1alpha1.PassthroughDisk{
+					{Controller: "RAID.Integrated.1-1", PhysicalDisk: "Disk.Bay.0"},
+				},
+				SoftwareRAIDVolumes: []metal3v1alpha1.SoftwareRAIDVolume{
+					{
+						Level: "1",
+					},
+				},
+			},
+			expected: []nodes.LogicalDisk{
+				{
+					RAIDLevel:     nodes.JBOD,
+					Controller:    "RAID.Integrated.1-1",
+					PhysicalDisks: []interface{}{"Disk.Bay.0"},
+				},
+			},
+		},
 		{
 			name: "software raid",
 			raid: &metal3v1alpha1.RAIDConfig{
@@ -255,6 +357,49 @@ func TestBuildRAIDCleanSteps(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "hardware raid and passthrough disks",
+			raid: &metal3v1alpha1.RAIDConfig{
+				HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+					{
+						Level:         "1",
+						Controller:    "RAID.Integrated.1-1",
+						PhysicalDisks: []string{"Disk.Bay.0", "Disk.Bay.1"},
+					},
+				},
+				PassthroughDisks: []metal3v1alpha1.PassthroughDisk{
+					{Controller: "RAID.Integrated.1-1", PhysicalDisk: "Disk.Bay.2"},
+				},
+			},
+			expected: []nodes.CleanStep{
+				{
+					Interface: "raid",
+					Step:      "delete_configuration",
+				},
+				{
+					Interface: "raid",
+					Step:      "create_configuration",
+				},
+			},
+		},
+		{
+			name: "only passthrough disks",
+			raid: &metal3v1alpha1.RAIDConfig{
+				PassthroughDisks: []metal3v1alpha1.PassthroughDisk{
+					{Controller: "RAID.Integrated.1-1", PhysicalDisk: "Disk.Bay.0"},
+				},
+			},
+			expected: []nodes.CleanStep{
+				{
+					Interface: "raid",
+					Step:      "delete_configuration",
+				},
+				{
+					Interface: "raid",
+					Step:      "create_configuration",
+				},
+			},
+		},
 		{
 			name: "raid is nil",
 			raid: nil,
@@ -302,3 +447,206 @@ func TestBuildRAIDCleanSteps(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateHardwareRAIDVolumes(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	disks := func(count int, size metal3v1alpha1.Capacity) *metal3v1alpha1.HardwareDetails {
+		controller := metal3v1alpha1.RAIDController{Name: "Controller_0"}
+		for i := 0; i < count; i++ {
+			controller.PhysicalDisks = append(controller.PhysicalDisks,
+				metal3v1alpha1.RAIDPhysicalDisk{Slot: fmt.Sprintf("32:%d", i), SizeBytes: size})
+		}
+		return &metal3v1alpha1.HardwareDetails{RAIDControllers: []metal3v1alpha1.RAIDController{controller}}
+	}
+
+	cases := []struct {
+		name          string
+		volumes       []metal3v1alpha1.HardwareRAIDVolume
+		details       *metal3v1alpha1.HardwareDetails
+		expectedError string
+	}{
+		{
+			name: "default disk counts",
+			volumes: []metal3v1alpha1.HardwareRAIDVolume{
+				{Level: "1"},
+				{Level: "1+0"},
+			},
+			details: disks(6, 500*metal3v1alpha1.GigaByte),
+		},
+		{
+			name: "single disk raid0",
+			volumes: []metal3v1alpha1.HardwareRAIDVolume{
+				{Level: "0"},
+			},
+			details: disks(1, 500*metal3v1alpha1.GigaByte),
+		},
+		{
+			name: "no inventory",
+			volumes: []metal3v1alpha1.HardwareRAIDVolume{
+				{Level: "5", NumberOfPhysicalDisks: intPtr(12), SizeGibibytes: intPtr(100000)},
+			},
+		},
+		{
+			name: "too few disks for the level",
+			volumes: []metal3v1alpha1.HardwareRAIDVolume{
+				{Level: "1"},
+				{Level: "6", NumberOfPhysicalDisks: intPtr(3)},
+			},
+			expectedError: "volume[1] of RAID level 6 needs at least 4 physical disks, got 3",
+		},
+		{
+			name: "raid10 odd disks",
+			volumes: []metal3v1alpha1.HardwareRAIDVolume{
+				{Level: "1+0", NumberOfPhysicalDisks: intPtr(5)},
+			},
+			details:       disks(6, 500*metal3v1alpha1.GigaByte),
+			expectedError: "volume[0] of RAID level 1+0 needs an even number of physical disks, got 5",
+		},
+		{
+			name: "more disks than detected",
+			volumes: []metal3v1alpha1.HardwareRAIDVolume{
+				{Level: "1+0", NumberOfPhysicalDisks: intPtr(6)},
+			},
+			details:       disks(4, 500*metal3v1alpha1.GigaByte),
+			expectedError: "volume[0] needs 6 physical disks, but only 4 were detected",
+		},
+		{
+			name: "more disks than detected in total",
+			volumes: []metal3v1alpha1.HardwareRAIDVolume{
+				{Level: "1"},
+				{Level: "5"},
+			},
+			details:       disks(4, 500*metal3v1alpha1.GigaByte),
+			expectedError: "the RAID volumes need 5 physical disks, but only 4 were detected",
+		},
+		{
+			name: "capacity fits",
+			volumes: []metal3v1alpha1.HardwareRAIDVolume{
+				{Level: "1", SizeGibibytes: intPtr(100)},
+				{Level: "5", NumberOfPhysicalDisks: intPtr(4), SizeGibibytes: intPtr(600)},
+			},
+			details: disks(6, 300*metal3v1alpha1.GibiByte),
+		},
+		{
+			name: "capacity over-allocated",
+			volumes: []metal3v1alpha1.HardwareRAIDVolume{
+				{Level: "1", SizeGibibytes: intPtr(400)},
+				{Level: "1+0", NumberOfPhysicalDisks: intPtr(4), SizeGibibytes: intPtr(400)},
+			},
+			details:       disks(6, 200*metal3v1alpha1.GibiByte),
+			expectedError: "the RAID volumes need 1600 GiB of physical disks, but only 1200 GiB were detected",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateHardwareRAIDVolumes(c.volumes, c.details)
+			if c.expectedError == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %s", err)
+				}
+			} else if err == nil || err.Error() != c.expectedError {
+				t.Errorf("expected error: %s, got: %v", c.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestBuildNodeRAIDCleanSteps(t *testing.T) {
+	size := 100
+	raid := &metal3v1alpha1.RAIDConfig{
+		HardwareRAIDVolumes: []metal3v1alpha1.HardwareRAIDVolume{
+			{
+				Name:          "root",
+				Level:         "1",
+				SizeGibibytes: &size,
+			},
+			{
+				Level: "5",
+			},
+		},
+	}
+	allSteps := []nodes.CleanStep{
+		{
+			Interface: "raid",
+			Step:      "delete_configuration",
+		},
+		{
+			Interface: "raid",
+			Step:      "create_configuration",
+		},
+	}
+
+	cases := []struct {
+		name       string
+		raid       *metal3v1alpha1.RAIDConfig
+		raidConfig map[string]interface{}
+		expected   []nodes.CleanStep
+	}{
+		{
+			name:     "no current configuration",
+			raid:     raid,
+			expected: allSteps,
+		},
+		{
+			name: "configuration applied",
+			raid: raid,
+			raidConfig: map[string]interface{}{
+				"logical_disks": []interface{}{
+					map[string]interface{}{"raid_level": "5", "size_gb": float64(1862), "volume_name": "Virtual Disk 1"},
+					map[string]interface{}{"raid_level": "1", "size_gb": float64(100), "volume_name": "root", "is_root_volume": true},
+				},
+			},
+		},
+		{
+			name: "level changed",
+			raid: raid,
+			raidConfig: map[string]interface{}{
+				"logical_disks": []interface{}{
+					map[string]interface{}{"raid_level": "1", "size_gb": float64(100), "volume_name": "root"},
+					map[string]interface{}{"raid_level": "6", "size_gb": float64(1862)},
+				},
+			},
+			expected: allSteps,
+		},
+		{
+			name: "size changed",
+			raid: raid,
+			raidConfig: map[string]interface{}{
+				"logical_disks": []interface{}{
+					map[string]interface{}{"raid_level": "1", "size_gb": float64(200), "volume_name": "root"},
+					map[string]interface{}{"raid_level": "5", "size_gb": float64(1862)},
+				},
+			},
+			expected: allSteps,
+		},
+		{
+			name: "volume added",
+			raid: raid,
+			raidConfig: map[string]interface{}{
+				"logical_disks": []interface{}{
+					map[string]interface{}{"raid_level": "1", "size_gb": float64(100), "volume_name": "root"},
+				},
+			},
+			expected: allSteps,
+		},
+		{
+			name: "configuration removed",
+			raidConfig: map[string]interface{}{
+				"logical_disks": []interface{}{
+					map[string]interface{}{"raid_level": "1", "size_gb": float64(100), "volume_name": "root"},
+				},
+			},
+			expected: allSteps[:1],
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			steps := buildNodeRAIDCleanSteps(&nodes.Node{RAIDConfig: c.raidConfig}, c.raid)
+			if !reflect.DeepEqual(c.expected, steps) {
+				t.Errorf("expected: %v, got: %v", c.expected, steps)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package ironic
+
+import (
+	"fmt"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// redfishAuthTypes are the Redfish authentication methods supported
+// by Ironic
+var redfishAuthTypes = map[metal3v1alpha1.RedfishAuthType]bool{
+	metal3v1alpha1.RedfishAuthAuto:    true,
+	metal3v1alpha1.RedfishAuthBasic:   true,
+	metal3v1alpha1.RedfishAuthSession: true,
+}
+
+// validateRedfishAuthType checks that the authentication method is
+// supported and only set for Redfish BMCs
+func validateRedfishAuthType(authType metal3v1alpha1.RedfishAuthType, driverInfo map[string]interface{}) error {
+	if authType == "" {
+		return nil
+	}
+	if !redfishAuthTypes[authType] {
+		return fmt.Errorf("invalid redfishAuthType %q, expected one of auto, basic or session", authType)
+	}
+	if _, isRedfish := driverInfo["redfish_address"]; !isRedfish {
+		return fmt.Errorf("redfishAuthType is only supported for Redfish BMCs")
+	}
+	return nil
+}
+
+// redfishAuthTypeFields returns the driver_info field setting the
+// authentication method of Redfish BMCs, which is removed when unset
+// so that the default of the conductor, auto, is used.
+func redfishAuthTypeFields(authType metal3v1alpha1.RedfishAuthType, driverInfo map[string]interface{}) optionsData {
+	if _, isRedfish := driverInfo["redfish_address"]; !isRedfish {
+		return nil
+	}
+	if authType == "" {
+		return optionsData{"redfish_auth_type": nil}
+	}
+	return optionsData{"redfish_auth_type": string(authType)}
+}
+
+// setRedfishAuthTypeUpdateOpts sets the authentication method of the
+// host in the driver_info of the node
+func setRedfishAuthTypeUpdateOpts(ironicNode *nodes.Node, authType metal3v1alpha1.RedfishAuthType, driverInfo map[string]interface{}, updater *nodeUpdater) {
+	updater.SetDriverInfoOpts(redfishAuthTypeFields(authType, driverInfo), ironicNode)
+}
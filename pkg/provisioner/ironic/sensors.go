@@ -0,0 +1,315 @@
+package ironic
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// redfishSensorStatus holds the status of a Redfish sensor
+type redfishSensorStatus struct {
+	State  string `json:"State"`
+	Health string `json:"Health"`
+}
+
+// redfishThresholds holds the thresholds of a Redfish sensor, unset
+// when the BMC does not report them
+type redfishThresholds struct {
+	LowerThresholdNonCritical *float64 `json:"LowerThresholdNonCritical"`
+	LowerThresholdCritical    *float64 `json:"LowerThresholdCritical"`
+	LowerThresholdFatal       *float64 `json:"LowerThresholdFatal"`
+	UpperThresholdNonCritical *float64 `json:"UpperThresholdNonCritical"`
+	UpperThresholdCritical    *float64 `json:"UpperThresholdCritical"`
+	UpperThresholdFatal       *float64 `json:"UpperThresholdFatal"`
+}
+
+// redfishThermal holds the parts of a Redfish Thermal resource
+// describing the fans and temperatures of a chassis
+type redfishThermal struct {
+	Fans []struct {
+		redfishThresholds
+		Name         string              `json:"Name"`
+		FanName      string              `json:"FanName"`
+		Reading      *float64            `json:"Reading"`
+		ReadingUnits string              `json:"ReadingUnits"`
+		Status       redfishSensorStatus `json:"Status"`
+	} `json:"Fans"`
+	Temperatures []struct {
+		redfishThresholds
+		Name           string              `json:"Name"`
+		ReadingCelsius *float64            `json:"ReadingCelsius"`
+		Status         redfishSensorStatus `json:"Status"`
+	} `json:"Temperatures"`
+}
+
+// redfishLink is a link to another Redfish resource
+type redfishLink struct {
+	ID string `json:"@odata.id"`
+}
+
+// redfishChassisThermal holds the links of a Redfish Chassis to its
+// thermal resources. ThermalSubsystem replaces the deprecated Thermal
+// resource since the 2020.4 release of the schema.
+type redfishChassisThermal struct {
+	Thermal          redfishLink `json:"Thermal"`
+	ThermalSubsystem redfishLink `json:"ThermalSubsystem"`
+}
+
+// redfishThermalSubsystem holds the links of a Redfish
+// ThermalSubsystem to its fans and temperature readings
+type redfishThermalSubsystem struct {
+	Fans           redfishLink `json:"Fans"`
+	ThermalMetrics redfishLink `json:"ThermalMetrics"`
+}
+
+// redfishFan holds the parts of a Redfish Fan resource, its reading
+// linking to the sensor reporting it
+type redfishFan struct {
+	Name         string `json:"Name"`
+	SpeedPercent struct {
+		DataSourceURI string   `json:"DataSourceUri"`
+		Reading       *float64 `json:"Reading"`
+	} `json:"SpeedPercent"`
+	Status redfishSensorStatus `json:"Status"`
+}
+
+// redfishThermalMetrics holds the temperature readings of a Redfish
+// ThermalMetrics resource, each linking to the sensor reporting it
+type redfishThermalMetrics struct {
+	TemperatureReadingsCelsius []struct {
+		DataSourceURI string   `json:"DataSourceUri"`
+		DeviceName    string   `json:"DeviceName"`
+		Reading       *float64 `json:"Reading"`
+	} `json:"TemperatureReadingsCelsius"`
+}
+
+// redfishThreshold is a threshold of a Redfish Sensor resource
+type redfishThreshold struct {
+	Reading *float64 `json:"Reading"`
+}
+
+// redfishSensor holds the parts of a Redfish Sensor resource
+type redfishSensor struct {
+	Name         string              `json:"Name"`
+	Reading      *float64            `json:"Reading"`
+	ReadingUnits string              `json:"ReadingUnits"`
+	Status       redfishSensorStatus `json:"Status"`
+	Thresholds   struct {
+		LowerCaution  redfishThreshold `json:"LowerCaution"`
+		LowerCritical redfishThreshold `json:"LowerCritical"`
+		LowerFatal    redfishThreshold `json:"LowerFatal"`
+		UpperCaution  redfishThreshold `json:"UpperCaution"`
+		UpperCritical redfishThreshold `json:"UpperCritical"`
+		UpperFatal    redfishThreshold `json:"UpperFatal"`
+	} `json:"Thresholds"`
+}
+
+// thresholds converts the thresholds of the sensor to the ones of the
+// deprecated Thermal resource
+func (s *redfishSensor) thresholds() redfishThresholds {
+	return redfishThresholds{
+		LowerThresholdNonCritical: s.Thresholds.LowerCaution.Reading,
+		LowerThresholdCritical:    s.Thresholds.LowerCritical.Reading,
+		LowerThresholdFatal:       s.Thresholds.LowerFatal.Reading,
+		UpperThresholdNonCritical: s.Thresholds.UpperCaution.Reading,
+		UpperThresholdCritical:    s.Thresholds.UpperCritical.Reading,
+		UpperThresholdFatal:       s.Thresholds.UpperFatal.Reading,
+	}
+}
+
+// outOfRange returns whether the reading is below a lower threshold
+// or above an upper threshold of the sensor
+func (t *redfishThresholds) outOfRange(reading *float64) bool {
+	if reading == nil {
+		return false
+	}
+	for _, lower := range []*float64{t.LowerThresholdNonCritical, t.LowerThresholdCritical, t.LowerThresholdFatal} {
+		if lower != nil && *reading < *lower {
+			return true
+		}
+	}
+	for _, upper := range []*float64{t.UpperThresholdNonCritical, t.UpperThresholdCritical, t.UpperThresholdFatal} {
+		if upper != nil && *reading > *upper {
+			return true
+		}
+	}
+	return false
+}
+
+// roundReading rounds a Redfish reading to an integer
+func roundReading(reading *float64) *int {
+	if reading == nil {
+		return nil
+	}
+	rounded := int(math.Round(*reading))
+	return &rounded
+}
+
+// toSensorStatus converts the Redfish thermal readings, skipping the
+// sensors that are not installed
+func (t *redfishThermal) toSensorStatus() *metal3v1alpha1.SensorStatus {
+	sensors := &metal3v1alpha1.SensorStatus{}
+	for _, fan := range t.Fans {
+		if fan.Status.State == "Absent" {
+			continue
+		}
+		name := fan.Name
+		if name == "" {
+			// Before version 1.1 of the schema, fans only had a FanName
+			name = fan.FanName
+		}
+		sensors.Fans = append(sensors.Fans, metal3v1alpha1.FanReading{
+			Name:       name,
+			Reading:    roundReading(fan.Reading),
+			Units:      fan.ReadingUnits,
+			Health:     fan.Status.Health,
+			OutOfRange: fan.outOfRange(fan.Reading),
+		})
+	}
+	for _, temperature := range t.Temperatures {
+		if temperature.Status.State == "Absent" {
+			continue
+		}
+		sensors.Temperatures = append(sensors.Temperatures, metal3v1alpha1.TemperatureReading{
+			Name:                   temperature.Name,
+			ReadingCelsius:         roundReading(temperature.ReadingCelsius),
+			UpperThresholdCritical: roundReading(temperature.UpperThresholdCritical),
+			Health:                 temperature.Status.Health,
+			OutOfRange:             temperature.outOfRange(temperature.ReadingCelsius),
+		})
+	}
+	return sensors
+}
+
+// GetSensors reads the fans and temperatures of the chassis containing
+// the system of the host, from its ThermalSubsystem or, for BMCs not
+// providing one, from the deprecated Thermal resource. They are only
+// known for BMCs using Redfish, nil is returned for the others and for
+// systems not linked to a chassis.
+func (p *ironicProvisioner) GetSensors() (*metal3v1alpha1.SensorStatus, error) {
+	bmcAccess, err := p.bmcAccess()
+	if err != nil {
+		return nil, err
+	}
+	driverInfo := bmcAccess.DriverInfo(p.bmcCreds)
+	address, isRedfish := driverInfo["redfish_address"].(string)
+	if !isRedfish {
+		return nil, nil
+	}
+	client := redfishClient(driverInfo)
+
+	system, err := p.findRedfishSystem(client, address, driverInfo)
+	if err != nil {
+		return nil, err
+	}
+	chassisPath, err := p.getChassisPath(client, address, system)
+	if err != nil || chassisPath == "" {
+		return nil, err
+	}
+
+	var chassis redfishChassisThermal
+	if err := p.redfishGet(client, address, chassisPath, &chassis); err != nil {
+		return nil, err
+	}
+	switch {
+	case chassis.ThermalSubsystem.ID != "":
+		return p.getThermalSubsystemSensors(client, address, chassis.ThermalSubsystem.ID)
+	case chassis.Thermal.ID != "":
+		var thermal redfishThermal
+		if err := p.redfishGet(client, address, chassis.Thermal.ID, &thermal); err != nil {
+			return nil, err
+		}
+		return thermal.toSensorStatus(), nil
+	}
+	return nil, nil
+}
+
+// getThermalSubsystemSensors reads the fans and temperatures of a
+// Redfish ThermalSubsystem. The thresholds are read from the sensors
+// the readings link to, the readings not linked to a sensor have none.
+func (p *ironicProvisioner) getThermalSubsystemSensors(client *http.Client, address, path string) (*metal3v1alpha1.SensorStatus, error) {
+	var subsystem redfishThermalSubsystem
+	if err := p.redfishGet(client, address, path, &subsystem); err != nil {
+		return nil, err
+	}
+
+	readSensor := func(uri string, sensor *redfishSensor) error {
+		if uri == "" {
+			return nil
+		}
+		return p.redfishGet(client, address, uri, sensor)
+	}
+
+	sensors := &metal3v1alpha1.SensorStatus{}
+	if subsystem.Fans.ID != "" {
+		var fans redfishResource
+		if err := p.redfishGet(client, address, subsystem.Fans.ID, &fans); err != nil {
+			return nil, err
+		}
+		for _, member := range fans.Members {
+			var fan redfishFan
+			if err := p.redfishGet(client, address, member.ID, &fan); err != nil {
+				return nil, err
+			}
+			if fan.Status.State == "Absent" {
+				continue
+			}
+			sensor := redfishSensor{Reading: fan.SpeedPercent.Reading, ReadingUnits: "%"}
+			if err := readSensor(fan.SpeedPercent.DataSourceURI, &sensor); err != nil {
+				return nil, err
+			}
+			thresholds := sensor.thresholds()
+			sensors.Fans = append(sensors.Fans, metal3v1alpha1.FanReading{
+				Name:       fan.Name,
+				Reading:    roundReading(sensor.Reading),
+				Units:      sensor.ReadingUnits,
+				Health:     fan.Status.Health,
+				OutOfRange: thresholds.outOfRange(sensor.Reading),
+			})
+		}
+	}
+
+	if subsystem.ThermalMetrics.ID != "" {
+		var metrics redfishThermalMetrics
+		if err := p.redfishGet(client, address, subsystem.ThermalMetrics.ID, &metrics); err != nil {
+			return nil, err
+		}
+		for _, temperature := range metrics.TemperatureReadingsCelsius {
+			sensor := redfishSensor{Name: temperature.DeviceName, Reading: temperature.Reading}
+			if err := readSensor(temperature.DataSourceURI, &sensor); err != nil {
+				return nil, err
+			}
+			if sensor.Status.State == "Absent" {
+				continue
+			}
+			thresholds := sensor.thresholds()
+			sensors.Temperatures = append(sensors.Temperatures, metal3v1alpha1.TemperatureReading{
+				Name:                   sensor.Name,
+				ReadingCelsius:         roundReading(sensor.Reading),
+				UpperThresholdCritical: roundReading(thresholds.UpperThresholdCritical),
+				Health:                 sensor.Status.Health,
+				OutOfRange:             thresholds.outOfRange(sensor.Reading),
+			})
+		}
+	}
+	return sensors, nil
+}
+
+// findRedfishSystem returns the path of the configured system, or of
+// the only system of the BMC if none is configured.
+func (p *ironicProvisioner) findRedfishSystem(client *http.Client, address string, driverInfo map[string]interface{}) (system string, err error) {
+	if system, _ = driverInfo["redfish_system_id"].(string); system != "" {
+		return
+	}
+
+	var resource redfishResource
+	if err = p.redfishGet(client, address, redfishSystemsPath, &resource); err != nil {
+		return
+	}
+	if len(resource.Members) != 1 {
+		return "", fmt.Errorf("no single system listed at %s", redfishSystemsPath)
+	}
+	return resource.Members[0].ID, nil
+}
@@ -0,0 +1,179 @@
+package ironic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+const sampleRedfishThermal = `{
+	"@odata.id": "/redfish/v1/Chassis/1U/Thermal",
+	"Id": "Thermal",
+	"Temperatures": [
+		{
+			"MemberId": "0",
+			"Name": "CPU1 Temp",
+			"ReadingCelsius": 41.4,
+			"UpperThresholdNonCritical": 42,
+			"UpperThresholdCritical": 45,
+			"UpperThresholdFatal": 48,
+			"Status": {"State": "Enabled", "Health": "OK"}
+		},
+		{
+			"MemberId": "1",
+			"Name": "CPU2 Temp",
+			"ReadingCelsius": 46,
+			"UpperThresholdCritical": 45,
+			"Status": {"State": "Enabled", "Health": "Critical"}
+		},
+		{
+			"MemberId": "2",
+			"Name": "CPU3 Temp",
+			"Status": {"State": "Absent"}
+		}
+	],
+	"Fans": [
+		{
+			"MemberId": "0",
+			"Name": "BaseBoard System Fan",
+			"Reading": 2100,
+			"ReadingUnits": "RPM",
+			"LowerThresholdFatal": 0,
+			"Status": {"State": "Enabled", "Health": "OK"}
+		},
+		{
+			"MemberId": "1",
+			"FanName": "BaseBoard System Fan Backup",
+			"Reading": 0,
+			"ReadingUnits": "RPM",
+			"LowerThresholdCritical": 5,
+			"Status": {"State": "Enabled", "Health": "Critical"}
+		}
+	]
+}`
+
+const sampleRedfishThermalMetrics = `{
+	"@odata.id": "/redfish/v1/Chassis/2U/ThermalSubsystem/ThermalMetrics",
+	"TemperatureReadingsCelsius": [
+		{"DataSourceUri": "/redfish/v1/Chassis/2U/Sensors/CPU1Temp", "DeviceName": "CPU1", "Reading": 46},
+		{"DeviceName": "Inlet", "Reading": 22.6}
+	]
+}`
+
+func TestGetSensors(t *testing.T) {
+	redfish := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redfish/v1/Systems/1":
+			w.Write([]byte(`{"Id": "1", "Links": {"Chassis": [{"@odata.id": "/redfish/v1/Chassis/1U"}]}}`))
+		case "/redfish/v1/Systems/2":
+			w.Write([]byte(`{"Id": "2", "Links": {}}`))
+		case "/redfish/v1/Systems/4":
+			w.Write([]byte(`{"Id": "4", "Links": {"Chassis": [{"@odata.id": "/redfish/v1/Chassis/2U"}]}}`))
+		case "/redfish/v1/Chassis/1U":
+			w.Write([]byte(`{"Id": "1U", "Thermal": {"@odata.id": "/redfish/v1/Chassis/1U/Thermal"}}`))
+		case "/redfish/v1/Chassis/1U/Thermal":
+			w.Write([]byte(sampleRedfishThermal))
+		case "/redfish/v1/Chassis/2U":
+			w.Write([]byte(`{"Id": "2U", "Thermal": {"@odata.id": "/redfish/v1/Chassis/2U/Thermal"}, "ThermalSubsystem": {"@odata.id": "/redfish/v1/Chassis/2U/ThermalSubsystem"}}`))
+		case "/redfish/v1/Chassis/2U/ThermalSubsystem":
+			w.Write([]byte(`{"Fans": {"@odata.id": "/redfish/v1/Chassis/2U/ThermalSubsystem/Fans"}, "ThermalMetrics": {"@odata.id": "/redfish/v1/Chassis/2U/ThermalSubsystem/ThermalMetrics"}}`))
+		case "/redfish/v1/Chassis/2U/ThermalSubsystem/Fans":
+			w.Write([]byte(`{"Members": [{"@odata.id": "/redfish/v1/Chassis/2U/ThermalSubsystem/Fans/Bay1"}, {"@odata.id": "/redfish/v1/Chassis/2U/ThermalSubsystem/Fans/Bay2"}]}`))
+		case "/redfish/v1/Chassis/2U/ThermalSubsystem/Fans/Bay1":
+			w.Write([]byte(`{"Name": "Fan Bay 1", "SpeedPercent": {"DataSourceUri": "/redfish/v1/Chassis/2U/Sensors/FanBay1", "Reading": 45}, "Status": {"State": "Enabled", "Health": "OK"}}`))
+		case "/redfish/v1/Chassis/2U/ThermalSubsystem/Fans/Bay2":
+			w.Write([]byte(`{"Name": "Fan Bay 2", "Status": {"State": "Absent"}}`))
+		case "/redfish/v1/Chassis/2U/ThermalSubsystem/ThermalMetrics":
+			w.Write([]byte(sampleRedfishThermalMetrics))
+		case "/redfish/v1/Chassis/2U/Sensors/FanBay1":
+			w.Write([]byte(`{"Name": "Fan Bay 1", "Reading": 45, "ReadingUnits": "%", "Thresholds": {"LowerCritical": {"Reading": 10}}, "Status": {"State": "Enabled", "Health": "OK"}}`))
+		case "/redfish/v1/Chassis/2U/Sensors/CPU1Temp":
+			w.Write([]byte(`{"Name": "CPU1 Temp", "Reading": 46, "ReadingUnits": "Cel", "Thresholds": {"UpperCritical": {"Reading": 45}}, "Status": {"State": "Enabled", "Health": "Critical"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer redfish.Close()
+	redfishHost := strings.TrimPrefix(redfish.URL, "http://")
+
+	intPtr := func(i int) *int { return &i }
+	cases := []struct {
+		name            string
+		address         string
+		expectedSensors *metal3v1alpha1.SensorStatus
+		expectedError   string
+	}{
+		{
+			name:    "sensors",
+			address: "redfish+http://" + redfishHost + "/redfish/v1/Systems/1",
+			expectedSensors: &metal3v1alpha1.SensorStatus{
+				Fans: []metal3v1alpha1.FanReading{
+					{Name: "BaseBoard System Fan", Reading: intPtr(2100), Units: "RPM", Health: "OK"},
+					{Name: "BaseBoard System Fan Backup", Reading: intPtr(0), Units: "RPM", Health: "Critical", OutOfRange: true},
+				},
+				Temperatures: []metal3v1alpha1.TemperatureReading{
+					{Name: "CPU1 Temp", ReadingCelsius: intPtr(41), UpperThresholdCritical: intPtr(45), Health: "OK"},
+					{Name: "CPU2 Temp", ReadingCelsius: intPtr(46), UpperThresholdCritical: intPtr(45), Health: "Critical", OutOfRange: true},
+				},
+			},
+		},
+		{
+			name:    "thermal subsystem",
+			address: "redfish+http://" + redfishHost + "/redfish/v1/Systems/4",
+			expectedSensors: &metal3v1alpha1.SensorStatus{
+				Fans: []metal3v1alpha1.FanReading{
+					{Name: "Fan Bay 1", Reading: intPtr(45), Units: "%", Health: "OK"},
+				},
+				Temperatures: []metal3v1alpha1.TemperatureReading{
+					{Name: "CPU1 Temp", ReadingCelsius: intPtr(46), UpperThresholdCritical: intPtr(45), Health: "Critical", OutOfRange: true},
+					{Name: "Inlet", ReadingCelsius: intPtr(23)},
+				},
+			},
+		},
+		{
+			name:    "no chassis",
+			address: "redfish+http://" + redfishHost + "/redfish/v1/Systems/2",
+		},
+		{
+			name:          "unknown system",
+			address:       "redfish+http://" + redfishHost + "/redfish/v1/Systems/3",
+			expectedError: "/redfish/v1/Systems/3 returned 404 Not Found",
+		},
+		{
+			name:    "not redfish",
+			address: "ipmi://192.168.122.1:6233",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BMC.Address = tc.address
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "pa$$w0rd"}, nullEventPublisher,
+				testserver.NewIronic(t).Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			sensors, err := prov.GetSensors()
+
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedSensors, sensors)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package ironic
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+)
+
+const (
+	// nodeShardMicroversion is the first API version exposing the
+	// shard of the nodes
+	nodeShardMicroversion = "1.82"
+
+	// maxShardLength is the longest shard name Ironic accepts
+	maxShardLength = 255
+)
+
+// nodeShards holds the shard each node was last seen in or moved to.
+// Reading the shard needs a request of its own, so it is only checked
+// again when the shard of the host changes. It is kept in the process
+// and rebuilt as the hosts are reconciled after a restart.
+var nodeShards = struct {
+	sync.Mutex
+	shards map[string]string
+}{shards: map[string]string{}}
+
+// knownNodeShard returns the shard the node was last seen in, and
+// whether it is known.
+func knownNodeShard(nodeUUID string) (shard string, known bool) {
+	nodeShards.Lock()
+	defer nodeShards.Unlock()
+	shard, known = nodeShards.shards[nodeUUID]
+	return
+}
+
+// rememberNodeShard records the shard the node is in.
+func rememberNodeShard(nodeUUID, shard string) {
+	nodeShards.Lock()
+	defer nodeShards.Unlock()
+	nodeShards.shards[nodeUUID] = shard
+}
+
+// forgetNodeShard drops the shard of a node that is no longer managed.
+func forgetNodeShard(nodeUUID string) {
+	nodeShards.Lock()
+	defer nodeShards.Unlock()
+	delete(nodeShards.shards, nodeUUID)
+}
+
+// unsupportedShardError is returned when a shard is requested from a
+// version of Ironic without shards.
+type unsupportedShardError struct{}
+
+func (e unsupportedShardError) Error() string {
+	return "the version of Ironic does not support shards"
+}
+
+// validateShard checks that the shard can be set on the node. An empty
+// shard removes the node from its shard.
+func validateShard(shard string) error {
+	if shard == "" {
+		return nil
+	}
+	if strings.TrimSpace(shard) == "" {
+		return fmt.Errorf("the shard must not be blank")
+	}
+	if len(shard) > maxShardLength {
+		return fmt.Errorf("the shard must not be longer than %d characters", maxShardLength)
+	}
+	return nil
+}
+
+// shardClient returns a copy of the client using the API version which
+// exposes the shard of the nodes.
+func (p *ironicProvisioner) shardClient() *gophercloud.ServiceClient {
+	client := *p.client
+	client.Microversion = nodeShardMicroversion
+	return &client
+}
+
+// getNodeShard returns the shard of the node, which is not part of the
+// node structure of the client library. Found is false if the version
+// of Ironic does not support shards.
+func (p *ironicProvisioner) getNodeShard(ironicNode *nodes.Node) (shard string, found bool, err error) {
+	client := p.shardClient()
+	var body struct {
+		Shard *string `json:"shard"`
+	}
+	_, err = client.Get(client.ServiceURL("nodes", ironicNode.UUID)+"?fields=shard", &body, nil)
+	if err != nil {
+		if respErr, ok := err.(gophercloud.ErrUnexpectedResponseCode); ok && respErr.Actual == http.StatusNotAcceptable {
+			return "", false, nil
+		}
+		return
+	}
+	if body.Shard != nil {
+		shard = *body.Shard
+	}
+	return shard, true, nil
+}
+
+// shardNeedsUpdate returns whether the shard of the node differs from
+// the one of the host. The node is only read if the shard of the host
+// is not the one the node was last seen in.
+func (p *ironicProvisioner) shardNeedsUpdate(ironicNode *nodes.Node, shard string) (bool, error) {
+	if known, ok := knownNodeShard(ironicNode.UUID); ok && known == shard {
+		return false, nil
+	}
+
+	current, found, err := p.getNodeShard(ironicNode)
+	if err != nil {
+		if shard == "" {
+			// Nothing to remove as far as we know
+			p.log.Info("could not read the node shard", "error", err)
+			return false, nil
+		}
+		return false, err
+	}
+	if current == shard {
+		rememberNodeShard(ironicNode.UUID, current)
+		return false, nil
+	}
+	if !found {
+		return false, unsupportedShardError{}
+	}
+	return true, nil
+}
+
+// setNodeShard replaces the shard of the node with the one of the host,
+// removing it when the host has none. The shard needs a newer API
+// version than the rest of the node settings, so it is not updated
+// along with them.
+func (p *ironicProvisioner) setNodeShard(ironicNode *nodes.Node, shard string) error {
+	op := nodes.RemoveOp
+	var value interface{}
+	if shard != "" {
+		op = nodes.ReplaceOp
+		value = shard
+	}
+	p.log.Info("updating the node shard", "shard", shard)
+	_, err := nodes.Update(p.shardClient(), ironicNode.UUID, nodes.UpdateOpts{
+		nodes.UpdateOperation{Op: op, Path: "/shard", Value: value},
+	}).Extract()
+	if err == nil {
+		rememberNodeShard(ironicNode.UUID, shard)
+	}
+	return err
+}
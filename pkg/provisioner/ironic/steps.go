@@ -0,0 +1,95 @@
+package ironic
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// The agent reports the steps it supports when it boots, and Ironic
+// caches them by interface in the driver_internal_info of the node.
+const (
+	cachedCleanStepsKey  = "agent_cached_clean_steps"
+	cachedDeployStepsKey = "agent_cached_deploy_steps"
+)
+
+// cachedStep is a step as cached by Ironic
+type cachedStep struct {
+	Interface string `json:"interface"`
+	Step      string `json:"step"`
+	Priority  int    `json:"priority"`
+	ArgsInfo  map[string]struct {
+		Description string `json:"description"`
+		Required    bool   `json:"required"`
+	} `json:"argsinfo"`
+}
+
+// GetAvailableSteps returns the clean and deploy steps the agent
+// reported for the interfaces of the node. Ironic only caches the agent
+// steps; listing the out-of-band steps of the other interfaces would
+// need an API that Ironic does not provide, so they are not returned.
+func (p *ironicProvisioner) GetAvailableSteps() (steps provisioner.AvailableSteps, err error) {
+	ironicNode, err := p.getNode()
+	if err != nil {
+		return
+	}
+
+	if steps.Clean, err = parseCachedSteps(ironicNode.DriverInternalInfo[cachedCleanStepsKey]); err != nil {
+		err = errors.Wrap(err, "failed to read the clean steps")
+		return
+	}
+	if steps.Deploy, err = parseCachedSteps(ironicNode.DriverInternalInfo[cachedDeployStepsKey]); err != nil {
+		err = errors.Wrap(err, "failed to read the deploy steps")
+	}
+	return
+}
+
+// parseCachedSteps converts the steps cached by Ironic, a list of steps
+// for each interface, sorted by interface and then by name.
+func parseCachedSteps(cached interface{}) (steps []provisioner.Step, err error) {
+	if cached == nil {
+		return
+	}
+	// The steps are decoded as generic JSON with the rest of the node
+	encoded, err := json.Marshal(cached)
+	if err != nil {
+		return
+	}
+	var byInterface map[string][]cachedStep
+	if err = json.Unmarshal(encoded, &byInterface); err != nil {
+		return
+	}
+
+	for iface, cachedSteps := range byInterface {
+		for _, cachedStep := range cachedSteps {
+			step := provisioner.Step{
+				Interface: cachedStep.Interface,
+				Name:      cachedStep.Step,
+				Priority:  cachedStep.Priority,
+			}
+			if step.Interface == "" {
+				step.Interface = iface
+			}
+			if len(cachedStep.ArgsInfo) != 0 {
+				step.Arguments = make(map[string]provisioner.StepArgument, len(cachedStep.ArgsInfo))
+				for name, arg := range cachedStep.ArgsInfo {
+					step.Arguments[name] = provisioner.StepArgument{
+						Description: arg.Description,
+						Required:    arg.Required,
+					}
+				}
+			}
+			steps = append(steps, step)
+		}
+	}
+	sort.Slice(steps, func(i, j int) bool {
+		if steps[i].Interface != steps[j].Interface {
+			return steps[i].Interface < steps[j].Interface
+		}
+		return steps[i].Name < steps[j].Name
+	})
+	return
+}
@@ -0,0 +1,106 @@
+package ironic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+const sampleCachedSteps = `{
+	"agent_cached_clean_steps": {
+		"deploy": [
+			{"step": "erase_devices_metadata", "priority": 99, "interface": "deploy",
+			 "reboot_requested": false, "abortable": true},
+			{"step": "erase_devices", "priority": 10, "interface": "deploy",
+			 "reboot_requested": false, "abortable": true}
+		],
+		"raid": [
+			{"step": "delete_configuration", "priority": 0, "interface": "raid",
+			 "reboot_requested": false, "abortable": false, "argsinfo": null}
+		]
+	},
+	"agent_cached_deploy_steps": {
+		"deploy": [
+			{"step": "write_image", "priority": 80, "interface": "deploy", "reboot_requested": false,
+			 "argsinfo": {"image_url": {"description": "URL of the image", "required": true}}}
+		]
+	},
+	"agent_url": "http://192.168.111.20:9999"
+}`
+
+func TestGetAvailableSteps(t *testing.T) {
+	var driverInternalInfo map[string]interface{}
+	if err := json.Unmarshal([]byte(sampleCachedSteps), &driverInternalInfo); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name               string
+		driverInternalInfo map[string]interface{}
+		expected           provisioner.AvailableSteps
+	}{
+		{
+			name:               "agent steps",
+			driverInternalInfo: driverInternalInfo,
+			expected: provisioner.AvailableSteps{
+				Clean: []provisioner.Step{
+					{Interface: "deploy", Name: "erase_devices", Priority: 10},
+					{Interface: "deploy", Name: "erase_devices_metadata", Priority: 99},
+					{Interface: "raid", Name: "delete_configuration"},
+				},
+				Deploy: []provisioner.Step{
+					{
+						Interface: "deploy",
+						Name:      "write_image",
+						Priority:  80,
+						Arguments: map[string]provisioner.StepArgument{
+							"image_url": {Description: "URL of the image", Required: true},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "agent never booted",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:               nodeUUID,
+				DriverInternalInfo: tc.driverInternalInfo,
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			steps, err := prov.GetAvailableSteps()
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, steps)
+		})
+	}
+}
+
+func TestParseCachedStepsInvalid(t *testing.T) {
+	_, err := parseCachedSteps([]interface{}{"write_image"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,28 @@
+package ironic
+
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+)
+
+// tagExtraPrefix prefixes the keys of the node's extra field set from
+// the host's tags, so that they cannot collide with the keys set by
+// other users of the node.
+const tagExtraPrefix = "metal3_tag_"
+
+// setTagsUpdateOpts replaces the extra keys managed through the host's
+// tags with the current ones, leaving the other keys untouched.
+func setTagsUpdateOpts(ironicNode *nodes.Node, tags map[string]string, updater *nodeUpdater) {
+	settings := optionsData{}
+	for key := range ironicNode.Extra {
+		if strings.HasPrefix(key, tagExtraPrefix) {
+			settings[key] = nil
+		}
+	}
+	for key, value := range tags {
+		settings[tagExtraPrefix+key] = value
+	}
+
+	updater.SetExtraOpts(settings, ironicNode)
+}
@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/allocations"
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/ports"
 )
@@ -86,6 +88,15 @@ func (m *IronicMock) WithDrivers() *IronicMock {
 	return m
 }
 
+// DriverDetails configures the server with a valid response for [GET] /v1/drivers/<driver>
+func (m *IronicMock) DriverDetails(name string, managementInterfaces []string) *IronicMock {
+	m.ResponseJSON(m.buildURL("/v1/drivers/"+name, http.MethodGet), map[string]interface{}{
+		"name":                          name,
+		"enabled_management_interfaces": managementInterfaces,
+	})
+	return m
+}
+
 func (m *IronicMock) buildURL(url string, method string) string {
 	return fmt.Sprintf("%s:%s", url, method)
 }
@@ -114,6 +125,101 @@ func (m *IronicMock) Node(node nodes.Node) *IronicMock {
 	return m
 }
 
+// NodeWithConductor configures the server with a valid response for
+// /v1/nodes/<uuid> including the conductor managing the node. An empty
+// conductor name is reported as null.
+func (m *IronicMock) NodeWithConductor(node nodes.Node, conductor string) *IronicMock {
+	var value interface{}
+	if conductor != "" {
+		value = conductor
+	}
+	return m.nodeWithField(node, "conductor", value)
+}
+
+// NodeWithDescription configures the server with a valid response for
+// /v1/nodes/<uuid> including the description of the node. An empty
+// description is reported as null.
+func (m *IronicMock) NodeWithDescription(node nodes.Node, description string) *IronicMock {
+	var value interface{}
+	if description != "" {
+		value = description
+	}
+	return m.nodeWithField(node, "description", value)
+}
+
+// NodeWithShard configures the server with a valid response for
+// /v1/nodes/<uuid> including the shard of the node. An empty shard is
+// reported as null.
+func (m *IronicMock) NodeWithShard(node nodes.Node, shard string) *IronicMock {
+	var value interface{}
+	if shard != "" {
+		value = shard
+	}
+	return m.nodeWithField(node, "shard", value)
+}
+
+// NodeWithAllocation configures the server with a valid response for
+// /v1/nodes/<uuid> including the UUID of the allocation of the node.
+func (m *IronicMock) NodeWithAllocation(node nodes.Node, allocationUUID string) *IronicMock {
+	return m.nodeWithField(node, "allocation_uuid", allocationUUID)
+}
+
+// NodeWithInspectionFinishedAt configures the server with a valid
+// response for /v1/nodes/<uuid> including when the last inspection of
+// the node finished. An empty timestamp is reported as null.
+func (m *IronicMock) NodeWithInspectionFinishedAt(node nodes.Node, timestamp string) *IronicMock {
+	var value interface{}
+	if timestamp != "" {
+		value = timestamp
+	}
+	return m.nodeWithField(node, "inspection_finished_at", value)
+}
+
+// NodeWithProvisionUpdatedAt configures the server with a valid
+// response for /v1/nodes/<uuid> including when the provision state of
+// the node last changed.
+func (m *IronicMock) NodeWithProvisionUpdatedAt(node nodes.Node, timestamp time.Time) *IronicMock {
+	return m.nodeWithField(node, "provision_updated_at", timestamp.UTC().Format(time.RFC3339))
+}
+
+// NodeWithCreatedAt configures the server with a valid response for
+// /v1/nodes/<uuid> including when the node was created. An empty
+// timestamp is reported as null.
+func (m *IronicMock) NodeWithCreatedAt(node nodes.Node, createdAt string) *IronicMock {
+	var value interface{}
+	if createdAt != "" {
+		value = createdAt
+	}
+	return m.nodeWithField(node, "created_at", value)
+}
+
+// nodeWithField configures the server with a response for
+// /v1/nodes/<uuid> including a field unknown to the client library.
+func (m *IronicMock) nodeWithField(node nodes.Node, name string, value interface{}) *IronicMock {
+	var resp map[string]interface{}
+	content, err := json.Marshal(node)
+	if err == nil {
+		err = json.Unmarshal(content, &resp)
+	}
+	if err != nil {
+		m.MockServer.t.Error(err)
+	}
+
+	resp[name] = value
+
+	m.ResponseJSON(m.buildURL("/v1/nodes/"+node.UUID, http.MethodGet), resp)
+	return m
+}
+
+// Conductor configures the server with a valid response for /v1/conductors/<hostname>
+func (m *IronicMock) Conductor(hostname string, alive bool) *IronicMock {
+	m.ResponseJSON(m.buildURL("/v1/conductors/"+hostname, http.MethodGet), map[string]interface{}{
+		"hostname": hostname,
+		"alive":    alive,
+	})
+	return m
+}
+
 // NodeUpdateError configures configures the server with an error response for [PATCH] /v1/nodes/{id}
 func (m *IronicMock) NodeUpdateError(id string, errorCode int) *IronicMock {
 	m.ResponseWithCode(m.buildURL("/v1/nodes/"+id, http.MethodPatch), "", errorCode)
@@ -229,6 +335,26 @@ func (m *IronicMock) WithNodeStatesPowerUpdate(nodeUUID string, code int) *Ironi
 	return m.withNodeStatesPower(nodeUUID, code, http.MethodPut)
 }
 
+// SupportedBootDevices configures the server with a valid response for [GET] /v1/nodes/<node>/management/boot_device/supported
+func (m *IronicMock) SupportedBootDevices(nodeUUID string, devices []string) *IronicMock {
+	m.ResponseJSON(m.buildURL("/v1/nodes/"+nodeUUID+"/management/boot_device/supported", http.MethodGet), map[string]interface{}{
+		"supported_boot_devices": devices,
+	})
+	return m
+}
+
+// WithBootDeviceUpdate configures the server with a response for [PUT] /v1/nodes/<node>/management/boot_device
+func (m *IronicMock) WithBootDeviceUpdate(nodeUUID string, code int) *IronicMock {
+	m.ResponseWithCode(m.buildURL("/v1/nodes/"+nodeUUID+"/management/boot_device", http.MethodPut), "", code)
+	return m
+}
+
+// WithVendorPassthru configures the server with a response for [POST] /v1/nodes/<node>/vendor_passthru
+func (m *IronicMock) WithVendorPassthru(nodeUUID string, code int) *IronicMock {
+	m.ResponseWithCode(m.buildURL("/v1/nodes/"+nodeUUID+"/vendor_passthru", http.MethodPost), "", code)
+	return m
+}
+
 // WithNodeValidate configures the server with a valid response for /v1/nodes/<node>/validate
 func (m *IronicMock) WithNodeValidate(nodeUUID string) *IronicMock {
 	m.ResponseWithCode("/v1/nodes/"+nodeUUID+"/validate", "{}", http.StatusOK)
@@ -259,6 +385,63 @@ func (m *IronicMock) Port(port ports.Port) *IronicMock {
 	return m
 }
 
+// BIOS configures the server with a valid response for /v1/nodes/<node>/bios
+func (m *IronicMock) BIOS(nodeUUID string, settings map[string]string) *IronicMock {
+	type biosSetting struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	resp := struct {
+		Settings []biosSetting `json:"bios"`
+	}{}
+	for name, value := range settings {
+		resp.Settings = append(resp.Settings, biosSetting{Name: name, Value: value})
+	}
+
+	m.ResponseJSON(m.buildURL("/v1/nodes/"+nodeUUID+"/bios", http.MethodGet), resp)
+	return m
+}
+
+// Console configures the server with a valid response for [GET] /v1/nodes/<node>/states/console
+func (m *IronicMock) Console(nodeUUID string, consoleType, url string) *IronicMock {
+	m.ResponseJSON(m.buildURL("/v1/nodes/"+nodeUUID+"/states/console", http.MethodGet), map[string]interface{}{
+		"console_enabled": true,
+		"console_info": map[string]interface{}{
+			"type": consoleType,
+			"url":  url,
+		},
+	})
+	return m
+}
+
+// FirmwareComponents configures the server with a valid response for [GET] /v1/nodes/<node>/firmware
+func (m *IronicMock) FirmwareComponents(nodeUUID string, components []map[string]interface{}) *IronicMock {
+	m.ResponseJSON(m.buildURL("/v1/nodes/"+nodeUUID+"/firmware", http.MethodGet), map[string]interface{}{
+		"firmware": components,
+	})
+	return m
+}
+
+// Allocation configures the server with a valid response for [GET] /v1/allocations/<uuid>
+func (m *IronicMock) Allocation(allocation allocations.Allocation) *IronicMock {
+	m.ResponseJSON(m.buildURL("/v1/allocations/"+allocation.UUID, http.MethodGet), allocation)
+	return m
+}
+
+// WithNodeTraitsUpdate configures the server with a valid response for [PUT] /v1/nodes/<node>/traits
+func (m *IronicMock) WithNodeTraitsUpdate(nodeUUID string) *IronicMock {
+	m.ResponseWithCode(m.buildURL("/v1/nodes/"+nodeUUID+"/traits", http.MethodPut), "", http.StatusNoContent)
+	return m
+}
+
+// NodeHistory configures the server with a valid response for [GET] /v1/nodes/<node>/history
+func (m *IronicMock) NodeHistory(nodeUUID string, history []map[string]interface{}) *IronicMock {
+	m.ResponseJSON(m.buildURL("/v1/nodes/"+nodeUUID+"/history", http.MethodGet), map[string]interface{}{
+		"history": history,
+	})
+	return m
+}
+
 // Nodes configure the server with a valid response for /v1/nodes
 func (m *IronicMock) Nodes(allNodes []nodes.Node) *IronicMock {
 	resp := struct {
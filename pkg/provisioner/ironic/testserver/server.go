@@ -199,6 +199,17 @@ func (m *MockServer) GetLastRequestFor(pattern string, method string) (string, b
 	return "", false
 }
 
+// CountRequestsFor returns the number of requests received for the
+// specified pattern/method.
+func (m *MockServer) CountRequestsFor(pattern string, method string) (count int) {
+	for _, r := range m.FullRequests {
+		if r.pattern == pattern && (r.method == "" || r.method == method) {
+			count++
+		}
+	}
+	return
+}
+
 // AddDefaultResponse adds a default response for the specified pattern/method.
 // It is possible to use variables in the pattern using curly braces, ie `/v1/nodes/{id}/power`
 // Pattern variables can be reused in the payload, so that they will be substituted with the actual value when sending the response
@@ -0,0 +1,180 @@
+package ironic
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/allocations"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+)
+
+// traitsExtraKey is the key of the node's extra field listing the
+// traits that are managed through the host's traits.
+const traitsExtraKey = "metal3_traits"
+
+// maxTraits is the number of traits Ironic accepts for a node
+const maxTraits = 50
+
+// Ironic accepts standard traits, which are upper case, and custom
+// ones prefixed with CUSTOM_.
+var traitRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,254}$`)
+
+// validateTraits checks the format of the traits requested for the host
+func validateTraits(traits []string) error {
+	if len(traits) > maxTraits {
+		return fmt.Errorf("at most %d traits can be set, got %d", maxTraits, len(traits))
+	}
+	for _, trait := range traits {
+		if !traitRegexp.MatchString(trait) {
+			return fmt.Errorf("invalid trait %q, expected upper case letters, digits and underscores", trait)
+		}
+	}
+	return nil
+}
+
+// managedTraits returns the traits previously set from the host's traits
+func managedTraits(ironicNode *nodes.Node) (traits []string) {
+	values, ok := ironicNode.Extra[traitsExtraKey].([]interface{})
+	if !ok {
+		return
+	}
+	for _, value := range values {
+		if trait, ok := value.(string); ok {
+			traits = append(traits, trait)
+		}
+	}
+	return
+}
+
+// buildNodeTraits returns the traits the node should have: the ones
+// not managed through the host, which are left untouched, and the
+// host's traits. The result is sorted and changed reports whether it
+// differs from the current traits of the node.
+func buildNodeTraits(ironicNode *nodes.Node, traits []string) (desired []string, changed bool) {
+	managed := make(map[string]bool, len(ironicNode.Traits))
+	for _, trait := range managedTraits(ironicNode) {
+		managed[trait] = true
+	}
+
+	unique := make(map[string]bool, len(ironicNode.Traits)+len(traits))
+	for _, trait := range ironicNode.Traits {
+		if !managed[trait] {
+			unique[trait] = true
+		}
+	}
+	for _, trait := range traits {
+		unique[trait] = true
+	}
+
+	desired = make([]string, 0, len(unique))
+	for trait := range unique {
+		desired = append(desired, trait)
+	}
+	sort.Strings(desired)
+
+	current := append([]string{}, ironicNode.Traits...)
+	sort.Strings(current)
+	changed = len(current) != len(desired)
+	for i := 0; !changed && i < len(current); i++ {
+		changed = current[i] != desired[i]
+	}
+	return
+}
+
+// setNodeTraits replaces the traits of the node. Traits cannot be
+// changed by updating the node, and the client library does not
+// support the dedicated call.
+func (p *ironicProvisioner) setNodeTraits(ironicNode *nodes.Node, traits []string) error {
+	body := map[string]interface{}{"traits": traits}
+	_, err := p.client.Put(p.client.ServiceURL("nodes", ironicNode.UUID, "traits"), body, nil,
+		&gophercloud.RequestOpts{OkCodes: []int{204}})
+	return err
+}
+
+// setTraitsUpdateOpts records the traits managed through the host in
+// the node's extra field, so that they can be removed later.
+func setTraitsUpdateOpts(ironicNode *nodes.Node, traits []string, updater *nodeUpdater) {
+	sorted := append([]string{}, traits...)
+	sort.Strings(sorted)
+
+	settings := optionsData{traitsExtraKey: nil}
+	if len(sorted) != 0 {
+		settings[traitsExtraKey] = sorted
+	}
+	updater.SetExtraOpts(settings, ironicNode)
+}
+
+// validateInstanceTraits checks the traits requested for the instance,
+// which Ironic only accepts when the node has them too.
+func validateInstanceTraits(instanceTraits []string, ironicNode *nodes.Node) error {
+	if err := validateTraits(instanceTraits); err != nil {
+		return err
+	}
+	nodeTraits := make(map[string]bool, len(ironicNode.Traits))
+	for _, trait := range ironicNode.Traits {
+		nodeTraits[trait] = true
+	}
+	for _, trait := range instanceTraits {
+		if !nodeTraits[trait] {
+			return fmt.Errorf("instance trait %s is not one of the traits of the host", trait)
+		}
+	}
+	return nil
+}
+
+// setInstanceTraitsUpdateOpts sets the traits of the instance, which
+// select the deploy templates run while deploying the node.
+func setInstanceTraitsUpdateOpts(ironicNode *nodes.Node, traits []string, updater *nodeUpdater) {
+	sorted := append([]string{}, traits...)
+	sort.Strings(sorted)
+
+	settings := optionsData{"traits": nil}
+	if len(sorted) != 0 {
+		settings["traits"] = sorted
+	}
+	updater.SetInstanceInfoOpts(settings, ironicNode)
+}
+
+// getAllocationStatus returns the details of the allocation the node
+// belongs to, or nil if it is not allocated.
+func (p *ironicProvisioner) getAllocationStatus(ironicNode *nodes.Node) (status *metal3v1alpha1.AllocationStatus, err error) {
+	fields, err := p.getNodeFields(ironicNode)
+	if err != nil || fields.AllocationUUID == nil {
+		return
+	}
+
+	allocation, err := allocations.Get(p.client, *fields.AllocationUUID).Extract()
+	if err != nil {
+		return
+	}
+	status = &metal3v1alpha1.AllocationStatus{
+		UUID:      allocation.UUID,
+		Name:      allocation.Name,
+		State:     allocation.State,
+		Traits:    allocation.Traits,
+		LastError: allocation.LastError,
+	}
+	return
+}
+
+// getSchedulingStatus returns the resource class and traits of the
+// node, or nil if it has none.
+func getSchedulingStatus(ironicNode *nodes.Node) *metal3v1alpha1.SchedulingStatus {
+	if ironicNode.ResourceClass == "" && len(ironicNode.Traits) == 0 {
+		return nil
+	}
+
+	var traits []string
+	if len(ironicNode.Traits) != 0 {
+		traits = append(traits, ironicNode.Traits...)
+		sort.Strings(traits)
+	}
+	return &metal3v1alpha1.SchedulingStatus{
+		ResourceClass: ironicNode.ResourceClass,
+		Traits:        traits,
+	}
+}
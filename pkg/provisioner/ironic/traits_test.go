@@ -0,0 +1,234 @@
+package ironic
+
+import (
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/fixture"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestValidateTraits(t *testing.T) {
+	tooMany := make([]string, maxTraits+1)
+	for i := range tooMany {
+		tooMany[i] = "CUSTOM_TRAIT"
+	}
+
+	cases := []struct {
+		name          string
+		traits        []string
+		expectedError string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:   "valid",
+			traits: []string{"CUSTOM_GPU", "HW_CPU_X86_AVX2"},
+		},
+		{
+			name:          "lower case",
+			traits:        []string{"CUSTOM_gpu"},
+			expectedError: "invalid trait \"CUSTOM_gpu\", expected upper case letters, digits and underscores",
+		},
+		{
+			name:          "empty",
+			traits:        []string{""},
+			expectedError: "invalid trait \"\", expected upper case letters, digits and underscores",
+		},
+		{
+			name:          "too many",
+			traits:        tooMany,
+			expectedError: "at most 50 traits can be set, got 51",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateTraits(tc.traits)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestBuildNodeTraits(t *testing.T) {
+	cases := []struct {
+		name            string
+		current         []string
+		managed         []interface{}
+		traits          []string
+		expectedTraits  []string
+		expectedChanged bool
+	}{
+		{
+			name:           "none",
+			expectedTraits: []string{},
+		},
+		{
+			name:            "added",
+			current:         []string{"CUSTOM_RACK_1"},
+			traits:          []string{"CUSTOM_GPU"},
+			expectedTraits:  []string{"CUSTOM_GPU", "CUSTOM_RACK_1"},
+			expectedChanged: true,
+		},
+		{
+			name:            "managed trait removed",
+			current:         []string{"CUSTOM_GPU", "CUSTOM_RACK_1", "CUSTOM_SSD"},
+			managed:         []interface{}{"CUSTOM_GPU", "CUSTOM_SSD"},
+			traits:          []string{"CUSTOM_SSD"},
+			expectedTraits:  []string{"CUSTOM_RACK_1", "CUSTOM_SSD"},
+			expectedChanged: true,
+		},
+		{
+			name:           "unchanged",
+			current:        []string{"CUSTOM_SSD", "CUSTOM_GPU"},
+			managed:        []interface{}{"CUSTOM_GPU", "CUSTOM_SSD"},
+			traits:         []string{"CUSTOM_GPU", "CUSTOM_SSD"},
+			expectedTraits: []string{"CUSTOM_GPU", "CUSTOM_SSD"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironicNode := &nodes.Node{Traits: tc.current}
+			if tc.managed != nil {
+				ironicNode.Extra = map[string]interface{}{traitsExtraKey: tc.managed}
+			}
+
+			traits, changed := buildNodeTraits(ironicNode, tc.traits)
+			assert.Equal(t, tc.expectedTraits, traits)
+			assert.Equal(t, tc.expectedChanged, changed)
+		})
+	}
+}
+
+func TestValidateInstanceTraits(t *testing.T) {
+	cases := []struct {
+		name          string
+		traits        []string
+		expectedError string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:   "traits of the host",
+			traits: []string{"CUSTOM_RAID", "HW_CPU_X86_VMX"},
+		},
+		{
+			name:          "invalid",
+			traits:        []string{"custom_raid"},
+			expectedError: "invalid trait \"custom_raid\", expected upper case letters, digits and underscores",
+		},
+		{
+			name:          "not a trait of the host",
+			traits:        []string{"CUSTOM_RAID", "CUSTOM_GPU"},
+			expectedError: "instance trait CUSTOM_GPU is not one of the traits of the host",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironicNode := &nodes.Node{Traits: []string{"CUSTOM_RAID", "HW_CPU_X86_VMX"}}
+			err := validateInstanceTraits(tc.traits, ironicNode)
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestProvisionInstanceTraits(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name         string
+		traits       []string
+		instanceInfo map[string]interface{}
+
+		expectedUpdate       *nodes.UpdateOperation
+		expectedErrorMessage string
+	}{
+		{
+			name:   "traits set",
+			traits: []string{"CUSTOM_RAID", "CUSTOM_BIOS"},
+			expectedUpdate: &nodes.UpdateOperation{
+				Op:    nodes.AddOp,
+				Path:  "/instance_info/traits",
+				Value: []interface{}{"CUSTOM_BIOS", "CUSTOM_RAID"},
+			},
+		},
+		{
+			name:         "traits removed",
+			instanceInfo: map[string]interface{}{"traits": []interface{}{"CUSTOM_RAID"}},
+			expectedUpdate: &nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/instance_info/traits",
+			},
+		},
+		{
+			name:                 "not a trait of the host",
+			traits:               []string{"CUSTOM_GPU"},
+			expectedErrorMessage: "instance trait CUSTOM_GPU is not one of the traits of the host",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			node := nodes.Node{
+				ProvisionState: string(nodes.Available),
+				UUID:           nodeUUID,
+				Traits:         []string{"CUSTOM_BIOS", "CUSTOM_RAID"},
+				InstanceInfo:   tc.instanceInfo,
+			}
+			ironic := testserver.NewIronic(t).WithDefaultResponses().Node(node).NodeUpdate(node).
+				WithNodeStatesProvisionUpdate(nodeUUID)
+			ironic.ResponseJSON("/v1/nodes/"+nodeUUID+"/validate", nodes.NodeValidation{
+				Boot:   nodes.DriverValidation{Result: true},
+				Deploy: nodes.DriverValidation{Result: true},
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Provision(provisioner.ProvisionData{
+				Image:          *host.Spec.Image,
+				HostConfig:     fixture.NewHostConfigData("testUserData", "test: NetworkData", "test: Meta"),
+				BootMode:       metal3v1alpha1.DefaultBootMode,
+				InstanceTraits: tc.traits,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedErrorMessage, result.ErrorMessage)
+			var update *nodes.UpdateOperation
+			for _, op := range ironic.GetLastNodeUpdateRequestFor(nodeUUID) {
+				if op.Path == "/instance_info/traits" {
+					op := op
+					update = &op
+				}
+			}
+			assert.Equal(t, tc.expectedUpdate, update)
+		})
+	}
+}
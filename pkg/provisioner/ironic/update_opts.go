@@ -3,6 +3,8 @@ package ironic
 import (
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/go-logr/logr"
 
@@ -107,9 +109,13 @@ func getUpdateOperation(name string, currentData map[string]interface{}, desired
 	return nil
 }
 
+// nodeUpdater accumulates the changes to a node, so that all the
+// changes needed by a reconcile are sent in a single update call. It
+// is safe for concurrent use.
 type nodeUpdater struct {
-	Updates nodes.UpdateOpts
+	updates nodes.UpdateOpts
 	log     logr.Logger
+	lock    sync.Mutex
 }
 
 func updateOptsBuilder(logger logr.Logger) *nodeUpdater {
@@ -129,8 +135,26 @@ func (nu *nodeUpdater) logger(basepath, option string) logr.Logger {
 	return log
 }
 
+// pathEscaper escapes option names for use in a JSON pointer
+var pathEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
 func (nu *nodeUpdater) path(basepath, option string) string {
-	return fmt.Sprintf("%s/%s", basepath, option)
+	return fmt.Sprintf("%s/%s", basepath, pathEscaper.Replace(option))
+}
+
+// addUpdate records an operation, replacing any previous operation on
+// the same path so that the last change requested wins.
+func (nu *nodeUpdater) addUpdate(updateOp nodes.UpdateOperation) {
+	nu.lock.Lock()
+	defer nu.lock.Unlock()
+
+	for i, existing := range nu.updates {
+		if op, ok := existing.(nodes.UpdateOperation); ok && op.Path == updateOp.Path {
+			nu.updates[i] = updateOp
+			return
+		}
+	}
+	nu.updates = append(nu.updates, updateOp)
 }
 
 func (nu *nodeUpdater) setSectionUpdateOpts(currentData map[string]interface{}, settings optionsData, basepath string) {
@@ -138,11 +162,18 @@ func (nu *nodeUpdater) setSectionUpdateOpts(currentData map[string]interface{},
 		updateOp := getUpdateOperation(name, currentData, desiredValue,
 			nu.path(basepath, name), nu.logger(basepath, name))
 		if updateOp != nil {
-			nu.Updates = append(nu.Updates, *updateOp)
+			nu.addUpdate(*updateOp)
 		}
 	}
 }
 
+// pendingUpdates returns a copy of the accumulated changes
+func (nu *nodeUpdater) pendingUpdates() nodes.UpdateOpts {
+	nu.lock.Lock()
+	defer nu.lock.Unlock()
+	return append(nodes.UpdateOpts{}, nu.updates...)
+}
+
 func (nu *nodeUpdater) SetTopLevelOpt(name string, desiredValue, currentValue interface{}) *nodeUpdater {
 	currentData := map[string]interface{}{name: currentValue}
 	desiredData := optionsData{name: desiredValue}
@@ -160,3 +191,13 @@ func (nu *nodeUpdater) SetInstanceInfoOpts(settings optionsData, node *nodes.Nod
 	nu.setSectionUpdateOpts(node.InstanceInfo, settings, "/instance_info")
 	return nu
 }
+
+func (nu *nodeUpdater) SetDriverInfoOpts(settings optionsData, node *nodes.Node) *nodeUpdater {
+	nu.setSectionUpdateOpts(node.DriverInfo, settings, "/driver_info")
+	return nu
+}
+
+func (nu *nodeUpdater) SetExtraOpts(settings optionsData, node *nodes.Node) *nodeUpdater {
+	nu.setSectionUpdateOpts(node.Extra, settings, "/extra")
+	return nu
+}
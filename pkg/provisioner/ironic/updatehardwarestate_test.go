@@ -1,13 +1,20 @@
 package ironic
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/allocations"
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	metal3v1alpha1 "github.com/metal3-io/baremetal-operator/apis/metal3.io/v1alpha1"
 	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
 )
@@ -24,6 +31,7 @@ func TestUpdateHardwareState(t *testing.T) {
 		hostName             string
 
 		expectUnreadablePower bool
+		expectConductorDown   bool
 
 		expectedPublish string
 		expectedError   string
@@ -76,6 +84,38 @@ func TestUpdateHardwareState(t *testing.T) {
 			hostCurrentlyPowered:  true,
 			expectUnreadablePower: true,
 		},
+		{
+			name: "no-power-conductor-alive",
+			ironic: testserver.NewIronic(t).Ready().NodeWithConductor(nodes.Node{
+				UUID:       nodeUUID,
+				PowerState: "None",
+			}, "conductor-0").Conductor("conductor-0", true),
+			hostCurrentlyPowered:  true,
+			expectUnreadablePower: true,
+		},
+		{
+			name: "no-power-conductor-down",
+			ironic: testserver.NewIronic(t).Ready().NodeWithConductor(nodes.Node{
+				UUID:       nodeUUID,
+				PowerState: "None",
+			}, "conductor-0").Conductor("conductor-0", false),
+			hostCurrentlyPowered:  true,
+			expectUnreadablePower: true,
+			expectedError:         "Conductor managing the host is down: conductor-0",
+			expectConductorDown:   true,
+		},
+		{
+			name: "no-power-no-conductor",
+			ironic: testserver.NewIronic(t).Ready().NodeWithConductor(nodes.Node{
+				UUID:           nodeUUID,
+				PowerState:     "None",
+				ConductorGroup: "rack-1",
+			}, ""),
+			hostCurrentlyPowered:  true,
+			expectUnreadablePower: true,
+			expectedError:         "Conductor managing the host is down: no conductor available in group \"rack-1\"",
+			expectConductorDown:   true,
+		},
 		{
 			name: "node-not-found",
 
@@ -148,7 +188,611 @@ func TestUpdateHardwareState(t *testing.T) {
 				assert.Error(t, err)
 				assert.Regexp(t, tc.expectedError, err.Error())
 			}
+			assert.Equal(t, tc.expectConductorDown, errors.Is(err, provisioner.ErrConductorDown))
+
+		})
+	}
+}
+
+func TestUpdateHardwareStateHistory(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	history := []map[string]interface{}{}
+	// Return the entries newest first to check the ordering
+	for i := 11; i >= 0; i-- {
+		history = append(history, map[string]interface{}{
+			"uuid":       fmt.Sprintf("event-%d", i),
+			"created_at": fmt.Sprintf("2021-03-01T10:%02d:00.123456+00:00", i),
+			"severity":   "ERROR",
+			"event_type": "power",
+			"event":      fmt.Sprintf("power failure %d", i),
+			"conductor":  "conductor-0",
+			"user":       "admin",
+		})
+	}
+
+	expected := []metal3v1alpha1.HistoryEvent{}
+	for i := 2; i < 12; i++ {
+		expected = append(expected, metal3v1alpha1.HistoryEvent{
+			Time:      metav1.NewTime(time.Date(2021, 3, 1, 10, i, 0, 0, time.UTC)),
+			Severity:  "ERROR",
+			EventType: "power",
+			Event:     fmt.Sprintf("power failure %d", i),
+		})
+	}
+
+	cases := []struct {
+		name            string
+		ironic          *testserver.IronicMock
+		expectedHistory []metal3v1alpha1.HistoryEvent
+	}{
+		{
+			name: "history",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:       nodeUUID,
+				PowerState: "power on",
+			}).NodeHistory(nodeUUID, history),
+			expectedHistory: expected,
+		},
+		{
+			name: "empty-history",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:       nodeUUID,
+				PowerState: "power on",
+			}).NodeHistory(nodeUUID, []map[string]interface{}{}),
+			expectedHistory: []metal3v1alpha1.HistoryEvent{},
+		},
+		{
+			name: "history-not-supported",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:       nodeUUID,
+				PowerState: "power on",
+			}),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.ironic.Start()
+			defer tc.ironic.Stop()
+			forgetNodeHistory(nodeUUID)
+			defer forgetNodeHistory(nodeUUID)
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				tc.ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			hwStatus, err := prov.UpdateHardwareState()
+			assert.NoError(t, err)
+			assert.Len(t, hwStatus.History, len(tc.expectedHistory))
+			for i, event := range tc.expectedHistory {
+				assert.True(t, event.Time.Equal(&hwStatus.History[i].Time), "unexpected time %s", hwStatus.History[i].Time)
+				event.Time = hwStatus.History[i].Time
+				assert.Equal(t, event, hwStatus.History[i])
+			}
+			if tc.expectedHistory == nil {
+				assert.Nil(t, hwStatus.History)
+			}
+		})
+	}
+}
+
+func TestHistoryReadNeeded(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	forgetNodeHistory(nodeUUID)
+	defer forgetNodeHistory(nodeUUID)
+	node := &nodes.Node{UUID: nodeUUID, ProvisionState: "active", PowerState: "power on"}
+	now := time.Now()
+
+	assert.True(t, historyReadNeeded(node, now))
+	// Nothing changed
+	assert.False(t, historyReadNeeded(node, now.Add(time.Minute)))
+	// The node changed
+	node.PowerState = "power off"
+	assert.True(t, historyReadNeeded(node, now.Add(time.Minute)))
+	node.LastError = "power failure"
+	assert.True(t, historyReadNeeded(node, now.Add(2*time.Minute)))
+	assert.False(t, historyReadNeeded(node, now.Add(3*time.Minute)))
+	// The history is read again after a while
+	assert.True(t, historyReadNeeded(node, now.Add(2*time.Minute+historyRefreshInterval)))
+
+	forgetNodeHistory(nodeUUID)
+	assert.True(t, historyReadNeeded(node, now.Add(2*time.Minute+historyRefreshInterval)))
+}
+
+func TestUpdateHardwareStateSerialConsole(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name            string
+		ironic          *testserver.IronicMock
+		expectedConsole *metal3v1alpha1.SerialConsole
+		expectedUnknown bool
+	}{
+		{
+			name: "socat",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				PowerState:     "power on",
+				ConsoleEnabled: true,
+			}).Console(nodeUUID, "socat", "tcp://192.0.2.10:8023"),
+			expectedConsole: &metal3v1alpha1.SerialConsole{
+				Host: "192.0.2.10",
+				Port: 8023,
+			},
+		},
+		{
+			name: "socat-ipv6",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				PowerState:     "power on",
+				ConsoleEnabled: true,
+			}).Console(nodeUUID, "socat", "tcp://[2001:db8::10]:8023"),
+			expectedConsole: &metal3v1alpha1.SerialConsole{
+				Host: "2001:db8::10",
+				Port: 8023,
+			},
+		},
+		{
+			name: "shellinabox",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				PowerState:     "power on",
+				ConsoleEnabled: true,
+			}).Console(nodeUUID, "shellinabox", "http://192.0.2.10:8023"),
+		},
+		{
+			name: "invalid-address",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				PowerState:     "power on",
+				ConsoleEnabled: true,
+			}).Console(nodeUUID, "socat", "tcp://192.0.2.10"),
+			expectedUnknown: true,
+		},
+		{
+			name: "read-error",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:           nodeUUID,
+				PowerState:     "power on",
+				ConsoleEnabled: true,
+			}),
+			expectedUnknown: true,
+		},
+		{
+			name: "console-disabled",
+			ironic: testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:       nodeUUID,
+				PowerState: "power on",
+			}),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.ironic.Start()
+			defer tc.ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				tc.ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			hwStatus, err := prov.UpdateHardwareState()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedConsole, hwStatus.SerialConsole)
+			assert.Equal(t, tc.expectedUnknown, hwStatus.SerialConsoleUnknown)
+		})
+	}
+}
+
+func TestUpdateHardwareStateAllocation(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	allocationUUID := "0b1f5a3c-9d6e-4c7a-8f2b-3e4d5c6b7a89"
+	node := nodes.Node{
+		UUID:       nodeUUID,
+		PowerState: "power on",
+	}
+
+	cases := []struct {
+		name               string
+		ironic             *testserver.IronicMock
+		expectedAllocation *metal3v1alpha1.AllocationStatus
+		expectedUnknown    bool
+	}{
+		{
+			name: "allocated",
+			ironic: testserver.NewIronic(t).Ready().NodeWithAllocation(node, allocationUUID).Allocation(allocations.Allocation{
+				UUID:     allocationUUID,
+				Name:     "worker-0",
+				State:    "active",
+				NodeUUID: nodeUUID,
+				Traits:   []string{"CUSTOM_GPU"},
+			}),
+			expectedAllocation: &metal3v1alpha1.AllocationStatus{
+				UUID:   allocationUUID,
+				Name:   "worker-0",
+				State:  "active",
+				Traits: []string{"CUSTOM_GPU"},
+			},
+		},
+		{
+			name: "allocation failed",
+			ironic: testserver.NewIronic(t).Ready().NodeWithAllocation(node, allocationUUID).Allocation(allocations.Allocation{
+				UUID:      allocationUUID,
+				State:     "error",
+				NodeUUID:  nodeUUID,
+				LastError: "No available nodes match the resource class baremetal",
+			}),
+			expectedAllocation: &metal3v1alpha1.AllocationStatus{
+				UUID:      allocationUUID,
+				State:     "error",
+				LastError: "No available nodes match the resource class baremetal",
+			},
+		},
+		{
+			name:            "allocation not readable",
+			ironic:          testserver.NewIronic(t).Ready().NodeWithAllocation(node, allocationUUID),
+			expectedUnknown: true,
+		},
+		{
+			name:   "not allocated",
+			ironic: testserver.NewIronic(t).Ready().Node(node),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.ironic.Start()
+			defer tc.ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				tc.ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			hwStatus, err := prov.UpdateHardwareState()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedAllocation, hwStatus.Allocation)
+			assert.Equal(t, tc.expectedUnknown, hwStatus.AllocationUnknown)
+		})
+	}
+}
+
+func TestUpdateHardwareStateScheduling(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name               string
+		node               nodes.Node
+		expectedScheduling *metal3v1alpha1.SchedulingStatus
+	}{
+		{
+			name: "resource class and traits",
+			node: nodes.Node{
+				UUID:          nodeUUID,
+				PowerState:    "power on",
+				ResourceClass: "baremetal-gpu",
+				Traits:        []string{"CUSTOM_GPU", "CUSTOM_RACK_1"},
+			},
+			expectedScheduling: &metal3v1alpha1.SchedulingStatus{
+				ResourceClass: "baremetal-gpu",
+				Traits:        []string{"CUSTOM_GPU", "CUSTOM_RACK_1"},
+			},
+		},
+		{
+			name: "unsorted traits",
+			node: nodes.Node{
+				UUID:       nodeUUID,
+				PowerState: "power on",
+				Traits:     []string{"CUSTOM_RACK_1", "CUSTOM_GPU"},
+			},
+			expectedScheduling: &metal3v1alpha1.SchedulingStatus{
+				Traits: []string{"CUSTOM_GPU", "CUSTOM_RACK_1"},
+			},
+		},
+		{
+			name: "resource class only",
+			node: nodes.Node{
+				UUID:          nodeUUID,
+				PowerState:    "power on",
+				ResourceClass: "baremetal",
+			},
+			expectedScheduling: &metal3v1alpha1.SchedulingStatus{
+				ResourceClass: "baremetal",
+			},
+		},
+		{
+			name: "nothing set",
+			node: nodes.Node{
+				UUID:       nodeUUID,
+				PowerState: "power on",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().Node(tc.node)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			hwStatus, err := prov.UpdateHardwareState()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedScheduling, hwStatus.Scheduling)
+		})
+	}
+}
+
+func TestUpdateHardwareStateConductor(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name              string
+		node              nodes.Node
+		conductor         string
+		reported          bool
+		expectedConductor *metal3v1alpha1.ConductorStatus
+	}{
+		{
+			name: "managed and locked",
+			node: nodes.Node{
+				UUID:           nodeUUID,
+				PowerState:     "power on",
+				ConductorGroup: "rack-1",
+				Reservation:    "conductor-1",
+			},
+			conductor: "conductor-0",
+			reported:  true,
+			expectedConductor: &metal3v1alpha1.ConductorStatus{
+				Name:        "conductor-0",
+				Group:       "rack-1",
+				Reservation: "conductor-1",
+			},
+		},
+		{
+			name: "managed",
+			node: nodes.Node{
+				UUID:       nodeUUID,
+				PowerState: "power on",
+			},
+			conductor: "conductor-0",
+			reported:  true,
+			expectedConductor: &metal3v1alpha1.ConductorStatus{
+				Name: "conductor-0",
+			},
+		},
+		{
+			name: "no live conductor",
+			node: nodes.Node{
+				UUID:           nodeUUID,
+				PowerState:     "power on",
+				ConductorGroup: "rack-1",
+			},
+			reported: true,
+			expectedConductor: &metal3v1alpha1.ConductorStatus{
+				Group: "rack-1",
+			},
+		},
+		{
+			name: "not reported",
+			node: nodes.Node{
+				UUID:       nodeUUID,
+				PowerState: "power on",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready()
+			if tc.reported {
+				ironic.NodeWithConductor(tc.node, tc.conductor)
+			} else {
+				ironic.Node(tc.node)
+			}
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			hwStatus, err := prov.UpdateHardwareState()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedConductor, hwStatus.Conductor)
+		})
+	}
+}
+
+func TestUpdateHardwareStateLastInspected(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	node := nodes.Node{
+		UUID:       nodeUUID,
+		PowerState: "power on",
+	}
+	finished := metav1.NewTime(time.Date(2021, 5, 12, 10, 23, 45, 0, time.UTC))
+
+	cases := []struct {
+		name      string
+		timestamp string
+		expected  *metav1.Time
+	}{
+		{
+			name:      "inspected",
+			timestamp: "2021-05-12T10:23:45+00:00",
+			expected:  &finished,
+		},
+		{
+			name:      "fractional seconds",
+			timestamp: "2021-05-12T10:23:45.654321+00:00",
+			expected:  &finished,
+		},
+		{
+			name: "never inspected",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().NodeWithInspectionFinishedAt(node, tc.timestamp)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
 
+			hwStatus, err := prov.UpdateHardwareState()
+			assert.NoError(t, err)
+			if tc.expected == nil {
+				assert.Nil(t, hwStatus.LastInspected)
+			} else if assert.NotNil(t, hwStatus.LastInspected) {
+				assert.True(t, tc.expected.Equal(hwStatus.LastInspected), hwStatus.LastInspected)
+			}
+		})
+	}
+}
+
+func TestUpdateHardwareStateNodeCreatedAt(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	node := nodes.Node{
+		UUID:       nodeUUID,
+		PowerState: "power on",
+	}
+	created := metav1.NewTime(time.Date(2021, 5, 12, 10, 23, 45, 0, time.UTC))
+
+	cases := []struct {
+		name      string
+		createdAt string
+		expected  *metav1.Time
+	}{
+		{
+			name:      "created",
+			createdAt: "2021-05-12T10:23:45.123456+00:00",
+			expected:  &created,
+		},
+		{
+			name: "not reported",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().NodeWithCreatedAt(node, tc.createdAt)
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			hwStatus, err := prov.UpdateHardwareState()
+			assert.NoError(t, err)
+			if tc.expected == nil {
+				assert.Nil(t, hwStatus.NodeCreatedAt)
+			} else if assert.NotNil(t, hwStatus.NodeCreatedAt) {
+				assert.True(t, tc.expected.Equal(hwStatus.NodeCreatedAt), hwStatus.NodeCreatedAt)
+			}
+		})
+	}
+}
+
+func TestUpdateHardwareStateBootInterface(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+
+	cases := []struct {
+		name          string
+		bootInterface string
+	}{
+		{
+			name:          "network boot",
+			bootInterface: "ipxe",
+		},
+		{
+			name:          "virtual media",
+			bootInterface: "redfish-virtual-media",
+		},
+		{
+			name: "not reported",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:          nodeUUID,
+				PowerState:    "power on",
+				BootInterface: tc.bootInterface,
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Status.Provisioning.ID = nodeUUID
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			hwStatus, err := prov.UpdateHardwareState()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.bootInterface, hwStatus.BootInterface)
 		})
 	}
 }
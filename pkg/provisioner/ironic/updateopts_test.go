@@ -2,6 +2,9 @@ package ironic
 
 import (
 	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
@@ -15,6 +18,7 @@ import (
 	"github.com/metal3-io/baremetal-operator/pkg/hardware"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
 	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
 )
 
 func TestOptionValueEqual(t *testing.T) {
@@ -394,7 +398,7 @@ func TestGetUpdateOperation(t *testing.T) {
 func TestTopLevelUpdateOpt(t *testing.T) {
 	u := updateOptsBuilder(log)
 	u.SetTopLevelOpt("foo", "baz", "bar")
-	ops := u.Updates
+	ops := u.updates
 	assert.Len(t, ops, 1)
 	op := ops[0].(nodes.UpdateOperation)
 	assert.Equal(t, nodes.AddOp, op.Op)
@@ -403,7 +407,7 @@ func TestTopLevelUpdateOpt(t *testing.T) {
 
 	u = updateOptsBuilder(log)
 	u.SetTopLevelOpt("foo", "bar", "bar")
-	assert.Len(t, u.Updates, 0)
+	assert.Len(t, u.updates, 0)
 }
 
 func TestPropertiesUpdateOpts(t *testing.T) {
@@ -419,7 +423,7 @@ func TestPropertiesUpdateOpts(t *testing.T) {
 
 	u := updateOptsBuilder(log)
 	u.SetPropertiesOpts(newValues, &node)
-	ops := u.Updates
+	ops := u.updates
 	assert.Len(t, ops, 1)
 	op := ops[0].(nodes.UpdateOperation)
 	assert.Equal(t, nodes.AddOp, op.Op)
@@ -440,7 +444,7 @@ func TestInstanceInfoUpdateOpts(t *testing.T) {
 
 	u := updateOptsBuilder(log)
 	u.SetInstanceInfoOpts(newValues, &node)
-	ops := u.Updates
+	ops := u.updates
 	assert.Len(t, ops, 1)
 	op := ops[0].(nodes.UpdateOperation)
 	assert.Equal(t, nodes.AddOp, op.Op)
@@ -448,6 +452,72 @@ func TestInstanceInfoUpdateOpts(t *testing.T) {
 	assert.Equal(t, "/instance_info/baz", op.Path)
 }
 
+func TestNodeUpdaterSamePath(t *testing.T) {
+	node := nodes.Node{
+		Extra: map[string]interface{}{"foo": "bar"},
+	}
+
+	u := updateOptsBuilder(log)
+	u.SetExtraOpts(optionsData{"foo": "baz"}, &node)
+	u.SetExtraOpts(optionsData{"foo": nil}, &node)
+
+	assert.Equal(t, nodes.UpdateOpts{
+		nodes.UpdateOperation{Op: nodes.RemoveOp, Path: "/extra/foo"},
+	}, u.updates)
+}
+
+func TestNodeUpdaterConcurrent(t *testing.T) {
+	node := nodes.Node{}
+	u := updateOptsBuilder(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			u.SetExtraOpts(optionsData{fmt.Sprintf("key%d", i): i}, &node)
+			u.SetPropertiesOpts(optionsData{fmt.Sprintf("key%d", i): i}, &node)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, u.updates, 40)
+}
+
+func TestTryUpdateNodeBatched(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	ironicNode := nodes.Node{UUID: nodeUUID}
+	ironic := testserver.NewIronic(t).Ready().Node(ironicNode).NodeUpdate(ironicNode)
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Status.Provisioning.ID = nodeUUID
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	updater := updateOptsBuilder(log).
+		SetTopLevelOpt("automated_clean", true, nil).
+		SetPropertiesOpts(optionsData{"cpu_arch": "x86_64"}, &ironicNode).
+		SetExtraOpts(optionsData{"foo": "bar"}, &ironicNode)
+
+	success, result, err := prov.tryUpdateNode(&ironicNode, updater)
+	assert.NoError(t, err)
+	assert.True(t, success)
+	assert.Equal(t, "", result.ErrorMessage)
+	assert.Equal(t, 1, ironic.CountRequestsFor("/v1/nodes/"+nodeUUID, http.MethodPatch))
+	assert.ElementsMatch(t, []nodes.UpdateOperation{
+		{Op: nodes.AddOp, Path: "/automated_clean", Value: true},
+		{Op: nodes.AddOp, Path: "/properties/cpu_arch", Value: "x86_64"},
+		{Op: nodes.AddOp, Path: "/extra/foo", Value: "bar"},
+	}, ironic.GetLastNodeUpdateRequestFor(nodeUUID))
+}
+
 func TestGetUpdateOptsForNodeWithRootHints(t *testing.T) {
 
 	eventPublisher := func(reason, message string) {}
@@ -467,7 +537,7 @@ func TestGetUpdateOptsForNodeWithRootHints(t *testing.T) {
 		BootMode:        metal3v1alpha1.DefaultBootMode,
 		RootDeviceHints: host.Status.Provisioning.RootDeviceHints,
 	}
-	patches := prov.getUpdateOptsForNode(ironicNode, provData).Updates
+	patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
 
 	t.Logf("patches: %v", patches)
 
@@ -563,7 +633,7 @@ func TestGetUpdateOptsForNodeVirtual(t *testing.T) {
 		BootMode:        metal3v1alpha1.DefaultBootMode,
 		HardwareProfile: hwProf,
 	}
-	patches := prov.getUpdateOptsForNode(ironicNode, provData).Updates
+	patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
 
 	t.Logf("patches: %v", patches)
 
@@ -626,6 +696,476 @@ func TestGetUpdateOptsForNodeVirtual(t *testing.T) {
 	}
 }
 
+func TestGetUpdateOptsForNodeOCI(t *testing.T) {
+	host := makeHost()
+	host.Spec.Image = &metal3v1alpha1.Image{
+		URL: "oci://quay.io/example/image@sha256:8f3e1cd3e5a6e4d6c0dfd6a4b2f1c1a0e9d8c7b6a5f4e3d2c1b0a9f8e7d6c5b4",
+	}
+
+	eventPublisher := func(reason, message string) {}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+		"https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "could not create provisioner"))
+	}
+	ironicNode := &nodes.Node{
+		InstanceInfo: map[string]interface{}{
+			"image_source":        "http://example.com/image.qcow2",
+			"image_os_hash_algo":  "sha256",
+			"image_os_hash_value": "checksum",
+		},
+	}
+
+	hwProf, _ := hardware.GetProfile("libvirt")
+	provData := provisioner.ProvisionData{
+		Image:           *host.Spec.Image,
+		BootMode:        metal3v1alpha1.DefaultBootMode,
+		HardwareProfile: hwProf,
+	}
+	patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
+
+	instanceInfo := map[string]nodes.UpdateOperation{}
+	for _, patch := range patches {
+		update := patch.(nodes.UpdateOperation)
+		if strings.HasPrefix(update.Path, "/instance_info/") {
+			instanceInfo[strings.TrimPrefix(update.Path, "/instance_info/")] = update
+		}
+	}
+
+	assert.Equal(t, nodes.UpdateOperation{
+		Op:    nodes.AddOp,
+		Path:  "/instance_info/image_source",
+		Value: host.Spec.Image.URL,
+	}, instanceInfo["image_source"])
+	assert.Equal(t, nodes.RemoveOp, instanceInfo["image_os_hash_algo"].Op)
+	assert.Equal(t, nodes.RemoveOp, instanceInfo["image_os_hash_value"].Op)
+	assert.NotContains(t, instanceInfo, "image_checksum")
+	assert.NotContains(t, instanceInfo, "image_disk_format")
+}
+
+func TestGetUpdateOptsForNodeDownloadSource(t *testing.T) {
+	cases := []struct {
+		name           string
+		downloadSource metal3v1alpha1.ImageDownloadSource
+		current        interface{}
+		expected       *nodes.UpdateOperation
+	}{
+		{
+			name:           "set",
+			downloadSource: metal3v1alpha1.ImageDownloadSourceLocal,
+			expected: &nodes.UpdateOperation{
+				Op:    nodes.AddOp,
+				Path:  "/instance_info/image_download_source",
+				Value: "local",
+			},
+		},
+		{
+			name:           "changed",
+			downloadSource: metal3v1alpha1.ImageDownloadSourceHTTP,
+			current:        "swift",
+			expected: &nodes.UpdateOperation{
+				Op:    nodes.AddOp,
+				Path:  "/instance_info/image_download_source",
+				Value: "http",
+			},
+		},
+		{
+			name:           "unchanged",
+			downloadSource: metal3v1alpha1.ImageDownloadSourceHTTP,
+			current:        "http",
+		},
+		{
+			name:    "removed",
+			current: "local",
+			expected: &nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/instance_info/image_download_source",
+			},
+		},
+		{
+			name: "not set",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.Image = &metal3v1alpha1.Image{
+				URL:            "http://example.com/image.qcow2",
+				Checksum:       "http://example.com/image.qcow2.md5sum",
+				DownloadSource: tc.downloadSource,
+			}
+
+			eventPublisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+				"https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatal(errors.Wrap(err, "could not create provisioner"))
+			}
+			ironicNode := &nodes.Node{InstanceInfo: map[string]interface{}{}}
+			if tc.current != nil {
+				ironicNode.InstanceInfo["image_download_source"] = tc.current
+			}
+
+			hwProf, _ := hardware.GetProfile("libvirt")
+			provData := provisioner.ProvisionData{
+				Image:           *host.Spec.Image,
+				BootMode:        metal3v1alpha1.DefaultBootMode,
+				HardwareProfile: hwProf,
+			}
+			patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
+
+			var actual *nodes.UpdateOperation
+			for _, patch := range patches {
+				update := patch.(nodes.UpdateOperation)
+				if update.Path == "/instance_info/image_download_source" {
+					actual = &update
+				}
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestGetUpdateOptsForNodeRootPartitionSize(t *testing.T) {
+	rootPartitionSize := 40
+	cases := []struct {
+		name              string
+		rootPartitionSize *int
+		current           map[string]interface{}
+		expectedRootGB    *nodes.UpdateOperation
+		expectedImageType *nodes.UpdateOperation
+	}{
+		{
+			name:              "set",
+			rootPartitionSize: &rootPartitionSize,
+			current:           map[string]interface{}{},
+			expectedRootGB: &nodes.UpdateOperation{
+				Op:    nodes.AddOp,
+				Path:  "/instance_info/root_gb",
+				Value: 40,
+			},
+			expectedImageType: &nodes.UpdateOperation{
+				Op:    nodes.AddOp,
+				Path:  "/instance_info/image_type",
+				Value: "partition",
+			},
+		},
+		{
+			name:              "unchanged",
+			rootPartitionSize: &rootPartitionSize,
+			current:           map[string]interface{}{"root_gb": 40, "image_type": "partition"},
+		},
+		{
+			name:    "removed",
+			current: map[string]interface{}{"root_gb": 40, "image_type": "partition"},
+			expectedRootGB: &nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/instance_info/root_gb",
+			},
+			expectedImageType: &nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/instance_info/image_type",
+			},
+		},
+		{
+			name:    "not set",
+			current: map[string]interface{}{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.Image = &metal3v1alpha1.Image{
+				URL:                        "http://example.com/image.qcow2",
+				Checksum:                   "http://example.com/image.qcow2.md5sum",
+				RootPartitionSizeGibibytes: tc.rootPartitionSize,
+			}
+
+			eventPublisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+				"https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatal(errors.Wrap(err, "could not create provisioner"))
+			}
+			ironicNode := &nodes.Node{InstanceInfo: tc.current}
+
+			hwProf, _ := hardware.GetProfile("libvirt")
+			provData := provisioner.ProvisionData{
+				Image:           *host.Spec.Image,
+				BootMode:        metal3v1alpha1.DefaultBootMode,
+				HardwareProfile: hwProf,
+			}
+			patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
+
+			var rootGB, imageType *nodes.UpdateOperation
+			for _, patch := range patches {
+				update := patch.(nodes.UpdateOperation)
+				switch update.Path {
+				case "/instance_info/root_gb":
+					rootGB = &update
+				case "/instance_info/image_type":
+					imageType = &update
+				}
+			}
+			assert.Equal(t, tc.expectedRootGB, rootGB)
+			assert.Equal(t, tc.expectedImageType, imageType)
+		})
+	}
+}
+
+func TestGetUpdateOptsForNodeImageKind(t *testing.T) {
+	rootPartitionSize := 40
+	cases := []struct {
+		name     string
+		image    metal3v1alpha1.Image
+		current  map[string]interface{}
+		expected map[string]interface{}
+	}{
+		{
+			name: "whole disk",
+			image: metal3v1alpha1.Image{
+				Kind: metal3v1alpha1.ImageKindWholeDisk,
+			},
+			current: map[string]interface{}{"kernel": "http://example.com/vmlinuz", "ramdisk": "http://example.com/initrd"},
+			expected: map[string]interface{}{
+				"image_type": "whole-disk",
+				"kernel":     nil,
+				"ramdisk":    nil,
+			},
+		},
+		{
+			name: "partition",
+			image: metal3v1alpha1.Image{
+				Kind:                       metal3v1alpha1.ImageKindPartition,
+				RootPartitionSizeGibibytes: &rootPartitionSize,
+				KernelURL:                  "http://example.com/vmlinuz",
+				RamdiskURL:                 "http://example.com/initrd",
+			},
+			current: map[string]interface{}{"image_type": "whole-disk"},
+			expected: map[string]interface{}{
+				"image_type": "partition",
+				"root_gb":    40,
+				"kernel":     "http://example.com/vmlinuz",
+				"ramdisk":    "http://example.com/initrd",
+			},
+		},
+		{
+			name: "detected partition",
+			image: metal3v1alpha1.Image{
+				RootPartitionSizeGibibytes: &rootPartitionSize,
+				KernelURL:                  "http://example.com/vmlinuz",
+				RamdiskURL:                 "http://example.com/initrd",
+			},
+			current: map[string]interface{}{},
+			expected: map[string]interface{}{
+				"image_type": "partition",
+				"root_gb":    40,
+				"kernel":     "http://example.com/vmlinuz",
+				"ramdisk":    "http://example.com/initrd",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			image := tc.image
+			image.URL = "http://example.com/image.qcow2"
+			image.Checksum = "http://example.com/image.qcow2.md5sum"
+			host.Spec.Image = &image
+
+			eventPublisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+				"https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatal(errors.Wrap(err, "could not create provisioner"))
+			}
+			ironicNode := &nodes.Node{InstanceInfo: tc.current}
+
+			hwProf, _ := hardware.GetProfile("libvirt")
+			provData := provisioner.ProvisionData{
+				Image:           *host.Spec.Image,
+				BootMode:        metal3v1alpha1.DefaultBootMode,
+				HardwareProfile: hwProf,
+			}
+			patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
+
+			actual := map[string]interface{}{}
+			for _, patch := range patches {
+				update := patch.(nodes.UpdateOperation)
+				switch update.Path {
+				case "/instance_info/root_gb", "/instance_info/image_type",
+					"/instance_info/kernel", "/instance_info/ramdisk":
+					actual[strings.TrimPrefix(update.Path, "/instance_info/")] = update.Value
+				}
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestGetUpdateOptsForNodeDeploymentID(t *testing.T) {
+	cases := []struct {
+		name         string
+		deploymentID string
+		current      interface{}
+		expected     *nodes.UpdateOperation
+	}{
+		{
+			name:         "set on deploy",
+			deploymentID: "machines/worker-0",
+			expected: &nodes.UpdateOperation{
+				Op:    nodes.AddOp,
+				Path:  "/instance_info/display_name",
+				Value: "machines/worker-0",
+			},
+		},
+		{
+			name:         "unchanged",
+			deploymentID: "machines/worker-0",
+			current:      "machines/worker-0",
+		},
+		{
+			name:    "removed without consumer",
+			current: "machines/worker-0",
+			expected: &nodes.UpdateOperation{
+				Op:   nodes.RemoveOp,
+				Path: "/instance_info/display_name",
+			},
+		},
+		{
+			name: "not set",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+
+			eventPublisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+				"https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatal(errors.Wrap(err, "could not create provisioner"))
+			}
+			ironicNode := &nodes.Node{InstanceInfo: map[string]interface{}{}}
+			if tc.current != nil {
+				ironicNode.InstanceInfo["display_name"] = tc.current
+			}
+
+			hwProf, _ := hardware.GetProfile("libvirt")
+			provData := provisioner.ProvisionData{
+				Image:           *host.Spec.Image,
+				BootMode:        metal3v1alpha1.DefaultBootMode,
+				HardwareProfile: hwProf,
+				DeploymentID:    tc.deploymentID,
+			}
+			patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
+
+			var actual *nodes.UpdateOperation
+			for _, patch := range patches {
+				update := patch.(nodes.UpdateOperation)
+				if update.Path == "/instance_info/display_name" {
+					actual = &update
+				}
+			}
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestGetUpdateOptsForNodeImageMirror(t *testing.T) {
+	image := metal3v1alpha1.Image{
+		URL:          "http://primary.test/image.qcow2",
+		Checksum:     "aaaa",
+		ChecksumType: metal3v1alpha1.SHA256,
+		Mirrors: []metal3v1alpha1.ImageMirror{
+			{URL: "http://mirror.test/image.qcow2.gz", Checksum: "bbbb", ChecksumType: metal3v1alpha1.SHA256},
+		},
+	}
+	cases := []struct {
+		name           string
+		currentSource  string
+		currentHash    string
+		expectedSource interface{}
+	}{
+		{
+			name:           "new image",
+			currentSource:  "http://old.test/image.qcow2",
+			currentHash:    "cccc",
+			expectedSource: "http://primary.test/image.qcow2",
+		},
+		{
+			name:          "deploying from the primary location",
+			currentSource: "http://primary.test/image.qcow2",
+			currentHash:   "aaaa",
+		},
+		{
+			name:          "deploying from a mirror",
+			currentSource: "http://mirror.test/image.qcow2.gz",
+			currentHash:   "bbbb",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.Image = &image
+
+			eventPublisher := func(reason, message string) {}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+				"https://ironic.test", auth, "https://ironic.test", auth,
+			)
+			if err != nil {
+				t.Fatal(errors.Wrap(err, "could not create provisioner"))
+			}
+			ironicNode := &nodes.Node{
+				InstanceInfo: map[string]interface{}{
+					"image_source":        tc.currentSource,
+					"image_os_hash_algo":  "sha256",
+					"image_os_hash_value": tc.currentHash,
+				},
+			}
+
+			hwProf, _ := hardware.GetProfile("libvirt")
+			provData := provisioner.ProvisionData{
+				Image:           image,
+				BootMode:        metal3v1alpha1.DefaultBootMode,
+				HardwareProfile: hwProf,
+			}
+			patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
+
+			var actualSource interface{}
+			for _, patch := range patches {
+				update := patch.(nodes.UpdateOperation)
+				if update.Path == "/instance_info/image_source" {
+					actualSource = update.Value
+				}
+			}
+			assert.Equal(t, tc.expectedSource, actualSource)
+		})
+	}
+}
+
 func TestGetUpdateOptsForNodeDell(t *testing.T) {
 	host := metal3v1alpha1.BareMetalHost{
 		ObjectMeta: metav1.ObjectMeta{
@@ -670,7 +1210,7 @@ func TestGetUpdateOptsForNodeDell(t *testing.T) {
 		BootMode:        metal3v1alpha1.DefaultBootMode,
 		HardwareProfile: hwProf,
 	}
-	patches := prov.getUpdateOptsForNode(ironicNode, provData).Updates
+	patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
 
 	t.Logf("patches: %v", patches)
 
@@ -742,7 +1282,7 @@ func TestGetUpdateOptsForNodeLiveIso(t *testing.T) {
 		Image:    *host.Spec.Image,
 		BootMode: metal3v1alpha1.DefaultBootMode,
 	}
-	patches := prov.getUpdateOptsForNode(ironicNode, provData).Updates
+	patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
 
 	t.Logf("patches: %v", patches)
 
@@ -811,7 +1351,7 @@ func TestGetUpdateOptsForNodeImageToLiveIso(t *testing.T) {
 		Image:    *host.Spec.Image,
 		BootMode: metal3v1alpha1.DefaultBootMode,
 	}
-	patches := prov.getUpdateOptsForNode(ironicNode, provData).Updates
+	patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
 
 	t.Logf("patches: %v", patches)
 
@@ -890,7 +1430,7 @@ func TestGetUpdateOptsForNodeLiveIsoToImage(t *testing.T) {
 		Image:    *host.Spec.Image,
 		BootMode: metal3v1alpha1.DefaultBootMode,
 	}
-	patches := prov.getUpdateOptsForNode(ironicNode, provData).Updates
+	patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
 
 	t.Logf("patches: %v", patches)
 
@@ -992,7 +1532,97 @@ func TestGetUpdateOptsForNodeSecureBoot(t *testing.T) {
 		BootMode:        metal3v1alpha1.UEFISecureBoot,
 		HardwareProfile: hwProf,
 	}
-	patches := prov.getUpdateOptsForNode(ironicNode, provData).Updates
+	patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
+
+	t.Logf("patches: %v", patches)
+
+	expected := []struct {
+		Path  string      // the node property path
+		Key   string      // if value is a map, the key we care about
+		Value interface{} // the value being passed to ironic (or value associated with the key)
+	}{
+		{
+			Path:  "/instance_info/image_source",
+			Value: "not-empty",
+		},
+		{
+			Path: "/instance_info/capabilities",
+			Value: map[string]string{
+				"secure_boot": "true",
+			},
+		},
+	}
+
+	for _, e := range expected {
+		t.Run(e.Path, func(t *testing.T) {
+			t.Logf("expected: %v", e)
+			var update nodes.UpdateOperation
+			for _, patch := range patches {
+				update = patch.(nodes.UpdateOperation)
+				if update.Path == e.Path {
+					break
+				}
+			}
+			if update.Path != e.Path {
+				t.Errorf("did not find %q in updates", e.Path)
+				return
+			}
+			t.Logf("update: %v", update)
+			assert.Equal(t, e.Value, update.Value, fmt.Sprintf("%s does not match", e.Path))
+		})
+	}
+}
+
+func TestGetUpdateOptsForNodeInstanceCapabilities(t *testing.T) {
+	host := metal3v1alpha1.BareMetalHost{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myhost",
+			Namespace: "myns",
+			UID:       "27720611-e5d1-45d3-ba3a-222dcfaa4ca2",
+		},
+		Spec: metal3v1alpha1.BareMetalHostSpec{
+			BMC: metal3v1alpha1.BMCDetails{
+				Address: "test://test.bmc/",
+			},
+			Image: &metal3v1alpha1.Image{
+				URL:          "not-empty",
+				Checksum:     "checksum",
+				ChecksumType: metal3v1alpha1.MD5,
+				DiskFormat:   pointer.StringPtr("raw"),
+			},
+			Online:          true,
+			HardwareProfile: "unknown",
+		},
+		Status: metal3v1alpha1.BareMetalHostStatus{
+			HardwareProfile: "libvirt",
+			Provisioning: metal3v1alpha1.ProvisionStatus{
+				ID: "provisioning-id",
+			},
+		},
+	}
+
+	eventPublisher := func(reason, message string) {}
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, eventPublisher,
+		"https://ironic.test", auth, "https://ironic.test", auth,
+	)
+	if err != nil {
+		t.Fatal(errors.Wrap(err, "could not create provisioner"))
+	}
+	ironicNode := &nodes.Node{}
+
+	hwProf, _ := hardware.GetProfile("libvirt")
+	provData := provisioner.ProvisionData{
+		Image:           *host.Spec.Image,
+		BootMode:        metal3v1alpha1.UEFISecureBoot,
+		HardwareProfile: hwProf,
+		InstanceCapabilities: map[string]string{
+			"hugepages":   "1G",
+			"cpu_pinning": "true",
+		},
+	}
+	patches := prov.getUpdateOptsForNode(ironicNode, provData).updates
 
 	t.Logf("patches: %v", patches)
 
@@ -1008,6 +1638,8 @@ func TestGetUpdateOptsForNodeSecureBoot(t *testing.T) {
 		{
 			Path: "/instance_info/capabilities",
 			Value: map[string]string{
+				"hugepages":   "1G",
+				"cpu_pinning": "true",
 				"secure_boot": "true",
 			},
 		},
@@ -2,6 +2,7 @@ package ironic
 
 import (
 	"net/http"
+	"sort"
 	"testing"
 
 	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
@@ -60,7 +61,7 @@ func TestValidateManagementAccessMACOptional(t *testing.T) {
 	defer ironic.Stop()
 
 	auth := clients.AuthConfig{Type: clients.NoAuth}
-	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nil,
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
 		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
 	)
 	if err != nil {
@@ -111,6 +112,180 @@ func TestValidateManagementAccessCreateNodeNoImage(t *testing.T) {
 	assert.Equal(t, createdNode.DeployInterface, "direct")
 }
 
+func TestValidateManagementAccessNodeDeleted(t *testing.T) {
+	// The host was registered before, but the node has been deleted
+	// from Ironic directly.
+	host := makeHost()
+	host.Spec.BootMACAddress = "11:11:11:11:11:11"
+	host.Status.Provisioning.ID = "deleted-uuid"
+
+	var createdNode *nodes.Node
+
+	createCallback := func(node nodes.Node) {
+		createdNode = &node
+	}
+
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).NoNode("deleted-uuid").
+		NoNode(host.Namespace + nameSeparator + host.Name).NoNode(host.Name)
+	ironic.AddDefaultResponse("/v1/nodes/node-0", "PATCH", http.StatusOK, "{}")
+	ironic.AddDefaultResponse("/v1/ports", "GET", http.StatusOK, `{"ports": []}`)
+	ironic.AddDefaultResponse("/v1/ports", "POST", http.StatusCreated, "{}")
+	ironic.Start()
+	defer ironic.Stop()
+
+	events := []string{}
+	publisher := func(reason, message string) {
+		events = append(events, reason+" "+message)
+	}
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, provID, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	assert.NotNil(t, createdNode)
+	assert.Equal(t, createdNode.UUID, provID)
+	assert.NotEqual(t, "deleted-uuid", provID)
+	assert.Equal(t, "test.bmc", createdNode.DriverInfo["test_address"])
+	assert.Equal(t, []string{"Registered Registered host again, node deleted-uuid was not found"}, events)
+
+	// The port for the boot MAC address is restored
+	portRequest, found := ironic.GetLastRequestFor("/v1/ports", http.MethodPost)
+	assert.True(t, found)
+	assert.Contains(t, portRequest, host.Spec.BootMACAddress)
+	assert.Contains(t, portRequest, provID)
+}
+
+func TestValidateManagementAccessCreateWithManagementInterface(t *testing.T) {
+	host := makeHost()
+	host.Spec.BootMACAddress = ""
+	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
+
+	var createdNode *nodes.Node
+
+	createCallback := func(node nodes.Node) {
+		createdNode = &node
+	}
+
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).NoNode(host.Namespace+nameSeparator+host.Name).NoNode(host.Name).
+		DriverDetails("test", []string{"fake", "noop"})
+	ironic.AddDefaultResponse("/v1/nodes/node-0", "PATCH", http.StatusOK, "{}")
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{ManagementInterface: "noop"}, false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	assert.Equal(t, "noop", createdNode.ManagementInterface)
+}
+
+func TestValidateManagementAccessManagementInterface(t *testing.T) {
+	clean := true
+	cases := []struct {
+		name                string
+		managementInterface string
+		provisionState      nodes.ProvisionState
+		current             string
+		expectedUpdates     []nodes.UpdateOperation
+		expectedError       string
+	}{
+		{
+			name:           "default",
+			provisionState: nodes.Manageable,
+			current:        "ipmitool",
+		},
+		{
+			name:                "unchanged",
+			managementInterface: "ipmitool",
+			provisionState:      nodes.Manageable,
+			current:             "ipmitool",
+		},
+		{
+			name:                "changed",
+			managementInterface: "redfish",
+			provisionState:      nodes.Manageable,
+			current:             "ipmitool",
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/management_interface",
+					Value: "redfish",
+				},
+			},
+		},
+		{
+			name:                "not enabled",
+			managementInterface: "idrac-redfish",
+			provisionState:      nodes.Manageable,
+			current:             "ipmitool",
+			expectedError:       "management interface \"idrac-redfish\" is not enabled for driver ipmi, expected one of ipmitool, redfish, noop",
+		},
+		{
+			name:                "provisioned",
+			managementInterface: "redfish",
+			provisionState:      nodes.Active,
+			current:             "ipmitool",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:                host.Namespace + nameSeparator + host.Name,
+				UUID:                "uuid",
+				DriverInfo:          registeredDriverInfo(t, host.Spec.BMC, nil),
+				Driver:              "ipmi",
+				ProvisionState:      string(tc.provisionState),
+				ManagementInterface: tc.current,
+				AutomatedClean:      &clean,
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			}).DriverDetails("ipmi", []string{"ipmitool", "redfish", "noop"})
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{ManagementInterface: tc.managementInterface}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+
+			updates := ironic.GetLastNodeUpdateRequestFor("uuid")
+			assert.Equal(t, tc.expectedUpdates, updates)
+		})
+	}
+}
+
 func TestValidateManagementAccessCreateWithImage(t *testing.T) {
 	// Create a host with Image specified in the Spec
 	host := makeHost()
@@ -304,6 +479,7 @@ func TestValidateManagementAccessExistingNodeContinue(t *testing.T) {
 			ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).Node(nodes.Node{
 				Name:           host.Namespace + nameSeparator + host.Name,
 				UUID:           "uuid", // to match status in host
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, nil),
 				ProvisionState: string(status),
 				AutomatedClean: &clean,
 			}).NodeUpdate(nodes.Node{
@@ -387,6 +563,7 @@ func TestValidateManagementAccessExistingSteadyStateNoUpdate(t *testing.T) {
 			ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).Node(nodes.Node{
 				Name:            host.Namespace + nameSeparator + host.Name,
 				UUID:            "uuid", // to match status in host
+				DriverInfo:      registeredDriverInfo(t, host.Spec.BMC, nil),
 				ProvisionState:  string(nodes.Manageable),
 				AutomatedClean:  &clean,
 				InstanceUUID:    string(host.UID),
@@ -438,6 +615,7 @@ func TestValidateManagementAccessExistingNodeWaiting(t *testing.T) {
 			node := nodes.Node{
 				Name:           host.Namespace + nameSeparator + host.Name,
 				UUID:           "uuid", // to match status in host
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, nil),
 				ProvisionState: string(status),
 			}
 			ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).Node(node).NodeUpdate(nodes.Node{
@@ -469,89 +647,1064 @@ func TestValidateManagementAccessExistingNodeWaiting(t *testing.T) {
 	}
 }
 
-func TestValidateManagementAccessNewCredentials(t *testing.T) {
-	// Create a host without a bootMACAddress and with a BMC that
-	// does not require one.
-	host := makeHost()
-	host.Spec.BootMACAddress = ""
-	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
-
-	ironic := testserver.NewIronic(t).
-		Node(
-			nodes.Node{
-				Name: host.Namespace + nameSeparator + host.Name,
-				UUID: "uuid",
-			}).
-		NodeUpdate(
-			nodes.Node{
-				Name: host.Namespace + nameSeparator + host.Name,
-				UUID: "uuid",
-				DriverInfo: map[string]interface{}{
-					"test_address": "test.bmc",
+func TestValidateManagementAccessAutomatedCleaningMode(t *testing.T) {
+	clean := true
+	cases := []struct {
+		name            string
+		mode            metal3v1alpha1.AutomatedCleaningMode
+		expectedUpdates []nodes.UpdateOperation
+		expectedError   string
+	}{
+		{
+			name: "metadata",
+			mode: metal3v1alpha1.CleaningModeMetadata,
+		},
+		{
+			name: "full",
+			mode: metal3v1alpha1.CleaningModeFull,
+		},
+		{
+			name: "disabled",
+			mode: metal3v1alpha1.CleaningModeDisabled,
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/automated_clean",
+					Value: false,
 				},
-			})
-	ironic.Start()
-	defer ironic.Stop()
-
-	auth := clients.AuthConfig{Type: clients.NoAuth}
-	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
-		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
-	)
-	if err != nil {
-		t.Fatalf("could not create provisioner: %s", err)
+			},
+		},
+		{
+			name:          "invalid",
+			mode:          "quick",
+			expectedError: "unsupported automated cleaning mode \"quick\"",
+		},
 	}
 
-	result, provID, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, true, false)
-	if err != nil {
-		t.Fatalf("error from ValidateManagementAccess: %s", err)
-	}
-	assert.Equal(t, "", result.ErrorMessage)
-	assert.Equal(t, "uuid", provID)
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
 
-	updates := ironic.GetLastNodeUpdateRequestFor("uuid")
-	assert.Equal(t, "/driver_info", updates[0].Path)
-	newValues := updates[0].Value.(map[string]interface{})
-	assert.Equal(t, "test.bmc", newValues["test_address"])
-}
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, nil),
+				ProvisionState: string(nodes.Manageable),
+				AutomatedClean: &clean,
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			})
+			ironic.Start()
+			defer ironic.Stop()
 
-func TestValidateManagementAccessLinkExistingIronicNodeByMAC(t *testing.T) {
-	// Create an Ironic node, and then create a host with a matching MAC
-	// Test to see if the node was found, and if the link is made
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
 
-	existingNode := nodes.Node{
-		UUID: "33ce8659-7400-4c68-9535-d10766f07a58",
-	}
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{AutomatedCleaningMode: tc.mode}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
 
-	existingNodePort := ports.Port{
-		NodeUUID: existingNode.UUID,
-		Address:  "11:11:11:11:11:11",
+			updates := ironic.GetLastNodeUpdateRequestFor("uuid")
+			sort.Slice(updates, func(i, j int) bool { return updates[i].Path < updates[j].Path })
+			assert.Equal(t, tc.expectedUpdates, updates)
+		})
 	}
+}
 
-	createCallback := func(node nodes.Node) {
-		t.Fatal("create callback should not be invoked for existing node")
+func TestValidateManagementAccessTags(t *testing.T) {
+	clean := true
+	cases := []struct {
+		name            string
+		tags            map[string]string
+		extra           map[string]interface{}
+		expectedUpdates []nodes.UpdateOperation
+	}{
+		{
+			name: "no tags",
+			extra: map[string]interface{}{
+				"owner": "someone",
+			},
+		},
+		{
+			name: "add",
+			tags: map[string]string{
+				"rack": "r1",
+				"role": "worker",
+			},
+			extra: map[string]interface{}{
+				"owner": "someone",
+			},
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/extra/metal3_tag_rack",
+					Value: "r1",
+				},
+				{
+					Op:    nodes.AddOp,
+					Path:  "/extra/metal3_tag_role",
+					Value: "worker",
+				},
+			},
+		},
+		{
+			name: "update and delete",
+			tags: map[string]string{
+				"rack": "r2",
+			},
+			extra: map[string]interface{}{
+				"owner":           "someone",
+				"metal3_tag_rack": "r1",
+				"metal3_tag_role": "worker",
+			},
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/extra/metal3_tag_rack",
+					Value: "r2",
+				},
+				{
+					Op:   nodes.RemoveOp,
+					Path: "/extra/metal3_tag_role",
+				},
+			},
+		},
+		{
+			name: "delete all",
+			extra: map[string]interface{}{
+				"owner":           "someone",
+				"metal3_tag_rack": "r1",
+			},
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:   nodes.RemoveOp,
+					Path: "/extra/metal3_tag_rack",
+				},
+			},
+		},
+		{
+			name: "unchanged",
+			tags: map[string]string{
+				"rack": "r1",
+			},
+			extra: map[string]interface{}{
+				"metal3_tag_rack": "r1",
+			},
+		},
+		{
+			name: "same key as unmanaged",
+			tags: map[string]string{
+				"owner": "team",
+			},
+			extra: map[string]interface{}{
+				"owner": "someone",
+			},
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/extra/metal3_tag_owner",
+					Value: "team",
+				},
+			},
+		},
 	}
 
-	ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).Node(existingNode).Port(existingNodePort)
-	ironic.AddDefaultResponse("/v1/nodes/myns"+nameSeparator+"myhost", "GET", http.StatusNotFound, "")
-	ironic.AddDefaultResponse("/v1/nodes/myhost", "GET", http.StatusNotFound, "")
-	ironic.AddDefaultResponse("/v1/nodes/"+existingNode.UUID, "PATCH", http.StatusOK, "{}")
-	ironic.Start()
-	defer ironic.Stop()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
 
-	host := makeHost()
-	host.Spec.BootMACAddress = "11:11:11:11:11:11"
-	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, nil),
+				ProvisionState: string(nodes.Manageable),
+				AutomatedClean: &clean,
+				Extra:          tc.extra,
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			})
+			ironic.Start()
+			defer ironic.Stop()
 
-	auth := clients.AuthConfig{Type: clients.NoAuth}
-	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
-		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
-	)
-	if err != nil {
-		t.Fatalf("could not create provisioner: %s", err)
-	}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
 
-	result, provID, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
-	if err != nil {
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{Tags: tc.tags}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Empty(t, result.ErrorMessage)
+
+			updates := ironic.GetLastNodeUpdateRequestFor("uuid")
+			sort.Slice(updates, func(i, j int) bool { return updates[i].Path < updates[j].Path })
+			assert.Equal(t, tc.expectedUpdates, updates)
+		})
+	}
+}
+
+func TestValidateManagementAccessDescription(t *testing.T) {
+	clean := true
+	cases := []struct {
+		name            string
+		description     string
+		current         string
+		expectedUpdates []nodes.UpdateOperation
+	}{
+		{
+			name: "no description",
+		},
+		{
+			name:        "set",
+			description: "rack 3, purchased 2022",
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/description",
+					Value: "rack 3, purchased 2022",
+				},
+			},
+		},
+		{
+			name:        "replace",
+			description: "rack 4, purchased 2022",
+			current:     "rack 3, purchased 2022",
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/description",
+					Value: "rack 4, purchased 2022",
+				},
+			},
+		},
+		{
+			name:    "clear",
+			current: "rack 3, purchased 2022",
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:   nodes.RemoveOp,
+					Path: "/description",
+				},
+			},
+		},
+		{
+			name:        "unchanged",
+			description: "rack 3, purchased 2022",
+			current:     "rack 3, purchased 2022",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+
+			ironic := testserver.NewIronic(t).Ready().NodeWithDescription(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, nil),
+				ProvisionState: string(nodes.Manageable),
+				AutomatedClean: &clean,
+			}, tc.current).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{Description: tc.description}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, "", result.ErrorMessage)
+			assert.Equal(t, tc.expectedUpdates, ironic.GetLastNodeUpdateRequestFor("uuid"))
+		})
+	}
+}
+
+func TestValidateManagementAccessShard(t *testing.T) {
+	clean := true
+	rack1 := "rack-1"
+	cases := []struct {
+		name            string
+		shard           string
+		current         string
+		known           *string
+		expectedUpdates []nodes.UpdateOperation
+		expectedError   string
+	}{
+		{
+			name: "no shard",
+		},
+		{
+			name:  "set",
+			shard: "rack-1",
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.ReplaceOp,
+					Path:  "/shard",
+					Value: "rack-1",
+				},
+			},
+		},
+		{
+			name:    "replace",
+			shard:   "rack-2",
+			current: "rack-1",
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.ReplaceOp,
+					Path:  "/shard",
+					Value: "rack-2",
+				},
+			},
+		},
+		{
+			name:    "clear",
+			current: "rack-1",
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:   nodes.RemoveOp,
+					Path: "/shard",
+				},
+			},
+		},
+		{
+			name:    "unchanged",
+			shard:   "rack-1",
+			current: "rack-1",
+		},
+		{
+			name:    "known",
+			shard:   "rack-1",
+			current: "rack-2",
+			known:   &rack1,
+		},
+		{
+			name:    "known before the change",
+			shard:   "rack-2",
+			current: "rack-1",
+			known:   &rack1,
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.ReplaceOp,
+					Path:  "/shard",
+					Value: "rack-2",
+				},
+			},
+		},
+		{
+			name:          "blank",
+			shard:         "  ",
+			expectedError: "the shard must not be blank",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+
+			forgetNodeShard("uuid")
+			if tc.known != nil {
+				rememberNodeShard("uuid", *tc.known)
+			}
+
+			ironic := testserver.NewIronic(t).Ready().NodeWithShard(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, nil),
+				ProvisionState: string(nodes.Manageable),
+				AutomatedClean: &clean,
+			}, tc.current).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{Shard: tc.shard}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			assert.Equal(t, tc.expectedUpdates, ironic.GetLastNodeUpdateRequestFor("uuid"))
+		})
+	}
+}
+
+func TestValidateManagementAccessTraits(t *testing.T) {
+	clean := true
+	cases := []struct {
+		name            string
+		traits          []string
+		current         []string
+		managed         []interface{}
+		expectedTraits  string
+		expectedUpdates []nodes.UpdateOperation
+		expectedError   string
+	}{
+		{
+			name: "no traits",
+		},
+		{
+			name:           "set",
+			traits:         []string{"CUSTOM_GPU"},
+			current:        []string{"CUSTOM_RACK_1"},
+			expectedTraits: `{"traits":["CUSTOM_GPU","CUSTOM_RACK_1"]}`,
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/extra/metal3_traits",
+					Value: []interface{}{"CUSTOM_GPU"},
+				},
+			},
+		},
+		{
+			name:           "clear",
+			current:        []string{"CUSTOM_GPU", "CUSTOM_RACK_1"},
+			managed:        []interface{}{"CUSTOM_GPU"},
+			expectedTraits: `{"traits":["CUSTOM_RACK_1"]}`,
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:   nodes.RemoveOp,
+					Path: "/extra/metal3_traits",
+				},
+			},
+		},
+		{
+			name:    "unchanged",
+			traits:  []string{"CUSTOM_GPU"},
+			current: []string{"CUSTOM_GPU"},
+			managed: []interface{}{"CUSTOM_GPU"},
+		},
+		{
+			name:          "invalid",
+			traits:        []string{"gpu"},
+			expectedError: "invalid trait \"gpu\", expected upper case letters, digits and underscores",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+
+			node := nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, nil),
+				ProvisionState: string(nodes.Manageable),
+				AutomatedClean: &clean,
+				Traits:         tc.current,
+			}
+			if tc.managed != nil {
+				node.Extra = map[string]interface{}{traitsExtraKey: tc.managed}
+			}
+			ironic := testserver.NewIronic(t).Ready().Node(node).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			}).WithNodeTraitsUpdate("uuid")
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{Traits: tc.traits}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+			body, found := ironic.GetLastRequestFor("/v1/nodes/uuid/traits", http.MethodPut)
+			if tc.expectedTraits == "" {
+				assert.False(t, found)
+			} else {
+				assert.JSONEq(t, tc.expectedTraits, body)
+			}
+			assert.Equal(t, tc.expectedUpdates, ironic.GetLastNodeUpdateRequestFor("uuid"))
+		})
+	}
+}
+
+func TestValidateManagementAccessBootModeDrift(t *testing.T) {
+	clean := true
+	cases := []struct {
+		name                 string
+		bootMode             metal3v1alpha1.BootMode
+		provisionState       nodes.ProvisionState
+		capabilities         string
+		expectedCapabilities string
+	}{
+		{
+			name:                 "bios change switched to legacy",
+			bootMode:             metal3v1alpha1.UEFI,
+			provisionState:       nodes.Manageable,
+			capabilities:         "cpu_vt:true,boot_mode:bios",
+			expectedCapabilities: "cpu_vt:true,boot_mode:uefi",
+		},
+		{
+			name:                 "bios change switched to uefi",
+			bootMode:             metal3v1alpha1.Legacy,
+			provisionState:       nodes.Available,
+			capabilities:         "boot_mode:uefi,cpu_vt:true",
+			expectedCapabilities: "cpu_vt:true,boot_mode:bios",
+		},
+		{
+			name:                 "secure boot lost",
+			bootMode:             metal3v1alpha1.UEFISecureBoot,
+			provisionState:       nodes.Active,
+			capabilities:         "boot_mode:uefi",
+			expectedCapabilities: "boot_mode:uefi,secure_boot:true",
+		},
+		{
+			name:                 "boot mode missing",
+			bootMode:             metal3v1alpha1.UEFI,
+			provisionState:       nodes.Manageable,
+			capabilities:         "cpu_vt:true",
+			expectedCapabilities: "cpu_vt:true,boot_mode:uefi",
+		},
+		{
+			name:           "consistent",
+			bootMode:       metal3v1alpha1.UEFISecureBoot,
+			provisionState: nodes.Manageable,
+			capabilities:   "secure_boot:true,cpu_vt:true,boot_mode:uefi",
+		},
+		{
+			name:           "not corrected while cleaning",
+			bootMode:       metal3v1alpha1.UEFI,
+			provisionState: nodes.Cleaning,
+			capabilities:   "boot_mode:bios",
+		},
+		{
+			name:           "no boot mode recorded",
+			provisionState: nodes.Manageable,
+			capabilities:   "boot_mode:bios",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, nil),
+				ProvisionState: string(tc.provisionState),
+				AutomatedClean: &clean,
+				Properties:     map[string]interface{}{"capabilities": tc.capabilities},
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{BootMode: tc.bootMode}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, "", result.ErrorMessage)
+
+			updates := ironic.GetLastNodeUpdateRequestFor("uuid")
+			if tc.expectedCapabilities == "" {
+				assert.Len(t, updates, 0)
+			} else {
+				assert.Equal(t, []nodes.UpdateOperation{
+					{
+						Op:    nodes.AddOp,
+						Path:  "/properties/capabilities",
+						Value: tc.expectedCapabilities,
+					},
+				}, updates)
+			}
+		})
+	}
+}
+
+func TestValidateManagementAccessAutomatedCleanDrift(t *testing.T) {
+	enabled := true
+	disabled := false
+	cases := []struct {
+		name           string
+		mode           metal3v1alpha1.AutomatedCleaningMode
+		state          metal3v1alpha1.ProvisioningState
+		automatedClean *bool
+		expectedValue  interface{}
+		expectedEvent  string
+	}{
+		{
+			name:           "disabled in ironic",
+			mode:           metal3v1alpha1.CleaningModeMetadata,
+			state:          metal3v1alpha1.StateAvailable,
+			automatedClean: &disabled,
+			expectedValue:  true,
+			expectedEvent:  "The automated_clean setting of the node was false, resetting it to true for cleaning mode \"metadata\"",
+		},
+		{
+			name:           "enabled in ironic",
+			mode:           metal3v1alpha1.CleaningModeDisabled,
+			state:          metal3v1alpha1.StateProvisioned,
+			automatedClean: &enabled,
+			expectedValue:  false,
+			expectedEvent:  "The automated_clean setting of the node was true, resetting it to false for cleaning mode \"disabled\"",
+		},
+		{
+			name:           "consistent",
+			mode:           metal3v1alpha1.CleaningModeDisabled,
+			state:          metal3v1alpha1.StateProvisioned,
+			automatedClean: &disabled,
+		},
+		{
+			name:           "set while registering",
+			mode:           metal3v1alpha1.CleaningModeDisabled,
+			state:          metal3v1alpha1.StateRegistering,
+			automatedClean: &enabled,
+			expectedValue:  false,
+		},
+		{
+			name:          "not set yet",
+			mode:          metal3v1alpha1.CleaningModeMetadata,
+			state:         metal3v1alpha1.StateAvailable,
+			expectedValue: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, nil),
+				ProvisionState: string(nodes.Manageable),
+				AutomatedClean: tc.automatedClean,
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			var events []string
+			publisher := func(reason, message string) {
+				events = append(events, message)
+			}
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, publisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{
+				AutomatedCleaningMode: tc.mode,
+				State:                 tc.state,
+			}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, "", result.ErrorMessage)
+
+			updates := ironic.GetLastNodeUpdateRequestFor("uuid")
+			var value interface{}
+			for _, update := range updates {
+				if update.Path == "/automated_clean" {
+					assert.Equal(t, nodes.AddOp, update.Op)
+					value = update.Value
+				}
+			}
+			assert.Equal(t, tc.expectedValue, value)
+			if tc.expectedEvent == "" {
+				assert.Empty(t, events)
+			} else {
+				assert.Equal(t, []string{tc.expectedEvent}, events)
+			}
+		})
+	}
+}
+
+func TestValidateManagementAccessCreateWithPreprovisioningImage(t *testing.T) {
+	host := makeHost()
+	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
+
+	var createdNode *nodes.Node
+
+	createCallback := func(node nodes.Node) {
+		createdNode = &node
+	}
+
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).NoNode(host.Namespace + nameSeparator + host.Name).NoNode(host.Name)
+	ironic.AddDefaultResponse("/v1/nodes/node-0", "PATCH", http.StatusOK, "{}")
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{
+		PreprovisioningImage: &provisioner.PreprovisioningImage{
+			KernelURL: "http://images.test/arm64.kernel",
+			ImageURL:  "http://images.test/arm64.initramfs",
+		},
+	}, false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	assert.Equal(t, "http://images.test/arm64.kernel", createdNode.DriverInfo["deploy_kernel"])
+	assert.Equal(t, "http://images.test/arm64.initramfs", createdNode.DriverInfo["deploy_ramdisk"])
+}
+
+func TestValidateManagementAccessPreprovisioningImage(t *testing.T) {
+	clean := true
+	image := &provisioner.PreprovisioningImage{
+		KernelURL: "http://images.test/arm64.kernel",
+		ImageURL:  "http://images.test/arm64.initramfs",
+	}
+	cases := []struct {
+		name            string
+		image           *provisioner.PreprovisioningImage
+		current         map[string]interface{}
+		expectedUpdates []nodes.UpdateOperation
+	}{
+		{
+			name: "default ramdisk",
+			current: map[string]interface{}{
+				"deploy_kernel":  deployKernelURL,
+				"deploy_ramdisk": deployRamdiskURL,
+			},
+		},
+		{
+			name:  "image selected",
+			image: image,
+			current: map[string]interface{}{
+				"deploy_kernel":  deployKernelURL,
+				"deploy_ramdisk": deployRamdiskURL,
+			},
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/driver_info/deploy_kernel",
+					Value: "http://images.test/arm64.kernel",
+				},
+				{
+					Op:    nodes.AddOp,
+					Path:  "/driver_info/deploy_ramdisk",
+					Value: "http://images.test/arm64.initramfs",
+				},
+			},
+		},
+		{
+			name:  "image unchanged",
+			image: image,
+			current: map[string]interface{}{
+				"deploy_kernel":  "http://images.test/arm64.kernel",
+				"deploy_ramdisk": "http://images.test/arm64.initramfs",
+			},
+		},
+		{
+			name: "image no longer selected",
+			current: map[string]interface{}{
+				"deploy_kernel":  "http://images.test/arm64.kernel",
+				"deploy_ramdisk": "http://images.test/arm64.initramfs",
+			},
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/driver_info/deploy_kernel",
+					Value: deployKernelURL,
+				},
+				{
+					Op:    nodes.AddOp,
+					Path:  "/driver_info/deploy_ramdisk",
+					Value: deployRamdiskURL,
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				ProvisionState: string(nodes.Manageable),
+				AutomatedClean: &clean,
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, tc.current),
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{PreprovisioningImage: tc.image}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, "", result.ErrorMessage)
+			assert.ElementsMatch(t, tc.expectedUpdates, ironic.GetLastNodeUpdateRequestFor("uuid"))
+		})
+	}
+}
+
+func TestValidateManagementAccessCreateWithIPMIPort(t *testing.T) {
+	host := makeHost()
+	host.Spec.BMC.Address = "ipmi://192.168.122.1:6234"
+	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
+
+	var createdNode *nodes.Node
+
+	createCallback := func(node nodes.Node) {
+		createdNode = &node
+	}
+
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).NoNode(host.Namespace + nameSeparator + host.Name).NoNode(host.Name)
+	ironic.AddDefaultResponse("/v1/nodes/node-0", "PATCH", http.StatusOK, "{}")
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	assert.Equal(t, "6234", createdNode.DriverInfo["ipmi_port"])
+}
+
+func TestValidateManagementAccessIPMIPort(t *testing.T) {
+	clean := true
+	cases := []struct {
+		name            string
+		address         string
+		current         map[string]interface{}
+		expectedUpdates []nodes.UpdateOperation
+	}{
+		{
+			name:    "port unchanged",
+			address: "ipmi://192.168.122.1:6234",
+			current: map[string]interface{}{"ipmi_port": "6234"},
+		},
+		{
+			name:    "port changed",
+			address: "ipmi://192.168.122.1:6235",
+			current: map[string]interface{}{"ipmi_port": "6234"},
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/driver_info/ipmi_port",
+					Value: "6235",
+				},
+			},
+		},
+		{
+			name:    "default port",
+			address: "ipmi://192.168.122.1",
+			current: map[string]interface{}{"ipmi_port": "6234"},
+			expectedUpdates: []nodes.UpdateOperation{
+				{
+					Op:    nodes.AddOp,
+					Path:  "/driver_info/ipmi_port",
+					Value: "623",
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BMC.Address = tc.address
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				ProvisionState: string(nodes.Manageable),
+				AutomatedClean: &clean,
+				DriverInfo:     registeredDriverInfo(t, host.Spec.BMC, tc.current),
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, "", result.ErrorMessage)
+			assert.ElementsMatch(t, tc.expectedUpdates, ironic.GetLastNodeUpdateRequestFor("uuid"))
+		})
+	}
+}
+
+func TestValidateManagementAccessNewCredentials(t *testing.T) {
+	// Create a host without a bootMACAddress and with a BMC that
+	// does not require one.
+	host := makeHost()
+	host.Spec.BootMACAddress = ""
+	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
+
+	ironic := testserver.NewIronic(t).
+		Node(
+			nodes.Node{
+				Name: host.Namespace + nameSeparator + host.Name,
+				UUID: "uuid",
+			}).
+		NodeUpdate(
+			nodes.Node{
+				Name: host.Namespace + nameSeparator + host.Name,
+				UUID: "uuid",
+				DriverInfo: map[string]interface{}{
+					"test_address": "test.bmc",
+				},
+			})
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, provID, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, true, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	assert.Equal(t, "uuid", provID)
+
+	updates := ironic.GetLastNodeUpdateRequestFor("uuid")
+	assert.Equal(t, "/driver_info", updates[0].Path)
+	newValues := updates[0].Value.(map[string]interface{})
+	assert.Equal(t, "test.bmc", newValues["test_address"])
+}
+
+func TestValidateManagementAccessLinkExistingIronicNodeByMAC(t *testing.T) {
+	// Create an Ironic node, and then create a host with a matching MAC
+	// Test to see if the node was found, and if the link is made
+
+	existingNode := nodes.Node{
+		UUID: "33ce8659-7400-4c68-9535-d10766f07a58",
+	}
+
+	existingNodePort := ports.Port{
+		NodeUUID: existingNode.UUID,
+		Address:  "11:11:11:11:11:11",
+	}
+
+	createCallback := func(node nodes.Node) {
+		t.Fatal("create callback should not be invoked for existing node")
+	}
+
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).Node(existingNode).Port(existingNodePort)
+	ironic.AddDefaultResponse("/v1/nodes/myns"+nameSeparator+"myhost", "GET", http.StatusNotFound, "")
+	ironic.AddDefaultResponse("/v1/nodes/myhost", "GET", http.StatusNotFound, "")
+	ironic.AddDefaultResponse("/v1/nodes/"+existingNode.UUID, "PATCH", http.StatusOK, "{}")
+	ironic.Start()
+	defer ironic.Stop()
+
+	host := makeHost()
+	host.Spec.BootMACAddress = "11:11:11:11:11:11"
+	host.Status.Provisioning.ID = "" // so we don't lookup by uuid
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, provID, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{}, false, false)
+	if err != nil {
 		t.Fatalf("error from ValidateManagementAccess: %s", err)
 	}
 	assert.Equal(t, "", result.ErrorMessage)
@@ -758,3 +1911,351 @@ func TestValidateManagementAccessMalformedBMCAddress(t *testing.T) {
 	}
 	assert.Equal(t, "failed to parse BMC address information: failed to parse BMC address information: parse \"<ipmi://192.168.122.1:6233>\": first path segment in URL cannot contain colon", result.ErrorMessage)
 }
+
+func TestValidateManagementAccessDeployNetworksCreate(t *testing.T) {
+	host := makeHost()
+	host.Spec.BootMACAddress = ""
+	host.Status.Provisioning.ID = ""
+
+	var createdNode *nodes.Node
+	createCallback := func(node nodes.Node) {
+		createdNode = &node
+	}
+
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).NoNode(host.Namespace + nameSeparator + host.Name).NoNode(host.Name)
+	ironic.AddDefaultResponse("/v1/nodes/node-0", "PATCH", http.StatusOK, "{}")
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{
+		DeployNetworks: &metal3v1alpha1.DeployNetworks{
+			ProvisioningNetwork: "6b3a1c8e-2f4d-4e5a-9b7c-0d1e2f3a4b5c",
+		},
+	}, false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	assert.NotNil(t, createdNode)
+	assert.Equal(t, "6b3a1c8e-2f4d-4e5a-9b7c-0d1e2f3a4b5c", createdNode.DriverInfo["provisioning_network"])
+	assert.NotContains(t, createdNode.DriverInfo, "cleaning_network")
+}
+
+func TestValidateManagementAccessDeployNetworks(t *testing.T) {
+	provisioningNetwork := "6b3a1c8e-2f4d-4e5a-9b7c-0d1e2f3a4b5c"
+	cleaningNetwork := "0a9b8c7d-6e5f-4a3b-8c1d-2e3f4a5b6c7d"
+	cases := []struct {
+		name       string
+		driverInfo map[string]interface{}
+		networks   *metal3v1alpha1.DeployNetworks
+
+		expectedUpdates []nodes.UpdateOperation
+		expectedError   string
+	}{
+		{
+			name:     "set",
+			networks: &metal3v1alpha1.DeployNetworks{ProvisioningNetwork: provisioningNetwork, CleaningNetwork: cleaningNetwork},
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.AddOp, Path: "/driver_info/provisioning_network", Value: provisioningNetwork},
+				{Op: nodes.AddOp, Path: "/driver_info/cleaning_network", Value: cleaningNetwork},
+			},
+		},
+		{
+			name:       "unchanged",
+			driverInfo: map[string]interface{}{"provisioning_network": provisioningNetwork},
+			networks:   &metal3v1alpha1.DeployNetworks{ProvisioningNetwork: provisioningNetwork},
+		},
+		{
+			name:       "conductor default restored",
+			driverInfo: map[string]interface{}{"provisioning_network": provisioningNetwork, "cleaning_network": cleaningNetwork},
+			networks:   &metal3v1alpha1.DeployNetworks{ProvisioningNetwork: provisioningNetwork},
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.RemoveOp, Path: "/driver_info/cleaning_network"},
+			},
+		},
+		{
+			name:          "invalid UUID",
+			networks:      &metal3v1alpha1.DeployNetworks{CleaningNetwork: "cleaning"},
+			expectedError: "invalid cleaning_network \"cleaning\", expected a network UUID",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				ProvisionState: string(nodes.Manageable),
+				DriverInfo:     tc.driverInfo,
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{
+				DeployNetworks: tc.networks,
+			}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+
+			var updates []nodes.UpdateOperation
+			for _, update := range ironic.GetLastNodeUpdateRequestFor("uuid") {
+				if update.Path == "/driver_info/provisioning_network" || update.Path == "/driver_info/cleaning_network" {
+					updates = append(updates, update)
+				}
+			}
+			assert.ElementsMatch(t, tc.expectedUpdates, updates)
+		})
+	}
+}
+
+func TestValidateManagementAccessRedfishAuthTypeCreate(t *testing.T) {
+	host := makeHost()
+	host.Spec.BMC.Address = "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/1"
+	host.Spec.BootMACAddress = "11:11:11:11:11:11"
+	host.Status.Provisioning.ID = ""
+
+	var createdNode *nodes.Node
+	createCallback := func(node nodes.Node) {
+		createdNode = &node
+	}
+
+	ironic := testserver.NewIronic(t).Ready().CreateNodes(createCallback).NoNode(host.Namespace + nameSeparator + host.Name).NoNode(host.Name)
+	ironic.AddDefaultResponse("/v1/nodes/node-0", "PATCH", http.StatusOK, "{}")
+	ironic.AddDefaultResponse("/v1/ports", "GET", http.StatusOK, `{"ports": []}`)
+	ironic.AddDefaultResponse("/v1/ports", "POST", http.StatusCreated, "{}")
+	ironic.Start()
+	defer ironic.Stop()
+
+	auth := clients.AuthConfig{Type: clients.NoAuth}
+	prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+		ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+	)
+	if err != nil {
+		t.Fatalf("could not create provisioner: %s", err)
+	}
+
+	result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{
+		RedfishAuthType: metal3v1alpha1.RedfishAuthSession,
+	}, false, false)
+	if err != nil {
+		t.Fatalf("error from ValidateManagementAccess: %s", err)
+	}
+	assert.Equal(t, "", result.ErrorMessage)
+	assert.NotNil(t, createdNode)
+	assert.Equal(t, "session", createdNode.DriverInfo["redfish_auth_type"])
+}
+
+func TestValidateManagementAccessRedfishAuthType(t *testing.T) {
+	cases := []struct {
+		name       string
+		address    string
+		driverInfo map[string]interface{}
+		authType   metal3v1alpha1.RedfishAuthType
+
+		expectedUpdates []nodes.UpdateOperation
+		expectedError   string
+	}{
+		{
+			name:     "set",
+			address:  "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/1",
+			authType: metal3v1alpha1.RedfishAuthBasic,
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.AddOp, Path: "/driver_info/redfish_auth_type", Value: "basic"},
+			},
+		},
+		{
+			name:       "changed",
+			address:    "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/1",
+			driverInfo: map[string]interface{}{"redfish_auth_type": "basic"},
+			authType:   metal3v1alpha1.RedfishAuthSession,
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.AddOp, Path: "/driver_info/redfish_auth_type", Value: "session"},
+			},
+		},
+		{
+			name:       "unchanged",
+			address:    "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/1",
+			driverInfo: map[string]interface{}{"redfish_auth_type": "auto"},
+			authType:   metal3v1alpha1.RedfishAuthAuto,
+		},
+		{
+			name:       "conductor default restored",
+			address:    "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/1",
+			driverInfo: map[string]interface{}{"redfish_auth_type": "session"},
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.RemoveOp, Path: "/driver_info/redfish_auth_type"},
+			},
+		},
+		{
+			name:          "invalid",
+			address:       "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/1",
+			authType:      "token",
+			expectedError: "invalid redfishAuthType \"token\", expected one of auto, basic or session",
+		},
+		{
+			name:          "not redfish",
+			address:       "ipmi://192.168.122.1:6233",
+			authType:      metal3v1alpha1.RedfishAuthBasic,
+			expectedError: "redfishAuthType is only supported for Redfish BMCs",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BMC.Address = tc.address
+			host.Spec.BootMACAddress = "11:11:11:11:11:11"
+			host.Status.Provisioning.ID = "uuid"
+
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				ProvisionState: string(nodes.Manageable),
+				DriverInfo:     tc.driverInfo,
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			}).Port(ports.Port{
+				NodeUUID: "uuid",
+				Address:  "11:11:11:11:11:11",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{
+				RedfishAuthType: tc.authType,
+			}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+
+			var updates []nodes.UpdateOperation
+			for _, update := range ironic.GetLastNodeUpdateRequestFor("uuid") {
+				if update.Path == "/driver_info/redfish_auth_type" {
+					updates = append(updates, update)
+				}
+			}
+			assert.ElementsMatch(t, tc.expectedUpdates, updates)
+		})
+	}
+}
+
+func TestValidateManagementAccessCapabilities(t *testing.T) {
+	cases := []struct {
+		name         string
+		capabilities string
+		extra        map[string]interface{}
+		custom       string
+
+		expectedUpdates []nodes.UpdateOperation
+		expectedError   string
+	}{
+		{
+			name:         "merged",
+			capabilities: "boot_mode:uefi,cpu_vt:false",
+			custom:       "cpu_vt:true,hugepages_1G:true",
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.AddOp, Path: "/properties/capabilities", Value: "boot_mode:uefi,cpu_vt:true,hugepages_1G:true"},
+				{Op: nodes.AddOp, Path: "/extra/metal3_capabilities", Value: "cpu_vt:true,hugepages_1G:true"},
+			},
+		},
+		{
+			name:         "unchanged",
+			capabilities: "boot_mode:uefi,cpu_vt:true",
+			extra:        map[string]interface{}{"metal3_capabilities": "cpu_vt:true"},
+			custom:       "cpu_vt:true",
+		},
+		{
+			name:         "removed",
+			capabilities: "boot_mode:uefi,cpu_aes:true,cpu_vt:true",
+			extra:        map[string]interface{}{"metal3_capabilities": "cpu_vt:true"},
+			expectedUpdates: []nodes.UpdateOperation{
+				{Op: nodes.AddOp, Path: "/properties/capabilities", Value: "boot_mode:uefi,cpu_aes:true"},
+				{Op: nodes.RemoveOp, Path: "/extra/metal3_capabilities"},
+			},
+		},
+		{
+			name:          "invalid",
+			capabilities:  "boot_mode:uefi",
+			custom:        "cpu_vt",
+			expectedError: "invalid capability \"cpu_vt\", expected key:value",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			host := makeHost()
+			host.Spec.BootMACAddress = ""
+			host.Status.Provisioning.ID = "uuid"
+
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				Name:           host.Namespace + nameSeparator + host.Name,
+				UUID:           "uuid",
+				ProvisionState: string(nodes.Manageable),
+				Properties:     map[string]interface{}{"capabilities": tc.capabilities},
+				Extra:          tc.extra,
+			}).NodeUpdate(nodes.Node{
+				UUID: "uuid",
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, _, err := prov.ValidateManagementAccess(provisioner.ManagementAccessData{
+				BootMode:     metal3v1alpha1.UEFI,
+				Capabilities: tc.custom,
+			}, false, false)
+			if err != nil {
+				t.Fatalf("error from ValidateManagementAccess: %s", err)
+			}
+			assert.Equal(t, tc.expectedError, result.ErrorMessage)
+
+			var updates []nodes.UpdateOperation
+			for _, update := range ironic.GetLastNodeUpdateRequestFor("uuid") {
+				if update.Path == "/properties/capabilities" || update.Path == "/extra/metal3_capabilities" {
+					updates = append(updates, update)
+				}
+			}
+			assert.ElementsMatch(t, tc.expectedUpdates, updates)
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package ironic
+
+import (
+	"strings"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner"
+)
+
+// ejectVirtualMediaMethod is the vendor passthru method of the Redfish
+// vendor interface ejecting the virtual media of the node
+const ejectVirtualMediaMethod = "eject_vmedia"
+
+// ejectVirtualMediaDevice is the virtual media device Ironic inserts
+// the deploy ISO in when booting from virtual media
+const ejectVirtualMediaDevice = "cd"
+
+// ejectVirtualMedia makes sure that the deploy ISO is not left
+// inserted in the virtual CD of hosts booted from virtual media, as it
+// may remain mounted after cleaning and block later operations. The
+// media is ejected through the vendor passthru of Ironic, which only
+// the Redfish vendor interface provides, and other devices, which may
+// hold media inserted by the user, are left alone. Ejecting is a best
+// effort: failures are logged and do not block the operation.
+func (p *ironicProvisioner) ejectVirtualMedia(ironicNode *nodes.Node) (result provisioner.Result, err error) {
+	if !strings.Contains(ironicNode.BootInterface, "virtual-media") || ironicNode.VendorInterface != "redfish" {
+		return operationComplete()
+	}
+
+	if p.nodeLocked(ironicNode) {
+		return retryAfterDelay(nodeLockedRequeueDelay)
+	}
+
+	p.log.Info("ejecting virtual media", "device", ejectVirtualMediaDevice)
+	url := p.client.ServiceURL("nodes", ironicNode.UUID, "vendor_passthru") + "?method=" + ejectVirtualMediaMethod
+	_, err = p.client.Post(url, map[string]string{"boot_device": ejectVirtualMediaDevice}, nil,
+		&gophercloud.RequestOpts{OkCodes: []int{200, 202, 204}})
+
+	switch err.(type) {
+	case nil:
+	case gophercloud.ErrDefault409:
+		p.log.Info("host is locked, trying again after delay", "delay", nodeLockedRequeueDelay)
+		return retryAfterDelay(nodeLockedRequeueDelay)
+	default:
+		p.log.Info("failed to eject the virtual media, ignoring", "error", err)
+	}
+	return operationComplete()
+}
@@ -0,0 +1,106 @@
+package ironic
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gophercloud/gophercloud/openstack/baremetal/v1/nodes"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/metal3-io/baremetal-operator/pkg/bmc"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/clients"
+	"github.com/metal3-io/baremetal-operator/pkg/provisioner/ironic/testserver"
+)
+
+func TestDeprovisionEjectsVirtualMedia(t *testing.T) {
+	nodeUUID := "33ce8659-7400-4c68-9535-d10766f07a58"
+	passthruPath := "/v1/nodes/" + nodeUUID + "/vendor_passthru"
+
+	cases := []struct {
+		name            string
+		state           nodes.ProvisionState
+		bootInterface   string
+		vendorInterface string
+		ejectStatus     int
+
+		expectedEject bool
+	}{
+		{
+			name:            "available",
+			state:           nodes.Available,
+			bootInterface:   "redfish-virtual-media",
+			vendorInterface: "redfish",
+			ejectStatus:     http.StatusAccepted,
+			expectedEject:   true,
+		},
+		{
+			name:            "manageable",
+			state:           nodes.Manageable,
+			bootInterface:   "redfish-virtual-media",
+			vendorInterface: "redfish",
+			ejectStatus:     http.StatusAccepted,
+			expectedEject:   true,
+		},
+		{
+			name:            "eject failure is ignored",
+			state:           nodes.Available,
+			bootInterface:   "redfish-virtual-media",
+			vendorInterface: "redfish",
+			ejectStatus:     http.StatusInternalServerError,
+			expectedEject:   true,
+		},
+		{
+			name:            "no redfish vendor interface",
+			state:           nodes.Available,
+			bootInterface:   "idrac-redfish-virtual-media",
+			vendorInterface: "no-vendor",
+		},
+		{
+			name:            "network boot",
+			state:           nodes.Available,
+			bootInterface:   "ipxe",
+			vendorInterface: "redfish",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ejected := false
+			ironic := testserver.NewIronic(t).Ready().Node(nodes.Node{
+				UUID:            nodeUUID,
+				ProvisionState:  string(tc.state),
+				BootInterface:   tc.bootInterface,
+				VendorInterface: tc.vendorInterface,
+			})
+			ironic.Handler(passthruPath, func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "method=eject_vmedia", r.URL.RawQuery)
+				var body map[string]string
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.Equal(t, map[string]string{"boot_device": "cd"}, body)
+				ejected = true
+				w.WriteHeader(tc.ejectStatus)
+			})
+			ironic.Start()
+			defer ironic.Stop()
+
+			host := makeHost()
+			host.Spec.BMC.Address = "redfish-virtualmedia://192.168.122.1/redfish/v1/Systems/1"
+			host.Status.Provisioning.ID = nodeUUID
+			auth := clients.AuthConfig{Type: clients.NoAuth}
+			prov, err := newProvisionerWithSettings(host, bmc.Credentials{Username: "admin", Password: "pa$$w0rd"}, nullEventPublisher,
+				ironic.Endpoint(), auth, testserver.NewInspector(t).Endpoint(), auth,
+			)
+			if err != nil {
+				t.Fatalf("could not create provisioner: %s", err)
+			}
+
+			result, err := prov.Deprovision(false)
+
+			assert.NoError(t, err)
+			assert.Equal(t, "", result.ErrorMessage)
+			assert.False(t, result.Dirty)
+			assert.Equal(t, tc.expectedEject, ejected)
+		})
+	}
+}
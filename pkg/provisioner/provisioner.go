@@ -25,6 +25,7 @@ type HostData struct {
 	BMCCredentials                 bmc.Credentials
 	DisableCertificateVerification bool
 	BootMACAddress                 string
+	BootNetworkIPVersion           metal3v1alpha1.IPVersion
 	ProvisionerID                  string
 }
 
@@ -35,6 +36,7 @@ func BuildHostData(host metal3v1alpha1.BareMetalHost, bmcCreds bmc.Credentials)
 		BMCCredentials:                 bmcCreds,
 		DisableCertificateVerification: host.Spec.BMC.DisableCertificateVerification,
 		BootMACAddress:                 host.Spec.BootMACAddress,
+		BootNetworkIPVersion:           host.Spec.BootNetworkIPVersion,
 		ProvisionerID:                  host.Status.Provisioning.ID,
 	}
 }
@@ -55,6 +57,11 @@ type HostConfigData interface {
 	// MetaData is the interface for a function to retrieve metadata
 	// configuration for a host.
 	MetaData() (string, error)
+
+	// ExtraFiles is the interface for a function to retrieve the
+	// content of the extra files of the config drive, indexed by
+	// their path on the host.
+	ExtraFiles() (map[string]string, error)
 }
 
 type ManagementAccessData struct {
@@ -62,6 +69,31 @@ type ManagementAccessData struct {
 	AutomatedCleaningMode metal3v1alpha1.AutomatedCleaningMode
 	State                 metal3v1alpha1.ProvisioningState
 	CurrentImage          *metal3v1alpha1.Image
+	Tags                  map[string]string
+	ManagementInterface   string
+	RedfishAuthType       metal3v1alpha1.RedfishAuthType
+	Description           string
+	Traits                []string
+	Shard                 string
+	NodeProperties        *metal3v1alpha1.NodeProperties
+	DeployNetworks        *metal3v1alpha1.DeployNetworks
+	// FastTrack overrides the fast track setting of the provisioner
+	// if set
+	FastTrack *bool
+	// Capabilities are merged into the capabilities of the node
+	Capabilities string
+	// InstanceCapabilities are merged into the instance_info
+	// capabilities set for CurrentImage
+	InstanceCapabilities map[string]string
+	// PreprovisioningImage replaces the default ramdisk if set
+	PreprovisioningImage *PreprovisioningImage
+}
+
+// PreprovisioningImage holds the location of the ramdisk the host
+// boots for inspection, cleaning and deployment.
+type PreprovisioningImage struct {
+	KernelURL string
+	ImageURL  string
 }
 
 type AdoptData struct {
@@ -70,11 +102,24 @@ type AdoptData struct {
 
 type InspectData struct {
 	BootMode metal3v1alpha1.BootMode
+	// KernelParams are added to the kernel command line of the
+	// inspection ramdisk only.
+	KernelParams string
 }
 
 type PrepareData struct {
 	RAIDConfig      *metal3v1alpha1.RAIDConfig
 	RootDeviceHints *metal3v1alpha1.RootDeviceHints
+	FirmwareConfig  *metal3v1alpha1.FirmwareConfig
+	// HardwareDetails are used to check that the RAID volumes fit the
+	// detected disks.
+	HardwareDetails *metal3v1alpha1.HardwareDetails
+	// EraseDevices tells whether the whole disks are overwritten
+	// before the host is configured.
+	EraseDevices bool
+	// RetryRecoverableFailure allows the provisioner to clean again
+	// after a failure caused by a transient problem.
+	RetryRecoverableFailure bool
 }
 
 type ProvisionData struct {
@@ -83,11 +128,46 @@ type ProvisionData struct {
 	BootMode        metal3v1alpha1.BootMode
 	HardwareProfile hardware.Profile
 	RootDeviceHints *metal3v1alpha1.RootDeviceHints
+	// HardwareDetails are used to resolve root device hints that do
+	// not require all hints to match.
+	HardwareDetails *metal3v1alpha1.HardwareDetails
+	// DeploymentID tags the provisioned instance with the consumer
+	// of the host, it is not set if the host has no consumer.
+	DeploymentID string
+	// RetryRecoverableFailure allows the provisioner to deploy again
+	// after a failure caused by a transient problem.
+	RetryRecoverableFailure bool
+	// TimeSettings are added to the meta data of the instance.
+	TimeSettings *metal3v1alpha1.TimeSettings
+	// InstanceCapabilities are merged into the capabilities of the
+	// instance, e.g. for huge pages or CPU pinning.
+	InstanceCapabilities map[string]string
+	// InstanceTraits select the deploy templates run while deploying
+	// the instance.
+	InstanceTraits []string
+	// ImageHeaders are the HTTP headers sent when downloading the
+	// image, indexed by their name.
+	ImageHeaders map[string]string
+	// NetworkBootTimeout limits the wait for the deploy ramdisk to
+	// check in, DeployTimeout the whole deploy since ProvisionStarted.
+	// Zero leaves the limit to the provisioner.
+	NetworkBootTimeout time.Duration
+	DeployTimeout      time.Duration
+	ProvisionStarted   time.Time
+	// PostDeployScriptURL is the script run by the deploy ramdisk once
+	// the image is written, if any.
+	PostDeployScriptURL string
 }
 
 // Provisioner holds the state information for talking to the
 // provisioning backend.
 type Provisioner interface {
+	// CheckBMCAccess quickly verifies that the BMC accepts the
+	// credentials, so that registration can fail early with a precise
+	// error message. It returns a dirty result while the check is
+	// still running.
+	CheckBMCAccess() (result Result, err error)
+
 	// ValidateManagementAccess tests the connection information for
 	// the host to verify that the location and credentials work. The
 	// boolean argument tells the provisioner whether the current set
@@ -115,11 +195,27 @@ type Provisioner interface {
 	// Prepare remove existing configuration and set new configuration
 	Prepare(data PrepareData, unprepared bool) (result Result, started bool, err error)
 
+	// CheckFirmwareSettings compares the requested firmware settings
+	// with the ones reported by the host and returns the settings
+	// that have not been applied yet.
+	CheckFirmwareSettings(config *metal3v1alpha1.FirmwareConfig) (pending map[string]string, err error)
+
+	// DiffFirmwareSettings compares the requested firmware settings
+	// with the ones reported by the host and returns the settings
+	// that would be added, changed, or are not requested anymore
+	// since the previous configuration.
+	DiffFirmwareSettings(config, previous *metal3v1alpha1.FirmwareConfig) (diff []metal3v1alpha1.BIOSSettingDiff, err error)
+
 	// Provision writes the image from the host spec to the host. It
 	// may be called multiple times, and should return true for its
 	// dirty flag until the deprovisioning operation is completed.
 	Provision(data ProvisionData) (result Result, err error)
 
+	// AbortDeploy cancels the deploy in progress, if any. It may be
+	// called multiple times, and should return true for its dirty flag
+	// until the deploy is stopped.
+	AbortDeploy() (result Result, err error)
+
 	// Deprovision removes the host from the image. It may be called
 	// multiple times, and should return true for its dirty flag until
 	// the deprovisioning operation is completed.
@@ -146,6 +242,47 @@ type Provisioner interface {
 	// if a hard reboot (force power off) is required - true if so.
 	PowerOff(rebootMode metal3v1alpha1.RebootMode) (result Result, err error)
 
+	// SetBootDevice sets the device the host boots from, either for
+	// the next boot only or persistently.
+	SetBootDevice(device string, persistent bool) (result Result, err error)
+
+	// ResetBMC restarts the BMC of the host, if the driver supports
+	// it.
+	ResetBMC() (result Result, err error)
+
+	// SyncPowerState makes the provisioner read the power state of the
+	// host from the BMC, instead of waiting for a periodic sync, by
+	// requesting the power state the host should be in. A host already
+	// in that state is not changed.
+	SyncPowerState(online bool) (result Result, err error)
+
+	// GetBlockingReasons returns what the host is waiting for in the
+	// provisioner, e.g. an inspection or cleaning to finish, or an
+	// unreachable BMC.
+	GetBlockingReasons() (reasons []metal3v1alpha1.BlockingReason, err error)
+
+	// GetSensors reads the fan and temperature sensors of the host,
+	// or returns nil if the BMC does not report them.
+	GetSensors() (sensors *metal3v1alpha1.SensorStatus, err error)
+
+	// ClearFault clears the fault of the host once the cause of a
+	// failure has been fixed, so that the failed operation can be
+	// retried. It may be called multiple times, and should return true
+	// for its dirty flag until the host is ready for the retry.
+	ClearFault() (result Result, err error)
+
+	// IsImageCached tells whether the image is already cached by the
+	// provisioner, so that its deploy does not wait for a download. It
+	// returns nil if the provisioner cannot tell.
+	IsImageCached(image metal3v1alpha1.Image) (cached *bool, err error)
+
+	// GetAvailableSteps returns the clean and deploy steps supported
+	// by the interfaces of the host, e.g. to validate custom steps.
+	// Only the steps reported by the provisioning agent are listed,
+	// and only once the host has booted it; the steps run by the BMC
+	// drivers out of band, e.g. for BIOS or RAID, are not included.
+	GetAvailableSteps() (steps AvailableSteps, err error)
+
 	// IsReady checks if the provisioning backend is available to accept
 	// all the incoming requests.
 	IsReady() (result bool, err error)
@@ -164,6 +301,9 @@ type Result struct {
 	RequeueAfter time.Duration
 	// Any error message produced by the provisioner.
 	ErrorMessage string
+	// Retried indicates that the provisioner restarted a failed
+	// operation automatically.
+	Retried bool
 }
 
 // HardwareState holds the response from an UpdateHardwareState call
@@ -171,7 +311,95 @@ type HardwareState struct {
 	// PoweredOn is a pointer to a bool indicating whether the Host is currently
 	// powered on. The value is nil if the power state cannot be determined.
 	PoweredOn *bool
+
+	// History holds the most recent events recorded by the
+	// provisioner for the host. The value is nil if the history
+	// cannot be read.
+	History []metal3v1alpha1.HistoryEvent
+
+	// SerialConsole holds the details of the serial-over-LAN console
+	// of the Host. The value is nil if no such console is enabled.
+	SerialConsole *metal3v1alpha1.SerialConsole
+
+	// SerialConsoleUnknown is true if the details of the console
+	// cannot be read, and the previous ones should be kept.
+	SerialConsoleUnknown bool
+
+	// Allocation holds the details of the allocation of the Host. The
+	// value is nil if the Host is not allocated.
+	Allocation *metal3v1alpha1.AllocationStatus
+
+	// AllocationUnknown is true if the details of the allocation
+	// cannot be read, and the previous ones should be kept.
+	AllocationUnknown bool
+
+	// Scheduling holds the resource class and traits of the Host. The
+	// value is nil if neither is set.
+	Scheduling *metal3v1alpha1.SchedulingStatus
+
+	// Conductor holds the conductor serving the Host. The value is
+	// nil if it is not known.
+	Conductor *metal3v1alpha1.ConductorStatus
+
+	// ConductorUnknown is true if the conductor cannot be read, and
+	// the previous details should be kept.
+	ConductorUnknown bool
+
+	// LastInspected is when the last inspection of the Host finished.
+	// The value is nil if the Host was never inspected.
+	LastInspected *metav1.Time
+
+	// NodeCreatedAt is when the Host was created in the provisioning
+	// backend. The value is nil if it is not known.
+	NodeCreatedAt *metav1.Time
+
+	// TimestampsUnknown is true if the times above cannot be read,
+	// and the previous ones should be kept.
+	TimestampsUnknown bool
+
+	// BootInterface is the boot interface used for the Host, which
+	// tells network boot from virtual media. The value is empty if
+	// it is not known.
+	BootInterface string
+
+	// HardwareFault is the reason the Host was put in maintenance
+	// mode because of a hardware fault. The value is empty if the
+	// Host is not in such a maintenance mode.
+	HardwareFault string
+}
+
+// Step is a clean or deploy step supported by an interface of the host
+type Step struct {
+	// Interface is the hardware interface providing the step, e.g.
+	// deploy or raid.
+	Interface string
+
+	// Name is the name of the step within its interface.
+	Name string
+
+	// Priority is the default priority of the step, 0 if the step
+	// only runs when requested.
+	Priority int
+
+	// Arguments describes the arguments the step accepts, by name.
+	Arguments map[string]StepArgument
+}
+
+// StepArgument describes an argument of a step
+type StepArgument struct {
+	Description string
+	Required    bool
+}
+
+// AvailableSteps holds the steps supported by the host, sorted by
+// interface and then by name
+type AvailableSteps struct {
+	Clean  []Step
+	Deploy []Step
 }
 
 // ErrNeedsRegistration raised if the host is not registered
 var ErrNeedsRegistration = errors.New("Host not registered")
+
+// ErrConductorDown raised if no live conductor manages the host
+var ErrConductorDown = errors.New("Conductor managing the host is down")